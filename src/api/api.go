@@ -1,7 +1,13 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gen-concept-api/api/middleware"
 	"gen-concept-api/api/router"
@@ -22,6 +28,9 @@ import (
 
 var logger = logging.NewLogger(config.GetConfig())
 
+// shutdownTimeout is how long in-flight requests and generation runs get to finish once the server is stopping
+const shutdownTimeout = 30 * time.Second
+
 func InitServer(cfg *config.Config) {
 	gin.SetMode(cfg.Server.RunMode)
 	r := gin.New()
@@ -33,17 +42,34 @@ func InitServer(cfg *config.Config) {
 	r.Use(middleware.Prometheus())
 	r.Use(gin.CustomRecovery(middleware.ErrorHandler) /*middleware.TestMiddleware()*/, middleware.LimitByRequest())
 
-	RegisterRoutes(r, cfg)
+	shutdown := RegisterRoutes(r, cfg)
 	RegisterSwagger(r, cfg)
 	logger := logging.NewLogger(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Addr: fmt.Sprintf(":%s", cfg.Server.InternalPort), Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error(logging.General, logging.Shutdown, err.Error(), nil)
+		}
+	}()
+
 	logger.Info(logging.General, logging.Startup, "Started", nil)
-	err := r.Run(fmt.Sprintf(":%s", cfg.Server.InternalPort))
-	if err != nil {
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Fatal(logging.General, logging.Startup, err.Error(), nil)
 	}
+	shutdown()
+	logger.Info(logging.General, logging.Shutdown, "Stopped", nil)
 }
 
-func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
+// RegisterRoutes registers every route and returns the function releasing what the handlers started,
+// such as the generation worker pool
+func RegisterRoutes(r *gin.Engine, cfg *config.Config) (shutdown func()) {
 	api := r.Group("/api")
 
 	v1 := api.Group("/v1")
@@ -107,7 +133,14 @@ func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
 		// Libraries
 		router.Library(libraries, cfg)
 		// Generation
-		router.Generation(generation, cfg)
+		generationRuns := router.Generation(generation, cfg)
+		shutdown = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := generationRuns.Close(ctx); err != nil {
+				logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+			}
+		}
 		// Importer
 		router.Importer(importer, cfg)
 
@@ -123,6 +156,7 @@ func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
 		health := v2.Group("/health")
 		router.Health(health)
 	}
+	return shutdown
 }
 
 func RegisterValidators() {
@@ -1,7 +1,11 @@
 package dto
 
 import (
+	"fmt"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
 	"gen-concept-api/usecase/dto"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -13,23 +17,62 @@ type Blueprint struct {
 	Type            string          `json:"type"`
 	Description     string          `json:"description"`
 	TemplatePath    string          `json:"templatePath"`
+	TemplateVersion int             `json:"templateVersion"`
+	Templates       []Template      `json:"templates"`
 	Placeholders    []Placeholder   `json:"placeholders"`
 	Functionalities []Functionality `json:"functionalities"`
 	Libraries       []Library       `json:"libraries"`
+	// LintWarnings are set by the server when the blueprint is saved, for templates that render but look wrong
+	LintWarnings []service.TemplateLintIssue `json:"lintWarnings,omitempty"`
+}
+
+// Template is a single template file of a blueprint.
+// Path, Content, Language and Scope are set by clients; Checksum and Version are assigned by the server.
+type Template struct {
+	Uuid     uuid.UUID                `json:"uuid"`
+	Path     string                   `json:"path"`
+	Content  string                   `json:"content"`
+	Language enum.ProgrammingLanguage `json:"language"`
+	Scope    enum.TemplateScope       `json:"scope"` // ENTITY (default) renders the file per entity, PROJECT once per project
+	Checksum string                   `json:"checksum"`
+	Version  int                      `json:"version"`
+	Removed  bool                     `json:"removed,omitempty"`
 }
 
 type Placeholder struct {
-	Uuid        uuid.UUID `json:"uuid"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Type        string    `json:"type"`
-	DefaultVal  string    `json:"defaultVal"`
+	Uuid          uuid.UUID `json:"uuid"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Type          string    `json:"type"`
+	DefaultVal    string    `json:"defaultVal"`
+	AllowedValues []string  `json:"allowedValues"`
 }
 
 func (b Blueprint) Validate() error {
 	if b.StandardName == "" {
 		// handle error
 	}
+	seen := make(map[string]bool, len(b.Templates))
+	for _, t := range b.Templates {
+		path := strings.TrimSpace(t.Path)
+		if path == "" {
+			return fmt.Errorf("template path is required")
+		}
+		if seen[path] {
+			return fmt.Errorf("duplicate template path: %s", path)
+		}
+		seen[path] = true
+	}
+	names := make(map[string]bool, len(b.Placeholders))
+	for _, p := range b.Placeholders {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate placeholder: %s", p.Name)
+		}
+		names[p.Name] = true
+	}
 	for _, f := range b.Functionalities {
 		if err := f.Validate(); err != nil {
 			return err
@@ -38,6 +81,16 @@ func (b Blueprint) Validate() error {
 	return nil
 }
 
+func (p Placeholder) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("placeholder name is required")
+	}
+	if strings.EqualFold(p.Type, "enum") && len(p.AllowedValues) == 0 {
+		return fmt.Errorf("placeholder %s: allowed values are required for an enum", p.Name)
+	}
+	return nil
+}
+
 // Functionality represents a specific functionality of the blueprint
 type Functionality struct {
 	Uuid               uuid.UUID             `json:"uuid"`
@@ -83,21 +136,43 @@ func ToUseCaseBlueprint(from Blueprint) dto.Blueprint {
 		Type:            from.Type,
 		Description:     from.Description,
 		TemplatePath:    from.TemplatePath,
+		TemplateVersion: from.TemplateVersion,
+		Templates:       ToUseCaseTemplates(from.Templates),
 		Placeholders:    ToUseCasePlaceholders(from.Placeholders),
 		Functionalities: ToUseCaseFunctionalities(from.Functionalities),
 		Libraries:       ToUseCaseLibraries(from.Libraries),
 	}
 }
 
+// ToUseCaseTemplates converts a slice of Template.
+// A nil slice stays nil so an update without templates leaves the stored files untouched.
+func ToUseCaseTemplates(from []Template) []dto.Template {
+	if from == nil {
+		return nil
+	}
+	templates := make([]dto.Template, len(from))
+	for i, t := range from {
+		templates[i] = dto.Template{
+			Uuid:     t.Uuid,
+			Path:     strings.TrimSpace(t.Path),
+			Content:  t.Content,
+			Language: t.Language,
+			Scope:    t.Scope,
+		}
+	}
+	return templates
+}
+
 func ToUseCasePlaceholders(from []Placeholder) []dto.Placeholder {
 	pl := make([]dto.Placeholder, len(from))
 	for i, p := range from {
 		pl[i] = dto.Placeholder{
-			Uuid:        p.Uuid,
-			Name:        p.Name,
-			Description: p.Description,
-			Type:        p.Type,
-			DefaultVal:  p.DefaultVal,
+			Uuid:          p.Uuid,
+			Name:          p.Name,
+			Description:   p.Description,
+			Type:          p.Type,
+			DefaultVal:    p.DefaultVal,
+			AllowedValues: p.AllowedValues,
 		}
 	}
 	return pl
@@ -160,21 +235,43 @@ func ToBlueprintResponse(from dto.Blueprint) Blueprint {
 		Type:            from.Type,
 		Description:     from.Description,
 		TemplatePath:    from.TemplatePath,
+		TemplateVersion: from.TemplateVersion,
+		Templates:       ToTemplatesResponse(from.Templates),
 		Placeholders:    ToPlaceholdersResponse(from.Placeholders),
 		Functionalities: ToFunctionalitiesResponse(from.Functionalities),
 		Libraries:       ToLibrariesResponse(from.Libraries),
+		LintWarnings:    from.LintWarnings,
+	}
+}
+
+// ToTemplatesResponse converts a slice of usecase Templates
+func ToTemplatesResponse(from []dto.Template) []Template {
+	templates := make([]Template, len(from))
+	for i, t := range from {
+		templates[i] = Template{
+			Uuid:     t.Uuid,
+			Path:     t.Path,
+			Content:  t.Content,
+			Language: t.Language,
+			Scope:    t.Scope,
+			Checksum: t.Checksum,
+			Version:  t.Version,
+			Removed:  t.Removed,
+		}
 	}
+	return templates
 }
 
 func ToPlaceholdersResponse(from []dto.Placeholder) []Placeholder {
 	pl := make([]Placeholder, len(from))
 	for i, p := range from {
 		pl[i] = Placeholder{
-			Uuid:        p.Uuid,
-			Name:        p.Name,
-			Description: p.Description,
-			Type:        p.Type,
-			DefaultVal:  p.DefaultVal,
+			Uuid:          p.Uuid,
+			Name:          p.Name,
+			Description:   p.Description,
+			Type:          p.Type,
+			DefaultVal:    p.DefaultVal,
+			AllowedValues: p.AllowedValues,
 		}
 	}
 	return pl
@@ -0,0 +1,130 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// SchemaImportRequest imports a database schema, an OpenAPI document or .proto files into a new project, or merges
+// it into an existing one identified by projectId or projectName. A schema split over several files is sent as files.
+type SchemaImportRequest struct {
+	Content     string              `json:"content,omitempty"`
+	Files       []SchemaFileRequest `json:"files,omitempty" binding:"dive"`
+	Format      string              `json:"format" binding:"required"` // postgres, mysql, openapi or proto
+	ProjectId   uuid.UUID           `json:"projectId,omitempty"`
+	ProjectName string              `json:"projectName,omitempty"`
+}
+
+type SchemaFileRequest struct {
+	Name    string `json:"name" binding:"required"` // Path the other files import it by
+	Content string `json:"content" binding:"required"`
+}
+
+func (r SchemaImportRequest) Validate() error {
+	if r.Content == "" && len(r.Files) == 0 {
+		return validator.ValidationErrors{newFieldError("Content", "required_without", "Files", r.Content)}
+	}
+	if r.ProjectId == uuid.Nil && r.ProjectName == "" {
+		return validator.ValidationErrors{newFieldError("ProjectName", "required_without", "ProjectId", r.ProjectName)}
+	}
+	return nil
+}
+
+type SchemaImportResponse struct {
+	Project    Project  `json:"project"`
+	Created    []string `json:"created"`
+	Updated    []string `json:"updated"`
+	Operations []string `json:"operations"`
+	Unmapped   []string `json:"unmapped"`
+}
+
+func ToUseCaseSchemaImport(from SchemaImportRequest) dto.SchemaImport {
+	files := make([]dto.SchemaFile, len(from.Files))
+	for i, f := range from.Files {
+		files[i] = dto.SchemaFile{Name: f.Name, Content: f.Content}
+	}
+	return dto.SchemaImport{
+		Content:     from.Content,
+		Files:       files,
+		Format:      from.Format,
+		ProjectId:   from.ProjectId,
+		ProjectName: from.ProjectName,
+	}
+}
+
+func ToSchemaImportResponse(from dto.SchemaImportResult) SchemaImportResponse {
+	return SchemaImportResponse{
+		Project:    ToProjectResponse(from.Project),
+		Created:    from.Created,
+		Updated:    from.Updated,
+		Operations: from.Operations,
+		Unmapped:   from.Unmapped,
+	}
+}
+
+// SampleImportRequest asks for a draft of the entities of a resource, inferred from example JSON documents of it
+// such as API responses. A list of objects counts as one sample per object.
+type SampleImportRequest struct {
+	Resource string            `json:"resource" binding:"required"`
+	Samples  []json.RawMessage `json:"samples" binding:"required,min=1"`
+}
+
+type EntityDraftResponse struct {
+	Entities []Entity `json:"entities"`
+	Unmapped []string `json:"unmapped"`
+}
+
+// EntityImportRequest saves confirmed entities, such as a reviewed draft, into a new project, or merges them into
+// an existing one identified by projectId or projectName
+type EntityImportRequest struct {
+	Entities    []Entity  `json:"entities" binding:"required,min=1"`
+	ProjectId   uuid.UUID `json:"projectId,omitempty"`
+	ProjectName string    `json:"projectName,omitempty"`
+}
+
+func (r EntityImportRequest) Validate() error {
+	var validationErrs validator.ValidationErrors
+	if r.ProjectId == uuid.Nil && r.ProjectName == "" {
+		validationErrs = append(validationErrs, newFieldError("ProjectName", "required_without", "ProjectId", r.ProjectName))
+	}
+	for i, entity := range r.Entities {
+		if err := entity.Validate(); err != nil {
+			if ve, ok := err.(validator.ValidationErrors); ok {
+				validationErrs = append(validationErrs, ve...)
+			} else {
+				return fmt.Errorf("entity %d error: %w", i, err)
+			}
+		}
+	}
+	if len(validationErrs) > 0 {
+		return validationErrs
+	}
+	return nil
+}
+
+func ToUseCaseSampleImport(from SampleImportRequest) dto.SampleImport {
+	samples := make([]string, len(from.Samples))
+	for i, sample := range from.Samples {
+		samples[i] = string(sample)
+	}
+	return dto.SampleImport{Resource: from.Resource, Samples: samples}
+}
+
+func ToEntityDraftResponse(from dto.EntityDraft) EntityDraftResponse {
+	return EntityDraftResponse{
+		Entities: ToEntitiesResponse(from.Entities),
+		Unmapped: from.Unmapped,
+	}
+}
+
+func ToUseCaseEntityImport(from EntityImportRequest) dto.EntityImport {
+	return dto.EntityImport{
+		Entities:    ToUsecaseEntities(from.Entities),
+		ProjectId:   from.ProjectId,
+		ProjectName: from.ProjectName,
+	}
+}
@@ -43,6 +43,7 @@ type BackendJourney struct {
 	Error           string                     `json:"error,omitempty"`
 	Curl            string                     `json:"curl,omitempty"`
 	SampleResponse  string                     `json:"sampleResponse,omitempty"`
+	HTTPCall        *dto.HTTPCallSpec          `json:"httpCall,omitempty"` // Read only, parsed from curl and sampleResponse
 	Retry           bool                       `json:"retry,omitempty"`
 	RetryCount      int                        `json:"retryCount,omitempty"`
 	RetryInterval   int                        `json:"retryInterval,omitempty"`
@@ -168,6 +169,7 @@ func (bj *BackendJourney) FromUsecaseBackendJourneyDTO(ucBackendJourney *dto.Jou
 	bj.Error = ucBackendJourney.Error
 	bj.Curl = ucBackendJourney.Curl
 	bj.SampleResponse = ucBackendJourney.SampleResponse
+	bj.HTTPCall = ucBackendJourney.HTTPCall
 	bj.Retry = ucBackendJourney.Retry
 	bj.RetryCount = ucBackendJourney.RetryCount
 	bj.RetryInterval = ucBackendJourney.RetryInterval
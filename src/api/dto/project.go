@@ -5,6 +5,8 @@ import (
 	"gen-concept-api/enum"
 	"gen-concept-api/usecase/dto"
 	"reflect"
+	"regexp"
+	"strings"
 
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -144,6 +146,21 @@ func (e Entity) Validate() error {
 		}
 	}
 
+	// Cross-field rules compare with another field of the same entity
+	fieldNames := make(map[string]bool, len(e.EntityFields))
+	for _, field := range e.EntityFields {
+		fieldNames[strings.ToLower(field.FieldName)] = true
+	}
+	for _, field := range e.EntityFields {
+		for _, iv := range field.InputValidations {
+			if iv.RuleType == enum.CrossFieldRule && iv.OtherField != "" && !fieldNames[strings.ToLower(iv.OtherField)] {
+				validationErrs = append(
+					validationErrs,
+					newFieldError("OtherField", "invalid", "", fmt.Sprintf("%s is not a field of entity %s", iv.OtherField, e.EntityName)))
+			}
+		}
+	}
+
 	if len(validationErrs) > 0 {
 		return validationErrs
 	}
@@ -196,7 +213,11 @@ func (ef EntityField) Validate() error {
 
 	// Validate input validations
 	for i, iv := range ef.InputValidations {
-		if err := iv.Validate(); err != nil {
+		err := iv.Validate()
+		if err == nil {
+			err = iv.validateFor(ef)
+		}
+		if err != nil {
 			if ve, ok := err.(validator.ValidationErrors); ok {
 				validationErrs = append(validationErrs, ve...)
 			} else {
@@ -213,11 +234,59 @@ func (ef EntityField) Validate() error {
 func (iv InputValidation) Validate() error {
 	var validationErrs validator.ValidationErrors
 
-	if iv.Description == "" {
-		validationErrs = append(
-			validationErrs,
-			newFieldError("Description", "required", "", iv.Description),
-		)
+	switch iv.RuleType {
+	case enum.CustomRule:
+		if iv.Description == "" {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Description", "required", "", iv.Description),
+			)
+		}
+	case enum.LengthRule, enum.RangeRule:
+		if iv.Min == nil && iv.Max == nil {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Min", "required", "", "min or max is required for a "+iv.RuleType.String()+" rule"))
+		}
+		if iv.Min != nil && iv.Max != nil && *iv.Min > *iv.Max {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Max", "gtefield", "Min", fmt.Sprint(*iv.Max)))
+		}
+		if iv.RuleType == enum.LengthRule && ((iv.Min != nil && *iv.Min < 0) || (iv.Max != nil && *iv.Max < 0)) {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Min", "min", "0", "lengths can't be negative"))
+		}
+	case enum.PatternRule:
+		if iv.Pattern == "" {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Pattern", "required", "", "pattern is required for a PATTERN rule"))
+		} else if _, err := regexp.Compile(iv.Pattern); err != nil {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Pattern", "invalid", "", err.Error()))
+		}
+	case enum.OneOfRule:
+		if len(iv.AllowedValues) == 0 {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("AllowedValues", "required", "", "allowed values are required for a ONE_OF rule"))
+		}
+	case enum.CrossFieldRule:
+		if iv.OtherField == "" {
+			validationErrs = append(
+				validationErrs,
+				newFieldError("OtherField", "required", "", "other field is required for a CROSS_FIELD rule"))
+		}
+		switch iv.Operator {
+		case enum.Equals, enum.NotEquals, enum.GreaterThan, enum.GreaterThanOrEqual, enum.LessThan, enum.LessThanOrEqual:
+		default:
+			validationErrs = append(
+				validationErrs,
+				newFieldError("Operator", "oneof", "", "a CROSS_FIELD rule compares with EQUALS, NOT_EQUALS, GREATER_THAN, GREATER_THAN_OR_EQUAL, LESS_THAN or LESS_THAN_OR_EQUAL"))
+		}
 	}
 
 	if len(validationErrs) > 0 {
@@ -226,6 +295,28 @@ func (iv InputValidation) Validate() error {
 	return nil
 }
 
+// validateFor checks that the rule applies to the field's type
+func (iv InputValidation) validateFor(ef EntityField) error {
+	text := ef.FieldType == enum.String || ef.FieldType == enum.Enum
+	var fits bool
+	switch iv.RuleType {
+	case enum.LengthRule:
+		fits = text || ef.IsCollection
+	case enum.RangeRule:
+		fits = ef.FieldType == enum.Int || ef.FieldType == enum.Float
+	case enum.PatternRule, enum.EmailRule, enum.URLRule:
+		fits = ef.FieldType == enum.String
+	default:
+		fits = true
+	}
+	if !fits {
+		return validator.ValidationErrors{
+			newFieldError("RuleType", "invalid", "", fmt.Sprintf("a %s rule does not apply to the %s field %s", iv.RuleType, ef.FieldType, ef.FieldName)),
+		}
+	}
+	return nil
+}
+
 type Entity struct {
 	EntityName                 string             `json:"entityName"`
 	Uuid                       uuid.UUID          `json:"uuid"`
@@ -280,11 +371,20 @@ type EntityField struct {
 	InputValidations         []InputValidation       `json:"inputValidations"`
 }
 
+// InputValidation is a rule of an entity field. CUSTOM rules are described in free text; the other rule types
+// are generated as checks in the target language, see docs/FIELDS.md.
 type InputValidation struct {
-	Description        string    `json:"description"`
-	Uuid               uuid.UUID `json:"uuid"`
-	AbortOnFailure     bool      `json:"abortOnFailure"`
-	CustomErrorMessage string    `json:"customErrorMessage"`
+	Description        string                  `json:"description"`
+	Uuid               uuid.UUID               `json:"uuid"`
+	AbortOnFailure     bool                    `json:"abortOnFailure"`
+	CustomErrorMessage string                  `json:"customErrorMessage"`
+	RuleType           enum.ValidationRuleType `json:"ruleType"`
+	Min                *float64                `json:"min,omitempty"`
+	Max                *float64                `json:"max,omitempty"`
+	Pattern            string                  `json:"pattern,omitempty"`
+	AllowedValues      []string                `json:"allowedValues,omitempty"`
+	Operator           enum.OperatorType       `json:"operator"`
+	OtherField         string                  `json:"otherField,omitempty"`
 }
 
 func ToUseCaseProject(from Project) dto.Project {
@@ -387,6 +487,13 @@ func ToUseCaseInputValidation(from []InputValidation) []dto.InputValidation {
 			Description:        inputValidation.Description,
 			AbortOnFailure:     inputValidation.AbortOnFailure,
 			CustomErrorMessage: inputValidation.CustomErrorMessage,
+			RuleType:           inputValidation.RuleType,
+			Min:                inputValidation.Min,
+			Max:                inputValidation.Max,
+			Pattern:            inputValidation.Pattern,
+			AllowedValues:      inputValidation.AllowedValues,
+			Operator:           inputValidation.Operator,
+			OtherField:         inputValidation.OtherField,
 		})
 	}
 	return validations
@@ -497,6 +604,13 @@ func ToInputValidationResponse(from []dto.InputValidation) []InputValidation {
 			Uuid:               inputValidation.Uuid,
 			AbortOnFailure:     inputValidation.AbortOnFailure,
 			CustomErrorMessage: inputValidation.CustomErrorMessage,
+			RuleType:           inputValidation.RuleType,
+			Min:                inputValidation.Min,
+			Max:                inputValidation.Max,
+			Pattern:            inputValidation.Pattern,
+			AllowedValues:      inputValidation.AllowedValues,
+			Operator:           inputValidation.Operator,
+			OtherField:         inputValidation.OtherField,
 		})
 	}
 	return validations
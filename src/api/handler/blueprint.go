@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"gen-concept-api/api/dto"
 	"gen-concept-api/api/helper"
+	"gen-concept-api/api/validation"
 	"gen-concept-api/config"
 	"gen-concept-api/dependency"
 	"gen-concept-api/domain/filter"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase"
 	"net/http"
 
@@ -53,8 +57,7 @@ func (h *BlueprintHandler) Create(c *gin.Context) {
 	Blueprint, err := h.usecase.Create(c, dto.ToUseCaseBlueprint(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithTemplateLintError(c, err)
 		return
 	}
 
@@ -98,8 +101,7 @@ func (h *BlueprintHandler) Update(c *gin.Context) {
 	Blueprint, err := h.usecase.Update(c, uuid, dto.ToUseCaseBlueprint(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithTemplateLintError(c, err)
 		return
 	}
 
@@ -174,6 +176,37 @@ func (h *BlueprintHandler) GetById(c *gin.Context) {
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
 
+// GetTemplateHistory godoc
+// @Summary Get Blueprint template history
+// @Description Get every stored version of a Blueprint's template files
+// @Tags Blueprints
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.Template} "Template versions response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/Blueprints/{id}/templates/history [get]
+// @Security AuthBearer
+func (h *BlueprintHandler) GetTemplateHistory(c *gin.Context) {
+	uuidStr := c.Params.ByName("id")
+	uuid, uuidErr := uuid.Parse(uuidStr)
+	if uuidErr != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, uuidErr))
+		return
+	}
+
+	history, err := h.usecase.GetTemplateHistory(c, uuid)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(dto.ToTemplatesResponse(history), true, 0))
+}
+
 // GetProperties godoc
 // @Summary Get Properties
 // @Description Get Properties
@@ -220,3 +253,24 @@ func (h *BlueprintHandler) GetByFilter(c *gin.Context) {
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
+
+// abortWithTemplateLintError reports template lint errors as validation errors located by template and line
+func abortWithTemplateLintError(c *gin.Context, err error) {
+	var lintErr *service.TemplateLintError
+	if errors.As(err, &lintErr) {
+		validationErrors := make([]validation.ValidationError, len(lintErr.Issues))
+		for i, issue := range lintErr.Issues {
+			validationErrors[i] = validation.ValidationError{
+				Property: fmt.Sprintf("%s:%d", issue.Template, issue.Line),
+				Tag:      "invalid",
+				Message:  issue.Message,
+			}
+		}
+		response := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		response.ValidationErrors = &validationErrors
+		c.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+	c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+		helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"gen-concept-api/api/dto"
 	"gen-concept-api/api/helper"
+	"gen-concept-api/api/validation"
 	"gen-concept-api/config"
 	"gen-concept-api/dependency"
 	"gen-concept-api/domain/filter"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase"
 	"net/http"
 
@@ -53,8 +57,7 @@ func (h *EntityHandler) Create(c *gin.Context) {
 	Entity, err := h.usecase.Create(c, dto.ToUseCaseEntity(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithDerivedFieldError(c, err)
 		return
 	}
 
@@ -98,8 +101,7 @@ func (h *EntityHandler) Update(c *gin.Context) {
 	Entity, err := h.usecase.Update(c, uuid, dto.ToUseCaseEntity(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithDerivedFieldError(c, err)
 		return
 	}
 
@@ -220,3 +222,24 @@ func (h *EntityHandler) GetByFilter(c *gin.Context) {
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
+
+// abortWithDerivedFieldError answers invalid derivative expressions with 400 and one validation error per field
+func abortWithDerivedFieldError(c *gin.Context, err error) {
+	var derivedErr *service.DerivedFieldError
+	if errors.As(err, &derivedErr) {
+		validationErrors := make([]validation.ValidationError, len(derivedErr.Issues))
+		for i, issue := range derivedErr.Issues {
+			validationErrors[i] = validation.ValidationError{
+				Property: fmt.Sprintf("%s.%s.derivativeExpression", issue.Entity, issue.Field),
+				Tag:      "invalid",
+				Message:  issue.Message,
+			}
+		}
+		response := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		response.ValidationErrors = &validationErrors
+		c.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+	c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+		helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+}
@@ -53,8 +53,7 @@ func (h *EntityFieldHandler) Create(c *gin.Context) {
 	entityField, err := h.usecase.Create(c, dto.ToUseCaseEntityField(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithDerivedFieldError(c, err)
 		return
 	}
 
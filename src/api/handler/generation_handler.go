@@ -1,21 +1,31 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"gen-concept-api/api/helper"
+	"gen-concept-api/api/validation"
 	"gen-concept-api/config"
 	"gen-concept-api/dependency"
 	"gen-concept-api/domain/service"
 	gen_ai "gen-concept-api/infra/ai"
 	"gen-concept-api/infra/git"
+	"gen-concept-api/pkg/archive"
 	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type GenerationHandler struct {
-	usecase *usecase.GenerationUsecase
+	usecase         *usecase.GenerationUsecase
+	diffUsecase     *usecase.GenerationDiffUsecase
+	manifestUsecase *usecase.GenerationManifestUsecase
 }
 
 func NewGenerationHandler(cfg *config.Config) *GenerationHandler {
@@ -23,19 +33,45 @@ func NewGenerationHandler(cfg *config.Config) *GenerationHandler {
 	blueprintRepo := dependency.GetBlueprintRepository(cfg)
 	entityRepo := dependency.GetEntityRepository(cfg)
 	libraryRepo := dependency.GetLibraryRepository(cfg)
+	journeyRepo := dependency.GetJourneyRepository(cfg)
 	gitProvider := git.NewGitHubProvider() // Should probably be singleton or passed in
 	aiProvider := gen_ai.NewMockAIProvider()
-	genService := service.NewGenerationService(gitProvider, aiProvider, libraryRepo)
+	genService := service.NewGenerationService(gitProvider, aiProvider, libraryRepo, journeyRepo)
+	genService.SetSandbox(generationSandbox(cfg))
+
+	projectRepo := dependency.GetProjectRepository(cfg)
+	manifestRepo := dependency.GetGenerationManifestRepository(cfg)
+	userRepo := dependency.GetUserRepository(cfg)
 
 	return &GenerationHandler{
-		usecase: usecase.NewGenerationUsecase(cfg, blueprintRepo, entityRepo, genService),
+		usecase: usecase.NewGenerationUsecase(cfg, blueprintRepo, entityRepo, projectRepo, manifestRepo, userRepo, genService),
+		diffUsecase: usecase.NewGenerationDiffUsecase(cfg, blueprintRepo, entityRepo, projectRepo,
+			dependency.GetGenerationRunRepository(cfg), userRepo, genService),
+		manifestUsecase: usecase.NewGenerationManifestUsecase(cfg, manifestRepo, blueprintRepo, entityRepo, projectRepo, userRepo, genService),
+	}
+}
+
+// generationSandbox reads the limits templates render under from the configuration
+func generationSandbox(cfg *config.Config) service.Sandbox {
+	limits := cfg.Generation.Sandbox
+	sandbox := service.Sandbox{
+		Timeout:        limits.Timeout * time.Second,
+		MaxOutputBytes: limits.MaxOutputBytes,
+		MaxDepth:       limits.MaxDepth,
+	}
+	if len(limits.AllowedFunctions) > 0 {
+		sandbox.AllowedFunctions = limits.AllowedFunctions
 	}
+	return sandbox
 }
 
 func (h *GenerationHandler) Preview(c *gin.Context) {
 	request := struct {
-		BlueprintID string            `json:"blueprintId" binding:"required"`
-		Inputs      map[string]string `json:"inputs"`
+		BlueprintID     string            `json:"blueprintId" binding:"required"`
+		TemplateVersion int               `json:"templateVersion"` // 0 renders the current version
+		Inputs          map[string]string `json:"inputs"`
+		PreviousFiles   map[string]string `json:"previousFiles"`   // Earlier output by path, for protected regions
+		PreviousArchive []byte            `json:"previousArchive"` // Base64 zip or tar.gz of the earlier output
 	}{}
 
 	err := c.ShouldBindJSON(&request)
@@ -52,12 +88,203 @@ func (h *GenerationHandler) Preview(c *gin.Context) {
 		return
 	}
 
-	code, err := h.usecase.Generate(c, blueprintUUID, request.Inputs)
+	blueprint, file, regions, err := h.usecase.Generate(c, dto.GenerationRequest{
+		BlueprintID:     blueprintUUID,
+		TemplateVersion: request.TemplateVersion,
+		Inputs:          request.Inputs,
+		Previous:        dto.PreviousOutput{Files: request.PreviousFiles, Archive: request.PreviousArchive},
+	})
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+		abortWithGenerationError(c, err)
+		return
+	}
+
+	diagnostics := file.Diagnostics
+	if diagnostics == nil {
+		diagnostics = []service.Diagnostic{}
+	}
+	if regions == nil {
+		regions = []service.RegionIssue{}
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(map[string]interface{}{
+		"code":            file.Content,
+		"diagnostics":     diagnostics,
+		"regions":         regions,
+		"templateVersion": blueprint.TemplateVersion,
+	}, true, 0))
+}
+
+// Download renders every file declared by the blueprint and returns them as a zip or tar.gz archive
+func (h *GenerationHandler) Download(c *gin.Context) {
+	request := struct {
+		BlueprintID     string            `json:"blueprintId" binding:"required"`
+		TemplateVersion int               `json:"templateVersion"` // 0 renders the current version
+		Inputs          map[string]string `json:"inputs"`
+		Format          string            `json:"format"`          // zip (default) or tar.gz
+		PreviousFiles   map[string]string `json:"previousFiles"`   // Earlier output by path, for protected regions
+		PreviousArchive []byte            `json:"previousArchive"` // Base64 zip or tar.gz of the earlier output
+	}{}
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	blueprintUUID, err := uuid.Parse(request.BlueprintID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	format, err := archive.ParseFormat(request.Format)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	output, err := h.usecase.GenerateFiles(c, dto.GenerationRequest{
+		BlueprintID:     blueprintUUID,
+		TemplateVersion: request.TemplateVersion,
+		Inputs:          request.Inputs,
+		Previous:        dto.PreviousOutput{Files: request.PreviousFiles, Archive: request.PreviousArchive},
+	})
+	if err != nil {
+		abortWithGenerationError(c, err)
+		return
+	}
+
+	// Protected regions that could not be carried over travel with the archive so no code is lost
+	if len(output.Regions) > 0 {
+		c.Header("X-Gen-Region-Issues", strconv.Itoa(len(output.Regions)))
+	}
+	if len(output.Warnings) > 0 {
+		c.Header("X-Gen-Warnings", strconv.Itoa(len(output.Warnings)))
+	}
+	c.Header("X-Gen-Manifest", output.Manifest.Uuid.String())
+
+	// Build the archive in memory first so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, format, output.Entries()); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError,
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.GenerateBaseResponse(map[string]string{"code": code}, true, 0))
+	blueprint := output.Blueprint
+	fileName := blueprint.StandardName
+	if fileName == "" {
+		fileName = blueprint.Uuid.String()
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-v%d.%s"`, fileName, blueprint.TemplateVersion, format.Extension()))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+// Diff renders the blueprint for an entity or a project and returns a unified diff per file against the
+// uploaded baseline, or against the last recorded output for the same blueprint and entities
+func (h *GenerationHandler) Diff(c *gin.Context) {
+	request := struct {
+		BlueprintID     uuid.UUID         `json:"blueprintId" binding:"required"`
+		TemplateVersion int               `json:"templateVersion"` // 0 renders the current version
+		EntityID        *uuid.UUID        `json:"entityId"`
+		ProjectID       *uuid.UUID        `json:"projectId"`
+		Inputs          map[string]string `json:"inputs"`
+		BaselineFiles   map[string]string `json:"baselineFiles"`   // Baseline content by path
+		BaselineArchive []byte            `json:"baselineArchive"` // Base64 zip or tar.gz of the baseline
+	}{}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	res, err := h.diffUsecase.Diff(c, dto.GenerationDiffRequest{
+		BlueprintID:     request.BlueprintID,
+		TemplateVersion: request.TemplateVersion,
+		EntityID:        request.EntityID,
+		ProjectID:       request.ProjectID,
+		Inputs:          request.Inputs,
+		Baseline:        dto.PreviousOutput{Files: request.BaselineFiles, Archive: request.BaselineArchive},
+	})
+	if err != nil {
+		abortWithGenerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(res, true, helper.Success))
+}
+
+// GetManifest returns a recorded generation manifest
+func (h *GenerationHandler) GetManifest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	manifest, err := h.manifestUsecase.GetById(c, id)
+	if err != nil {
+		abortWithGenerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(map[string]interface{}{
+		"uuid":     manifest.Uuid,
+		"digest":   manifest.Digest,
+		"manifest": manifest.Content,
+	}, true, helper.Success))
+}
+
+// ReproduceManifest renders a recorded manifest again and reports whether the output is byte-identical
+func (h *GenerationHandler) ReproduceManifest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	res, err := h.manifestUsecase.Reproduce(c, id)
+	if err != nil {
+		abortWithGenerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(res, true, helper.Success))
+}
+
+// abortWithGenerationError reports unresolved placeholders as validation errors, templates stopped by the sandbox
+// as the limit they hit and anything else as an internal error
+func abortWithGenerationError(c *gin.Context, err error) {
+	var placeholderErr *service.PlaceholderError
+	if errors.As(err, &placeholderErr) {
+		validationErrors := make([]validation.ValidationError, len(placeholderErr.Issues))
+		for i, issue := range placeholderErr.Issues {
+			validationErrors[i] = validation.ValidationError{
+				Property: issue.Name,
+				Tag:      issue.Problem,
+				Value:    issue.Value,
+				Message:  issue.Message,
+			}
+		}
+		response := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		response.ValidationErrors = &validationErrors
+		c.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+	var limitErr *service.SandboxLimitError
+	if errors.As(err, &limitErr) {
+		validationErrors := []validation.ValidationError{{
+			Property: limitErr.Template,
+			Tag:      limitErr.Limit,
+			Value:    limitErr.Value,
+			Message:  limitErr.Error(),
+		}}
+		response := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		response.ValidationErrors = &validationErrors
+		c.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+	c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+		helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
 }
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"gen-concept-api/api/helper"
+	"gen-concept-api/config"
+	"gen-concept-api/dependency"
+	"gen-concept-api/domain/service"
+	gen_ai "gen-concept-api/infra/ai"
+	"gen-concept-api/infra/git"
+	"gen-concept-api/pkg/jobs"
+	"gen-concept-api/pkg/logging"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GenerationRunHandler struct {
+	usecase *usecase.GenerationRunUsecase
+}
+
+// NewGenerationRunHandler starts the worker pool of the background generation runs.
+// Runs a previous process of this instance left unfinished are marked failed first. Close stops the pool.
+func NewGenerationRunHandler(cfg *config.Config) *GenerationRunHandler {
+	libraryRepo := dependency.GetLibraryRepository(cfg)
+	journeyRepo := dependency.GetJourneyRepository(cfg)
+	genService := service.NewGenerationService(git.NewGitHubProvider(), gen_ai.NewMockAIProvider(), libraryRepo, journeyRepo)
+	genService.SetSandbox(generationSandbox(cfg))
+	pool := jobs.NewPool(cfg.Generation.Workers, cfg.Generation.MaxRunsPerOrganization)
+
+	u := usecase.NewGenerationRunUsecase(cfg,
+		dependency.GetGenerationRunRepository(cfg),
+		dependency.GetBlueprintRepository(cfg),
+		dependency.GetEntityRepository(cfg),
+		dependency.GetProjectRepository(cfg),
+		dependency.GetFileRepository(cfg),
+		dependency.GetGenerationManifestRepository(cfg),
+		dependency.GetUserRepository(cfg),
+		genService, pool)
+	if err := u.FailInterrupted(context.Background()); err != nil {
+		logging.NewLogger(cfg).Error(logging.Postgres, logging.Update, err.Error(), nil)
+	}
+	return &GenerationRunHandler{usecase: u}
+}
+
+// Close stops the worker pool on shutdown, failing the runs it had not finished
+func (h *GenerationRunHandler) Close(ctx context.Context) error {
+	return h.usecase.Close(ctx)
+}
+
+// Submit queues a generation of one or more entities and returns the run to poll
+func (h *GenerationRunHandler) Submit(c *gin.Context) {
+	request := struct {
+		BlueprintID     uuid.UUID         `json:"blueprintId" binding:"required"`
+		TemplateVersion int               `json:"templateVersion"` // 0 renders the current version
+		EntityIDs       []uuid.UUID       `json:"entityIds"`
+		Inputs          map[string]string `json:"inputs"`
+		Format          string            `json:"format"`          // zip (default) or tar.gz
+		PreviousFiles   map[string]string `json:"previousFiles"`   // Earlier output by path, for protected regions
+		PreviousArchive []byte            `json:"previousArchive"` // Base64 zip or tar.gz of the earlier output
+	}{}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	res, err := h.usecase.Submit(c, dto.GenerationRunRequest{
+		BlueprintID:     request.BlueprintID,
+		TemplateVersion: request.TemplateVersion,
+		EntityIDs:       request.EntityIDs,
+		Inputs:          request.Inputs,
+		Format:          request.Format,
+		Previous:        dto.PreviousOutput{Files: request.PreviousFiles, Archive: request.PreviousArchive},
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+	c.JSON(http.StatusAccepted, helper.GenerateBaseResponse(res, true, helper.Success))
+}
+
+// GetById reports the status and progress of a run
+func (h *GenerationRunHandler) GetById(c *gin.Context) {
+	h.withRun(c, h.usecase.GetById)
+}
+
+// Cancel stops a queued or running run
+func (h *GenerationRunHandler) Cancel(c *gin.Context) {
+	h.withRun(c, h.usecase.Cancel)
+}
+
+// Download serves the archive of a succeeded run
+func (h *GenerationRunHandler) Download(c *gin.Context) {
+	id, err := uuid.Parse(c.Params.ByName("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	file, err := h.usecase.Download(c, id)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+	c.Header("Content-Type", file.MimeType)
+	c.FileAttachment(filepath.Join(file.Directory, file.Name), fmt.Sprintf("generation-%s", file.Name))
+}
+
+func (h *GenerationRunHandler) withRun(c *gin.Context, action func(ctx context.Context, id uuid.UUID) (dto.GenerationRun, error)) {
+	id, err := uuid.Parse(c.Params.ByName("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	res, err := action(c, id)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(res, true, helper.Success))
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
 	api_dto "gen-concept-api/api/dto"
 	"gen-concept-api/api/helper"
 	"gen-concept-api/config"
+	"gen-concept-api/dependency"
 	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
 	"gen-concept-api/infra/parser"
+	"gen-concept-api/usecase"
 	"gen-concept-api/usecase/dto"
 	"net/http"
 
@@ -13,16 +17,29 @@ import (
 )
 
 type ImporterHandler struct {
-	service *service.ImporterService
+	service       *service.ImporterService
+	schemaUsecase *usecase.SchemaImportUsecase
 }
 
 func NewImporterHandler(cfg *config.Config) *ImporterHandler {
 	// Initialize dependencies
 	goParser := parser.NewGoParser()
 	importerService := service.NewImporterService(goParser)
+	importerService.RegisterSchemaParser("postgres", parser.NewSQLParser(enum.Postgres))
+	importerService.RegisterSchemaParser("mysql", parser.NewSQLParser(enum.Mysql))
+	importerService.RegisterSchemaParser("openapi", parser.NewOpenAPIParser())
+	protoParser := parser.NewProtoParser()
+	importerService.RegisterSchemaParser("proto", protoParser)
+	importerService.RegisterCodeParser("proto", protoParser)
+	importerService.RegisterCodeParser("typescript", parser.NewTypeScriptParser())
+	importerService.RegisterCodeParser("java", parser.NewJavaParser())
+	importerService.RegisterCodeParser("csharp", parser.NewCSharpParser())
+	importerService.RegisterCodeParser("python", parser.NewPythonParser())
+	importerService.RegisterSampleParser(parser.NewJSONSampleParser())
 
 	return &ImporterHandler{
-		service: importerService,
+		service:       importerService,
+		schemaUsecase: usecase.NewSchemaImportUsecase(cfg, dependency.GetProjectRepository(cfg), dependency.GetJourneyRepository(cfg), importerService),
 	}
 }
 
@@ -52,3 +69,111 @@ func (h *ImporterHandler) Parse(c *gin.Context) {
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
+
+// ImportProject godoc
+// @Summary Import a Project from a database schema, an OpenAPI document or .proto files
+// @Description Creates the entities, fields and relations of CREATE TABLE statements, OpenAPI schemas or protobuf messages in a new project, or merges them into an existing one. OpenAPI operations and gRPC methods are added to the project's journey.
+// @Tags Importer
+// @Accept json
+// @produces json
+// @Param Request body api_dto.SchemaImportRequest true "Schema to import"
+// @Success 200 {object} helper.BaseHttpResponse{result=api_dto.SchemaImportResponse} "Imported project"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/importer/project [post]
+// @Security AuthBearer
+func (h *ImporterHandler) ImportProject(c *gin.Context) {
+	request := new(api_dto.SchemaImportRequest)
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	result, err := h.schemaUsecase.ImportProject(c, api_dto.ToUseCaseSchemaImport(*request))
+	if err != nil {
+		var schemaErr *service.SchemaError
+		if errors.As(err, &schemaErr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest,
+				helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err))
+			return
+		}
+		abortWithJourneyError(c, err) // The merged journey may not pass its checks
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(api_dto.ToSchemaImportResponse(result), true, 0))
+}
+
+// DraftFromSamples godoc
+// @Summary Draft entities from example JSON documents
+// @Description Infers the fields, types, optionality, enums and nested entities of a resource from example documents such as API responses. Nothing is saved: the draft is reviewed and then sent to /v1/importer/entities.
+// @Tags Importer
+// @Accept json
+// @produces json
+// @Param Request body api_dto.SampleImportRequest true "Samples of the resource"
+// @Success 200 {object} helper.BaseHttpResponse{result=api_dto.EntityDraftResponse} "Draft entities"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/importer/samples [post]
+// @Security AuthBearer
+func (h *ImporterHandler) DraftFromSamples(c *gin.Context) {
+	request := new(api_dto.SampleImportRequest)
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	draft, err := h.schemaUsecase.DraftFromSamples(api_dto.ToUseCaseSampleImport(*request))
+	if err != nil {
+		var schemaErr *service.SchemaError
+		if errors.As(err, &schemaErr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest,
+				helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err))
+			return
+		}
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(api_dto.ToEntityDraftResponse(draft), true, 0))
+}
+
+// ImportEntities godoc
+// @Summary Save confirmed entities into a Project
+// @Description Creates the entities, such as a reviewed draft, in a new project, or merges them into an existing one
+// @Tags Importer
+// @Accept json
+// @produces json
+// @Param Request body api_dto.EntityImportRequest true "Entities to save"
+// @Success 200 {object} helper.BaseHttpResponse{result=api_dto.SchemaImportResponse} "Imported project"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/importer/entities [post]
+// @Security AuthBearer
+func (h *ImporterHandler) ImportEntities(c *gin.Context) {
+	request := new(api_dto.EntityImportRequest)
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	result, err := h.schemaUsecase.ImportEntities(c, api_dto.ToUseCaseEntityImport(*request))
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(api_dto.ToSchemaImportResponse(result), true, 0))
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"gen-concept-api/api/dto"
 	"gen-concept-api/api/helper"
+	"gen-concept-api/api/validation"
 	"gen-concept-api/config"
 	"gen-concept-api/dependency"
 	"gen-concept-api/domain/filter"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase"
 	"net/http"
 
@@ -19,7 +23,7 @@ type JourneyHandler struct {
 
 func NewJourneyHandler(cfg *config.Config) *JourneyHandler {
 	return &JourneyHandler{
-		usecase: usecase.NewJourneyUsecase(cfg, dependency.GetJourneyRepository(cfg)),
+		usecase: usecase.NewJourneyUsecase(cfg, dependency.GetJourneyRepository(cfg), dependency.GetProjectRepository(cfg)),
 	}
 }
 
@@ -48,8 +52,7 @@ func (h *JourneyHandler) Create(c *gin.Context) {
 	journey, err := h.usecase.Create(c, *request.ToUsecaseJourneyDTO())
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithJourneyError(c, err)
 		return
 	}
 
@@ -95,8 +98,7 @@ func (h *JourneyHandler) Update(c *gin.Context) {
 	journey, err := h.usecase.Update(c, uuid, *request.ToUsecaseJourneyDTO())
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithJourneyError(c, err)
 		return
 	}
 
@@ -220,3 +222,24 @@ func (h *JourneyHandler) GetByFilter(c *gin.Context) {
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
+
+// abortWithJourneyError reports invalid journey steps as validation errors and anything else as an internal error
+func abortWithJourneyError(c *gin.Context, err error) {
+	var journeyErr *service.JourneyValidationError
+	if errors.As(err, &journeyErr) {
+		validationErrors := make([]validation.ValidationError, len(journeyErr.Issues))
+		for i, issue := range journeyErr.Issues {
+			validationErrors[i] = validation.ValidationError{
+				Property: fmt.Sprintf("%s.%s.backendJourney[%d].%s", issue.Entity, issue.Operation, issue.StepIndex, issue.Property),
+				Tag:      "invalid",
+				Message:  issue.Message,
+			}
+		}
+		response := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		response.ValidationErrors = &validationErrors
+		c.AbortWithStatusJSON(http.StatusBadRequest, response)
+		return
+	}
+	c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+		helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+}
@@ -53,8 +53,7 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 	project, err := h.usecase.Create(c, dto.ToUseCaseProject(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithDerivedFieldError(c, err)
 		return
 	}
 
@@ -98,8 +97,7 @@ func (h *ProjectHandler) Update(c *gin.Context) {
 	project, err := h.usecase.Update(c, uuid, dto.ToUseCaseProject(*request))
 
 	if err != nil {
-		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		abortWithDerivedFieldError(c, err)
 		return
 	}
 
@@ -18,6 +18,13 @@ var StatusCodeMapping = map[string]int{
 	service_errors.UsernameExists:   409,
 	service_errors.RecordNotFound:   404,
 	service_errors.PermissionDenied: 403,
+
+	// Generation
+	service_errors.GenerationRunFinished: 409,
+	service_errors.GenerationRunNotReady: 409,
+	service_errors.InvalidPreviousOutput: 400,
+	service_errors.BaselineTooLarge:      413,
+	service_errors.EntityRequired:        400,
 }
 
 func TranslateErrorToStatusCode(err error) int {
@@ -14,5 +14,6 @@ func Blueprint(r *gin.RouterGroup, cfg *config.Config) {
 	r.PUT("/:id", h.Update)
 	r.DELETE("/:id", h.Delete)
 	r.GET("/:id", h.GetById)
+	r.GET("/:id/templates/history", h.GetTemplateHistory)
 	r.POST(GetByFilterExp, h.GetByFilter)
 }
@@ -7,8 +7,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Generation(r *gin.RouterGroup, cfg *config.Config) {
+// Generation registers the generation routes. The returned handler's worker pool must be closed on shutdown.
+func Generation(r *gin.RouterGroup, cfg *config.Config) *handler.GenerationRunHandler {
 	h := handler.NewGenerationHandler(cfg)
 
 	r.POST("/preview", h.Preview)
+	r.POST("/download", h.Download)
+	r.POST("/diff", h.Diff)
+	r.GET("/manifests/:id", h.GetManifest)
+	r.POST("/manifests/:id/reproduce", h.ReproduceManifest)
+
+	runs := handler.NewGenerationRunHandler(cfg)
+	r.POST("/runs", runs.Submit)
+	r.GET("/runs/:id", runs.GetById)
+	r.POST("/runs/:id/cancel", runs.Cancel)
+	r.GET("/runs/:id/download", runs.Download)
+	return runs
 }
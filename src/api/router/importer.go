@@ -11,4 +11,7 @@ func Importer(r *gin.RouterGroup, cfg *config.Config) {
 	h := handler.NewImporterHandler(cfg)
 
 	r.POST("/parse", h.Parse)
+	r.POST("/project", h.ImportProject)
+	r.POST("/samples", h.DraftFromSamples)
+	r.POST("/entities", h.ImportEntities)
 }
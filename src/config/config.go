@@ -10,14 +10,15 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Postgres PostgresConfig
-	Redis    RedisConfig
-	Password PasswordConfig
-	Cors     CorsConfig
-	Logger   LoggerConfig
-	Otp      OtpConfig
-	JWT      JWTConfig
+	Server     ServerConfig
+	Postgres   PostgresConfig
+	Redis      RedisConfig
+	Password   PasswordConfig
+	Cors       CorsConfig
+	Logger     LoggerConfig
+	Otp        OtpConfig
+	JWT        JWTConfig
+	Generation GenerationConfig
 }
 
 type ServerConfig struct {
@@ -84,6 +85,22 @@ type JWTConfig struct {
 	RefreshSecret              string
 }
 
+type GenerationConfig struct {
+	Workers                int    // Background generation runs executed at once
+	MaxRunsPerOrganization int    // Runs of one organization executed at once, 0 for no limit
+	OutputDirectory        string // Directory the archives of finished runs are stored in
+	InstanceID             string // Names this server among those sharing the database; defaults to the host name
+	Sandbox                SandboxConfig
+}
+
+// SandboxConfig limits template execution; 0 (or no allowed functions) disables a limit
+type SandboxConfig struct {
+	Timeout          time.Duration // Seconds a generation may take
+	MaxOutputBytes   int           // Size of one rendered file
+	MaxDepth         int           // Nested {{template}} calls
+	AllowedFunctions []string      // Engine template functions blueprints may call
+}
+
 func GetConfig() *Config {
 	cfgPath := getConfigPath(os.Getenv("APP_ENV"))
 	v, err := LoadConfig(cfgPath, "yml")
@@ -27,12 +27,7 @@ func GetPropertyRepository(cfg *config.Config) contractRepository.PropertyReposi
 	return infraRepository.NewBaseRepository[model.Property](cfg, preloads)
 }
 func GetProjectRepository(cfg *config.Config) contractRepository.ProjectRepository {
-	var preloads []database.PreloadEntity = []database.PreloadEntity{
-		{Entity: "Entities"},
-		{Entity: "Entities.DependsOnEntities"},
-		{Entity: "Entities.EntityFields"},
-		{Entity: "Entities.EntityFields.InputValidations"}}
-	return infraRepository.NewBaseRepository[model.Project](cfg, preloads)
+	return infraRepository.NewProjectRepository(cfg)
 }
 func GetEntityRepository(cfg *config.Config) contractRepository.EntityRepository {
 	var preloads []database.PreloadEntity = []database.PreloadEntity{
@@ -55,6 +50,15 @@ func GetBlueprintRepository(cfg *config.Config) contractRepository.BlueprintRepo
 	return infraRepository.NewBlueprintRepository(cfg)
 }
 
+func GetGenerationRunRepository(cfg *config.Config) contractRepository.GenerationRunRepository {
+	return infraRepository.NewGenerationRunRepository(cfg)
+}
+
+func GetGenerationManifestRepository(cfg *config.Config) contractRepository.GenerationManifestRepository {
+	var preloads []database.PreloadEntity = []database.PreloadEntity{}
+	return infraRepository.NewBaseRepository[model.GenerationManifest](cfg, preloads)
+}
+
 func GetLibraryRepository(cfg *config.Config) contractRepository.LibraryRepository {
 	return infraRepository.NewLibraryRepository(cfg)
 }
@@ -1,23 +1,28 @@
 package model
 
+import "gen-concept-api/enum"
+
 type Blueprint struct {
 	BaseModel
-	StandardName    string          `gorm:"size:255"`
-	Type            string          `gorm:"size:100"`
-	Description     string          `gorm:"size:1000"`
-	TemplatePath    string          `gorm:"size:1000"` // Path to the code template
-	Placeholders    []Placeholder   `gorm:"foreignKey:BlueprintID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Functionalities []Functionality `gorm:"foreignKey:BlueprintID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Libraries       []Library       `gorm:"many2many:blueprint_libraries"`
+	StandardName    string              `gorm:"size:255"`
+	Type            string              `gorm:"size:100"`
+	Description     string              `gorm:"size:1000"`
+	TemplatePath    string              `gorm:"size:1000"` // Path of the main template file
+	TemplateVersion int                 // Current template revision, bumped whenever a template file changes
+	Templates       []BlueprintTemplate `gorm:"foreignKey:BlueprintID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Placeholders    []Placeholder       `gorm:"foreignKey:BlueprintID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Functionalities []Functionality     `gorm:"foreignKey:BlueprintID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Libraries       []Library           `gorm:"many2many:blueprint_libraries"`
 }
 
 type Placeholder struct {
 	BaseModel
-	Name        string `gorm:"size:150;not null"`
-	Description string `gorm:"size:1000"`
-	Type        string `gorm:"size:50"` // e.g., String, Boolean, Enum
-	DefaultVal  string `gorm:"size:1000"`
-	BlueprintID uint
+	Name          string   `gorm:"size:150;not null"`
+	Description   string   `gorm:"size:1000"`
+	Type          string   `gorm:"size:50"` // e.g., String, Boolean, Int, Enum
+	DefaultVal    string   `gorm:"size:1000"`
+	AllowedValues []string `gorm:"type:text;serializer:json"` // Values accepted by an Enum placeholder
+	BlueprintID   uint
 }
 
 type Functionality struct {
@@ -37,3 +42,19 @@ type FunctionalOperation struct {
 	Description     string `gorm:"size:1000"`
 	FunctionalityID uint
 }
+
+// BlueprintTemplate is one version of a template file.
+// Rows are never edited: every change appends a row carrying the blueprint's next TemplateVersion,
+// so the files of any revision are the latest rows with Version <= that revision.
+// A path has at most one row per version.
+type BlueprintTemplate struct {
+	BaseModel
+	BlueprintID uint                     `gorm:"index;uniqueIndex:idx_blueprint_template_path_version"`
+	Path        string                   `gorm:"size:500;not null;uniqueIndex:idx_blueprint_template_path_version"`
+	Content     string                   `gorm:"type:text"`
+	Language    enum.ProgrammingLanguage `gorm:"type:varchar(50)"`
+	Scope       enum.TemplateScope       `gorm:"type:varchar(20)"` // Rendered once per entity or once per project
+	Checksum    string                   `gorm:"size:64"`
+	Version     int                      `gorm:"not null;uniqueIndex:idx_blueprint_template_path_version"`
+	Removed     bool                     // Tombstone: the file was deleted in this version
+}
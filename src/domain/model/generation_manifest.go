@@ -0,0 +1,69 @@
+package model
+
+import "github.com/google/uuid"
+
+// GenerationManifest records the provenance of a generation, so its output can be reproduced and verified
+type GenerationManifest struct {
+	BaseModel
+	OrganizationID  uint      `gorm:"index"`
+	BlueprintUuid   uuid.UUID `gorm:"type:uuid;index"`
+	TemplateVersion int
+	GenerationRunID *uint    `gorm:"index"`   // Set when the manifest belongs to a background run
+	Digest          string   `gorm:"size:64"` // SHA-256 of the manifest document
+	Content         Manifest `gorm:"type:text;serializer:json"`
+}
+
+// Manifest is the document written to gen-manifest.json next to the generated files
+type Manifest struct {
+	EngineVersion    string             `json:"engineVersion"`
+	Blueprint        ManifestBlueprint  `json:"blueprint"`
+	Projects         []ManifestSnapshot `json:"projects"`
+	Entities         []ManifestSnapshot `json:"entities"`
+	Journeys         []ManifestSnapshot `json:"journeys"`       // Journeys of the projects, which the entities' operations come from
+	Inputs           map[string]string  `json:"inputs"`         // Inputs as requested
+	ResolvedInputs   map[string]any     `json:"resolvedInputs"` // Placeholder values after defaults and coercion
+	Libraries        []ManifestLibrary  `json:"libraries"`
+	AICalls          []ManifestAICall   `json:"aiCalls"`
+	PreservedRegions []ManifestRegion   `json:"preservedRegions"` // Hand-written code carried over from the previous output
+	Files            []ManifestFile     `json:"files"`
+}
+
+type ManifestBlueprint struct {
+	Uuid            uuid.UUID `json:"uuid"`
+	Name            string    `json:"name"`
+	TemplateVersion int       `json:"templateVersion"`
+}
+
+// ManifestSnapshot identifies the state of an entity, project or journey by the hash of its content
+type ManifestSnapshot struct {
+	Uuid uuid.UUID `json:"uuid"`
+	Name string    `json:"name"`
+	Hash string    `json:"hash"`
+}
+
+type ManifestLibrary struct {
+	Uuid       uuid.UUID `json:"uuid"`
+	Name       string    `json:"name"`
+	Version    string    `json:"version"`
+	Tag        string    `json:"tag,omitempty"`
+	CommitHash string    `json:"commitHash,omitempty"`
+}
+
+// ManifestAICall is an AI prompt and the answer the generation used, replayed on reproduction
+type ManifestAICall struct {
+	PromptHash string `json:"promptHash"`
+	Prompt     string `json:"prompt"`
+	Response   string `json:"response"`
+}
+
+type ManifestRegion struct {
+	File    string `json:"file"`
+	Region  string `json:"region"`
+	Content string `json:"content"`
+}
+
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
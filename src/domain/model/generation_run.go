@@ -0,0 +1,40 @@
+package model
+
+import (
+	"gen-concept-api/enum"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GenerationRun is a generation executed in the background, for one or more entities of a blueprint.
+// Its archive is stored as a File once the run succeeds.
+type GenerationRun struct {
+	BaseModel
+	OrganizationID  uint                     `gorm:"index"`
+	BlueprintUuid   uuid.UUID                `gorm:"type:uuid;not null"`
+	TemplateVersion int                      // 0 renders the current version
+	EntityUuids     []uuid.UUID              `gorm:"type:text;serializer:json"`
+	Inputs          map[string]string        `gorm:"type:text;serializer:json"`
+	Format          string                   `gorm:"size:10"` // zip or tar.gz
+	Status          enum.GenerationRunStatus `gorm:"type:varchar(20);index"`
+	Instance        string                   `gorm:"size:255;index"` // Server instance whose worker pool executes the run
+	TotalFiles      int
+	CompletedFiles  int
+	Files           []GenerationRunFile `gorm:"type:text;serializer:json"`
+	RegionIssues    int                 // Protected regions that were not carried over, listed in the archive
+	Error           string              `gorm:"size:2000"`
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	FileID          *uint
+	File            *File      `gorm:"foreignKey:FileID"`
+	ManifestUuid    *uuid.UUID `gorm:"type:uuid"` // Manifest of the stored archive
+}
+
+// GenerationRunFile records a file rendered by a run
+type GenerationRunFile struct {
+	Path        string `json:"path"`
+	Entity      string `json:"entity,omitempty"`
+	Size        int    `json:"size"`
+	Diagnostics int    `json:"diagnostics"` // Number of formatter diagnostics
+}
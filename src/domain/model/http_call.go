@@ -0,0 +1,31 @@
+package model
+
+// HTTPCallSpec is the structured form of an API_CALL step: its curl command and sample response.
+// Values may hold {{field}} references, listed in Bindings.
+type HTTPCallSpec struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"` // Scheme, host and path, without the query string
+	Headers    map[string]string `json:"headers,omitempty"`
+	Query      map[string]string `json:"query,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	BodyFormat string            `json:"bodyFormat,omitempty"` // json, form, multipart or raw
+	BasicAuth  string            `json:"basicAuth,omitempty"`  // user:password given with -u
+	Bindings   []HTTPBinding     `json:"bindings,omitempty"`
+	Response   *ResponseSchema   `json:"response,omitempty"`
+}
+
+// HTTPBinding is a {{field}} reference inside the call
+type HTTPBinding struct {
+	Name     string `json:"name"`
+	Location string `json:"location"` // path, query:<name>, header:<name> or body
+	FieldID  string `json:"fieldId,omitempty"`
+	Resolved bool   `json:"resolved"` // The name matches one of the step's FieldsInvolved
+}
+
+// ResponseSchema is a JSON schema inferred from a sample response
+type ResponseSchema struct {
+	Type       string                     `json:"type"` // object, array, string, integer, number, boolean or null
+	Format     string                     `json:"format,omitempty"`
+	Properties map[string]*ResponseSchema `json:"properties,omitempty"`
+	Items      *ResponseSchema            `json:"items,omitempty"`
+}
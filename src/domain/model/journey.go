@@ -44,9 +44,10 @@ type JourneyStep struct {
 	FieldsInvolved  []FieldInvolved `gorm:"foreignKey:JourneyStepID"`
 	Condition       string          `gorm:"size:1000"`
 	AbortOnFail     bool
-	Error           string `gorm:"size:1000"`
-	Curl            string `gorm:"size:1000"`
-	SampleResponse  string `gorm:"size:1000"`
+	Error           string        `gorm:"size:1000"`
+	Curl            string        `gorm:"size:1000"`
+	SampleResponse  string        `gorm:"size:1000"`
+	HTTPCall        *HTTPCallSpec `gorm:"type:text;serializer:json"` // Parsed from Curl and SampleResponse when the journey is saved
 	Retry           bool
 	RetryCount      int
 	RetryInterval   int
@@ -80,5 +80,12 @@ type InputValidation struct {
 	EntityFieldID      uint
 	EntityField        EntityField `gorm:"foreignKey:EntityFieldID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	AbortOnFailure     bool
-	CustomErrorMessage string `gorm:"size:1000"`
+	CustomErrorMessage string                  `gorm:"size:1000"`
+	RuleType           enum.ValidationRuleType `gorm:"type:varchar(20)"`
+	Min                *float64                // Lower bound of a LENGTH or RANGE rule, nil when unbounded
+	Max                *float64                // Upper bound of a LENGTH or RANGE rule, nil when unbounded
+	Pattern            string                  `gorm:"size:1000"`                 // Regular expression of a PATTERN rule
+	AllowedValues      []string                `gorm:"type:text;serializer:json"` // Values of a ONE_OF rule
+	Operator           enum.OperatorType       `gorm:"type:varchar(30)"`          // Comparison of a CROSS_FIELD rule
+	OtherField         string                  `gorm:"size:250"`                  // Field a CROSS_FIELD rule compares with
 }
@@ -30,11 +30,17 @@ type PropertyRepository interface {
 }
 type ProjectRepository interface {
 	BaseRepository[model.Project]
+	// FindByName returns the project with the unique name, reporting whether there is one
+	FindByName(ctx context.Context, name string) (model.Project, bool, error)
+	// SaveProject creates or updates the project together with its entities, fields and relations and, when given,
+	// a journey of the project, all in one transaction
+	SaveProject(ctx context.Context, project *model.Project, journey *model.Journey) (*model.Project, error)
 }
 
 type JourneyRepository interface {
 	BaseRepository[model.Journey]
 	UpdateJourney(ctx context.Context, journey *model.Journey) (*model.Journey, error)
+	GetByProjectUuid(ctx context.Context, projectUuid uuid.UUID) ([]model.Journey, error)
 }
 
 type BlueprintRepository interface {
@@ -42,6 +48,25 @@ type BlueprintRepository interface {
 	CreateWithRelationships(ctx context.Context, blueprint model.Blueprint) (model.Blueprint, error)
 	UpdateWithRelationships(ctx context.Context, uuid uuid.UUID, blueprint model.Blueprint) (model.Blueprint, error)
 	GetByUuidWithRelationships(ctx context.Context, uuid uuid.UUID) (model.Blueprint, error)
+	GetByUuidAtTemplateVersion(ctx context.Context, uuid uuid.UUID, version int) (model.Blueprint, error)
+	GetTemplateHistory(ctx context.Context, uuid uuid.UUID) ([]model.BlueprintTemplate, error)
+}
+
+type GenerationRunRepository interface {
+	BaseRepository[model.GenerationRun]
+	// SaveProgress stores the status, progress, error, timestamps and output file of a run.
+	// The list of rendered files is only stored once the run is final.
+	SaveProgress(ctx context.Context, run *model.GenerationRun) error
+	// FailUnfinished marks the queued and running runs of the instance as failed, e.g. those left over by
+	// its previous process
+	FailUnfinished(ctx context.Context, instance string, message string) (int64, error)
+	// GetLatestSucceeded returns the newest succeeded run of the organization for the blueprint,
+	// generated for exactly the given entities
+	GetLatestSucceeded(ctx context.Context, organizationId uint, blueprintUuid uuid.UUID, entityUuids []uuid.UUID) (model.GenerationRun, error)
+}
+
+type GenerationManifestRepository interface {
+	BaseRepository[model.GenerationManifest]
 }
 
 type EntityRepository interface {
@@ -59,6 +84,7 @@ type UserRepository interface {
 	FetchUserInfo(ctx context.Context, username string, password string) (model.User, error)
 	GetDefaultRole(ctx context.Context) (roleId uint, err error)
 	CreateUser(ctx context.Context, u model.User) (model.User, error)
+	GetOrganizationID(ctx context.Context, userId uint) (uint, error)
 }
 
 type RoleRepository interface {
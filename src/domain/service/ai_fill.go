@@ -0,0 +1,283 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gen-concept-api/domain/model"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultAICacheLimit is how many AI answers a new GenerationService keeps
+const DefaultAICacheLimit = 1024
+
+// aiCache keeps AI answers by prompt hash, so rendering the same prompt twice gives the same output.
+// Beyond its limit the least recently used answer is evicted.
+type aiCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List // Of *aiCacheEntry, most recently used first
+	answers map[string]*list.Element
+}
+
+type aiCacheEntry struct {
+	key    string
+	answer string
+}
+
+func newAICache(limit int) *aiCache {
+	return &aiCache{limit: limit, order: list.New(), answers: make(map[string]*list.Element)}
+}
+
+func (c *aiCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.answers[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*aiCacheEntry).answer, true
+}
+
+func (c *aiCache) set(key, answer string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.answers[key]; ok {
+		element.Value.(*aiCacheEntry).answer = answer
+		c.order.MoveToFront(element)
+		return
+	}
+	c.answers[key] = c.order.PushFront(&aiCacheEntry{key: key, answer: answer})
+	c.evict()
+}
+
+// resize changes the limit, evicting what no longer fits
+func (c *aiCache) resize(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	c.evict()
+}
+
+func (c *aiCache) evict() {
+	for c.order.Len() > max(c.limit, 0) {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.answers, oldest.Value.(*aiCacheEntry).key)
+	}
+}
+
+// SetAICacheLimit changes how many AI answers are kept; 0 disables the cache
+func (s *GenerationService) SetAICacheLimit(entries int) {
+	s.aiCache.resize(entries)
+}
+
+// PromptHash identifies a prompt in the AI cache
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// AIRecorder collects the AI calls of a generation for its manifest. Answers it replays are used
+// instead of asking the provider, so a manifest reproduces the same output.
+type AIRecorder struct {
+	mu     sync.Mutex
+	calls  map[string]model.ManifestAICall
+	replay map[string]string
+}
+
+type aiRecorderKey struct{}
+
+// WithAIRecorder returns a context recording the AI calls of the generations run with it
+func WithAIRecorder(ctx context.Context, replay []model.ManifestAICall) (context.Context, *AIRecorder) {
+	recorder := &AIRecorder{calls: make(map[string]model.ManifestAICall), replay: make(map[string]string)}
+	for _, call := range replay {
+		recorder.replay[call.PromptHash] = call.Response
+	}
+	return context.WithValue(ctx, aiRecorderKey{}, recorder), recorder
+}
+
+// Calls returns the recorded calls ordered by prompt hash
+func (r *AIRecorder) Calls() []model.ManifestAICall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]model.ManifestAICall, 0, len(r.calls))
+	for _, call := range r.calls {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].PromptHash < calls[j].PromptHash })
+	return calls
+}
+
+func (r *AIRecorder) record(key, prompt, answer string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[key] = model.ManifestAICall{PromptHash: key, Prompt: prompt, Response: answer}
+}
+
+// complete asks the AI provider for an answer unless the prompt was answered before
+func (s *GenerationService) complete(ctx context.Context, prompt string) (string, error) {
+	key := PromptHash(prompt)
+	recorder, _ := ctx.Value(aiRecorderKey{}).(*AIRecorder)
+	if recorder != nil {
+		if answer, ok := recorder.replay[key]; ok {
+			recorder.record(key, prompt, answer)
+			return answer, nil
+		}
+	}
+
+	answer, ok := s.aiCache.get(key)
+	if !ok {
+		if s.aiProvider == nil {
+			return "", fmt.Errorf("no AI provider configured")
+		}
+		var err error
+		if answer, err = s.aiProvider.GenerateContent(prompt); err != nil {
+			return "", fmt.Errorf("AI provider failed: %v", err)
+		}
+		s.aiCache.set(key, answer)
+	}
+	if recorder != nil {
+		recorder.record(key, prompt, answer)
+	}
+	return answer, nil
+}
+
+// bindAI replaces the placeholder "ai" function of the template set with one answering for the context of the
+// file hooks is rendering
+func (s *GenerationService) bindAI(tmpl *template.Template, hooks *sandboxHooks) {
+	tmpl.Funcs(template.FuncMap{
+		"ai": func(instruction string, subjects ...interface{}) (string, error) {
+			render, err := hooks.current()
+			if err != nil {
+				return "", err
+			}
+			return s.complete(render.ctx, buildAIPrompt(instruction, subjects, render.genCtx))
+		},
+	})
+}
+
+// relatedJourneys returns the entity journeys of the entity's project that belong to the entity.
+// The project's journeys are loaded once per scope.
+func (s *GenerationService) relatedJourneys(ctx context.Context, scope *GenerationScope, entity model.Entity) ([]model.EntityJourney, error) {
+	if s.journeyRepo == nil {
+		return nil, nil
+	}
+	journeys, ok := scope.journeys[entity.ProjectUuid]
+	if !ok {
+		var err error
+		if journeys, err = s.journeyRepo.GetByProjectUuid(ctx, entity.ProjectUuid); err != nil {
+			return nil, err
+		}
+		scope.journeys[entity.ProjectUuid] = journeys
+	}
+
+	var related []model.EntityJourney
+	for _, j := range journeys {
+		for _, ej := range j.EntityJourneys {
+			if ej.EntityID == entity.Uuid.String() || strings.EqualFold(ej.EntityName, entity.EntityName) {
+				related = append(related, ej)
+			}
+		}
+	}
+	return related, nil
+}
+
+// buildAIPrompt describes the instruction's subjects (the context entity when none is given),
+// the entity's journey operations and the available library functions
+func buildAIPrompt(instruction string, subjects []interface{}, genCtx GenContext) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(instruction))
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "Language: %s\n", genCtx.Language)
+	if genCtx.ProjectName != "" {
+		fmt.Fprintf(&b, "Project: %s\n", genCtx.ProjectName)
+	}
+
+	if len(subjects) == 0 {
+		subjects = []interface{}{genCtx.Entity}
+	}
+	for _, subject := range subjects {
+		switch v := subject.(type) {
+		case GenEntity:
+			writeEntityPrompt(&b, v)
+		case *GenEntity:
+			writeEntityPrompt(&b, *v)
+		case GenField:
+			fmt.Fprintf(&b, "Field: %s\n", describeField(v))
+		case []GenField:
+			for _, f := range v {
+				fmt.Fprintf(&b, "Field: %s\n", describeField(f))
+			}
+		default:
+			fmt.Fprintf(&b, "Context: %v\n", v)
+		}
+	}
+
+	if len(genCtx.Operations) > 0 {
+		b.WriteString("Journeys:\n")
+		for _, op := range genCtx.Operations {
+			fmt.Fprintf(&b, "- %s %s", op.Type, op.Name)
+			if op.Description != "" {
+				fmt.Fprintf(&b, ": %s", op.Description)
+			}
+			b.WriteString("\n")
+			writeStepsPrompt(&b, op.Steps, "  ")
+		}
+	}
+
+	if len(genCtx.LibraryFunctions) > 0 {
+		keys := make([]string, 0, len(genCtx.LibraryFunctions))
+		for key := range genCtx.LibraryFunctions {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("Library functions to use:\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "- %s: %s\n", key, genCtx.LibraryFunctions[key])
+		}
+	}
+
+	b.WriteString("\nAnswer with code only, without explanations or markdown fences.")
+	return b.String()
+}
+
+func writeStepsPrompt(b *strings.Builder, steps []GenStep, indent string) {
+	for _, step := range steps {
+		fmt.Fprintf(b, "%s%d. %s", indent, step.Index, step.Type)
+		if step.Description != "" {
+			fmt.Fprintf(b, ": %s", step.Description)
+		}
+		b.WriteString("\n")
+		writeStepsPrompt(b, step.SubSteps, indent+"  ")
+	}
+}
+
+func writeEntityPrompt(b *strings.Builder, entity GenEntity) {
+	fmt.Fprintf(b, "Entity: %s\n", entity.Name)
+	if len(entity.Fields) > 0 {
+		b.WriteString("Fields:\n")
+		for _, f := range entity.Fields {
+			fmt.Fprintf(b, "- %s\n", describeField(f))
+		}
+	}
+}
+
+func describeField(f GenField) string {
+	description := fmt.Sprintf("%s %s", f.Name, f.Type)
+	if f.Nullable {
+		description += " (optional)"
+	} else {
+		description += " (required)"
+	}
+	if len(f.EnumValues) > 0 {
+		description += " one of " + strings.Join(f.EnumValues, ", ")
+	}
+	return description
+}
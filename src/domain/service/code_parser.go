@@ -1,15 +1,69 @@
 package service
 
+import (
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+)
+
 type ParsedMetadata struct {
 	Name   string
 	Fields []ParsedField
 }
 
+// ParsedField is a field of a class, struct or model read from source code. Whatever the language, its type,
+// optionality and validation annotations are mapped onto the properties of an entity field.
 type ParsedField struct {
-	Name string
-	Type string
+	Name                     string
+	Type                     string // As written in the source, e.g. List<String> or Optional[str]
+	FieldType                enum.DataType
+	IsOptional               bool // Nullable or may be left out, e.g. String?, name?: string or Optional[str]
+	IsCollection             bool
+	CollectionType           enum.CollectionType
+	CollectionItemType       enum.CollectionItemType
+	NestedCollectionItemType enum.CollectionItemType
+	Reference                string // Class held by the field or its items when it is not a value type
+	EnumValues               []string
+	Annotations              []ParsedAnnotation
+	InputValidations         []model.InputValidation // Read from annotations such as @Size(max = 10) or Field(max_length=10)
+}
+
+// ParsedAnnotation is an annotation, attribute, decorator or struct tag on a field, or a call configuring it
+// such as pydantic's Field(...)
+type ParsedAnnotation struct {
+	Name      string            // Without @, brackets or package, e.g. NotNull
+	Arguments map[string]string // Named arguments by name, positional ones by position from "0"
 }
 
 type CodeParser interface {
 	Parse(content string) (ParsedMetadata, error)
 }
+
+// ParsedSchema is the structure of a whole project read from a schema: its entities with their fields and relations,
+// and the operations on them when the schema describes an API
+type ParsedSchema struct {
+	Entities []model.Entity
+	Journeys []model.EntityJourney
+	Unmapped []string // Parts of the schema the entity model cannot express, e.g. a composite foreign key
+}
+
+// SchemaParser reads the entities of a project from a schema such as a SQL script or an OpenAPI document
+type SchemaParser interface {
+	ParseSchema(content string) (ParsedSchema, error)
+}
+
+// SchemaFile is one file of a schema split over several files that import each other
+type SchemaFile struct {
+	Name    string // Path other files import it by, e.g. shop/v1/order.proto
+	Content string
+}
+
+// MultiFileSchemaParser reads a schema from several files at once, resolving the references between them
+type MultiFileSchemaParser interface {
+	SchemaParser
+	ParseFiles(files []SchemaFile) (ParsedSchema, error)
+}
+
+// SampleParser infers the entities of a resource from example documents of it, such as API responses
+type SampleParser interface {
+	ParseSamples(resource string, samples []string) (ParsedSchema, error)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"gen-concept-api/pkg/expr"
+	"strings"
+)
+
+// statusCodeVar is the HTTP status of the last API call, always in scope of conditions
+const statusCodeVar = "statusCode"
+
+// responseVar prefixes the properties of API call responses, e.g. response.total
+const responseVar = "response"
+
+// findEntity returns the project entity an entity journey belongs to, matched by uuid or name
+func findEntity(entities []model.Entity, id, name string) *model.Entity {
+	for i := range entities {
+		if entities[i].Uuid.String() == id {
+			return &entities[i]
+		}
+	}
+	for i := range entities {
+		if strings.EqualFold(entities[i].EntityName, name) {
+			return &entities[i]
+		}
+	}
+	return nil
+}
+
+// ConditionScope lists what the conditions of an operation may reference: the entity fields
+// (also as entity.<field> and <entityVar>.<field>), the fields involved in its steps,
+// the response properties of its API calls (also as response.<property>) and statusCode.
+func ConditionScope(entity *model.Entity, steps []model.JourneyStep) expr.Scope {
+	scope := expr.Scope{statusCodeVar: expr.Int}
+
+	var walk func([]model.JourneyStep)
+	walk = func(steps []model.JourneyStep) {
+		for _, step := range steps {
+			for _, f := range step.FieldsInvolved {
+				if f.Name != "" {
+					scope[f.Name] = expr.Any
+				}
+			}
+			if strings.EqualFold(strings.TrimSpace(step.Type), enum.APICall.String()) {
+				spec := step.HTTPCall
+				if spec == nil {
+					spec, _ = BuildHTTPCallSpec(step)
+				}
+				if spec == nil || spec.Response == nil {
+					// Nothing is known about the response
+					scope[responseVar] = expr.Any
+				} else {
+					addSchemaScope(scope, responseVar, spec.Response, true)
+				}
+			}
+			walk(step.SubSteps)
+		}
+	}
+	walk(steps)
+
+	// Entity fields take precedence over response properties of the same name
+	if entity != nil {
+		for _, f := range entity.EntityFields {
+			t := fieldExprType(f)
+			scope[f.FieldName] = t
+			scope["entity."+f.FieldName] = t
+			scope[ToCamel(entity.EntityName)+"."+f.FieldName] = t
+		}
+	}
+	return scope
+}
+
+// addSchemaScope registers the properties of a response schema under the prefix,
+// and at the top level too when topLevel is set
+func addSchemaScope(scope expr.Scope, prefix string, schema *model.ResponseSchema, topLevel bool) {
+	if schema.Type != "object" || len(schema.Properties) == 0 {
+		scope[prefix] = schemaExprType(schema)
+		return
+	}
+	for name, property := range schema.Properties {
+		addSchemaScope(scope, prefix+"."+name, property, false)
+		if topLevel {
+			addSchemaScope(scope, name, property, false)
+		}
+	}
+}
+
+func schemaExprType(schema *model.ResponseSchema) expr.Type {
+	switch schema.Type {
+	case "integer":
+		return expr.Int
+	case "number":
+		return expr.Float
+	case "boolean":
+		return expr.Bool
+	case "array":
+		return expr.ListOf
+	case "string":
+		if schema.Format == "date-time" {
+			return expr.DateTime
+		}
+		return expr.String
+	}
+	return expr.Any
+}
+
+func fieldExprType(f model.EntityField) expr.Type {
+	if f.IsCollection {
+		return expr.ListOf
+	}
+	switch f.FieldType {
+	case enum.String, enum.Enum:
+		return expr.String
+	case enum.Int:
+		return expr.Int
+	case enum.Float:
+		return expr.Float
+	case enum.Bool:
+		return expr.Bool
+	case enum.DateTime:
+		return expr.DateTime
+	case enum.Collection:
+		return expr.ListOf
+	}
+	return expr.Any
+}
+
+// CheckCondition parses and type checks a condition against the scope
+func CheckCondition(condition string, scope expr.Scope) (expr.Node, error) {
+	node, err := expr.Parse(condition)
+	if err != nil {
+		return nil, err
+	}
+	if err := expr.CheckCondition(node, scope); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// conditionDialects are the languages conditions are transpiled to
+var conditionDialects = map[enum.ProgrammingLanguage]expr.Dialect{
+	enum.Golang:     expr.Go,
+	enum.TypeScript: expr.TypeScript,
+	enum.JavaScript: expr.TypeScript,
+	enum.Java:       expr.Java,
+	enum.Csharp:     expr.CSharp,
+	enum.Python:     expr.Python,
+}
+
+// conditionRenderer transpiles the conditions of one operation for the generation language
+type conditionRenderer struct {
+	language  enum.ProgrammingLanguage
+	entity    *model.Entity
+	entityVar string
+	scope     expr.Scope
+}
+
+func newConditionRenderer(language enum.ProgrammingLanguage, entity *model.Entity, steps []model.JourneyStep) *conditionRenderer {
+	r := &conditionRenderer{language: language, entity: entity, scope: ConditionScope(entity, steps)}
+	if entity != nil {
+		r.entityVar = ToCamel(entity.EntityName)
+	}
+	return r
+}
+
+// Render returns the condition as code of the language, or "" when it is empty,
+// invalid or the language has no dialect
+func (r *conditionRenderer) Render(condition string) string {
+	if r == nil || strings.TrimSpace(condition) == "" {
+		return ""
+	}
+	node, err := CheckCondition(condition, r.scope)
+	if err != nil {
+		return ""
+	}
+	return r.code(node)
+}
+
+// code transpiles a checked expression, or returns "" when the language has no dialect
+func (r *conditionRenderer) code(node expr.Node) string {
+	dialect, ok := conditionDialects[r.language]
+	if !ok {
+		return ""
+	}
+	code, err := expr.Transpile(node, dialect, r.scope, func(path []string) string {
+		return r.ident(dialect, path)
+	})
+	if err != nil {
+		return ""
+	}
+	return code
+}
+
+// ident renders a reference: entity fields through the entity variable, response properties
+// through the response variable and anything else as a local variable
+func (r *conditionRenderer) ident(dialect expr.Dialect, path []string) string {
+	receiver := ""
+	if len(path) > 1 && r.entity != nil &&
+		(strings.EqualFold(path[0], "entity") || strings.EqualFold(path[0], r.entityVar)) {
+		receiver, path = r.entityVar, path[1:]
+	} else if len(path) == 1 && r.isEntityField(path[0]) {
+		receiver = r.entityVar
+	} else if len(path) > 1 && strings.EqualFold(path[0], responseVar) {
+		receiver, path = responseVar, path[1:]
+	}
+
+	if receiver == "" {
+		parts := make([]string, len(path))
+		for i, p := range path {
+			parts[i] = localName(dialect, p)
+		}
+		return strings.Join(parts, ".")
+	}
+	parts := []string{localName(dialect, receiver)}
+	for _, p := range path {
+		parts = append(parts, memberName(dialect, p))
+	}
+	return strings.Join(parts, ".")
+}
+
+func (r *conditionRenderer) isEntityField(name string) bool {
+	if r.entity == nil {
+		return false
+	}
+	for _, f := range r.entity.EntityFields {
+		if strings.EqualFold(f.FieldName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// localName is the variable naming of the dialect
+func localName(dialect expr.Dialect, name string) string {
+	if dialect == expr.Python {
+		return ToSnake(name)
+	}
+	return ToCamel(name)
+}
+
+// memberName is the field access naming of the dialect
+func memberName(dialect expr.Dialect, name string) string {
+	switch dialect {
+	case expr.Go, expr.CSharp:
+		return ToPascal(name)
+	case expr.Java:
+		return "get" + ToPascal(name) + "()"
+	case expr.Python:
+		return ToSnake(name)
+	}
+	return ToCamel(name)
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gen-concept-api/domain/model"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bindingPattern matches a {{field}} reference inside a curl command
+var bindingPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w.]*)\s*\}\}`)
+
+// curlFlagsWithoutValue are the curl options that take no argument and do not change the call
+var curlFlagsWithoutValue = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-k": true, "--insecure": true,
+	"-L": true, "--location": true, "-v": true, "--verbose": true, "-i": true, "--include": true,
+	"-f": true, "--fail": true, "--compressed": true, "-g": true, "--globoff": true,
+}
+
+// curlFlagsIgnoredValue are the curl options whose argument does not change the call
+var curlFlagsIgnoredValue = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"--retry": true, "-w": true, "--write-out": true,
+}
+
+// BuildHTTPCallSpec parses the curl command and sample response of an API_CALL step.
+// It returns nil when the step has no curl command.
+func BuildHTTPCallSpec(step model.JourneyStep) (*model.HTTPCallSpec, error) {
+	if strings.TrimSpace(step.Curl) == "" {
+		return nil, nil
+	}
+	spec, err := ParseCurl(step.Curl, step.FieldsInvolved)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(step.SampleResponse) != "" {
+		schema, err := InferResponseSchema(step.SampleResponse)
+		if err != nil {
+			return nil, err
+		}
+		spec.Response = schema
+	}
+	return spec, nil
+}
+
+// ParseCurl turns a curl command into method, URL, headers, query and body,
+// and resolves its {{field}} bindings against the fields involved in the step
+func ParseCurl(command string, fields []model.FieldInvolved) (*model.HTTPCallSpec, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+
+	spec := &model.HTTPCallSpec{Headers: map[string]string{}}
+	var rawURL string
+	var data []string
+	var form []string
+	useGet := false
+	head := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl option %s needs a value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		// Short options may carry their value directly, e.g. -XPOST
+		if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune("XHdFu", rune(arg[1])) {
+			args = append(args[:i+1], append([]string{arg[2:]}, args[i+1:]...)...)
+			arg = arg[:2]
+		}
+
+		switch {
+		case arg == "-X" || arg == "--request":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			spec.Method = strings.ToUpper(v)
+		case arg == "-H" || arg == "--header":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			name, headerValue, ok := strings.Cut(v, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("invalid header %q", v)
+			}
+			spec.Headers[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+		case arg == "-d" || arg == "--data" || arg == "--data-raw" || arg == "--data-binary" || arg == "--data-urlencode" || arg == "--json":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, v)
+			if arg == "--json" {
+				spec.Headers["Content-Type"] = "application/json"
+			}
+		case arg == "-F" || arg == "--form":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			form = append(form, v)
+		case arg == "-u" || arg == "--user":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			spec.BasicAuth = v
+		case arg == "-A" || arg == "--user-agent":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			spec.Headers["User-Agent"] = v
+		case arg == "-b" || arg == "--cookie":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			spec.Headers["Cookie"] = v
+		case arg == "-e" || arg == "--referer":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			spec.Headers["Referer"] = v
+		case arg == "--url":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			rawURL = v
+		case arg == "-G" || arg == "--get":
+			useGet = true
+		case arg == "-I" || arg == "--head":
+			head = true
+		case curlFlagsWithoutValue[arg]:
+		case curlFlagsIgnoredValue[arg]:
+			if _, err := value(); err != nil {
+				return nil, err
+			}
+		case isCombinedShortFlags(arg):
+		case strings.HasPrefix(arg, "-"):
+			return nil, fmt.Errorf("unsupported curl option %s", arg)
+		default:
+			if rawURL != "" {
+				return nil, fmt.Errorf("curl command has more than one URL: %s and %s", rawURL, arg)
+			}
+			rawURL = arg
+		}
+	}
+
+	if rawURL == "" {
+		return nil, fmt.Errorf("curl command has no URL")
+	}
+	if len(data) > 0 && useGet {
+		rawURL = appendQuery(rawURL, strings.Join(data, "&"))
+		data = nil
+	}
+	if err := splitURL(spec, rawURL); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(form) > 0:
+		spec.Body = strings.Join(form, "&")
+		spec.BodyFormat = "multipart"
+	case len(data) > 0:
+		spec.Body = strings.Join(data, "&")
+		spec.BodyFormat = bodyFormat(spec.Body, spec.Headers)
+	}
+
+	if spec.Method == "" {
+		switch {
+		case head:
+			spec.Method = "HEAD"
+		case spec.Body != "":
+			spec.Method = "POST"
+		default:
+			spec.Method = "GET"
+		}
+	}
+	if len(spec.Headers) == 0 {
+		spec.Headers = nil
+	}
+
+	spec.Bindings = collectBindings(spec, fields)
+	return spec, nil
+}
+
+// splitURL stores the URL without its query and the query parameters, keeping {{field}} bindings intact
+func splitURL(spec *model.HTTPCallSpec, rawURL string) error {
+	// Bindings are swapped for plain tokens so the URL parser does not escape them
+	var bindings []string
+	masked := bindingPattern.ReplaceAllStringFunc(rawURL, func(b string) string {
+		bindings = append(bindings, b)
+		return fmt.Sprintf("gencurlbinding%dx", len(bindings)-1)
+	})
+	unmask := func(s string) string {
+		for i := len(bindings) - 1; i >= 0; i-- {
+			s = strings.ReplaceAll(s, fmt.Sprintf("gencurlbinding%dx", i), bindings[i])
+		}
+		return s
+	}
+
+	if !strings.Contains(masked, "://") {
+		masked = "http://" + masked
+	}
+	parsed, err := url.Parse(masked)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("invalid URL %q: missing host", rawURL)
+	}
+
+	query, err := url.ParseQuery(parsed.RawQuery)
+	if err != nil {
+		return fmt.Errorf("invalid query in URL %q: %v", rawURL, err)
+	}
+	if len(query) > 0 {
+		spec.Query = make(map[string]string, len(query))
+		for name, values := range query {
+			spec.Query[unmask(name)] = unmask(strings.Join(values, ","))
+		}
+	}
+
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	spec.URL = unmask(parsed.String())
+	return nil
+}
+
+func appendQuery(rawURL, query string) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + query
+	}
+	return rawURL + "?" + query
+}
+
+// bodyFormat guesses the format of a request body from its content type or shape
+func bodyFormat(body string, headers map[string]string) string {
+	for name, value := range headers {
+		if strings.EqualFold(name, "Content-Type") {
+			switch {
+			case strings.Contains(value, "json"):
+				return "json"
+			case strings.Contains(value, "x-www-form-urlencoded"):
+				return "form"
+			case strings.Contains(value, "multipart"):
+				return "multipart"
+			}
+		}
+	}
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return "json"
+	}
+	if strings.Contains(trimmed, "=") && !strings.ContainsAny(trimmed, " \n") {
+		return "form"
+	}
+	return "raw"
+}
+
+// collectBindings lists every {{field}} reference of the call and resolves it against the involved fields
+func collectBindings(spec *model.HTTPCallSpec, fields []model.FieldInvolved) []model.HTTPBinding {
+	var bindings []model.HTTPBinding
+	add := func(location, text string) {
+		for _, match := range bindingPattern.FindAllStringSubmatch(text, -1) {
+			binding := model.HTTPBinding{Name: match[1], Location: location}
+			for _, f := range fields {
+				if strings.EqualFold(f.Name, match[1]) || (f.ID != "" && f.ID == match[1]) {
+					binding.FieldID = f.ID
+					binding.Resolved = true
+					break
+				}
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+
+	add("path", spec.URL)
+	for _, name := range sortedKeys(spec.Query) {
+		add("query:"+name, spec.Query[name])
+	}
+	for _, name := range sortedKeys(spec.Headers) {
+		add("header:"+name, spec.Headers[name])
+	}
+	add("body", spec.Body)
+	add("basicAuth", spec.BasicAuth)
+	return bindings
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isCombinedShortFlags accepts grouped value-less short options such as -sSL
+func isCombinedShortFlags(arg string) bool {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+		return false
+	}
+	for _, c := range arg[1:] {
+		if !curlFlagsWithoutValue["-"+string(c)] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitShellWords splits a command line like a POSIX shell: single and double quotes,
+// backslash escapes and backslash-newline continuations
+func splitShellWords(command string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else if c == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]) {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == '\\':
+			if i+1 < len(runes) {
+				i++
+				if runes[i] == '\n' || runes[i] == '\r' {
+					continue
+				}
+				current.WriteRune(runes[i])
+				inWord = true
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in curl command", quote)
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// InferResponseSchema infers a typed schema from a sample JSON response
+func InferResponseSchema(sample string) (*model.ResponseSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(sample)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("sample response is not valid JSON: %v", err)
+	}
+	return inferSchema(value), nil
+}
+
+func inferSchema(value interface{}) *model.ResponseSchema {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		schema := &model.ResponseSchema{Type: "object", Properties: make(map[string]*model.ResponseSchema, len(v))}
+		for name, property := range v {
+			schema.Properties[name] = inferSchema(property)
+		}
+		return schema
+	case []interface{}:
+		schema := &model.ResponseSchema{Type: "array"}
+		for _, item := range v {
+			schema.Items = mergeSchemas(schema.Items, inferSchema(item))
+		}
+		return schema
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return &model.ResponseSchema{Type: "number"}
+		}
+		return &model.ResponseSchema{Type: "integer"}
+	case string:
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			return &model.ResponseSchema{Type: "string", Format: "date-time"}
+		}
+		return &model.ResponseSchema{Type: "string"}
+	case bool:
+		return &model.ResponseSchema{Type: "boolean"}
+	default:
+		return &model.ResponseSchema{Type: "null"}
+	}
+}
+
+// mergeSchemas combines the schemas of two array items, widening integer to number
+// and joining object properties
+func mergeSchemas(a, b *model.ResponseSchema) *model.ResponseSchema {
+	switch {
+	case a == nil || a.Type == "null":
+		return b
+	case b == nil || b.Type == "null":
+		return a
+	case a.Type == "object" && b.Type == "object":
+		for name, property := range b.Properties {
+			a.Properties[name] = mergeSchemas(a.Properties[name], property)
+		}
+		return a
+	case a.Type == "array" && b.Type == "array":
+		a.Items = mergeSchemas(a.Items, b.Items)
+		return a
+	case (a.Type == "integer" && b.Type == "number") || (a.Type == "number" && b.Type == "integer"):
+		return &model.ResponseSchema{Type: "number"}
+	case a.Type == b.Type:
+		if a.Format != b.Format {
+			return &model.ResponseSchema{Type: a.Type}
+		}
+		return a
+	default:
+		return a
+	}
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"gen-concept-api/pkg/expr"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DerivedFieldIssue points at a derived field whose expression cannot be used
+type DerivedFieldIssue struct {
+	Entity  string `json:"entity"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// DerivedFieldError lists every invalid derived field of an entity
+type DerivedFieldError struct {
+	Issues []DerivedFieldIssue
+}
+
+func (e *DerivedFieldError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = fmt.Sprintf("%s.%s: %s", issue.Entity, issue.Field, issue.Message)
+	}
+	return "invalid derived fields: " + strings.Join(messages, "; ")
+}
+
+// DerivedField is the compiled DerivativeExpression of a field
+type DerivedField struct {
+	Field     model.EntityField
+	Type      enum.DerivativeType
+	Node      expr.Node   // Parsed expression; nil for Runtime fields, which are computed by hand written code
+	Terms     []expr.Node // Parts joined by a Concatenation
+	DependsOn []string    // Sibling fields the expression reads
+}
+
+// derivedScope lists the sibling fields an expression may read
+func derivedScope(fields []model.EntityField) expr.Scope {
+	scope := expr.Scope{}
+	for _, f := range fields {
+		scope[f.FieldName] = fieldExprType(f)
+	}
+	return scope
+}
+
+// CompileDerivedFields parses the derived fields of an entity, checks them against their sibling fields
+// and orders them so every field comes after the derived fields it reads.
+// It returns a *DerivedFieldError listing unknown fields, type mismatches and circular derivations.
+func CompileDerivedFields(entityName string, fields []model.EntityField) ([]DerivedField, error) {
+	scope := derivedScope(fields)
+	var issues []DerivedFieldIssue
+	issue := func(field, format string, args ...interface{}) {
+		issues = append(issues, DerivedFieldIssue{Entity: entityName, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	compiled := make(map[string]*DerivedField)
+	var names []string
+	for _, f := range fields {
+		if !f.IsDerived {
+			continue
+		}
+		derived, err := compileDerivedField(f, scope)
+		if err != nil {
+			issue(f.FieldName, "%v", err)
+			continue
+		}
+		compiled[strings.ToLower(f.FieldName)] = derived
+		names = append(names, f.FieldName)
+	}
+
+	// Depth first walk over the derived fields, reporting each cycle once
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var ordered []DerivedField
+	var path []string
+	var visit func(name string)
+	visit = func(name string) {
+		key := strings.ToLower(name)
+		derived, ok := compiled[key]
+		if !ok {
+			return
+		}
+		switch state[key] {
+		case done:
+			return
+		case visiting:
+			start := 0
+			for i, p := range path {
+				if strings.EqualFold(p, name) {
+					start = i
+				}
+			}
+			cycle := append(append([]string(nil), path[start:]...), derived.Field.FieldName)
+			issue(derived.Field.FieldName, "circular derivation %s", strings.Join(cycle, " -> "))
+			return
+		}
+		state[key] = visiting
+		path = append(path, derived.Field.FieldName)
+		for _, dep := range derived.DependsOn {
+			visit(dep)
+		}
+		path = path[:len(path)-1]
+		state[key] = done
+		ordered = append(ordered, *derived)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+
+	if len(issues) > 0 {
+		return nil, &DerivedFieldError{Issues: issues}
+	}
+	return ordered, nil
+}
+
+func compileDerivedField(f model.EntityField, scope expr.Scope) (*DerivedField, error) {
+	derived := &DerivedField{Field: f, Type: f.DerivativeType}
+	if strings.TrimSpace(f.DerivativeExpression) == "" {
+		return nil, fmt.Errorf("derivative expression is empty")
+	}
+	if f.DerivativeType == enum.Runtime {
+		// Runtime values are described in prose and computed by the generated code's hooks
+		return derived, nil
+	}
+
+	node, err := expr.Parse(f.DerivativeExpression)
+	if err != nil {
+		return nil, err
+	}
+	derived.Node = node
+	derived.DependsOn = expr.Fields(node)
+
+	switch f.DerivativeType {
+	case enum.Concatenation:
+		derived.Terms = concatTerms(node)
+		for _, term := range derived.Terms {
+			t, err := expr.Check(term, scope)
+			if err != nil {
+				return nil, err
+			}
+			if t == expr.ListOf {
+				return nil, fmt.Errorf("cannot concatenate a list")
+			}
+		}
+		if fieldType := fieldExprType(f); fieldType != expr.String && fieldType != expr.Any {
+			return nil, fmt.Errorf("a concatenation produces a string, the field is %s", fieldType)
+		}
+	case enum.Arithmetic, enum.Formula:
+		t, err := expr.Check(node, scope)
+		if err != nil {
+			return nil, err
+		}
+		if f.DerivativeType == enum.Arithmetic && t != expr.Int && t != expr.Float && t != expr.Any {
+			return nil, fmt.Errorf("an arithmetic expression must produce a number, got %s", t)
+		}
+		if fieldType := fieldExprType(f); !assignable(fieldType, t) {
+			return nil, fmt.Errorf("the expression produces %s, the field is %s", t, fieldType)
+		}
+	default:
+		return nil, fmt.Errorf("derivative type is required")
+	}
+	return derived, nil
+}
+
+// concatTerms flattens a chain of + into its parts
+func concatTerms(node expr.Node) []expr.Node {
+	if b, ok := node.(*expr.Binary); ok && b.Op == "+" {
+		return append(concatTerms(b.X), concatTerms(b.Y)...)
+	}
+	return []expr.Node{node}
+}
+
+func assignable(field, value expr.Type) bool {
+	return field == expr.Any || value == expr.Any || field == value ||
+		(field == expr.Float && value == expr.Int)
+}
+
+// SampleValues parses the SampleData of the fields into typed values; fields without usable sample data are left out
+func SampleValues(fields []model.EntityField) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, f := range fields {
+		sample := strings.TrimSpace(f.SampleData)
+		if sample == "" || f.IsDerived {
+			continue
+		}
+		switch fieldExprType(f) {
+		case expr.Int:
+			if n, err := strconv.ParseInt(sample, 10, 64); err == nil {
+				values[f.FieldName] = n
+			}
+		case expr.Float:
+			if n, err := strconv.ParseFloat(sample, 64); err == nil {
+				values[f.FieldName] = n
+			}
+		case expr.Bool:
+			if b, err := strconv.ParseBool(sample); err == nil {
+				values[f.FieldName] = b
+			}
+		case expr.DateTime:
+			if t, err := time.Parse(time.RFC3339, sample); err == nil {
+				values[f.FieldName] = t
+			}
+		case expr.String:
+			values[f.FieldName] = sample
+		}
+	}
+	return values
+}
+
+// ComputeDerivedValues evaluates the derived fields over the given values of their siblings
+// and returns the values extended with the derived ones. Runtime fields are left out.
+func ComputeDerivedValues(entityName string, fields []model.EntityField, values map[string]interface{}) (map[string]interface{}, error) {
+	derivedFields, err := CompileDerivedFields(entityName, fields)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		result[k] = v
+	}
+	for _, derived := range derivedFields {
+		value, err := derived.Eval(result)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", entityName, derived.Field.FieldName, err)
+		}
+		if value != nil {
+			result[derived.Field.FieldName] = value
+		}
+	}
+	return result, nil
+}
+
+// Eval computes the value of the field from its siblings; Runtime fields have no value
+func (d DerivedField) Eval(values map[string]interface{}) (interface{}, error) {
+	if d.Node == nil {
+		return nil, nil
+	}
+	if d.Type != enum.Concatenation {
+		return expr.Eval(d.Node, values)
+	}
+	var b strings.Builder
+	for _, term := range d.Terms {
+		value, err := expr.Eval(term, values)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(formatSampleValue(value))
+	}
+	return b.String(), nil
+}
+
+// formatSampleValue renders a computed value the way SampleData holds it
+func formatSampleValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// computedColumnDatabases support generated columns written in portable SQL
+var computedColumnDatabases = map[enum.PreferredDB]bool{
+	enum.Postgres: true,
+	enum.Mysql:    true,
+}
+
+// buildGenDerived renders the compiled field for the language and database of the generation
+func buildGenDerived(derived DerivedField, entity *model.Entity, language enum.ProgrammingLanguage, database enum.PreferredDB) *GenDerived {
+	genDerived := &GenDerived{
+		Type:       derived.Type.String(),
+		Expression: derived.Field.DerivativeExpression,
+		DependsOn:  derived.DependsOn,
+	}
+	if derived.Node == nil {
+		return genDerived
+	}
+
+	renderer := newConditionRenderer(language, entity, nil)
+	if derived.Type == enum.Concatenation {
+		genDerived.Code, genDerived.Imports = renderer.concat(derived.Terms)
+	} else {
+		genDerived.Code = renderer.code(derived.Node)
+	}
+
+	if computedColumnDatabases[database] {
+		column := func(path []string) string { return ToSnake(strings.Join(path, "_")) }
+		var sql string
+		var err error
+		if derived.Type == enum.Concatenation {
+			parts := make([]string, len(derived.Terms))
+			for i, term := range derived.Terms {
+				if parts[i], err = expr.Transpile(term, expr.SQL, renderer.scope, column); err != nil {
+					break
+				}
+			}
+			sql = "CONCAT(" + strings.Join(parts, ", ") + ")"
+		} else {
+			sql, err = expr.Transpile(derived.Node, expr.SQL, renderer.scope, column)
+		}
+		if err == nil {
+			genDerived.ColumnExpression = sql
+		}
+	}
+	return genDerived
+}
+
+// concat joins the terms as a string in the language, converting terms that are not strings.
+// It returns the imports the conversion needs.
+func (r *conditionRenderer) concat(terms []expr.Node) (string, []string) {
+	dialect, ok := conditionDialects[r.language]
+	if !ok {
+		return "", nil
+	}
+	var imports []string
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		code, err := expr.Transpile(term, dialect, r.scope, func(path []string) string {
+			return r.ident(dialect, path)
+		})
+		if err != nil {
+			return "", nil
+		}
+		if _, isBinary := term.(*expr.Binary); isBinary {
+			code = "(" + code + ")"
+		}
+		if t, _ := expr.Check(term, r.scope); t != expr.String {
+			switch dialect {
+			case expr.Go:
+				code = "fmt.Sprint(" + code + ")"
+				imports = []string{"fmt"}
+			case expr.TypeScript:
+				code = "String(" + code + ")"
+			case expr.Java:
+				code = "String.valueOf(" + code + ")"
+			case expr.CSharp:
+				code = "Convert.ToString(" + code + ")"
+				imports = []string{"System"}
+			case expr.Python:
+				code = "str(" + code + ")"
+			}
+		}
+		parts[i] = code
+	}
+	return strings.Join(parts, " + "), imports
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gen-concept-api/enum"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is a problem found in a generated file
+type Diagnostic struct {
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Column       int    `json:"column"`
+	Message      string `json:"message"`
+	Template     string `json:"template,omitempty"`     // Template the file was rendered from
+	TemplateLine int    `json:"templateLine,omitempty"` // Set when the offending line appears literally in the template
+}
+
+// CodeFormatter formats the generated code of one language and reports its syntax problems.
+// It returns the code unchanged when it cannot parse it. imports lists the imports of the generation context.
+type CodeFormatter interface {
+	Format(file, code string, imports []string) (string, []Diagnostic)
+}
+
+// CodeFormatterFunc adapts a function to a CodeFormatter
+type CodeFormatterFunc func(file, code string, imports []string) (string, []Diagnostic)
+
+func (f CodeFormatterFunc) Format(file, code string, imports []string) (string, []Diagnostic) {
+	return f(file, code, imports)
+}
+
+// defaultFormatters formats Go and checks the brackets of the C-like languages
+func defaultFormatters() map[enum.ProgrammingLanguage]CodeFormatter {
+	brackets := CodeFormatterFunc(CheckBrackets)
+	return map[enum.ProgrammingLanguage]CodeFormatter{
+		enum.Golang:     CodeFormatterFunc(FormatGo),
+		enum.TypeScript: brackets,
+		enum.JavaScript: brackets,
+		enum.Java:       brackets,
+		enum.Csharp:     brackets,
+	}
+}
+
+// RegisterFormatter sets the formatter of a language; nil leaves the language's output untouched
+func (s *GenerationService) RegisterFormatter(language enum.ProgrammingLanguage, formatter CodeFormatter) {
+	if formatter == nil {
+		delete(s.formatters, language)
+		return
+	}
+	s.formatters[language] = formatter
+}
+
+// formatFile runs the language's formatter over a rendered file and points its diagnostics at the template
+func (s *GenerationService) formatFile(file GeneratedFile, language enum.ProgrammingLanguage, imports []string, templateName, templateSource string) GeneratedFile {
+	formatter, ok := s.formatters[language]
+	if !ok {
+		return file
+	}
+	content, diagnostics := formatter.Format(file.Path, file.Content, imports)
+	lines := strings.Split(file.Content, "\n")
+	for i := range diagnostics {
+		diagnostics[i].File = file.Path
+		diagnostics[i].Template = templateName
+		if diagnostics[i].Line >= 1 && diagnostics[i].Line <= len(lines) {
+			diagnostics[i].TemplateLine = locateTemplateLine(templateSource, lines[diagnostics[i].Line-1])
+		}
+	}
+	file.Content = content
+	file.Diagnostics = diagnostics
+	return file
+}
+
+// locateTemplateLine returns the 1-based line of the template holding the output line verbatim, or 0
+func locateTemplateLine(templateSource, outputLine string) int {
+	want := strings.TrimSpace(outputLine)
+	if want == "" {
+		return 0
+	}
+	for i, line := range strings.Split(templateSource, "\n") {
+		if strings.TrimSpace(line) == want {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// FormatGo formats a .go file with go/format. Imports listed by the generation context are added when the file
+// uses them and dropped when it does not. Other files, and files that do not parse, are returned unchanged.
+func FormatGo(file, code string, imports []string) (string, []Diagnostic) {
+	if !strings.HasSuffix(file, ".go") {
+		return code, nil
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, code, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return code, syntaxDiagnostics(err)
+	}
+
+	if fixed, changed := fixGoImports(fset, f, imports); changed {
+		code = fixed
+	}
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return code, syntaxDiagnostics(err)
+	}
+	return string(formatted), nil
+}
+
+func syntaxDiagnostics(err error) []Diagnostic {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		diagnostics := make([]Diagnostic, len(list))
+		for i, e := range list {
+			diagnostics[i] = Diagnostic{Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Msg}
+		}
+		return diagnostics
+	}
+	return []Diagnostic{{Message: err.Error()}}
+}
+
+// majorVersion matches the /v2 suffix of a module path
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// goImportName is the package name an import path is referenced by
+func goImportName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersion.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// fixGoImports rewrites the import block when a context import is used but missing, or imported but unused.
+// Imports the context does not know are kept as written.
+func fixGoImports(fset *token.FileSet, f *ast.File, contextImports []string) (string, bool) {
+	// Package names referenced as name.Member that are not local identifiers
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	known := make(map[string]bool)
+	for _, imp := range contextImports {
+		known[imp] = true
+	}
+
+	type spec struct{ name, path string }
+	var kept []spec
+	imported := make(map[string]bool)
+	changed := false
+	for _, is := range f.Imports {
+		importPath, _ := strconv.Unquote(is.Path.Value)
+		name := goImportName(importPath)
+		alias := ""
+		if is.Name != nil {
+			alias = is.Name.Name
+			name = alias
+		}
+		if known[importPath] && alias != "_" && alias != "." && !used[name] {
+			changed = true
+			continue
+		}
+		imported[importPath] = true
+		kept = append(kept, spec{alias, importPath})
+	}
+	for _, imp := range contextImports {
+		if !imported[imp] && used[goImportName(imp)] {
+			imported[imp] = true
+			kept = append(kept, spec{"", imp})
+			changed = true
+		}
+	}
+	if !changed {
+		return "", false
+	}
+
+	// Print the file without its import declarations and comments inside them
+	var decls []ast.Decl
+	var importRanges [][2]token.Pos
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			importRanges = append(importRanges, [2]token.Pos{gd.Pos(), gd.End()})
+			continue
+		}
+		decls = append(decls, d)
+	}
+	var comments []*ast.CommentGroup
+	for _, cg := range f.Comments {
+		inside := false
+		for _, r := range importRanges {
+			inside = inside || (cg.Pos() >= r[0] && cg.End() <= r[1])
+		}
+		if !inside {
+			comments = append(comments, cg)
+		}
+	}
+	stripped := &ast.File{Doc: f.Doc, Package: f.Package, Name: f.Name, Decls: decls, Comments: comments}
+	var buf bytes.Buffer
+	if err := (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(&buf, fset, stripped); err != nil {
+		return "", false
+	}
+	if len(kept) == 0 {
+		return buf.String(), true
+	}
+
+	// Standard library first, then everything else, each group sorted
+	sort.SliceStable(kept, func(i, j int) bool {
+		iStd, jStd := isStdImport(kept[i].path), isStdImport(kept[j].path)
+		if iStd != jStd {
+			return iStd
+		}
+		return kept[i].path < kept[j].path
+	})
+	var block strings.Builder
+	block.WriteString("\n\nimport (\n")
+	for i, s := range kept {
+		if i > 0 && isStdImport(kept[i-1].path) && !isStdImport(s.path) {
+			block.WriteString("\n")
+		}
+		block.WriteString("\t")
+		if s.name != "" {
+			block.WriteString(s.name + " ")
+		}
+		block.WriteString(strconv.Quote(s.path) + "\n")
+	}
+	block.WriteString(")")
+
+	// Insert the block right after the package clause of the printed file
+	printed := buf.String()
+	clauseFset := token.NewFileSet()
+	clause, err := parser.ParseFile(clauseFset, "", printed, parser.PackageClauseOnly)
+	if err != nil {
+		return "", false
+	}
+	offset := clauseFset.Position(clause.Name.End()).Offset
+	return printed[:offset] + block.String() + printed[offset:], true
+}
+
+func isStdImport(importPath string) bool {
+	first := strings.SplitN(importPath, "/", 2)[0]
+	return !strings.Contains(first, ".")
+}
+
+// CheckBrackets reports unbalanced (), [] and {} in C-like code, skipping strings and comments.
+// The code is returned unchanged.
+func CheckBrackets(file, code string, imports []string) (string, []Diagnostic) {
+	type open struct {
+		char         rune
+		line, column int
+	}
+	closing := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []open
+	var diagnostics []Diagnostic
+
+	runes := []rune(code)
+	line, column := 1, 0
+	advance := func(r rune) {
+		if r == '\n' {
+			line++
+			column = 0
+		} else {
+			column++
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		advance(r)
+		switch {
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+				advance(runes[i])
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i++
+			advance(runes[i])
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+				advance(runes[i])
+			}
+			if i+1 < len(runes) {
+				i++
+				advance(runes[i])
+			}
+		case r == '"' || r == '\'' || r == '`':
+			startLine, startColumn := line, column
+			closed := false
+			for i+1 < len(runes) {
+				i++
+				advance(runes[i])
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					advance(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					closed = true
+					break
+				}
+				if runes[i] == '\n' && r != '`' {
+					break
+				}
+			}
+			if !closed {
+				diagnostics = append(diagnostics, Diagnostic{Line: startLine, Column: startColumn, Message: fmt.Sprintf("unterminated %c string", r)})
+			}
+		case r == '(' || r == '[' || r == '{':
+			stack = append(stack, open{r, line, column})
+		case closing[r] != 0:
+			if len(stack) == 0 {
+				diagnostics = append(diagnostics, Diagnostic{Line: line, Column: column, Message: fmt.Sprintf("unexpected %c", r)})
+				continue
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top.char != closing[r] {
+				diagnostics = append(diagnostics, Diagnostic{Line: line, Column: column,
+					Message: fmt.Sprintf("%c does not close %c opened at line %d", r, top.char, top.line)})
+			}
+		}
+	}
+	for _, o := range stack {
+		diagnostics = append(diagnostics, Diagnostic{Line: o.line, Column: o.column, Message: fmt.Sprintf("%c is never closed", o.char)})
+	}
+	return code, diagnostics
+}
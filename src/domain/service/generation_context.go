@@ -1,11 +1,33 @@
 package service
 
+import (
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+)
+
 // GenContext holds all data required for smart code generation
 type GenContext struct {
 	ProjectName      string
-	Entity           GenEntity
+	Language         enum.ProgrammingLanguage // Language of the template being rendered
+	Database         enum.PreferredDB         // Preferred database of the entity
+	Project          GenProject               // Set for per-project files, and for per-entity files when the project's entities are loaded
+	Entity           GenEntity                // Empty in per-project files
+	Operations       []GenOperation           // Journey operations of the entity
+	Inputs           map[string]interface{}   // Resolved placeholder values, keyed by placeholder name
 	Imports          []string
 	LibraryFunctions map[string]string // Map of key (e.g. "Encrypt") to Function Name
+
+	warnings []string // Relations left out while building the context, see BuildRelations
+}
+
+// GenProject is the project being generated, with all of its entities
+type GenProject struct {
+	Name           string
+	Description    string
+	Type           string // ProjectType, e.g. Microservice
+	IsMultiTenant  bool
+	IsMultiLingual bool
+	Entities       []GenEntity // Every entity of the project, with its fields and relations
 }
 
 // GenEntity represents the entity model for generation
@@ -14,12 +36,149 @@ type GenEntity struct {
 	VarName    string // lowerCamelCase name
 	Fields     []GenField
 	PrimaryKey string
+	Relations  []GenRelation // Associations declared in DependsOnEntities, resolved against the project's entities
+	Checks     []string      // Check code of the fields' validation rules, e.g. Java @AssertTrue methods or pydantic validators
 }
 
 // GenField represents a field within the entity
 type GenField struct {
+	Name         string
+	Type         string // Language type, e.g. time.Time or List<String>
+	NullableType string // Language type of an optional value, e.g. *time.Time or Long
+	Nullable     bool   // The field is not mandatory
+	ColumnType   string // Database column type, empty when the field has no column (e.g. a list of related entities)
+	DataType     string // Raw data type of the field, e.g. DateTime
+	EnumName     string // Type name of an enum field, e.g. OrderStatus
+	EnumValues   []string
+	Imports      []string // Imports the field's types need
+	JSONTag      string
+	ValidateTag  string
+	SampleData   string      // Sample value; computed from the siblings' samples for derived fields
+	Derived      *GenDerived // Set when the field is derived from its siblings
+
+	// Typed input validation rules in the language's idiom. In Go their tags are also in ValidateTag.
+	Validations []GenValidation
+	Constraints []string // Code of the rules that have one: decorators, annotations, attributes or pydantic Field arguments
+}
+
+// GenDerived is the compiled DerivativeExpression of a derived field
+type GenDerived struct {
+	Type             string // Arithmetic, Formula, Concatenation or Runtime
+	Expression       string // As written, e.g. quantity * unitPrice
+	DependsOn        []string
+	Code             string   // Expression in the template language over the entity variable, e.g. order.Quantity * order.UnitPrice; empty for Runtime
+	ColumnExpression string   // SQL of a generated column on Postgres and MySQL, e.g. quantity * unit_price
+	Imports          []string // Imports Code needs
+}
+
+// GenOperation is a journey operation of the entity, e.g. CreateCustomer
+type GenOperation struct {
 	Name        string
-	Type        string
-	JSONTag     string
-	ValidateTag string
+	MethodName  string // PascalCase name, e.g. CreateCustomer
+	Type        string // CREATE, READ, UPDATE, DELETE, CUSTOM_API, READ_BY_ID or BACKGROUND_TASK
+	Description string
+	Steps       []GenStep // Top level backend steps ordered by index
+	Filters     []GenFilter
+	SortFields  []string
+}
+
+// GenStep is a backend journey step. Only the sub-context matching the step type is set,
+// so templates can use {{with .DbAction}} and friends.
+type GenStep struct {
+	Index          int
+	Type           string // e.g. INPUT_VALIDATION, DATABASE_OPERATION
+	Description    string
+	Level          string
+	FieldsInvolved []string
+	Condition      string
+	ConditionCode  string // Condition transpiled to the template language, empty when there is none
+	AbortOnFail    bool
+	Error          string
+
+	Validation      *GenValidationStep   // INPUT_VALIDATION and BUSINESS_VALIDATION
+	APICall         *GenAPICallStep      // API_CALL
+	DbAction        *GenDbActionStep     // DATABASE_OPERATION
+	CacheAction     *GenCacheActionStep  // CACHE_OPERATION
+	Notification    *GenNotificationStep // NOTIFICATION
+	Retry           *GenRetry            // Set when the step retries
+	ResponseActions []GenResponseAction
+	SubSteps        []GenStep
+}
+
+// GenValidationStep checks a condition over the involved fields
+type GenValidationStep struct {
+	Condition     string
+	ConditionCode string
+	Fields        []string
+	AbortOnFail   bool
+	Error         string
+}
+
+// GenAPICallStep calls another API. The call fields are empty when the curl command cannot be parsed.
+type GenAPICallStep struct {
+	Curl           string
+	SampleResponse string
+	Method         string
+	URL            string
+	Headers        map[string]string
+	Query          map[string]string
+	Body           string
+	BodyFormat     string
+	Bindings       []model.HTTPBinding   // {{field}} references of the call
+	Response       *model.ResponseSchema // Inferred from the sample response
+}
+
+// GenDbActionStep runs a database action
+type GenDbActionStep struct {
+	Action         string // e.g. INSERT, READ, UPSERT
+	FailOnNotFound bool
+}
+
+// GenCacheActionStep reads from or writes to the cache
+type GenCacheActionStep struct {
+	Action string // READ or INSERT
+}
+
+// GenNotificationStep notifies recipients over channels
+type GenNotificationStep struct {
+	Channels   []string
+	Message    string
+	Recipients []string
+}
+
+// GenRetry holds the retry settings of a step
+type GenRetry struct {
+	Count      int
+	Interval   int
+	Conditions []GenRetryCondition
+}
+
+type GenRetryCondition struct {
+	Condition     string
+	ConditionCode string
+	Error         string
+}
+
+// GenResponseAction is applied to the response of a step
+type GenResponseAction struct {
+	Index         int
+	Type          string // e.g. SET_FIELD, VALIDATION
+	FieldID       string
+	Value         string
+	Description   string
+	Fields        []string
+	Condition     string
+	ConditionCode string
+	AbortOnFail   bool
+	Error         string
+	Nested        *GenResponseAction
+}
+
+// GenFilter is a filter accepted by a read operation
+type GenFilter struct {
+	Name     string
+	Type     string
+	FieldID  string
+	Operator string
+	Error    string
 }
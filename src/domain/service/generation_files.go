@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// GeneratedFile is a single rendered file of a multi-file generation
+type GeneratedFile struct {
+	Path        string
+	Content     string
+	Diagnostics []Diagnostic // Syntax problems reported by the language's formatter
+}
+
+// GenerateFiles renders every per-entity file of the blueprint for the entity: each template file (except partials
+// whose name starts with "_" and per-project templates) and each path declared in a functionality's FilePathsCSV.
+// Paths are templates themselves (e.g. internal/{{.Entity.VarName}}/handler.go).
+// A file's content comes from the template named by its unrendered path, falling back to the main template.
+// Each file is formatted for its language, with the formatter's findings in its Diagnostics.
+// Missing or invalid placeholder inputs fail with a *PlaceholderError.
+func (s *GenerationService) GenerateFiles(ctx context.Context, blueprint model.Blueprint, entity model.Entity, inputs map[string]string) ([]GeneratedFile, error) {
+	return s.GenerateFilesWithProgress(ctx, nil, blueprint, entity, inputs, nil)
+}
+
+// GenerateFilesWithProgress is GenerateFiles calling progress after each rendered file.
+// It stops with the context's error once ctx is cancelled.
+// Per-project template files are left out, GenerateProjectFiles renders them.
+// Generations of several entities pass one scope for all of them (see BuildContext).
+func (s *GenerationService) GenerateFilesWithProgress(ctx context.Context, scope *GenerationScope, blueprint model.Blueprint, entity model.Entity, inputs map[string]string, progress func(file GeneratedFile)) ([]GeneratedFile, error) {
+	return s.renderFiles(ctx, blueprint, EntityFilePaths(blueprint), inputs, func(ctx context.Context, language enum.ProgrammingLanguage) (GenContext, error) {
+		return s.BuildContext(ctx, scope, entity, language)
+	}, progress)
+}
+
+// GenerateProjectFiles renders the per-project template files of the blueprint once for the project,
+// with every entity of the project in .Project (see BuildProjectContext). Its warnings go to the scope, which may be nil.
+func (s *GenerationService) GenerateProjectFiles(ctx context.Context, scope *GenerationScope, blueprint model.Blueprint, project model.Project, inputs map[string]string, progress func(file GeneratedFile)) ([]GeneratedFile, error) {
+	return s.renderFiles(ctx, blueprint, ProjectFilePaths(blueprint), inputs, func(ctx context.Context, language enum.ProgrammingLanguage) (GenContext, error) {
+		genCtx, err := s.BuildProjectContext(ctx, project, language)
+		scope.warn(genCtx.warnings...)
+		return genCtx, err
+	}, progress)
+}
+
+// renderFiles renders the given paths of the blueprint, each with the context build returns for its template's language
+func (s *GenerationService) renderFiles(ctx context.Context, blueprint model.Blueprint, paths []string, inputs map[string]string,
+	build func(ctx context.Context, language enum.ProgrammingLanguage) (GenContext, error), progress func(file GeneratedFile)) ([]GeneratedFile, error) {
+	if len(OutputFilePaths(blueprint)) == 0 {
+		return nil, fmt.Errorf("blueprint %s declares no files", blueprint.StandardName)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	resolved, err := ResolvePlaceholders(blueprint.Placeholders, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.sandbox.withTimeout(ctx)
+	defer cancel()
+
+	tmpl, hooks, err := s.parseTemplates(blueprint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each file is rendered with the types of its template's language
+	contexts := make(map[enum.ProgrammingLanguage]GenContext)
+	contextFor := func(language enum.ProgrammingLanguage) (GenContext, error) {
+		if genCtx, ok := contexts[language]; ok {
+			return genCtx, nil
+		}
+		genCtx, err := build(ctx, language)
+		if err != nil {
+			return GenContext{}, err
+		}
+		genCtx.Inputs = resolved
+		contexts[language] = genCtx
+		return genCtx, nil
+	}
+
+	files := make([]GeneratedFile, 0, len(paths))
+	seen := make(map[string]string)
+	for _, rawPath := range paths {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx) // The sandbox timeout, or the caller's cancellation
+		}
+		fileTmpl := tmpl
+		if named := tmpl.Lookup(rawPath); named != nil {
+			fileTmpl = named
+		}
+
+		language := templateLanguage(blueprint, fileTmpl.Name())
+		genCtx, err := contextFor(language)
+		if err != nil {
+			return nil, err
+		}
+
+		filePath, err := s.renderFilePath(ctx, rawPath, genCtx)
+		if err != nil {
+			return nil, err
+		}
+		if previous, ok := seen[filePath]; ok {
+			return nil, fmt.Errorf("file paths %q and %q both render to %s", previous, rawPath, filePath)
+		}
+		seen[filePath] = rawPath
+
+		content, err := s.execute(ctx, hooks, fileTmpl, genCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute template for %s: %w", filePath, err)
+		}
+		file := s.formatFile(GeneratedFile{Path: filePath, Content: content}, language, genCtx.Imports, fileTmpl.Name(), templateSource(blueprint, fileTmpl.Name()))
+		files = append(files, file)
+		if progress != nil {
+			progress(file)
+		}
+	}
+
+	return files, nil
+}
+
+// OutputFilePaths lists the unrendered paths of every file a blueprint produces:
+// its template files first, then paths declared only through FilePathsCSV.
+func OutputFilePaths(blueprint model.Blueprint) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, t := range blueprint.Templates {
+		if isPartialTemplate(t.Path) || seen[t.Path] {
+			continue
+		}
+		seen[t.Path] = true
+		paths = append(paths, t.Path)
+	}
+	for _, p := range DeclaredFilePaths(blueprint) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// EntityFilePaths lists the unrendered paths of the files rendered once per entity: every output file
+// except the per-project template files
+func EntityFilePaths(blueprint model.Blueprint) []string {
+	project := make(map[string]bool)
+	for _, p := range ProjectFilePaths(blueprint) {
+		project[p] = true
+	}
+	var paths []string
+	for _, p := range OutputFilePaths(blueprint) {
+		if !project[p] {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// ProjectFilePaths lists the unrendered paths of the template files rendered once per project
+func ProjectFilePaths(blueprint model.Blueprint) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, t := range blueprint.Templates {
+		if t.Scope == enum.ScopeProject && !isPartialTemplate(t.Path) && !seen[t.Path] {
+			seen[t.Path] = true
+			paths = append(paths, t.Path)
+		}
+	}
+	return paths
+}
+
+// isPartialTemplate reports whether a template file only holds shared definitions and produces no output file
+func isPartialTemplate(templatePath string) bool {
+	return strings.HasPrefix(path.Base(templatePath), "_")
+}
+
+// DeclaredFilePaths collects the distinct file paths listed in the FilePathsCSV of every functionality
+func DeclaredFilePaths(blueprint model.Blueprint) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, f := range blueprint.Functionalities {
+		for _, p := range strings.Split(f.FilePathsCSV, ",") {
+			p = strings.TrimSpace(p)
+			if p == "" || seen[p] {
+				continue
+			}
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// renderFilePath executes a path template and makes sure the result stays inside the output tree
+func (s *GenerationService) renderFilePath(ctx context.Context, rawPath string, genCtx GenContext) (string, error) {
+	tmpl, err := template.New("path").Funcs(TemplateFuncs()).Parse(rawPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse file path %q: %v", rawPath, err)
+	}
+	hooks, err := s.sandboxTemplates(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := s.execute(ctx, hooks, tmpl, genCtx)
+	if err != nil {
+		return "", fmt.Errorf("failed to render file path %q: %w", rawPath, err)
+	}
+
+	rendered := strings.ReplaceAll(strings.TrimSpace(content), "\\", "/")
+	cleaned := path.Clean(rendered)
+	if rendered == "" || cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("file path %q renders to invalid path %q", rawPath, rendered)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"sort"
+	"strings"
+)
+
+// buildOperations converts the entity journeys into generation operations with ordered, nested steps,
+// their conditions transpiled to the language
+func buildOperations(journeys []model.EntityJourney, entity *model.Entity, language enum.ProgrammingLanguage) []GenOperation {
+	var operations []GenOperation
+	for _, ej := range journeys {
+		for _, op := range ej.Operations {
+			conditions := newConditionRenderer(language, entity, op.BackendJourney)
+			genOp := GenOperation{
+				Name:        op.Name,
+				MethodName:  ToPascal(op.Name),
+				Type:        op.Type.String(),
+				Description: op.Description,
+				Steps:       buildSteps(op.BackendJourney, conditions),
+			}
+			for _, f := range op.Filters {
+				genOp.Filters = append(genOp.Filters, GenFilter{
+					Name:     f.Name,
+					Type:     f.Type.String(),
+					FieldID:  f.FieldID,
+					Operator: f.Operator.String(),
+					Error:    f.Error,
+				})
+			}
+			for _, s := range op.Sort {
+				genOp.SortFields = append(genOp.SortFields, s.FieldID)
+			}
+			operations = append(operations, genOp)
+		}
+	}
+	return operations
+}
+
+// buildSteps orders the steps by index and nests sub steps under their parent.
+// Sub steps may arrive either preloaded on their parent or in the same flat list with a ParentStepID.
+func buildSteps(steps []model.JourneyStep, conditions *conditionRenderer) []GenStep {
+	children := make(map[uint][]model.JourneyStep)
+	known := make(map[uint]bool)
+	var roots []model.JourneyStep
+	for _, step := range steps {
+		known[step.ID] = true
+	}
+	for _, step := range steps {
+		if step.ParentStepID != nil && known[*step.ParentStepID] {
+			children[*step.ParentStepID] = append(children[*step.ParentStepID], step)
+			continue
+		}
+		roots = append(roots, step)
+	}
+	return nestSteps(roots, children, conditions)
+}
+
+func nestSteps(steps []model.JourneyStep, children map[uint][]model.JourneyStep, conditions *conditionRenderer) []GenStep {
+	sorted := append([]model.JourneyStep(nil), steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	genSteps := make([]GenStep, 0, len(sorted))
+	for _, step := range sorted {
+		genStep := buildStep(step, conditions)
+
+		subSteps := append([]model.JourneyStep(nil), step.SubSteps...)
+		seen := make(map[uint]bool)
+		for _, sub := range subSteps {
+			seen[sub.ID] = true
+		}
+		for _, sub := range children[step.ID] {
+			if !seen[sub.ID] {
+				subSteps = append(subSteps, sub)
+			}
+		}
+		if len(subSteps) > 0 {
+			genStep.SubSteps = nestSteps(subSteps, children, conditions)
+		}
+		genSteps = append(genSteps, genStep)
+	}
+	return genSteps
+}
+
+// buildStep maps a step and fills the sub-context matching its type
+func buildStep(step model.JourneyStep, conditions *conditionRenderer) GenStep {
+	genStep := GenStep{
+		Index:         step.Index,
+		Type:          strings.ToUpper(strings.TrimSpace(step.Type)),
+		Description:   step.Description,
+		Level:         step.Level,
+		Condition:     step.Condition,
+		ConditionCode: conditions.Render(step.Condition),
+		AbortOnFail:   step.AbortOnFail,
+		Error:         step.Error,
+	}
+	for _, f := range step.FieldsInvolved {
+		genStep.FieldsInvolved = append(genStep.FieldsInvolved, f.Name)
+	}
+
+	switch genStep.Type {
+	case enum.InputValidation.String(), enum.BusinessValidation.String():
+		genStep.Validation = &GenValidationStep{
+			Condition:     step.Condition,
+			ConditionCode: genStep.ConditionCode,
+			Fields:        genStep.FieldsInvolved,
+			AbortOnFail:   step.AbortOnFail,
+			Error:         step.Error,
+		}
+	case enum.APICall.String():
+		apiCall := &GenAPICallStep{
+			Curl:           step.Curl,
+			SampleResponse: step.SampleResponse,
+		}
+		// Journeys saved before curls were parsed have no stored spec
+		spec := step.HTTPCall
+		if spec == nil {
+			spec, _ = BuildHTTPCallSpec(step)
+		}
+		if spec != nil {
+			apiCall.Method = spec.Method
+			apiCall.URL = spec.URL
+			apiCall.Headers = spec.Headers
+			apiCall.Query = spec.Query
+			apiCall.Body = spec.Body
+			apiCall.BodyFormat = spec.BodyFormat
+			apiCall.Bindings = spec.Bindings
+			apiCall.Response = spec.Response
+		}
+		genStep.APICall = apiCall
+	case enum.DatabaseOperation.String():
+		genStep.DbAction = &GenDbActionStep{
+			Action:         step.DBAction.String(),
+			FailOnNotFound: step.FailOnNotFound,
+		}
+	case enum.CacheOperation.String():
+		genStep.CacheAction = &GenCacheActionStep{Action: step.CacheAction.String()}
+	case enum.Notification.String():
+		notification := &GenNotificationStep{
+			Message:    step.Message,
+			Recipients: step.Recipients,
+		}
+		for _, c := range step.Channels {
+			notification.Channels = append(notification.Channels, c.String())
+		}
+		genStep.Notification = notification
+	}
+
+	if step.Retry {
+		retry := &GenRetry{Count: step.RetryCount, Interval: step.RetryInterval}
+		for _, rc := range step.RetryConditions {
+			retry.Conditions = append(retry.Conditions, GenRetryCondition{
+				Condition:     rc.Condition,
+				ConditionCode: conditions.Render(rc.Condition),
+				Error:         rc.Error,
+			})
+		}
+		genStep.Retry = retry
+	}
+
+	actions := append([]model.ResponseAction(nil), step.ResponseActions...)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Index < actions[j].Index })
+	for _, a := range actions {
+		genStep.ResponseActions = append(genStep.ResponseActions, buildResponseAction(a, conditions))
+	}
+	return genStep
+}
+
+func buildResponseAction(a model.ResponseAction, conditions *conditionRenderer) GenResponseAction {
+	action := GenResponseAction{
+		Index:         a.Index,
+		Type:          a.Type.String(),
+		FieldID:       a.FieldID,
+		Value:         a.Value,
+		Description:   a.Description,
+		Condition:     a.Condition,
+		ConditionCode: conditions.Render(a.Condition),
+		AbortOnFail:   a.AbortOnFail,
+		Error:         a.Error,
+	}
+	for _, f := range a.FieldsInvolved {
+		action.Fields = append(action.Fields, f.Name)
+	}
+	if a.NestedResponseAction != nil {
+		nested := buildResponseAction(*a.NestedResponseAction, conditions)
+		action.Nested = &nested
+	}
+	return action
+}
@@ -1,107 +1,382 @@
 package service
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
 	"gen-concept-api/enum"
 
+	"sort"
 	"strings"
 	"text/template"
+
+	"github.com/google/uuid"
 )
 
 type GenerationService struct {
 	gitProvider GitProvider
 	aiProvider  AIProvider
 	libraryRepo repository.LibraryRepository
+	journeyRepo repository.JourneyRepository
+	typeMapper  *TypeMapper
+	aiCache     *aiCache
+	formatters  map[enum.ProgrammingLanguage]CodeFormatter
+	sandbox     Sandbox
 }
 
-func NewGenerationService(gitProvider GitProvider, aiProvider AIProvider, libraryRepo repository.LibraryRepository) *GenerationService {
+func NewGenerationService(gitProvider GitProvider, aiProvider AIProvider, libraryRepo repository.LibraryRepository, journeyRepo repository.JourneyRepository) *GenerationService {
 	return &GenerationService{
 		gitProvider: gitProvider,
 		aiProvider:  aiProvider,
 		libraryRepo: libraryRepo,
+		journeyRepo: journeyRepo,
+		typeMapper:  NewTypeMapper(),
+		aiCache:     newAICache(DefaultAICacheLimit),
+		formatters:  defaultFormatters(),
+		sandbox:     DefaultSandbox(),
 	}
 }
 
+// GenerateCode renders the blueprint's main template for the entity, formatted for its language.
+// The blueprint must carry the template files of the version to render (see BlueprintRepository.GetByUuidAtTemplateVersion).
+// Missing or invalid placeholder inputs fail with a *PlaceholderError.
 func (s *GenerationService) GenerateCode(ctx context.Context, blueprint model.Blueprint, entity model.Entity, inputs map[string]string) (string, error) {
-	// 1. Fetch/Prepare Template Content
-	var templateContent string
-	if blueprint.TemplatePath != "" {
-		// For MVP, assuming TemplatePath IS the template content if it's not a URL
-		// OR we fetch it. Retaining logic for now.
-		templateContent = blueprint.TemplatePath
+	file, err := s.PreviewCode(ctx, blueprint, entity, inputs)
+	if err != nil {
+		return "", err
+	}
+	return file.Content, nil
+}
+
+// PreviewCode renders the main template like GenerateCode and also returns the diagnostics of its language's formatter
+func (s *GenerationService) PreviewCode(ctx context.Context, blueprint model.Blueprint, entity model.Entity, inputs map[string]string) (GeneratedFile, error) {
+	// 1. Resolve Placeholders
+	resolved, err := ResolvePlaceholders(blueprint.Placeholders, inputs)
+	if err != nil {
+		return GeneratedFile{}, err
 	}
 
-	if templateContent == "" {
-		return "", fmt.Errorf("no template content")
+	ctx, cancel := s.sandbox.withTimeout(ctx)
+	defer cancel()
+
+	// 2. Parse Template Files
+	tmpl, hooks, err := s.parseTemplates(blueprint)
+	if err != nil {
+		return GeneratedFile{}, err
 	}
 
-	// 2. Build Context
-	genCtx, err := s.BuildContext(ctx, entity)
+	// 3. Build Context
+	language := templateLanguage(blueprint, tmpl.Name())
+	genCtx, err := s.BuildContext(ctx, nil, entity, language)
 	if err != nil {
-		return "", err
+		return GeneratedFile{}, err
+	}
+	genCtx.Inputs = resolved
+
+	// 4. Execute Template
+	content, err := s.execute(ctx, hooks, tmpl, genCtx)
+	if err != nil {
+		return GeneratedFile{}, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	// 5. Format
+	file := GeneratedFile{Path: tmpl.Name(), Content: content}
+	return s.formatFile(file, language, genCtx.Imports, tmpl.Name(), templateSource(blueprint, tmpl.Name())), nil
+}
+
+// templateSource returns the content of the named template file; legacy blueprints hold it in TemplatePath
+func templateSource(blueprint model.Blueprint, name string) string {
+	for _, t := range blueprint.Templates {
+		if t.Path == name {
+			return t.Content
+		}
+	}
+	if len(blueprint.Templates) == 0 {
+		return blueprint.TemplatePath
+	}
+	return ""
+}
+
+// parseTemplates parses every template file of the blueprint into one set, each named by its path,
+// so files can call each other with {{template "path"}}.
+// It returns the main template: the file at TemplatePath, or the first file otherwise, and the hooks to execute the set with.
+func (s *GenerationService) parseTemplates(blueprint model.Blueprint) (*template.Template, *sandboxHooks, error) {
+	root := template.New("blueprint").Funcs(TemplateFuncs())
+
+	if len(blueprint.Templates) == 0 {
+		// Legacy blueprints stored the template body itself in TemplatePath
+		if blueprint.TemplatePath == "" {
+			return nil, nil, fmt.Errorf("no template content")
+		}
+		if _, err := root.Parse(blueprint.TemplatePath); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse template: %v", err)
+		}
+		hooks, err := s.sandboxTemplates(root)
+		return root, hooks, err
+	}
+
+	for _, t := range blueprint.Templates {
+		if _, err := root.New(t.Path).Parse(t.Content); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse template %s: %v", t.Path, err)
+		}
 	}
 
-	// 3. Parse and Execute Template
-	tmpl, err := template.New("blueprint").Parse(templateContent)
+	hooks, err := s.sandboxTemplates(root)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %v", err)
+		return nil, nil, err
+	}
+
+	if main := root.Lookup(blueprint.TemplatePath); blueprint.TemplatePath != "" && main != nil {
+		return main, hooks, nil
+	}
+	return root.Lookup(blueprint.Templates[0].Path), hooks, nil
+}
+
+// sandboxTemplates checks the functions of a parsed template set against the sandbox and prepares it for execute.
+// It binds the functions depending on the render, so each file of the set is executed with the returned hooks.
+func (s *GenerationService) sandboxTemplates(root *template.Template) (*sandboxHooks, error) {
+	if err := s.sandbox.checkFunctions(root); err != nil {
+		return nil, err
+	}
+	hooks := &sandboxHooks{}
+	if err := instrument(root, hooks); err != nil {
+		return nil, err
+	}
+	s.bindAI(root, hooks)
+	return hooks, nil
+}
+
+// TypeMapper returns the registry used to map field types, so callers can register further languages and databases
+func (s *GenerationService) TypeMapper() *TypeMapper {
+	return s.typeMapper
+}
+
+// templateLanguage returns the language of the named template file; legacy single templates are Go
+func templateLanguage(blueprint model.Blueprint, name string) enum.ProgrammingLanguage {
+	for _, t := range blueprint.Templates {
+		if t.Path == name {
+			return t.Language
+		}
 	}
+	return enum.Golang
+}
+
+// GenerationScope holds what the entities of one generation share, so it is built once per generation rather
+// than for every entity and file: the project context of each project and language, and the journeys of each project.
+// It also collects the warnings of the generation. It is not safe for concurrent use.
+type GenerationScope struct {
+	projects map[projectScopeKey]GenProject
+	journeys map[uuid.UUID][]model.Journey
+	warnings []string
+	warned   map[string]bool
+}
+
+type projectScopeKey struct {
+	project  uuid.UUID
+	name     string
+	language enum.ProgrammingLanguage
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, genCtx); err != nil {
-		return "", fmt.Errorf("failed to execute template: %v", err)
+func NewGenerationScope() *GenerationScope {
+	return &GenerationScope{
+		projects: make(map[projectScopeKey]GenProject),
+		journeys: make(map[uuid.UUID][]model.Journey),
+		warned:   make(map[string]bool),
 	}
+}
 
-	return buf.String(), nil
+// Warnings returns what the generation left out, e.g. relations to deleted entities, in the order it was found
+func (scope *GenerationScope) Warnings() []string {
+	return scope.warnings
 }
 
-// BuildContext creates a generation context from the entity model
-func (s *GenerationService) BuildContext(ctx context.Context, entity model.Entity) (GenContext, error) {
-	// Simple lowerCamelCase conversion (naive implementation, use a library like strcase in production)
+// warn records warnings once each; a nil scope drops them
+func (scope *GenerationScope) warn(warnings ...string) {
+	if scope == nil {
+		return
+	}
+	for _, w := range warnings {
+		if !scope.warned[w] {
+			scope.warned[w] = true
+			scope.warnings = append(scope.warnings, w)
+		}
+	}
+}
+
+// Journeys returns the journeys the generation loaded, ordered by uuid
+func (scope *GenerationScope) Journeys() []model.Journey {
+	var journeys []model.Journey
+	for _, loaded := range scope.journeys {
+		journeys = append(journeys, loaded...)
+	}
+	sort.Slice(journeys, func(i, j int) bool { return journeys[i].Uuid.String() < journeys[j].Uuid.String() })
+	return journeys
+}
+
+// BuildContext creates a generation context from the entity model, with field types mapped for the language.
+// When the entity comes with its project's entities, relations resolve against them and .Project is set; the
+// project's entities that cannot be built are left out of it, their own generation reports why.
+// The scope shares the project context and journeys between the entities of a generation; nil loads them for this call only.
+func (s *GenerationService) BuildContext(ctx context.Context, scope *GenerationScope, entity model.Entity, language enum.ProgrammingLanguage) (GenContext, error) {
 	// Guard against empty name
 	if len(entity.EntityName) == 0 {
 		return GenContext{}, fmt.Errorf("entity name is empty")
 	}
-	varName := strings.ToLower(string(entity.EntityName[0])) + entity.EntityName[1:]
+	if scope == nil {
+		scope = NewGenerationScope()
+	}
+
+	genCtx := GenContext{
+		ProjectName:      entity.Project.ProjectName,
+		Language:         language,
+		Database:         entity.PreferredDB,
+		Imports:          []string{},
+		LibraryFunctions: make(map[string]string),
+	}
+	genEntity, err := s.buildEntity(&genCtx, entity, entity.Project.Entities, language)
+	if err != nil {
+		return GenContext{}, err
+	}
+	genCtx.Entity = genEntity
+	scope.warn(genCtx.warnings...)
+
+	// Journey operations of the entity
+	journeys, err := s.relatedJourneys(ctx, scope, entity)
+	if err != nil {
+		return GenContext{}, fmt.Errorf("failed to load journeys: %v", err)
+	}
+	genCtx.Operations = buildOperations(journeys, &entity, language)
 
-	// Project checks
-	projectName := ""
-	if entity.Project.ProjectName != "" {
-		projectName = entity.Project.ProjectName
+	if len(entity.Project.Entities) > 0 {
+		key := projectScopeKey{project: entity.Project.Uuid, name: entity.Project.ProjectName, language: language}
+		project, ok := scope.projects[key]
+		if !ok {
+			// The other entities' imports are not the entity's, they only matter to per-project files
+			scratch := GenContext{LibraryFunctions: make(map[string]string)}
+			project, _ = s.buildProject(&scratch, entity.Project, language)
+			scope.projects[key] = project
+			scope.warn(scratch.warnings...)
+		}
+		genCtx.Project = project
 	}
-	// Or maybe pass ProjectName from inputs/context if Entity.Project (gorm relation) is not loaded
+	return genCtx, nil
+}
 
+// BuildProjectContext creates the context of a per-project file: .Project with every entity of the project,
+// and the imports and library functions all of them need. .Entity and .Operations are empty.
+func (s *GenerationService) BuildProjectContext(ctx context.Context, project model.Project, language enum.ProgrammingLanguage) (GenContext, error) {
 	genCtx := GenContext{
-		ProjectName: projectName,
-		Entity: GenEntity{
-			Name:       entity.EntityName,
-			VarName:    varName,
-			PrimaryKey: "ID", // Default assumption, or check fields
-		},
+		ProjectName:      project.ProjectName,
+		Language:         language,
 		Imports:          []string{},
 		LibraryFunctions: make(map[string]string),
 	}
+	if len(project.Entities) > 0 {
+		genCtx.Database = project.Entities[0].PreferredDB
+	}
+	genProject, err := s.buildProject(&genCtx, project, language)
+	if err != nil { // Per-project files need every entity
+		return GenContext{}, err
+	}
+	genCtx.Project = genProject
+	return genCtx, nil
+}
 
-	importsMap := make(map[string]bool)
+// buildProject maps the project and all of its entities, adding what they need to genCtx.
+// Entities that cannot be built are left out of the project and reported in the error.
+func (s *GenerationService) buildProject(genCtx *GenContext, project model.Project, language enum.ProgrammingLanguage) (GenProject, error) {
+	genProject := GenProject{
+		Name:           project.ProjectName,
+		Description:    project.ProjectDescription,
+		Type:           project.ProjectType.String(),
+		IsMultiTenant:  project.IsMultiTenant,
+		IsMultiLingual: project.IsMultiLingual,
+		Entities:       make([]GenEntity, 0, len(project.Entities)),
+	}
+	var errs []error
+	for _, e := range project.Entities {
+		genEntity, err := s.buildEntity(genCtx, e, project.Entities, language)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entity %s: %w", e.EntityName, err))
+			continue
+		}
+		genProject.Entities = append(genProject.Entities, genEntity)
+	}
+	return genProject, errors.Join(errs...)
+}
+
+// buildEntity maps the entity's fields and relations for the language, adding the imports and
+// library functions they need to genCtx
+func (s *GenerationService) buildEntity(genCtx *GenContext, entity model.Entity, projectEntities []model.Entity, language enum.ProgrammingLanguage) (GenEntity, error) {
+	genEntity := GenEntity{
+		Name:       entity.EntityName,
+		VarName:    ToCamel(entity.EntityName),
+		PrimaryKey: "ID", // Default assumption, or check fields
+	}
+
+	// Relations, resolved against the project's entities when the project is loaded with them
+	relations, skipped := BuildRelations(entity, projectEntities)
+	genEntity.Relations = relations
+	genCtx.warnings = append(genCtx.warnings, skipped...)
+
+	importsMap := make(map[string]bool, len(genCtx.Imports))
+	for _, key := range genCtx.Imports {
+		importsMap[key] = true
+	}
+
+	// Derived fields, and sample values computed from their siblings' samples
+	derivedFields, err := CompileDerivedFields(entity.EntityName, entity.EntityFields)
+	if err != nil {
+		return GenEntity{}, err
+	}
+	derivedByName := make(map[string]DerivedField, len(derivedFields))
+	for _, d := range derivedFields {
+		derivedByName[d.Field.FieldName] = d
+	}
+	samples, err := ComputeDerivedValues(entity.EntityName, entity.EntityFields, SampleValues(entity.EntityFields))
+	if err != nil {
+		// Sample data is a convenience, a sample that cannot be computed is left empty
+		samples = SampleValues(entity.EntityFields)
+	}
 
 	for _, f := range entity.EntityFields {
+		mapped := s.typeMapper.MapField(language, entity.PreferredDB, entity, f)
 		genField := GenField{
-			Name: f.FieldName,
-			Type: string(f.FieldType), // Convert enum to string, logic might need mapping
+			Name:         f.FieldName,
+			Type:         mapped.Type,
+			NullableType: mapped.NullableType,
+			Nullable:     mapped.Nullable,
+			ColumnType:   mapped.ColumnType,
+			DataType:     f.FieldType.String(),
+			EnumName:     mapped.EnumName,
+			EnumValues:   f.EnumValues,
+			Imports:      mapped.Imports,
+		}
+
+		genField.SampleData = f.SampleData
+		if f.IsDerived {
+			if sample, ok := samples[f.FieldName]; ok {
+				genField.SampleData = formatSampleValue(sample)
+			}
+			if d, ok := derivedByName[f.FieldName]; ok {
+				genField.Derived = buildGenDerived(d, &entity, language, entity.PreferredDB)
+				for _, key := range genField.Derived.Imports {
+					if !importsMap[key] {
+						genCtx.Imports = append(genCtx.Imports, key)
+						importsMap[key] = true
+					}
+				}
+			}
 		}
 
 		// Smart Imports Logic
-		if f.FieldType == enum.DateTime {
-			key := "time"
+		for _, key := range mapped.Imports {
 			if !importsMap[key] {
 				genCtx.Imports = append(genCtx.Imports, key)
 				importsMap[key] = true
 			}
-			genField.Type = "time.Time" // Override enum string with Go type
 		}
 
 		// Sensitive Data Logic -> Library Discovery
@@ -123,22 +398,36 @@ func (s *GenerationService) BuildContext(ctx context.Context, entity model.Entit
 			genCtx.LibraryFunctions["Decrypt"] = "encryption.Decrypt"
 		}
 
-		// Validation Tags
-		if len(f.InputValidations) > 0 || f.IsMandatory {
-			tags := []string{}
-			if f.IsMandatory {
-				tags = append(tags, "required")
+		// Validation rules in the language's idiom, and the gin binding tag
+		genField.Validations = BuildValidations(entity, f, language)
+		for _, v := range genField.Validations {
+			if v.Code != "" {
+				genField.Constraints = append(genField.Constraints, v.Code)
+			}
+			if v.Check != "" {
+				genEntity.Checks = append(genEntity.Checks, v.Check)
+			}
+			if v.FieldType != "" && !f.IsCollection {
+				genField.NullableType = strings.Replace(genField.NullableType, genField.Type, v.FieldType, 1)
+				genField.Type = v.FieldType
 			}
-			// Add more mapping logic here
-			if len(tags) > 0 {
-				genField.ValidateTag = fmt.Sprintf(`binding:"%s"`, strings.Join(tags, ","))
+			for _, key := range v.Imports {
+				if !importsMap[key] {
+					genCtx.Imports = append(genCtx.Imports, key)
+					importsMap[key] = true
+				}
 			}
 		}
+		if language == enum.Golang {
+			genField.ValidateTag = goValidateTag(f, genField.Validations)
+		} else {
+			genField.ValidateTag = goValidateTag(f, nil)
+		}
 
-		genField.JSONTag = fmt.Sprintf(`json:"%s"`, strings.ToLower(string(f.FieldName[0]))+f.FieldName[1:])
+		genField.JSONTag = fmt.Sprintf(`json:"%s"`, ToCamel(f.FieldName))
 
-		genCtx.Entity.Fields = append(genCtx.Entity.Fields, genField)
+		genEntity.Fields = append(genEntity.Fields, genField)
 	}
 
-	return genCtx, nil
+	return genEntity, nil
 }
@@ -6,7 +6,9 @@ import (
 )
 
 type ImporterService struct {
-	parsers map[string]CodeParser
+	parsers       map[string]CodeParser
+	schemaParsers map[string]SchemaParser
+	sampleParser  SampleParser
 }
 
 func NewImporterService(goParser CodeParser) *ImporterService {
@@ -14,9 +16,84 @@ func NewImporterService(goParser CodeParser) *ImporterService {
 		parsers: map[string]CodeParser{
 			"go": goParser,
 		},
+		schemaParsers: map[string]SchemaParser{},
 	}
 }
 
+// RegisterSchemaParser makes a schema format, such as postgres or mysql, importable as a project
+func (s *ImporterService) RegisterSchemaParser(format string, parser SchemaParser) {
+	s.schemaParsers[format] = parser
+}
+
+// RegisterCodeParser makes a language importable as a blueprint
+func (s *ImporterService) RegisterCodeParser(lang string, parser CodeParser) {
+	s.parsers[lang] = parser
+}
+
+// RegisterSampleParser sets the parser inferring entities from example documents
+func (s *ImporterService) RegisterSampleParser(parser SampleParser) {
+	s.sampleParser = parser
+}
+
+// SchemaError reports a schema that cannot be read, or a format no parser is registered for
+type SchemaError struct {
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// ImportSchema reads the entities of a project from a schema in the given format
+func (s *ImporterService) ImportSchema(content string, format string) (ParsedSchema, error) {
+	parser, ok := s.schemaParsers[format]
+	if !ok {
+		return ParsedSchema{}, &SchemaError{Err: fmt.Errorf("unsupported schema format: %s", format)}
+	}
+	schema, err := parser.ParseSchema(content)
+	if err != nil {
+		return ParsedSchema{}, &SchemaError{Err: err}
+	}
+	return schema, nil
+}
+
+// ImportSchemaFiles reads the entities of a project from a schema split over several files. A single file can be
+// read by any parser; more need one that resolves the references between them.
+func (s *ImporterService) ImportSchemaFiles(files []SchemaFile, format string) (ParsedSchema, error) {
+	parser, ok := s.schemaParsers[format]
+	if !ok {
+		return ParsedSchema{}, &SchemaError{Err: fmt.Errorf("unsupported schema format: %s", format)}
+	}
+	multiFile, ok := parser.(MultiFileSchemaParser)
+	if !ok {
+		if len(files) != 1 {
+			return ParsedSchema{}, &SchemaError{Err: fmt.Errorf("%s schemas cannot be split over several files", format)}
+		}
+		return s.ImportSchema(files[0].Content, format)
+	}
+	schema, err := multiFile.ParseFiles(files)
+	if err != nil {
+		return ParsedSchema{}, &SchemaError{Err: err}
+	}
+	return schema, nil
+}
+
+// InferSchema drafts the entities of a resource from example documents of it
+func (s *ImporterService) InferSchema(resource string, samples []string) (ParsedSchema, error) {
+	if s.sampleParser == nil {
+		return ParsedSchema{}, &SchemaError{Err: fmt.Errorf("importing samples is not supported")}
+	}
+	schema, err := s.sampleParser.ParseSamples(resource, samples)
+	if err != nil {
+		return ParsedSchema{}, &SchemaError{Err: err}
+	}
+	return schema, nil
+}
+
 func (s *ImporterService) ImportFromSource(content string, lang string) (model.Blueprint, error) {
 	parser, ok := s.parsers[lang]
 	if !ok {
@@ -38,9 +115,10 @@ func (s *ImporterService) ImportFromSource(content string, lang string) (model.B
 
 	for _, field := range metadata.Fields {
 		blueprint.Placeholders = append(blueprint.Placeholders, model.Placeholder{
-			Name:        field.Name,
-			Type:        field.Type,
-			Description: fmt.Sprintf("Field extracted from %s", metadata.Name),
+			Name:          field.Name,
+			Type:          field.Type,
+			Description:   fmt.Sprintf("Field extracted from %s", metadata.Name),
+			AllowedValues: field.EnumValues,
 		})
 	}
 
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/pkg/expr"
+	"strings"
+)
+
+// JourneyIssue points at a journey step that cannot be saved
+type JourneyIssue struct {
+	Entity    string `json:"entity"`
+	Operation string `json:"operation"`
+	StepIndex int    `json:"stepIndex"`
+	Property  string `json:"property"` // e.g. curl, sampleResponse
+	Message   string `json:"message"`
+}
+
+// JourneyValidationError lists every invalid step of a journey
+type JourneyValidationError struct {
+	Issues []JourneyIssue
+}
+
+func (e *JourneyValidationError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = fmt.Sprintf("%s.%s step %d %s: %s", issue.Entity, issue.Operation, issue.StepIndex, issue.Property, issue.Message)
+	}
+	return "invalid journey: " + strings.Join(messages, "; ")
+}
+
+// PrepareJourney checks every step of the journey before it is saved and stores the derived forms
+// of the steps (the HTTP call spec of API calls). Conditions are type checked against the fields
+// of the project entities. It returns a *JourneyValidationError listing all problems.
+func PrepareJourney(journey *model.Journey, entities []model.Entity) error {
+	var issues []JourneyIssue
+	for i := range journey.EntityJourneys {
+		ej := &journey.EntityJourneys[i]
+		entity := findEntity(entities, ej.EntityID, ej.EntityName)
+		for j := range ej.Operations {
+			op := &ej.Operations[j]
+			issues = append(issues, prepareSteps(ej.EntityName, op.Name, op.BackendJourney)...)
+			scope := ConditionScope(entity, op.BackendJourney)
+			issues = append(issues, checkConditions(ej.EntityName, op.Name, op.BackendJourney, scope)...)
+		}
+	}
+	if len(issues) > 0 {
+		return &JourneyValidationError{Issues: issues}
+	}
+	return nil
+}
+
+func prepareSteps(entity, operation string, steps []model.JourneyStep) []JourneyIssue {
+	var issues []JourneyIssue
+	for i := range steps {
+		step := &steps[i]
+		issue := func(property string, err error) {
+			issues = append(issues, JourneyIssue{
+				Entity:    entity,
+				Operation: operation,
+				StepIndex: step.Index,
+				Property:  property,
+				Message:   err.Error(),
+			})
+		}
+
+		step.HTTPCall = nil
+		if strings.TrimSpace(step.Curl) != "" {
+			spec, err := ParseCurl(step.Curl, step.FieldsInvolved)
+			if err != nil {
+				issue("curl", err)
+			}
+			if strings.TrimSpace(step.SampleResponse) != "" {
+				schema, schemaErr := InferResponseSchema(step.SampleResponse)
+				if schemaErr != nil {
+					issue("sampleResponse", schemaErr)
+				} else if spec != nil {
+					spec.Response = schema
+				}
+			}
+			step.HTTPCall = spec
+		}
+
+		issues = append(issues, prepareSteps(entity, operation, step.SubSteps)...)
+	}
+	return issues
+}
+
+// checkConditions type checks the conditions of the steps, their retry conditions and response actions
+func checkConditions(entity, operation string, steps []model.JourneyStep, scope expr.Scope) []JourneyIssue {
+	var issues []JourneyIssue
+	for _, step := range steps {
+		check := func(property, condition string) {
+			if strings.TrimSpace(condition) == "" {
+				return
+			}
+			if _, err := CheckCondition(condition, scope); err != nil {
+				issues = append(issues, JourneyIssue{
+					Entity:    entity,
+					Operation: operation,
+					StepIndex: step.Index,
+					Property:  property,
+					Message:   err.Error(),
+				})
+			}
+		}
+
+		check("condition", step.Condition)
+		for i, rc := range step.RetryConditions {
+			check(fmt.Sprintf("retryConditions[%d].condition", i), rc.Condition)
+		}
+		for i := range step.ResponseActions {
+			property := fmt.Sprintf("responseActions[%d]", i)
+			for action := &step.ResponseActions[i]; action != nil; action = action.NestedResponseAction {
+				check(property+".condition", action.Condition)
+				property += ".nestedResponseAction"
+			}
+		}
+
+		issues = append(issues, checkConditions(entity, operation, step.SubSteps, scope)...)
+	}
+	return issues
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gen-concept-api/domain/model"
+	"sort"
+	"strings"
+)
+
+// EngineVersion identifies the generation engine in manifests. Bump it whenever a change alters the output
+// of existing blueprints, so reproductions can tell engine drift from template or model drift.
+const EngineVersion = "1.0.0"
+
+// ManifestPath is where the manifest is written in generated output
+const ManifestPath = "gen-manifest.json"
+
+// snapshotIgnored are the bookkeeping keys left out of snapshot hashes: database ids, audit columns and the
+// back references of nested records
+var snapshotIgnored = map[string]bool{
+	"ID": true, "CreatedAt": true, "ModifiedAt": true, "DeletedAt": true,
+	"CreatedBy": true, "ModifiedBy": true, "DeletedBy": true,
+	"Project": true, "Entities": true, "Entity": true,
+}
+
+// SnapshotHash hashes the content of an entity or project, ignoring ids, audit columns and nested
+// back references, so only a change of the model itself changes the hash
+func SnapshotHash(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(stripSnapshot(doc)) // Maps marshal with sorted keys
+	if err != nil {
+		return "", err
+	}
+	return ContentHash(canonical), nil
+}
+
+func stripSnapshot(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			if snapshotIgnored[key] {
+				delete(t, key)
+				continue
+			}
+			t[key] = stripSnapshot(value)
+		}
+	case []any:
+		for i := range t {
+			t[i] = stripSnapshot(t[i])
+		}
+	}
+	return v
+}
+
+// ContentHash is the hex SHA-256 of content
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ManifestInput is what a generation used, as recorded by BuildManifest
+type ManifestInput struct {
+	Blueprint model.Blueprint
+	Entities  []model.Entity
+	Projects  []model.Project
+	Journeys  []model.Journey
+	Inputs    map[string]string
+	AICalls   []model.ManifestAICall
+	Previous  map[string]string // Previous output the protected regions were taken from
+	Files     []GeneratedFile
+}
+
+// BuildManifest records the provenance of a generation. Entities and files keep their order while projects,
+// journeys and libraries are sorted, so the same generation always produces the same document.
+func BuildManifest(in ManifestInput) (model.Manifest, error) {
+	manifest := model.Manifest{
+		EngineVersion: EngineVersion,
+		Blueprint: model.ManifestBlueprint{
+			Uuid:            in.Blueprint.Uuid,
+			Name:            in.Blueprint.StandardName,
+			TemplateVersion: in.Blueprint.TemplateVersion,
+		},
+		Projects:         []model.ManifestSnapshot{},
+		Entities:         []model.ManifestSnapshot{},
+		Journeys:         []model.ManifestSnapshot{},
+		Inputs:           in.Inputs,
+		Libraries:        []model.ManifestLibrary{},
+		AICalls:          in.AICalls,
+		PreservedRegions: []model.ManifestRegion{},
+		Files:            []model.ManifestFile{},
+	}
+	if manifest.Inputs == nil {
+		manifest.Inputs = map[string]string{}
+	}
+	if manifest.AICalls == nil {
+		manifest.AICalls = []model.ManifestAICall{}
+	}
+	resolved, err := ResolvePlaceholders(in.Blueprint.Placeholders, in.Inputs)
+	if err != nil {
+		return model.Manifest{}, err
+	}
+	manifest.ResolvedInputs = resolved
+
+	for _, e := range in.Entities {
+		hash, err := SnapshotHash(e)
+		if err != nil {
+			return model.Manifest{}, fmt.Errorf("entity %s: %v", e.EntityName, err)
+		}
+		manifest.Entities = append(manifest.Entities, model.ManifestSnapshot{Uuid: e.Uuid, Name: e.EntityName, Hash: hash})
+	}
+	for _, p := range in.Projects {
+		hash, err := SnapshotHash(p)
+		if err != nil {
+			return model.Manifest{}, fmt.Errorf("project %s: %v", p.ProjectName, err)
+		}
+		manifest.Projects = append(manifest.Projects, model.ManifestSnapshot{Uuid: p.Uuid, Name: p.ProjectName, Hash: hash})
+	}
+	sort.Slice(manifest.Projects, func(i, j int) bool { return manifest.Projects[i].Name < manifest.Projects[j].Name })
+	for _, j := range in.Journeys {
+		hash, err := SnapshotHash(j)
+		if err != nil {
+			return model.Manifest{}, fmt.Errorf("journey %s: %v", j.Uuid, err)
+		}
+		manifest.Journeys = append(manifest.Journeys, model.ManifestSnapshot{Uuid: j.Uuid, Name: journeyName(j), Hash: hash})
+	}
+	sort.Slice(manifest.Journeys, func(i, j int) bool { return manifest.Journeys[i].Uuid.String() < manifest.Journeys[j].Uuid.String() })
+
+	for _, l := range in.Blueprint.Libraries {
+		manifest.Libraries = append(manifest.Libraries, model.ManifestLibrary{
+			Uuid: l.Uuid, Name: l.Name, Version: l.Version, Tag: l.Tag, CommitHash: l.CommitHash,
+		})
+	}
+	sort.Slice(manifest.Libraries, func(i, j int) bool { return manifest.Libraries[i].Name < manifest.Libraries[j].Name })
+
+	for _, f := range in.Files {
+		manifest.Files = append(manifest.Files, model.ManifestFile{Path: f.Path, Size: len(f.Content), SHA256: ContentHash([]byte(f.Content))})
+		old, ok := in.Previous[f.Path]
+		if !ok {
+			continue
+		}
+		regions, _ := scanRegions(f.Path, old, true)
+		for _, r := range regions {
+			manifest.PreservedRegions = append(manifest.PreservedRegions, model.ManifestRegion{File: f.Path, Region: r.id, Content: r.body})
+		}
+	}
+	return manifest, nil
+}
+
+// journeyName names a journey by the entities it covers, or by its uuid when it covers none
+func journeyName(journey model.Journey) string {
+	var names []string
+	for _, ej := range journey.EntityJourneys {
+		names = append(names, ej.EntityName)
+	}
+	if len(names) == 0 {
+		return journey.Uuid.String()
+	}
+	return "of " + strings.Join(names, ", ")
+}
+
+// ManifestDocument renders the manifest as written to gen-manifest.json, with its digest
+func ManifestDocument(manifest model.Manifest) ([]byte, string, error) {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	content = append(content, '\n')
+	return content, ContentHash(content), nil
+}
+
+// RegionsPrevious rebuilds a previous output holding only the preserved regions of a manifest,
+// which PreserveRegions carries into a new render exactly like the original previous output
+func RegionsPrevious(regions []model.ManifestRegion) map[string]string {
+	files := make(map[string]*strings.Builder)
+	var order []string
+	for _, r := range regions {
+		b, ok := files[r.File]
+		if !ok {
+			b = &strings.Builder{}
+			files[r.File] = b
+			order = append(order, r.File)
+		}
+		fmt.Fprintf(b, "gen:keep begin %s\n%sgen:keep end\n", r.Region, r.Content)
+	}
+	previous := make(map[string]string, len(files))
+	for _, f := range order {
+		previous[f] = files[f].String()
+	}
+	return previous
+}
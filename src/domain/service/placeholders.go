@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"strconv"
+	"strings"
+)
+
+const (
+	PlaceholderMissing = "missing"
+	PlaceholderInvalid = "invalid"
+)
+
+// PlaceholderIssue describes a placeholder that could not be resolved
+type PlaceholderIssue struct {
+	Name    string `json:"name"`
+	Problem string `json:"problem"` // missing or invalid
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// PlaceholderError lists every placeholder that is missing or has an invalid value
+type PlaceholderError struct {
+	Issues []PlaceholderIssue
+}
+
+func (e *PlaceholderError) Error() string {
+	names := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		names[i] = fmt.Sprintf("%s (%s)", issue.Name, issue.Problem)
+	}
+	return "unresolved placeholders: " + strings.Join(names, ", ")
+}
+
+// ResolvePlaceholders resolves every declared placeholder from the explicit input, then its default value,
+// and coerces the value to the declared type: String (default), Bool, Int or Enum (one of AllowedValues).
+// All problems are collected into a single *PlaceholderError.
+func ResolvePlaceholders(placeholders []model.Placeholder, inputs map[string]string) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(placeholders))
+	var issues []PlaceholderIssue
+
+	for _, p := range placeholders {
+		raw, ok := inputs[p.Name]
+		if !ok && p.DefaultVal != "" {
+			raw, ok = p.DefaultVal, true
+		}
+		if !ok {
+			issues = append(issues, PlaceholderIssue{
+				Name:    p.Name,
+				Problem: PlaceholderMissing,
+				Message: "no input and no default value",
+			})
+			continue
+		}
+
+		value, err := coercePlaceholder(p, raw)
+		if err != nil {
+			issues = append(issues, PlaceholderIssue{
+				Name:    p.Name,
+				Problem: PlaceholderInvalid,
+				Value:   raw,
+				Message: err.Error(),
+			})
+			continue
+		}
+		resolved[p.Name] = value
+	}
+
+	if len(issues) > 0 {
+		return nil, &PlaceholderError{Issues: issues}
+	}
+	return resolved, nil
+}
+
+// coercePlaceholder converts a raw value to the placeholder's declared type
+func coercePlaceholder(p model.Placeholder, raw string) (interface{}, error) {
+	value := strings.TrimSpace(raw)
+	switch strings.ToLower(strings.TrimSpace(p.Type)) {
+	case "", "string", "text":
+		return raw, nil
+	case "bool", "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected a boolean")
+		}
+		return b, nil
+	case "int", "integer", "number":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer")
+		}
+		return i, nil
+	case "enum":
+		for _, allowed := range p.AllowedValues {
+			if value == allowed {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("expected one of: %s", strings.Join(p.AllowedValues, ", "))
+	default:
+		return nil, fmt.Errorf("unsupported placeholder type %s", p.Type)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Protected regions hold hand-written code that survives regeneration. They are delimited by marker
+// comments in any comment syntax:
+//
+//	// gen:keep begin validation
+//	...
+//	// gen:keep end
+//
+// The template renders the markers with default content; the body of the same region in the previous
+// output of the file replaces it.
+var (
+	regionBegin = regexp.MustCompile(`gen:keep\s+begin\s+([\w.\-/:]+)`)
+	regionEnd   = regexp.MustCompile(`gen:keep\s+end\b`)
+)
+
+// Problems reported for protected regions
+const (
+	RegionOrphaned     = "orphaned"     // The new output has no region with that id; Content holds the dropped code
+	RegionUnterminated = "unterminated" // A begin marker without its end marker
+	RegionUnexpected   = "unexpected"   // An end marker without a begin marker, or a begin marker inside a region
+	RegionDuplicate    = "duplicate"    // The id appears more than once in the file
+)
+
+// RegionIssue is a protected region that could not be carried over
+type RegionIssue struct {
+	File     string `json:"file"`
+	Region   string `json:"region,omitempty"`
+	Line     int    `json:"line"`
+	Problem  string `json:"problem"`
+	Message  string `json:"message"`
+	Previous bool   `json:"previous"`          // The issue is in the previous output rather than the new one
+	Content  string `json:"content,omitempty"` // The hand-written code that was not carried over
+}
+
+type protectedRegion struct {
+	id         string
+	begin, end int // Line indexes of the markers
+	body       string
+}
+
+// PreserveRegions copies the protected regions of the previous output into the generated files with the
+// same path. previous maps paths to their earlier content. Regions the new files have no anchor for are
+// reported as orphaned with their content, as are malformed markers in either output.
+func PreserveRegions(files []GeneratedFile, previous map[string]string) ([]GeneratedFile, []RegionIssue) {
+	var issues []RegionIssue
+	generated := make(map[string]bool, len(files))
+	merged := make([]GeneratedFile, len(files))
+	for i, file := range files {
+		generated[file.Path] = true
+		oldContent, ok := previous[file.Path]
+		if !ok {
+			_, problems := scanRegions(file.Path, file.Content, false)
+			issues = append(issues, problems...)
+			merged[i] = file
+			continue
+		}
+		content, problems := mergeRegions(file.Path, file.Content, oldContent)
+		issues = append(issues, problems...)
+		file.Content = content
+		merged[i] = file
+	}
+
+	// Regions of files that are no longer generated
+	var removed []string
+	for filePath := range previous {
+		if !generated[filePath] {
+			removed = append(removed, filePath)
+		}
+	}
+	sort.Strings(removed)
+	for _, filePath := range removed {
+		regions, problems := scanRegions(filePath, previous[filePath], true)
+		issues = append(issues, problems...)
+		for _, r := range regions {
+			issues = append(issues, orphanedRegion(filePath, r, "the file is no longer generated"))
+		}
+	}
+	return merged, issues
+}
+
+// mergeRegions replaces the region bodies of content with the bodies of the same regions in previous
+func mergeRegions(file, content, previous string) (string, []RegionIssue) {
+	newRegions, issues := scanRegions(file, content, false)
+	oldRegions, oldIssues := scanRegions(file, previous, true)
+	issues = append(issues, oldIssues...)
+
+	oldBodies := make(map[string]protectedRegion, len(oldRegions))
+	for _, r := range oldRegions {
+		oldBodies[r.id] = r
+	}
+	anchored := make(map[string]bool, len(newRegions))
+	lines := strings.SplitAfter(content, "\n")
+	var out strings.Builder
+	next := 0
+	for _, r := range newRegions {
+		anchored[r.id] = true
+		old, ok := oldBodies[r.id]
+		if !ok {
+			continue
+		}
+		out.WriteString(strings.Join(lines[next:r.begin+1], ""))
+		out.WriteString(old.body)
+		next = r.end
+	}
+	out.WriteString(strings.Join(lines[next:], ""))
+
+	for _, r := range oldRegions {
+		if !anchored[r.id] {
+			issues = append(issues, orphanedRegion(file, r, "the new output has no region with this id"))
+		}
+	}
+	return out.String(), issues
+}
+
+// scanRegions finds the well-formed regions of a file in order, skipping duplicates of an id
+func scanRegions(file, content string, previous bool) ([]protectedRegion, []RegionIssue) {
+	var regions []protectedRegion
+	var issues []RegionIssue
+	report := func(line int, id, problem, message, body string) {
+		issues = append(issues, RegionIssue{File: file, Region: id, Line: line + 1, Problem: problem,
+			Message: message, Previous: previous, Content: body})
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	seen := make(map[string]bool)
+	open := -1
+	openID := ""
+	for i, line := range lines {
+		if m := regionBegin.FindStringSubmatch(line); m != nil {
+			if open >= 0 {
+				report(i, m[1], RegionUnexpected, fmt.Sprintf("region %s begins inside region %s", m[1], openID), "")
+				continue
+			}
+			open, openID = i, m[1]
+			continue
+		}
+		if !regionEnd.MatchString(line) {
+			continue
+		}
+		if open < 0 {
+			report(i, "", RegionUnexpected, "gen:keep end without a matching begin", "")
+			continue
+		}
+		r := protectedRegion{id: openID, begin: open, end: i, body: strings.Join(lines[open+1:i], "")}
+		if seen[r.id] {
+			report(open, r.id, RegionDuplicate, fmt.Sprintf("region %s appears more than once; only the first one is kept", r.id), r.body)
+		} else {
+			seen[r.id] = true
+			regions = append(regions, r)
+		}
+		open = -1
+	}
+	if open >= 0 {
+		report(open, openID, RegionUnterminated, fmt.Sprintf("region %s has no gen:keep end", openID), strings.Join(lines[open+1:], ""))
+	}
+	return regions, issues
+}
+
+func orphanedRegion(file string, r protectedRegion, reason string) RegionIssue {
+	return RegionIssue{
+		File:     file,
+		Region:   r.id,
+		Line:     r.begin + 1,
+		Problem:  RegionOrphaned,
+		Message:  fmt.Sprintf("region %s was not carried over: %s", r.id, reason),
+		Previous: true,
+		Content:  r.body,
+	}
+}
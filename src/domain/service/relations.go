@@ -0,0 +1,232 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"sort"
+	"strings"
+)
+
+// Cardinalities of a GenRelation, seen from the entity holding it
+const (
+	CardinalityOneToOne   = "OneToOne"
+	CardinalityManyToOne  = "ManyToOne"
+	CardinalityOneToMany  = "OneToMany"
+	CardinalityManyToMany = "ManyToMany"
+)
+
+// GenRelation is an association of the entity with another entity of its project
+type GenRelation struct {
+	Name          string // Field holding the related entity or entities, e.g. Customer or Items
+	Type          string // RelationType as declared, e.g. Composition
+	Target        string // Name of the related entity
+	TargetVarName string
+	Cardinality   string // OneToOne, ManyToOne, OneToMany or ManyToMany
+	Many          bool   // The field holds a collection
+	SelfReference bool   // The target is the entity itself
+
+	ForeignKey         string // Foreign-key field, e.g. CustomerID; empty for many-to-many
+	ForeignKeyColumn   string // Its column, e.g. customer_id
+	ForeignKeyOnTarget bool   // The key is a field of the target (OneToMany, or a OneToOne the target owns)
+
+	JoinTable          string // Join table of a many-to-many relation, e.g. orders_products
+	JoinForeignKey     string // Join table column referencing the entity, e.g. order_id
+	JoinReferences     string // Join table column referencing the target, e.g. product_id
+	BackReference      string // Field of the target relating back to the entity, empty when it declares none
+	BackReferenceMany  bool   // The back-reference holds a collection
+	OnDelete, OnUpdate string // Referential actions of the foreign key: CASCADE, SET NULL or RESTRICT
+}
+
+// BuildRelations resolves the entity's DependsOnEntities against the entities of its project.
+// When the project's entities are not known, targets are taken as declared and back-references stay empty.
+// Relations whose target is missing, e.g. a deleted entity, are left out and described in skipped.
+func BuildRelations(entity model.Entity, projectEntities []model.Entity) (relations []GenRelation, skipped []string) {
+	byName := make(map[string]model.Entity, len(projectEntities))
+	for _, e := range projectEntities {
+		byName[strings.ToLower(e.EntityName)] = e
+	}
+
+	for _, dep := range entity.DependsOnEntities {
+		if dep.RelationType == enum.NoRelation || dep.RelationType == enum.Inheritance {
+			continue // Nothing to associate; inheritance is the entity's shape, not a relation
+		}
+
+		target := model.Entity{EntityName: dep.EntityName}
+		if dep.RelationType == enum.SelfReferencing || strings.EqualFold(dep.EntityName, entity.EntityName) {
+			target = entity
+		} else if len(projectEntities) > 0 {
+			resolved, ok := byName[strings.ToLower(dep.EntityName)]
+			if !ok {
+				skipped = append(skipped, fmt.Sprintf("relation %s of entity %s is left out: %s is not an entity of its project",
+					dep.FieldName, entity.EntityName, dep.EntityName))
+				continue
+			}
+			target = resolved
+		}
+		if target.EntityName == "" {
+			skipped = append(skipped, fmt.Sprintf("relation %s of entity %s is left out: it has no target entity", dep.FieldName, entity.EntityName))
+			continue
+		}
+
+		relation := GenRelation{
+			Name:          ToPascal(dep.FieldName),
+			Type:          dep.RelationType.String(),
+			Target:        target.EntityName,
+			TargetVarName: ToCamel(target.EntityName),
+			Cardinality:   relationCardinality(entity, dep),
+			SelfReference: strings.EqualFold(target.EntityName, entity.EntityName),
+		}
+		relation.Many = relation.Cardinality == CardinalityOneToMany || relation.Cardinality == CardinalityManyToMany
+
+		back, hasBack := backReference(entity, dep, target)
+		if hasBack {
+			relation.BackReference = ToPascal(back.FieldName)
+			relation.BackReferenceMany = relationCardinality(target, back) == CardinalityOneToMany ||
+				relationCardinality(target, back) == CardinalityManyToMany
+		}
+
+		switch relation.Cardinality {
+		case CardinalityManyToMany:
+			relation.JoinTable = joinTable(entity.EntityName, target.EntityName)
+			relation.JoinForeignKey = ToSnake(entity.EntityName) + "_id"
+			relation.JoinReferences = ToSnake(target.EntityName) + "_id"
+			if relation.SelfReference {
+				relation.JoinReferences = ToSnake(Singularize(dep.FieldName)) + "_id"
+			}
+		case CardinalityOneToMany:
+			relation.ForeignKeyOnTarget = true
+			relation.ForeignKey = foreignKey(target, entity.EntityName)
+			if hasBack && !relation.BackReferenceMany {
+				relation.ForeignKey = foreignKey(target, back.FieldName)
+			}
+		case CardinalityOneToOne:
+			// Both sides may declare a one-to-one; the side with the key field owns it, else the first by name
+			owner := !hasBack || hasForeignKeyField(entity, dep.FieldName) ||
+				(!hasForeignKeyField(target, back.FieldName) && entity.EntityName <= target.EntityName)
+			if owner {
+				relation.ForeignKey = foreignKey(entity, dep.FieldName)
+			} else {
+				relation.ForeignKeyOnTarget = true
+				relation.ForeignKey = foreignKey(target, back.FieldName)
+			}
+		default:
+			relation.ForeignKey = foreignKey(entity, dep.FieldName)
+		}
+		if relation.ForeignKey != "" {
+			relation.ForeignKeyColumn = ToSnake(relation.ForeignKey)
+		}
+		holder, types := entity, []enum.RelationType{dep.RelationType}
+		if relation.ForeignKeyOnTarget {
+			holder = target
+		}
+		if hasBack {
+			types = append(types, back.RelationType)
+		}
+		relation.OnDelete, relation.OnUpdate = referentialActions(holder, relation.ForeignKey, types...)
+		relations = append(relations, relation)
+	}
+	return relations, skipped
+}
+
+// relationCardinality maps a RelationType to its cardinality. Types that only describe ownership
+// (Composition, Aggregation, Association, Dependency) hold many targets when their field is a collection.
+func relationCardinality(entity model.Entity, dep model.DependsOnEntity) string {
+	switch dep.RelationType {
+	case enum.OneToOne:
+		return CardinalityOneToOne
+	case enum.OneToMany:
+		return CardinalityOneToMany
+	case enum.ManyToOne, enum.SelfReferencing:
+		return CardinalityManyToOne
+	case enum.ManyToMany:
+		return CardinalityManyToMany
+	}
+	for _, f := range entity.EntityFields {
+		if strings.EqualFold(f.FieldName, dep.FieldName) && f.IsCollection {
+			return CardinalityOneToMany
+		}
+	}
+	return CardinalityManyToOne
+}
+
+// backReference finds the dependency of the target that points back at the entity, preferring the inverse cardinality
+func backReference(entity model.Entity, dep model.DependsOnEntity, target model.Entity) (model.DependsOnEntity, bool) {
+	inverse := map[string]string{
+		CardinalityOneToOne:   CardinalityOneToOne,
+		CardinalityManyToOne:  CardinalityOneToMany,
+		CardinalityOneToMany:  CardinalityManyToOne,
+		CardinalityManyToMany: CardinalityManyToMany,
+	}[relationCardinality(entity, dep)]
+
+	var candidates []model.DependsOnEntity
+	for _, d := range target.DependsOnEntities {
+		pointsBack := strings.EqualFold(d.EntityName, entity.EntityName) ||
+			(d.RelationType == enum.SelfReferencing && strings.EqualFold(target.EntityName, entity.EntityName))
+		if pointsBack && !strings.EqualFold(d.FieldName, dep.FieldName) {
+			candidates = append(candidates, d)
+		}
+	}
+	for _, d := range candidates {
+		if relationCardinality(target, d) == inverse {
+			return d, true
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0], true
+	}
+	return model.DependsOnEntity{}, false
+}
+
+// foreignKey names the key field of a relation held in the given field, reusing a declared field such as CustomerId
+func foreignKey(holder model.Entity, relationField string) string {
+	name := ToPascal(relationField) + "ID"
+	for _, f := range holder.EntityFields {
+		if strings.EqualFold(f.FieldName, name) {
+			return f.FieldName
+		}
+	}
+	return name
+}
+
+func hasForeignKeyField(holder model.Entity, relationField string) bool {
+	name := ToPascal(relationField) + "ID"
+	for _, f := range holder.EntityFields {
+		if strings.EqualFold(f.FieldName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinTable names the join table of two entities the same way from either side, e.g. orders_products
+func joinTable(a, b string) string {
+	names := []string{ToSnake(Pluralize(a)), ToSnake(Pluralize(b))}
+	sort.Strings(names)
+	if names[0] == names[1] {
+		return names[0] + "_links"
+	}
+	return names[0] + "_" + names[1]
+}
+
+// referentialActions picks what deleting or re-keying the referenced row does to the foreign key, the same
+// from both sides of the relation: owned parts (Composition, join rows) are deleted with their owner,
+// aggregated and self-referencing rows are released, mandatory keys block the delete and optional ones are cleared.
+func referentialActions(holder model.Entity, foreignKey string, types ...enum.RelationType) (string, string) {
+	for _, t := range types {
+		if t == enum.Composition || t == enum.ManyToMany {
+			return "CASCADE", "CASCADE"
+		}
+	}
+	for _, t := range types {
+		if t == enum.Aggregation || t == enum.SelfReferencing {
+			return "SET NULL", "CASCADE"
+		}
+	}
+	for _, f := range holder.EntityFields {
+		if strings.EqualFold(f.FieldName, foreignKey) && f.IsMandatory {
+			return "RESTRICT", "CASCADE"
+		}
+	}
+	return "SET NULL", "CASCADE"
+}
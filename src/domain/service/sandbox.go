@@ -0,0 +1,434 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Limits of the template sandbox, as named in a SandboxLimitError
+const (
+	LimitTimeout          = "timeout"
+	LimitMaxOutputBytes   = "maxOutputBytes"
+	LimitMaxDepth         = "maxDepth"
+	LimitAllowedFunctions = "allowedFunctions"
+)
+
+// Sandbox bounds what a blueprint's templates may do while they render. Zero values disable a limit.
+type Sandbox struct {
+	Timeout          time.Duration // Wall-clock time a generation may take, on top of the caller's deadline
+	MaxOutputBytes   int           // Size of one rendered file
+	MaxDepth         int           // Nested {{template}} calls, counting the file itself
+	AllowedFunctions []string      // Engine functions templates may call; nil allows all. Builtins like eq and len are always allowed.
+}
+
+// DefaultSandbox is the sandbox of a new GenerationService
+func DefaultSandbox() Sandbox {
+	return Sandbox{
+		Timeout:        30 * time.Second,
+		MaxOutputBytes: 10 << 20,
+		MaxDepth:       100,
+	}
+}
+
+// SandboxLimitError reports the sandbox limit a template ran into
+type SandboxLimitError struct {
+	Limit    string // One of the Limit constants
+	Value    string // The configured limit, or the offending function
+	Template string
+}
+
+func (e *SandboxLimitError) Error() string {
+	subject := "generation"
+	if e.Template != "" {
+		subject = "template " + e.Template
+	}
+	switch e.Limit {
+	case LimitTimeout:
+		return fmt.Sprintf("%s exceeded the sandbox timeout of %s", subject, e.Value)
+	case LimitMaxOutputBytes:
+		return fmt.Sprintf("%s exceeded the sandbox's maximum output size of %s bytes", subject, e.Value)
+	case LimitMaxDepth:
+		return fmt.Sprintf("%s exceeded the sandbox's maximum template depth of %s", subject, e.Value)
+	case LimitAllowedFunctions:
+		return fmt.Sprintf("%s calls %s, which is not in the sandbox's allowed functions", subject, e.Value)
+	}
+	return fmt.Sprintf("%s exceeded the sandbox limit %s (%s)", subject, e.Limit, e.Value)
+}
+
+// SetSandbox replaces the limits templates render under
+func (s *GenerationService) SetSandbox(sandbox Sandbox) {
+	s.sandbox = sandbox
+}
+
+// withTimeout bounds a generation by the sandbox timeout. Running out of time cancels the returned context
+// with a *SandboxLimitError as its cause.
+func (sb Sandbox) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if sb.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, sb.Timeout, &SandboxLimitError{Limit: LimitTimeout, Value: sb.Timeout.String()})
+}
+
+// checkFunctions rejects template sets calling engine functions outside the allowed list
+func (sb Sandbox) checkFunctions(tmpl *template.Template) error {
+	if sb.AllowedFunctions == nil {
+		return nil
+	}
+	allowed := make(map[string]bool, len(sb.AllowedFunctions))
+	for _, name := range sb.AllowedFunctions {
+		allowed[name] = true
+	}
+	engine := TemplateFuncs()
+
+	var names []string
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			names = append(names, t.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var denied string
+		inspectNodes(tmpl.Lookup(name).Tree.Root, func(node parse.Node) {
+			if ident, ok := node.(*parse.IdentifierNode); ok && denied == "" {
+				if _, isEngine := engine[ident.Ident]; isEngine && !allowed[ident.Ident] {
+					denied = ident.Ident
+				}
+			}
+		})
+		if denied != "" {
+			return &SandboxLimitError{Limit: LimitAllowedFunctions, Value: denied, Template: name}
+		}
+	}
+	return nil
+}
+
+// instrument counts the depth of every template of the set, so nested {{template}} calls can be capped, and makes
+// loops and branches check that the render may go on. Each template starts with {{sandboxEnter}} and ends with
+// {{sandboxExit}}, and the body of every if, range and with starts with {{sandboxCheck}}; none of them print anything.
+// The actions report to the render hooks is running.
+func instrument(tmpl *template.Template, hooks *sandboxHooks) error {
+	tmpl.Funcs(hooks.funcs())
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		if err := instrumentBranches(t.Tree.Root); err != nil {
+			return err
+		}
+		enter, err := sandboxAction("sandboxEnter")
+		if err != nil {
+			return err
+		}
+		exit, err := sandboxAction("sandboxExit")
+		if err != nil {
+			return err
+		}
+		nodes := append([]parse.Node{enter}, t.Tree.Root.Nodes...)
+		t.Tree.Root.Nodes = append(nodes, exit)
+	}
+	return nil
+}
+
+// instrumentBranches starts the body of every if, range and with below list with {{sandboxCheck}}, so a loop
+// that prints nothing still stops once the render ran out of time
+func instrumentBranches(list *parse.ListNode) error {
+	if list == nil {
+		return nil
+	}
+	for _, node := range list.Nodes {
+		var branch *parse.BranchNode
+		switch n := node.(type) {
+		case *parse.IfNode:
+			branch = &n.BranchNode
+		case *parse.RangeNode:
+			branch = &n.BranchNode
+		case *parse.WithNode:
+			branch = &n.BranchNode
+		default:
+			continue
+		}
+		for _, body := range []*parse.ListNode{branch.List, branch.ElseList} {
+			if body == nil {
+				continue
+			}
+			if err := instrumentBranches(body); err != nil {
+				return err
+			}
+			check, err := sandboxAction("sandboxCheck")
+			if err != nil {
+				return err
+			}
+			body.Nodes = append([]parse.Node{check}, body.Nodes...)
+		}
+	}
+	return nil
+}
+
+func sandboxAction(function string) (parse.Node, error) {
+	tree := parse.New("sandbox")
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse("{{"+function+"}}", "", "", make(map[string]*parse.Tree)); err != nil {
+		return nil, err
+	}
+	return tree.Root.Nodes[0], nil
+}
+
+// execute renders tmpl inside the sandbox. ctx must come from withTimeout; once it is done the render
+// stops at its next output, template call, loop iteration or branch.
+// hooks must be the ones tmpl's set was instrumented with.
+func (s *GenerationService) execute(ctx context.Context, hooks *sandboxHooks, tmpl *template.Template, genCtx GenContext) (string, error) {
+	state := &sandboxState{ctx: ctx, sandbox: s.sandbox, template: tmpl.Name(), genCtx: genCtx}
+	hooks.start(state)
+	defer hooks.finish(state)
+
+	err := tmpl.Execute(&sandboxWriter{state: state}, genCtx)
+	if failure := state.failure(); failure != nil {
+		return "", failure
+	}
+	if err != nil {
+		return "", err
+	}
+	return state.buf.String(), nil
+}
+
+// errNoRender fails the render-bound functions of a set called outside execute
+var errNoRender = errors.New("template is not being rendered")
+
+// sandboxHooks bind the functions of a template set that depend on the render, such as the instrumented
+// actions, once per set. A set renders one file at a time.
+type sandboxHooks struct {
+	mu     sync.Mutex
+	render *sandboxState
+}
+
+func (h *sandboxHooks) start(state *sandboxState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.render = state
+}
+
+func (h *sandboxHooks) finish(state *sandboxState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.render == state {
+		h.render = nil
+	}
+}
+
+// current is the render running on the set
+func (h *sandboxHooks) current() (*sandboxState, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.render == nil {
+		return nil, errNoRender
+	}
+	return h.render, nil
+}
+
+func (h *sandboxHooks) funcs() template.FuncMap {
+	return template.FuncMap{
+		"sandboxEnter": func() (string, error) {
+			st, err := h.current()
+			if err != nil {
+				return "", err
+			}
+			return st.enter()
+		},
+		"sandboxExit": func() string {
+			if st, err := h.current(); err == nil {
+				st.exit()
+			}
+			return ""
+		},
+		"sandboxCheck": func() (string, error) {
+			st, err := h.current()
+			if err != nil {
+				return "", err
+			}
+			return "", st.stop()
+		},
+		// The functions allocating what the template asks for are bounded by the output left to the render
+		"repeat": func(count int, s string) (string, error) {
+			st, err := h.current()
+			if err != nil {
+				return "", err
+			}
+			if err := st.reserve(count, len(s)); err != nil {
+				return "", err
+			}
+			return repeat(count, s)
+		},
+		"indent": func(spaces int, s string) (string, error) {
+			st, err := h.current()
+			if err != nil {
+				return "", err
+			}
+			if err := st.reserve(spaces, strings.Count(s, "\n")+1); err != nil {
+				return "", err
+			}
+			return indent(spaces, s)
+		},
+		"nindent": func(spaces int, s string) (string, error) {
+			st, err := h.current()
+			if err != nil {
+				return "", err
+			}
+			if err := st.reserve(spaces, strings.Count(s, "\n")+1); err != nil {
+				return "", err
+			}
+			return nindent(spaces, s)
+		},
+	}
+}
+
+// sandboxState tracks one render
+type sandboxState struct {
+	ctx      context.Context
+	sandbox  Sandbox
+	template string
+	genCtx   GenContext
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	depth int
+	err   error
+}
+
+func (st *sandboxState) enter() (string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.check(); err != nil {
+		return "", err
+	}
+	st.depth++
+	if st.sandbox.MaxDepth > 0 && st.depth > st.sandbox.MaxDepth {
+		st.err = &SandboxLimitError{Limit: LimitMaxDepth, Value: fmt.Sprint(st.sandbox.MaxDepth), Template: st.template}
+		return "", st.err
+	}
+	return "", nil
+}
+
+func (st *sandboxState) exit() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.depth--
+	return ""
+}
+
+// check fails once a limit was hit or the context is done
+func (st *sandboxState) check() error {
+	if st.err == nil && st.ctx.Err() != nil {
+		st.err = st.ctx.Err()
+		var limitErr *SandboxLimitError
+		if cause := context.Cause(st.ctx); errors.As(cause, &limitErr) {
+			st.err = &SandboxLimitError{Limit: limitErr.Limit, Value: limitErr.Value, Template: st.template}
+		}
+	}
+	return st.err
+}
+
+func (st *sandboxState) stop() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.check()
+}
+
+// reserve fails when count copies of size bytes would not fit in the output left to the render, before
+// anything is allocated
+func (st *sandboxState) reserve(count, size int) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.check(); err != nil {
+		return err
+	}
+	max := st.sandbox.MaxOutputBytes
+	if max <= 0 || count <= 0 || size == 0 {
+		return nil
+	}
+	if count > (max-st.buf.Len())/size {
+		st.err = &SandboxLimitError{Limit: LimitMaxOutputBytes, Value: fmt.Sprint(max), Template: st.template}
+		return st.err
+	}
+	return nil
+}
+
+// failure is the limit or cancellation that ended the render, if any
+func (st *sandboxState) failure() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.err
+}
+
+// sandboxWriter collects the output of a render up to the size limit
+type sandboxWriter struct {
+	state *sandboxState
+}
+
+func (w *sandboxWriter) Write(p []byte) (int, error) {
+	st := w.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.check(); err != nil {
+		return 0, err
+	}
+	if max := st.sandbox.MaxOutputBytes; max > 0 && st.buf.Len()+len(p) > max {
+		st.err = &SandboxLimitError{Limit: LimitMaxOutputBytes, Value: fmt.Sprint(max), Template: st.template}
+		return 0, st.err
+	}
+	return st.buf.Write(p)
+}
+
+// inspectNodes calls fn for node and every node below it
+func inspectNodes(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			inspectNodes(child, fn)
+		}
+	case *parse.ActionNode:
+		inspectNodes(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			inspectNodes(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			inspectNodes(arg, fn)
+		}
+	case *parse.ChainNode:
+		inspectNodes(n.Node, fn)
+	case *parse.IfNode:
+		inspectBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		inspectBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		inspectBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		inspectNodes(n.Pipe, fn)
+	}
+}
+
+func inspectBranch(n *parse.BranchNode, fn func(parse.Node)) {
+	inspectNodes(n.Pipe, fn)
+	inspectNodes(n.List, fn)
+	inspectNodes(n.ElseList, fn)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"strings"
+)
+
+// SchemaMerge is the outcome of merging imported entities into a project
+type SchemaMerge struct {
+	Entities []model.Entity
+	Created  []string // Entities the import added
+	Updated  []string // Entities of the project the import matched
+	Dropped  []string // Imported relations left out because their target is not an entity of the project
+}
+
+// MergeEntities merges imported entities into the existing entities of a project, matching entities, fields
+// and relations by name regardless of case. The schema decides the type, nullability, uniqueness and enum values
+// of a field; what only the project knows, such as descriptions, validations and sample data, is kept.
+// Relations the project already declares keep their type, which may say more than a schema can (a Composition
+// rather than a ManyToOne). New fields and relations are added and nothing is removed.
+func MergeEntities(existing, imported []model.Entity) SchemaMerge {
+	names := map[string]bool{}
+	for _, e := range append(append([]model.Entity(nil), existing...), imported...) {
+		names[strings.ToLower(e.EntityName)] = true
+	}
+
+	merge := SchemaMerge{Entities: make([]model.Entity, len(existing))}
+	for i, e := range existing {
+		e.EntityFields = append([]model.EntityField(nil), e.EntityFields...)
+		e.DependsOnEntities = append([]model.DependsOnEntity(nil), e.DependsOnEntities...)
+		merge.Entities[i] = e
+	}
+
+	for _, entity := range imported {
+		var relations []model.DependsOnEntity
+		for _, dep := range entity.DependsOnEntities {
+			if dep.RelationType != enum.SelfReferencing && !names[strings.ToLower(dep.EntityName)] {
+				merge.Dropped = append(merge.Dropped, fmt.Sprintf("relation %s.%s: %s is not an entity of the project",
+					entity.EntityName, dep.FieldName, dep.EntityName))
+				continue
+			}
+			relations = append(relations, dep)
+		}
+		entity.DependsOnEntities = relations
+
+		target := entityNamed(merge.Entities, entity.EntityName)
+		if target == nil {
+			merge.Entities = append(merge.Entities, entity)
+			merge.Created = append(merge.Created, entity.EntityName)
+			continue
+		}
+		mergeEntity(target, entity)
+		merge.Updated = append(merge.Updated, target.EntityName)
+	}
+	return merge
+}
+
+func entityNamed(entities []model.Entity, name string) *model.Entity {
+	for i := range entities {
+		if strings.EqualFold(entities[i].EntityName, name) {
+			return &entities[i]
+		}
+	}
+	return nil
+}
+
+func mergeEntity(target *model.Entity, imported model.Entity) {
+	if target.EntityDescription == "" {
+		target.EntityDescription = imported.EntityDescription
+	}
+
+	for _, field := range imported.EntityFields {
+		i := -1
+		for j, f := range target.EntityFields {
+			if strings.EqualFold(f.FieldName, field.FieldName) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			target.EntityFields = append(target.EntityFields, field)
+			continue
+		}
+
+		f := &target.EntityFields[i]
+		f.FieldType = field.FieldType
+		f.IsMandatory = field.IsMandatory
+		f.IsUnique = field.IsUnique
+		f.IsCollection = field.IsCollection
+		f.CollectionType = field.CollectionType
+		f.CollectionItemType = field.CollectionItemType
+		f.IsEnum = field.IsEnum
+		f.EnumValues = field.EnumValues
+		if f.FieldDescription == "" {
+			f.FieldDescription = field.FieldDescription
+		}
+	}
+
+	for _, dep := range imported.DependsOnEntities {
+		declared := false
+		for _, d := range target.DependsOnEntities {
+			if strings.EqualFold(d.FieldName, dep.FieldName) {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			target.DependsOnEntities = append(target.DependsOnEntities, dep)
+		}
+	}
+}
+
+// MergeJourneys merges imported entity journeys into the existing journeys of a project, matching entities and
+// operations by name regardless of case. Operations the project already has are kept as they are, since their
+// backend journey says more than an API description; the operations added are returned as Entity.operation.
+func MergeJourneys(existing, imported []model.EntityJourney) ([]model.EntityJourney, []string) {
+	merged := append([]model.EntityJourney(nil), existing...)
+	var added []string
+	for _, journey := range imported {
+		i := -1
+		for j := range merged {
+			if strings.EqualFold(merged[j].EntityName, journey.EntityName) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			merged = append(merged, journey)
+			for _, op := range journey.Operations {
+				added = append(added, journey.EntityName+"."+op.Name)
+			}
+			continue
+		}
+
+		target := &merged[i]
+		target.Operations = append([]model.Operation(nil), target.Operations...)
+		for _, op := range journey.Operations {
+			declared := false
+			for _, o := range target.Operations {
+				if strings.EqualFold(o.Name, op.Name) {
+					declared = true
+					break
+				}
+			}
+			if !declared {
+				target.Operations = append(target.Operations, op)
+				added = append(added, target.EntityName+"."+op.Name)
+			}
+		}
+	}
+	return merged, added
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// TemplateFuncs returns the helper functions registered on every blueprint template.
+// Functions taking a value and a parameter expect the value last so they work in pipelines:
+// {{.Entity.Name | plural | snake}}, {{.Names | join ", "}}, {{.Body | indent 4}}.
+// The full list is documented in docs/BLUEPRINTS.md.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Case conversion
+		"camel":          ToCamel,
+		"pascal":         ToPascal,
+		"snake":          ToSnake,
+		"kebab":          ToKebab,
+		"screamingSnake": ToScreamingSnake,
+		"lower":          strings.ToLower,
+		"upper":          strings.ToUpper,
+		"title":          toTitle,
+
+		// Pluralisation
+		"plural":   Pluralize,
+		"singular": Singularize,
+
+		// Strings
+		"join":       join,
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     repeat,
+		"indent":     indent,
+		"nindent":    nindent,
+		"quote":      func(v interface{}) string { return strconv.Quote(toString(v)) },
+		"squote":     func(v interface{}) string { return "'" + strings.ReplaceAll(toString(v), "'", "\\'") + "'" },
+
+		// Collections
+		"list":      func(items ...interface{}) []interface{} { return items },
+		"first":     first,
+		"last":      last,
+		"uniq":      uniq,
+		"reverse":   reverse,
+		"sortAlpha": sortAlpha,
+		"has":       has,
+
+		// Conditionals
+		"default":            defaultValue,
+		"empty":              isEmpty,
+		"ternary":            ternary,
+		"hasField":           hasField,
+		"field":              field,
+		"hasImport":          hasImport,
+		"hasLibraryFunction": hasLibraryFunction,
+
+		// AI, bound to the provider by the generation service before execution
+		"ai": func(instruction string, subjects ...interface{}) (string, error) {
+			return "", fmt.Errorf("ai is only available during generation")
+		},
+	}
+}
+
+// SplitWords breaks an identifier into words on separators, lower-to-upper transitions
+// and acronym boundaries: "HTTPServer_id" -> ["HTTP", "Server", "id"]
+func SplitWords(s string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 {
+			prev := runes[i-1]
+			switch {
+			case unicode.IsLower(prev) && unicode.IsUpper(r):
+				flush()
+			case unicode.IsDigit(prev) && unicode.IsUpper(r):
+				flush()
+			case unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// normalizedWords splits s into words. Inputs without any lower case letter (EMAIL_ADDRESS)
+// are lower cased first so they are not mistaken for acronyms.
+func normalizedWords(s string) []string {
+	words := SplitWords(s)
+	if !strings.ContainsFunc(s, unicode.IsLower) {
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+	}
+	return words
+}
+
+// ToPascal converts an identifier to PascalCase, keeping acronyms: "user_id" -> "UserId", "userID" -> "UserID"
+func ToPascal(s string) string {
+	var b strings.Builder
+	for _, w := range normalizedWords(s) {
+		b.WriteString(upperFirst(w))
+	}
+	return b.String()
+}
+
+// ToCamel converts an identifier to lowerCamelCase: "HTTPServer" -> "httpServer"
+func ToCamel(s string) string {
+	var b strings.Builder
+	for i, w := range normalizedWords(s) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(w))
+			continue
+		}
+		b.WriteString(upperFirst(w))
+	}
+	return b.String()
+}
+
+// ToSnake converts an identifier to snake_case: "OrderItem" -> "order_item"
+func ToSnake(s string) string {
+	return joinWordsLower(s, "_")
+}
+
+// ToKebab converts an identifier to kebab-case: "OrderItem" -> "order-item"
+func ToKebab(s string) string {
+	return joinWordsLower(s, "-")
+}
+
+// ToScreamingSnake converts an identifier to SCREAMING_SNAKE_CASE: "maxRetries" -> "MAX_RETRIES"
+func ToScreamingSnake(s string) string {
+	return strings.ToUpper(ToSnake(s))
+}
+
+func joinWordsLower(s string, sep string) string {
+	words := SplitWords(s)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, sep)
+}
+
+func upperFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func toTitle(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = upperFirst(strings.ToLower(w))
+	}
+	return strings.Join(words, " ")
+}
+
+var uncountables = map[string]bool{
+	"data": true, "metadata": true, "information": true, "equipment": true, "feedback": true,
+	"software": true, "hardware": true, "money": true, "news": true, "series": true,
+	"species": true, "sheep": true, "fish": true, "deer": true, "rice": true, "media": true,
+}
+
+var irregularPlurals = map[string]string{
+	"person": "people", "child": "children", "man": "men", "woman": "women", "mouse": "mice",
+	"goose": "geese", "tooth": "teeth", "foot": "feet", "ox": "oxen", "criterion": "criteria",
+	"leaf": "leaves", "wolf": "wolves", "half": "halves", "shelf": "shelves", "thief": "thieves",
+	"knife": "knives", "life": "lives", "wife": "wives", "hero": "heroes", "potato": "potatoes",
+	"tomato": "tomatoes", "echo": "echoes", "quiz": "quizzes",
+}
+
+var irregularSingulars = func() map[string]string {
+	m := make(map[string]string, len(irregularPlurals))
+	for singular, plural := range irregularPlurals {
+		m[plural] = singular
+	}
+	return m
+}()
+
+// Pluralize returns the English plural of the last word of an identifier: "OrderItem" -> "OrderItems"
+func Pluralize(s string) string {
+	return inflectLastWord(s, pluralizeWord)
+}
+
+// Singularize returns the English singular of the last word of an identifier: "categories" -> "category"
+func Singularize(s string) string {
+	return inflectLastWord(s, singularizeWord)
+}
+
+func inflectLastWord(s string, inflect func(string) string) string {
+	words := SplitWords(s)
+	if len(words) == 0 {
+		return s
+	}
+	lastWord := words[len(words)-1]
+	idx := strings.LastIndex(s, lastWord)
+
+	lower := strings.ToLower(lastWord)
+	inflected := inflect(lower)
+	isUpper := lastWord == strings.ToUpper(lastWord) && strings.ContainsFunc(lastWord, unicode.IsLetter)
+	switch {
+	case isUpper && strings.ContainsFunc(s, unicode.IsLower) && len(inflected) > len(lower):
+		// Acronym inside a mixed case identifier: userID -> userIDs
+		inflected = lastWord + inflected[len(lower):]
+	case isUpper:
+		inflected = strings.ToUpper(inflected)
+	case lastWord != lower:
+		inflected = upperFirst(inflected)
+	}
+	return s[:idx] + inflected + s[idx+len(lastWord):]
+}
+
+func pluralizeWord(w string) string {
+	if uncountables[w] {
+		return w
+	}
+	if p, ok := irregularPlurals[w]; ok {
+		return p
+	}
+	if _, ok := irregularSingulars[w]; ok {
+		return w
+	}
+	switch {
+	case strings.HasSuffix(w, "sis"):
+		return strings.TrimSuffix(w, "sis") + "ses"
+	case strings.HasSuffix(w, "s"), strings.HasSuffix(w, "x"), strings.HasSuffix(w, "z"),
+		strings.HasSuffix(w, "ch"), strings.HasSuffix(w, "sh"):
+		return w + "es"
+	case strings.HasSuffix(w, "y") && len(w) > 1 && !isVowel(rune(w[len(w)-2])):
+		return strings.TrimSuffix(w, "y") + "ies"
+	}
+	return w + "s"
+}
+
+func singularizeWord(w string) string {
+	if uncountables[w] {
+		return w
+	}
+	if s, ok := irregularSingulars[w]; ok {
+		return s
+	}
+	if _, ok := irregularPlurals[w]; ok {
+		return w
+	}
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 3:
+		return strings.TrimSuffix(w, "ies") + "y"
+	case strings.HasSuffix(w, "yses"):
+		return strings.TrimSuffix(w, "es") + "is"
+	case strings.HasSuffix(w, "ouses"):
+		return strings.TrimSuffix(w, "s")
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "uses"), strings.HasSuffix(w, "xes"),
+		strings.HasSuffix(w, "zes"), strings.HasSuffix(w, "ches"), strings.HasSuffix(w, "shes"):
+		return strings.TrimSuffix(w, "es")
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"), strings.HasSuffix(w, "is"):
+		return w
+	case strings.HasSuffix(w, "s") && len(w) > 1:
+		return strings.TrimSuffix(w, "s")
+	}
+	return w
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", r)
+}
+
+// repeat rejects negative counts, which strings.Repeat panics on
+func repeat(count int, s string) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("repeat count %d is negative", count)
+	}
+	return strings.Repeat(s, count), nil
+}
+
+func indent(spaces int, s string) (string, error) {
+	if spaces < 0 {
+		return "", fmt.Errorf("indent of %d spaces is negative", spaces)
+	}
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad), nil
+}
+
+func nindent(spaces int, s string) (string, error) {
+	indented, err := indent(spaces, s)
+	return "\n" + indented, err
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// toSlice turns any slice or array into []interface{}; other values become a one element slice
+func toSlice(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+func join(sep string, v interface{}) string {
+	items := toSlice(v)
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = toString(item)
+	}
+	return strings.Join(parts, sep)
+}
+
+func first(v interface{}) interface{} {
+	items := toSlice(v)
+	if len(items) == 0 {
+		return nil
+	}
+	return items[0]
+}
+
+func last(v interface{}) interface{} {
+	items := toSlice(v)
+	if len(items) == 0 {
+		return nil
+	}
+	return items[len(items)-1]
+}
+
+func uniq(v interface{}) []interface{} {
+	var result []interface{}
+	for _, item := range toSlice(v) {
+		if !containsItem(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func reverse(v interface{}) []interface{} {
+	items := toSlice(v)
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[len(items)-1-i] = item
+	}
+	return result
+}
+
+func sortAlpha(v interface{}) []string {
+	items := toSlice(v)
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = toString(item)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func has(needle interface{}, haystack interface{}) bool {
+	return containsItem(toSlice(haystack), needle)
+}
+
+func containsItem(items []interface{}, needle interface{}) bool {
+	for _, item := range items {
+		if reflect.DeepEqual(item, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultValue(def interface{}, v interface{}) interface{} {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+func ternary(vt interface{}, vf interface{}, cond bool) interface{} {
+	if cond {
+		return vt
+	}
+	return vf
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// field looks up a field of the entity by name, preferring an exact match over a case-insensitive one
+func field(entity GenEntity, name string) (GenField, error) {
+	for _, f := range entity.Fields {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	for _, f := range entity.Fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, nil
+		}
+	}
+	return GenField{}, fmt.Errorf("entity %s has no field %s", entity.Name, name)
+}
+
+func hasField(entity GenEntity, name string) bool {
+	_, err := field(entity, name)
+	return err == nil
+}
+
+func hasImport(ctx GenContext, importPath string) bool {
+	for _, i := range ctx.Imports {
+		if i == importPath {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLibraryFunction(ctx GenContext, key string) bool {
+	_, ok := ctx.LibraryFunctions[key]
+	return ok
+}
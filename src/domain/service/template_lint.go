@@ -0,0 +1,636 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// TemplateLintIssue is a problem found in a blueprint template before it is ever rendered
+type TemplateLintIssue struct {
+	Template string `json:"template"` // Template file path, "placeholders", or the file path template
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"` // error or warning
+	Message  string `json:"message"`
+}
+
+// TemplateLintError lists the lint errors that keep a blueprint from being saved
+type TemplateLintError struct {
+	Issues []TemplateLintIssue
+}
+
+func (e *TemplateLintError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = fmt.Sprintf("%s:%d: %s", issue.Template, issue.Line, issue.Message)
+	}
+	return "invalid templates: " + strings.Join(messages, "; ")
+}
+
+// builtinResults are the result types of the text/template builtins; nil when it depends on the arguments
+var builtinResults = map[string]reflect.Type{
+	"and": nil, "or": nil, "call": nil, "index": nil, "slice": nil,
+	"not": reflect.TypeOf(false), "eq": reflect.TypeOf(false), "ne": reflect.TypeOf(false),
+	"lt": reflect.TypeOf(false), "le": reflect.TypeOf(false), "gt": reflect.TypeOf(false), "ge": reflect.TypeOf(false),
+	"len": reflect.TypeOf(0), "print": reflect.TypeOf(""), "printf": reflect.TypeOf(""), "println": reflect.TypeOf(""),
+	"html": reflect.TypeOf(""), "js": reflect.TypeOf(""), "urlquery": reflect.TypeOf(""),
+}
+
+var (
+	genContextType   = reflect.TypeOf(GenContext{})
+	placeholdersType = reflect.TypeOf(GenContext{}.Inputs)
+	parseErrorRegex  = regexp.MustCompile(`^template: (.*?):(\d+):(?:(\d+):)?\s*(.*)$`)
+)
+
+// LintBlueprint parses the blueprint's templates and file path templates and checks every field path,
+// range variable, function call, template call and placeholder against the GenContext types.
+// Warnings are returned; errors, which would fail every generation, come back as a *TemplateLintError.
+func LintBlueprint(blueprint model.Blueprint) ([]TemplateLintIssue, error) {
+	issues := LintTemplates(blueprint)
+	var errs, warnings []TemplateLintIssue
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			errs = append(errs, issue)
+		} else {
+			warnings = append(warnings, issue)
+		}
+	}
+	if len(errs) > 0 {
+		return warnings, &TemplateLintError{Issues: errs}
+	}
+	return warnings, nil
+}
+
+// LintTemplates returns every lint error and warning of the blueprint, ordered by template and line
+func LintTemplates(blueprint model.Blueprint) []TemplateLintIssue {
+	l := &templateLinter{
+		funcs:        TemplateFuncs(),
+		trees:        make(map[string]*parse.Tree),
+		placeholders: make(map[string]bool),
+		usedInputs:   make(map[string]bool),
+		calls:        make(map[string][]reflect.Type),
+		walked:       make(map[string]bool),
+	}
+	for _, p := range blueprint.Placeholders {
+		l.placeholders[p.Name] = true
+	}
+
+	// Every file is executed with a GenContext, partials only through {{template}} with whatever they are given
+	var files []string
+	if len(blueprint.Templates) == 0 && blueprint.TemplatePath != "" {
+		if l.parse("blueprint", blueprint.TemplatePath) {
+			files = append(files, "blueprint")
+		}
+	}
+	for _, t := range blueprint.Templates {
+		if t.Removed {
+			continue
+		}
+		if l.parse(t.Path, t.Content) && !isPartialTemplate(t.Path) {
+			files = append(files, t.Path)
+		}
+	}
+	for _, name := range files {
+		l.walkTemplate(name, genContextType)
+	}
+	// A template defined with a declared file path as its name renders that file
+	for _, raw := range OutputFilePaths(blueprint) {
+		l.walkTemplate(raw, genContextType)
+	}
+
+	// Defined templates are checked with the type they are called with, once every caller was seen
+	for {
+		name, ok := l.nextCalled()
+		if !ok {
+			break
+		}
+		l.walkTemplate(name, l.calledWith(name))
+	}
+	var unused []string
+	for name := range l.trees {
+		if !l.walked[name] && !isPartialTemplate(name) {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	for _, name := range unused {
+		tree := l.trees[name]
+		l.report(tree, tree.Root, LintWarning, "template %q is never used", name)
+		l.walkTemplate(name, nil)
+	}
+	for name := range l.trees {
+		l.walkTemplate(name, nil) // Partial files themselves, outside their definitions
+	}
+
+	// File paths are templates too
+	for _, raw := range OutputFilePaths(blueprint) {
+		if !strings.Contains(raw, "{{") {
+			continue
+		}
+		tree := parse.New(raw)
+		tree.Mode = parse.SkipFuncCheck
+		if _, err := tree.Parse(raw, "", "", make(map[string]*parse.Tree)); err != nil {
+			l.parseError(raw, err)
+			continue
+		}
+		l.walk(tree, tree.Root, newLintScope(genContextType))
+	}
+
+	if !l.dynamicInputs {
+		var names []string
+		for name := range l.placeholders {
+			if !l.usedInputs[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			l.issues = append(l.issues, TemplateLintIssue{
+				Template: "placeholders", Severity: LintWarning, Message: fmt.Sprintf("placeholder %s is never used", name),
+			})
+		}
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := l.issues[i], l.issues[j]
+		if a.Template != b.Template {
+			return a.Template < b.Template
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.issues
+}
+
+type templateLinter struct {
+	funcs         map[string]interface{}
+	trees         map[string]*parse.Tree // Every template of the set by name, files and {{define}}s
+	placeholders  map[string]bool
+	usedInputs    map[string]bool
+	dynamicInputs bool                      // .Inputs is used as a whole, so unused placeholders are not reported
+	calls         map[string][]reflect.Type // Types each template is called with; nil for unknown
+	walked        map[string]bool
+	issues        []TemplateLintIssue
+}
+
+// lintScope is what a template node sees: the type of dot, the declared variables and the pointer
+// paths checked by an enclosing {{if}}
+type lintScope struct {
+	dot    reflect.Type // nil when unknown
+	vars   map[string]reflect.Type
+	guards map[string]bool
+}
+
+func newLintScope(dot reflect.Type) *lintScope {
+	return &lintScope{dot: dot, vars: map[string]reflect.Type{"$": dot}, guards: map[string]bool{}}
+}
+
+func (s *lintScope) child() *lintScope {
+	c := &lintScope{dot: s.dot, vars: make(map[string]reflect.Type, len(s.vars)), guards: make(map[string]bool, len(s.guards))}
+	for k, v := range s.vars {
+		c.vars[k] = v
+	}
+	for k := range s.guards {
+		c.guards[k] = true
+	}
+	return c
+}
+
+// withDot moves dot; guards relative to the old dot no longer apply
+func (s *lintScope) withDot(dot reflect.Type) {
+	s.dot = dot
+	for k := range s.guards {
+		if strings.HasPrefix(k, ".") {
+			delete(s.guards, k)
+		}
+	}
+}
+
+// parse adds a template file and its {{define}}s to the set. A definition repeated in another file wins at
+// render time like it does in text/template, with a warning.
+func (l *templateLinter) parse(name, text string) bool {
+	trees := make(map[string]*parse.Tree)
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		l.parseError(name, err)
+		return false
+	}
+	for defined, t := range trees {
+		if previous, ok := l.trees[defined]; ok && defined != name {
+			l.report(t, t.Root, LintWarning, "template %q is also defined in %s; the later definition wins", defined, previous.ParseName)
+		}
+		l.trees[defined] = t
+	}
+	return true
+}
+
+func (l *templateLinter) parseError(name string, err error) {
+	issue := TemplateLintIssue{Template: name, Severity: LintError, Message: err.Error()}
+	if m := parseErrorRegex.FindStringSubmatch(err.Error()); m != nil {
+		issue.Line, _ = strconv.Atoi(m[2])
+		issue.Column, _ = strconv.Atoi(m[3])
+		issue.Message = m[4]
+	}
+	l.issues = append(l.issues, issue)
+}
+
+func (l *templateLinter) report(tree *parse.Tree, node parse.Node, severity, format string, args ...interface{}) {
+	issue := TemplateLintIssue{Template: tree.ParseName, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		// ErrorContext locates the node as "name:line:column"
+		location, _ := tree.ErrorContext(node)
+		parts := strings.Split(location, ":")
+		if len(parts) >= 3 {
+			issue.Line, _ = strconv.Atoi(parts[len(parts)-2])
+			issue.Column, _ = strconv.Atoi(parts[len(parts)-1])
+		}
+	}
+	l.issues = append(l.issues, issue)
+}
+
+func (l *templateLinter) walkTemplate(name string, dot reflect.Type) {
+	tree, ok := l.trees[name]
+	if !ok || l.walked[name] {
+		return
+	}
+	l.walked[name] = true
+	if tree.Root != nil {
+		l.walk(tree, tree.Root, newLintScope(dot))
+	}
+}
+
+// nextCalled returns a template that was called but not checked yet
+func (l *templateLinter) nextCalled() (string, bool) {
+	var names []string
+	for name := range l.calls {
+		if !l.walked[name] {
+			if _, ok := l.trees[name]; ok {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
+
+// calledWith is the type every call passes to the template, or nil when the calls disagree
+func (l *templateLinter) calledWith(name string) reflect.Type {
+	types := l.calls[name]
+	for _, t := range types[1:] {
+		if t != types[0] {
+			return nil
+		}
+	}
+	return types[0]
+}
+
+func (l *templateLinter) walk(tree *parse.Tree, node parse.Node, scope *lintScope) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(tree, child, scope)
+		}
+	case *parse.ActionNode:
+		l.pipe(tree, n.Pipe, scope, true)
+	case *parse.IfNode:
+		inner := scope.child()
+		l.pipe(tree, n.Pipe, inner, true)
+		for _, guard := range guardedPaths(n.Pipe) {
+			inner.guards[guard] = true
+		}
+		l.walk(tree, n.List, inner)
+		l.walk(tree, n.ElseList, scope.child())
+	case *parse.WithNode:
+		inner := scope.child()
+		dot := l.pipe(tree, n.Pipe, inner, true)
+		if dot != nil && dot.Kind() == reflect.Pointer {
+			dot = dot.Elem() // {{with}} skips nil pointers
+		}
+		inner.withDot(dot)
+		l.walk(tree, n.List, inner)
+		l.walk(tree, n.ElseList, scope.child())
+	case *parse.RangeNode:
+		inner := scope.child()
+		collection := l.pipe(tree, n.Pipe, inner, false)
+		key, elem, ok := rangeTypes(collection)
+		if !ok {
+			l.report(tree, n, LintError, "range can't iterate over %s", typeName(collection))
+		}
+		switch len(n.Pipe.Decl) {
+		case 1:
+			inner.vars[n.Pipe.Decl[0].Ident[0]] = elem
+		case 2:
+			inner.vars[n.Pipe.Decl[0].Ident[0]] = key
+			inner.vars[n.Pipe.Decl[1].Ident[0]] = elem
+		}
+		if collection == placeholdersType {
+			l.dynamicInputs = true
+		}
+		inner.withDot(elem)
+		l.walk(tree, n.List, inner)
+		l.walk(tree, n.ElseList, scope.child())
+	case *parse.TemplateNode:
+		var dot reflect.Type
+		if n.Pipe != nil {
+			dot = l.pipe(tree, n.Pipe, scope, true)
+		}
+		if _, ok := l.trees[n.Name]; !ok {
+			l.report(tree, n, LintError, "template %q is not defined", n.Name)
+			return
+		}
+		l.calls[n.Name] = append(l.calls[n.Name], dot)
+	}
+}
+
+// guardedPaths are the field and variable paths an {{if}} checks, directly or through and
+func guardedPaths(pipe *parse.PipeNode) []string {
+	if len(pipe.Cmds) != 1 {
+		return nil
+	}
+	args := pipe.Cmds[0].Args
+	if ident, ok := args[0].(*parse.IdentifierNode); ok && ident.Ident == "and" {
+		args = args[1:]
+	} else if len(args) != 1 {
+		return nil
+	}
+	var paths []string
+	for _, arg := range args {
+		switch arg.(type) {
+		case *parse.FieldNode, *parse.VariableNode:
+			paths = append(paths, arg.String())
+		}
+	}
+	return paths
+}
+
+// pipe checks a pipeline and returns the type of its result; declare binds the declared variables to it
+func (l *templateLinter) pipe(tree *parse.Tree, pipe *parse.PipeNode, scope *lintScope, declare bool) reflect.Type {
+	var result reflect.Type
+	for i, cmd := range pipe.Cmds {
+		result = l.command(tree, cmd, scope, i > 0, result)
+	}
+	if declare && !pipe.IsAssign {
+		for _, v := range pipe.Decl {
+			scope.vars[v.Ident[0]] = result
+		}
+	}
+	return result
+}
+
+// command checks a command of a pipeline; piped is set when the previous command's result is its final argument
+func (l *templateLinter) command(tree *parse.Tree, cmd *parse.CommandNode, scope *lintScope, piped bool, previous reflect.Type) reflect.Type {
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		return l.call(tree, ident, cmd.Args[1:], scope, piped, previous)
+	}
+	return l.arg(tree, cmd.Args[0], scope)
+}
+
+func (l *templateLinter) call(tree *parse.Tree, ident *parse.IdentifierNode, args []parse.Node, scope *lintScope, piped bool, previous reflect.Type) reflect.Type {
+	argTypes := make([]reflect.Type, len(args))
+	for i, arg := range args {
+		argTypes[i] = l.arg(tree, arg, scope)
+	}
+	if piped {
+		argTypes = append(argTypes, previous)
+	}
+
+	if result, ok := builtinResults[ident.Ident]; ok {
+		if ident.Ident == "index" && len(args) >= 2 {
+			return l.index(tree, ident, argTypes[0], args[1:])
+		}
+		return result
+	}
+	fn, ok := l.funcs[ident.Ident]
+	if !ok {
+		names := make([]string, 0, len(l.funcs)+len(builtinResults))
+		for name := range l.funcs {
+			names = append(names, name)
+		}
+		for name := range builtinResults {
+			names = append(names, name)
+		}
+		l.report(tree, ident, LintError, "function %q is not defined%s", ident.Ident, didYouMean(ident.Ident, names))
+		return nil
+	}
+
+	fnType := reflect.TypeOf(fn)
+	switch {
+	case fnType.IsVariadic() && len(argTypes) < fnType.NumIn()-1:
+		l.report(tree, ident, LintError, "%s needs at least %d arguments, got %d", ident.Ident, fnType.NumIn()-1, len(argTypes))
+	case !fnType.IsVariadic() && len(argTypes) != fnType.NumIn():
+		l.report(tree, ident, LintError, "%s needs %d arguments, got %d", ident.Ident, fnType.NumIn(), len(argTypes))
+	}
+	return known(fnType.Out(0))
+}
+
+// index follows {{index}} through maps and slices, checking literal placeholder names
+func (l *templateLinter) index(tree *parse.Tree, ident *parse.IdentifierNode, t reflect.Type, keys []parse.Node) reflect.Type {
+	for _, key := range keys {
+		if t == placeholdersType {
+			if name, ok := key.(*parse.StringNode); ok {
+				l.placeholder(tree, key, name.Text)
+			} else {
+				l.dynamicInputs = true
+			}
+		}
+		if t == nil {
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			t = known(t.Elem())
+		default:
+			l.report(tree, ident, LintError, "can't index %s", typeName(t))
+			return nil
+		}
+	}
+	return t
+}
+
+func (l *templateLinter) placeholder(tree *parse.Tree, node parse.Node, name string) {
+	l.usedInputs[name] = true
+	if !l.placeholders[name] {
+		names := make([]string, 0, len(l.placeholders))
+		for p := range l.placeholders {
+			names = append(names, p)
+		}
+		l.report(tree, node, LintError, "unknown placeholder %s%s", name, didYouMean(name, names))
+	}
+}
+
+// arg returns the type of an argument, checking its field path; nil when it cannot be known
+func (l *templateLinter) arg(tree *parse.Tree, node parse.Node, scope *lintScope) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return scope.dot
+	case *parse.FieldNode:
+		return l.fields(tree, n, scope, scope.dot, "", n.Ident)
+	case *parse.VariableNode:
+		return l.fields(tree, n, scope, scope.vars[n.Ident[0]], n.Ident[0], n.Ident[1:])
+	case *parse.ChainNode:
+		return l.fields(tree, n, scope, l.arg(tree, n.Node, scope), "", n.Field)
+	case *parse.PipeNode:
+		return l.pipe(tree, n, scope.child(), true)
+	case *parse.IdentifierNode:
+		return l.call(tree, n, nil, scope, false, nil)
+	case *parse.StringNode:
+		return reflect.TypeOf("")
+	case *parse.BoolNode:
+		return reflect.TypeOf(false)
+	case *parse.NumberNode:
+		if n.IsInt {
+			return reflect.TypeOf(0)
+		}
+		return reflect.TypeOf(0.0)
+	}
+	return nil
+}
+
+// fields follows a field path from t. Pointers reached through the path, like .Derived in .Derived.Code,
+// are reported unless an enclosing {{if}} checked them.
+func (l *templateLinter) fields(tree *parse.Tree, node parse.Node, scope *lintScope, t reflect.Type, base string, idents []string) reflect.Type {
+	for i, name := range idents {
+		if t == nil {
+			return nil
+		}
+		if t.Kind() == reflect.Pointer {
+			if path := base + "." + strings.Join(idents[:i], "."); i > 0 && !scope.guards[path] {
+				l.report(tree, node, LintWarning, "%s may be nil; guard it with {{with}} or {{if}} before reading %s", path, name)
+			}
+			t = t.Elem()
+		}
+		// Methods come first, as in text/template, so enums like .Language can call String
+		if m, ok := t.MethodByName(name); ok && m.Type.NumOut() > 0 {
+			t = known(m.Type.Out(0))
+			continue
+		}
+		if m, ok := reflect.PointerTo(t).MethodByName(name); ok && t.Kind() != reflect.Interface && m.Type.NumOut() > 0 {
+			t = known(m.Type.Out(0))
+			continue
+		}
+		switch t.Kind() {
+		case reflect.Interface:
+			return nil
+		case reflect.Map:
+			if t == placeholdersType {
+				l.placeholder(tree, node, name)
+			}
+			t = known(t.Elem())
+		case reflect.Struct:
+			if f, ok := t.FieldByName(name); ok && f.IsExported() {
+				t = f.Type
+				continue
+			}
+			names := make([]string, 0, t.NumField())
+			for j := 0; j < t.NumField(); j++ {
+				if t.Field(j).IsExported() {
+					names = append(names, t.Field(j).Name)
+				}
+			}
+			l.report(tree, node, LintError, "%s has no field %s%s", typeName(t), name, didYouMean(name, names))
+			return nil
+		default:
+			l.report(tree, node, LintError, "can't evaluate field %s in type %s", name, typeName(t))
+			return nil
+		}
+	}
+	if len(idents) == 0 || base != "" || t != placeholdersType {
+		return t
+	}
+	l.dynamicInputs = true // .Inputs handed on as a whole
+	return t
+}
+
+// rangeTypes returns the key and element types of a range; ok is false when t cannot be ranged over
+func rangeTypes(t reflect.Type) (reflect.Type, reflect.Type, bool) {
+	if t == nil {
+		return nil, nil, true
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0), known(t.Elem()), true
+	case reflect.Map:
+		return known(t.Key()), known(t.Elem()), true
+	case reflect.Int, reflect.Int64:
+		return t, t, true
+	case reflect.Chan:
+		return known(t.Elem()), known(t.Elem()), true
+	case reflect.Interface:
+		return nil, nil, true
+	}
+	return nil, nil, false
+}
+
+// known drops interface types, whose dynamic type is only known at render time
+func known(t reflect.Type) reflect.Type {
+	if t == nil || t.Kind() == reflect.Interface {
+		return nil
+	}
+	return t
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "an unknown value"
+	}
+	return strings.ReplaceAll(t.String(), "service.", "")
+}
+
+// didYouMean suggests the candidate closest to name, ignoring case, when it is close enough to be a typo
+func didYouMean(name string, candidates []string) string {
+	best, bestDistance := "", max(3, len(name)/3+1)
+	sort.Strings(candidates)
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		if d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", best)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
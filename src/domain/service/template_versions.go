@@ -0,0 +1,91 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gen-concept-api/domain/model"
+)
+
+// TemplateChanges returns the rows to append so the files of a blueprint become incoming: one row with
+// nextVersion for every file that was added or changed, and a tombstone for every current file missing from
+// incoming. It returns no rows when nothing differs.
+func TemplateChanges(current, incoming []model.BlueprintTemplate, blueprintID uint, nextVersion int) ([]model.BlueprintTemplate, error) {
+	currentByPath := make(map[string]model.BlueprintTemplate, len(current))
+	for _, t := range current {
+		currentByPath[t.Path] = t
+	}
+
+	var changes []model.BlueprintTemplate
+	seen := make(map[string]bool, len(incoming))
+	for _, t := range incoming {
+		path := strings.TrimSpace(t.Path)
+		if path == "" {
+			return nil, fmt.Errorf("template path is required")
+		}
+		if seen[path] {
+			return nil, fmt.Errorf("duplicate template path: %s", path)
+		}
+		seen[path] = true
+
+		checksum := TemplateChecksum(t.Content)
+		if existing, ok := currentByPath[path]; ok && existing.Checksum == checksum && existing.Language == t.Language && existing.Scope == t.Scope {
+			continue
+		}
+		changes = append(changes, model.BlueprintTemplate{
+			BlueprintID: blueprintID,
+			Path:        path,
+			Content:     t.Content,
+			Language:    t.Language,
+			Scope:       t.Scope,
+			Checksum:    checksum,
+			Version:     nextVersion,
+		})
+	}
+
+	// Files missing from the incoming set get a tombstone so older versions stay reproducible
+	for _, t := range current {
+		if !seen[t.Path] {
+			changes = append(changes, model.BlueprintTemplate{
+				BlueprintID: blueprintID,
+				Path:        t.Path,
+				Language:    t.Language,
+				Version:     nextVersion,
+				Removed:     true,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// TemplatesAtVersion resolves the template files of a blueprint at the given version from its template rows:
+// the latest row of every path with Version <= version, unless that row is a tombstone. Files are sorted by path.
+func TemplatesAtVersion(rows []model.BlueprintTemplate, version int) []model.BlueprintTemplate {
+	latest := make(map[string]model.BlueprintTemplate)
+	for _, row := range rows {
+		if row.Version > version {
+			continue
+		}
+		if previous, ok := latest[row.Path]; !ok || row.Version >= previous.Version {
+			latest[row.Path] = row
+		}
+	}
+
+	templates := make([]model.BlueprintTemplate, 0, len(latest))
+	for _, t := range latest {
+		if !t.Removed {
+			templates = append(templates, t)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Path < templates[j].Path })
+	return templates
+}
+
+// TemplateChecksum is the SHA-256 of a template file's content
+func TemplateChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
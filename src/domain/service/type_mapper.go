@@ -0,0 +1,371 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"strings"
+)
+
+// TypeRef is a language type together with the import it needs (empty when built in).
+// Imports are qualified names the template renders in its own syntax: a Go package path,
+// a Java class, a C# namespace or a Python "module.name".
+type TypeRef struct {
+	Name   string
+	Import string
+}
+
+// LanguageTypes describes how a programming language spells field types.
+// Formats use %s for the wrapped type.
+type LanguageTypes struct {
+	Scalars            map[enum.DataType]TypeRef       // String, Int, Float, Bool and DateTime
+	Collections        map[enum.CollectionType]TypeRef // e.g. "List<%s>"; maps are keyed by strings
+	Nullable           string                          // Format of an optional value, e.g. "*%s" or "%s | null"
+	Boxed              map[string]string               // Types to use inside generics and for nullable values (Java: int -> Integer)
+	NilableCollections bool                            // Collections already accept a null value, so they are not wrapped by Nullable
+}
+
+// DatabaseTypes describes the column (or document) types of a database.
+type DatabaseTypes struct {
+	Scalars    map[enum.DataType]string // String, Int, Float, Bool and DateTime
+	Enum       func(values []string) string
+	Collection string // Column holding a collection of values
+	Reference  string // Column holding a reference to another entity
+	// EmbedsEntityCollections is true for document stores, where a collection of entities lives in the record.
+	// Relational databases keep them in the other table, so such fields get no column.
+	EmbedsEntityCollections bool
+}
+
+// MappedType is the result of mapping an entity field for a language and a database
+type MappedType struct {
+	Type         string
+	NullableType string
+	Nullable     bool
+	ColumnType   string
+	EnumName     string
+	Imports      []string
+}
+
+// TypeMapper maps entity fields to language and database types.
+// Languages and databases without a registration fall back to the raw data type name and no column type.
+type TypeMapper struct {
+	languages map[enum.ProgrammingLanguage]LanguageTypes
+	databases map[enum.PreferredDB]DatabaseTypes
+}
+
+// NewTypeMapper returns a mapper with Go, TypeScript, Java, C# and Python, and Postgres, MySQL and MongoDB registered
+func NewTypeMapper() *TypeMapper {
+	m := &TypeMapper{
+		languages: make(map[enum.ProgrammingLanguage]LanguageTypes),
+		databases: make(map[enum.PreferredDB]DatabaseTypes),
+	}
+	for lang, types := range defaultLanguageTypes {
+		m.RegisterLanguage(lang, types)
+	}
+	for db, types := range defaultDatabaseTypes {
+		m.RegisterDatabase(db, types)
+	}
+	return m
+}
+
+// RegisterLanguage adds or replaces the types of a programming language
+func (m *TypeMapper) RegisterLanguage(lang enum.ProgrammingLanguage, types LanguageTypes) {
+	m.languages[lang] = types
+}
+
+// RegisterDatabase adds or replaces the column types of a database
+func (m *TypeMapper) RegisterDatabase(db enum.PreferredDB, types DatabaseTypes) {
+	m.databases[db] = types
+}
+
+// MapField maps a field of the entity to its language type, nullable variant, imports and column type
+func (m *TypeMapper) MapField(lang enum.ProgrammingLanguage, db enum.PreferredDB, entity model.Entity, f model.EntityField) MappedType {
+	mapped := MappedType{Nullable: !f.IsMandatory}
+	if f.IsEnum || f.FieldType == enum.Enum {
+		mapped.EnumName = ToPascal(entity.EntityName) + ToPascal(f.FieldName)
+	}
+
+	imports := make(map[string]bool)
+	addImport := func(path string) {
+		if path != "" && !imports[path] {
+			imports[path] = true
+			mapped.Imports = append(mapped.Imports, path)
+		}
+	}
+
+	types, ok := m.languages[lang]
+	if !ok {
+		mapped.Type = f.FieldType.String()
+		mapped.NullableType = mapped.Type
+	} else {
+		isCollection := f.IsCollection || f.FieldType == enum.Collection
+		if isCollection {
+			item := m.itemType(types, f.CollectionItemType, f, mapped.EnumName, addImport)
+			if f.CollectionItemType == enum.NestedCollectionType {
+				nested := m.itemType(types, f.NestedCollectionItemType, f, mapped.EnumName, addImport)
+				item = m.collectionType(types, enum.List, nested, addImport)
+			}
+			mapped.Type = m.collectionType(types, f.CollectionType, item, addImport)
+		} else {
+			mapped.Type = m.scalarType(types, f.FieldType, referencedEntity(entity, f), mapped.EnumName, addImport)
+		}
+
+		mapped.NullableType = mapped.Type
+		if !(isCollection && types.NilableCollections) {
+			mapped.NullableType = nullableType(types, mapped.Type)
+		}
+	}
+
+	if dbTypes, ok := m.databases[db]; ok {
+		mapped.ColumnType = columnType(dbTypes, f)
+	}
+	return mapped
+}
+
+// scalarType maps a non collection data type; enums and entity references become named types
+func (m *TypeMapper) scalarType(types LanguageTypes, dataType enum.DataType, refEntity, enumName string, addImport func(string)) string {
+	if enumName != "" {
+		return enumName
+	}
+	if dataType == enum.Entity {
+		return ToPascal(refEntity)
+	}
+	ref, ok := types.Scalars[dataType]
+	if !ok {
+		return dataType.String()
+	}
+	addImport(ref.Import)
+	return ref.Name
+}
+
+// itemType maps the type of a collection item, boxed for use inside generics
+func (m *TypeMapper) itemType(types LanguageTypes, itemType enum.CollectionItemType, f model.EntityField, enumName string, addImport func(string)) string {
+	var name string
+	switch itemType {
+	case enum.EnumType:
+		if enumName == "" {
+			enumName = ToPascal(f.FieldName)
+		}
+		name = enumName
+	case enum.OtherEntityType:
+		name = ToPascal(Singularize(defaultString(f.CollectionEntity, f.FieldName)))
+	default:
+		dataType, ok := collectionItemDataTypes[itemType]
+		if !ok {
+			dataType = enum.String
+		}
+		name = m.scalarType(types, dataType, "", "", addImport)
+	}
+	if boxed, ok := types.Boxed[name]; ok {
+		return boxed
+	}
+	return name
+}
+
+// collectionType wraps the item type in the collection of the language, falling back to a list
+func (m *TypeMapper) collectionType(types LanguageTypes, collectionType enum.CollectionType, item string, addImport func(string)) string {
+	ref, ok := types.Collections[collectionType]
+	if !ok {
+		ref, ok = types.Collections[enum.List]
+		if !ok {
+			return item
+		}
+	}
+	addImport(ref.Import)
+	return fmt.Sprintf(ref.Name, item)
+}
+
+func nullableType(types LanguageTypes, t string) string {
+	if boxed, ok := types.Boxed[t]; ok {
+		t = boxed
+	}
+	if types.Nullable == "" {
+		return t
+	}
+	return fmt.Sprintf(types.Nullable, t)
+}
+
+// columnType maps a field to the column type of the database
+func columnType(types DatabaseTypes, f model.EntityField) string {
+	switch {
+	case f.IsCollection || f.FieldType == enum.Collection:
+		if f.CollectionItemType == enum.OtherEntityType && !types.EmbedsEntityCollections {
+			return ""
+		}
+		return types.Collection
+	case f.IsEnum || f.FieldType == enum.Enum:
+		if types.Enum != nil {
+			return types.Enum(f.EnumValues)
+		}
+		return types.Scalars[enum.String]
+	case f.FieldType == enum.Entity:
+		return types.Reference
+	default:
+		return types.Scalars[f.FieldType]
+	}
+}
+
+// referencedEntity finds the entity an Entity typed field points to: the dependency declared on the field,
+// then the field's CollectionEntity, then the field name itself
+func referencedEntity(entity model.Entity, f model.EntityField) string {
+	for _, dep := range entity.DependsOnEntities {
+		if strings.EqualFold(dep.FieldName, f.FieldName) && dep.EntityName != "" {
+			return dep.EntityName
+		}
+	}
+	return defaultString(f.CollectionEntity, f.FieldName)
+}
+
+func defaultString(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+var collectionItemDataTypes = map[enum.CollectionItemType]enum.DataType{
+	enum.StringType:   enum.String,
+	enum.IntType:      enum.Int,
+	enum.FloatType:    enum.Float,
+	enum.BoolType:     enum.Bool,
+	enum.DateTimeType: enum.DateTime,
+}
+
+var defaultLanguageTypes = map[enum.ProgrammingLanguage]LanguageTypes{
+	enum.Golang: {
+		Scalars: map[enum.DataType]TypeRef{
+			enum.String:   {Name: "string"},
+			enum.Int:      {Name: "int64"},
+			enum.Float:    {Name: "float64"},
+			enum.Bool:     {Name: "bool"},
+			enum.DateTime: {Name: "time.Time", Import: "time"},
+		},
+		Collections: map[enum.CollectionType]TypeRef{
+			enum.List:  {Name: "[]%s"},
+			enum.Array: {Name: "[]%s"},
+			enum.Set:   {Name: "map[%s]struct{}"},
+			enum.Map:   {Name: "map[string]%s"},
+		},
+		Nullable:           "*%s",
+		NilableCollections: true,
+	},
+	enum.TypeScript: {
+		Scalars: map[enum.DataType]TypeRef{
+			enum.String:   {Name: "string"},
+			enum.Int:      {Name: "number"},
+			enum.Float:    {Name: "number"},
+			enum.Bool:     {Name: "boolean"},
+			enum.DateTime: {Name: "Date"},
+		},
+		Collections: map[enum.CollectionType]TypeRef{
+			enum.List:  {Name: "Array<%s>"},
+			enum.Array: {Name: "Array<%s>"},
+			enum.Set:   {Name: "Set<%s>"},
+			enum.Map:   {Name: "Record<string, %s>"},
+		},
+		Nullable: "%s | null",
+	},
+	enum.Java: {
+		Scalars: map[enum.DataType]TypeRef{
+			enum.String:   {Name: "String"},
+			enum.Int:      {Name: "long"},
+			enum.Float:    {Name: "double"},
+			enum.Bool:     {Name: "boolean"},
+			enum.DateTime: {Name: "OffsetDateTime", Import: "java.time.OffsetDateTime"},
+		},
+		Collections: map[enum.CollectionType]TypeRef{
+			enum.List:  {Name: "List<%s>", Import: "java.util.List"},
+			enum.Array: {Name: "%s[]"},
+			enum.Set:   {Name: "Set<%s>", Import: "java.util.Set"},
+			enum.Map:   {Name: "Map<String, %s>", Import: "java.util.Map"},
+		},
+		Nullable: "%s",
+		Boxed: map[string]string{
+			"long":    "Long",
+			"double":  "Double",
+			"boolean": "Boolean",
+		},
+	},
+	enum.Csharp: {
+		Scalars: map[enum.DataType]TypeRef{
+			enum.String:   {Name: "string"},
+			enum.Int:      {Name: "long"},
+			enum.Float:    {Name: "double"},
+			enum.Bool:     {Name: "bool"},
+			enum.DateTime: {Name: "DateTimeOffset", Import: "System"},
+		},
+		Collections: map[enum.CollectionType]TypeRef{
+			enum.List:  {Name: "List<%s>", Import: "System.Collections.Generic"},
+			enum.Array: {Name: "%s[]"},
+			enum.Set:   {Name: "HashSet<%s>", Import: "System.Collections.Generic"},
+			enum.Map:   {Name: "Dictionary<string, %s>", Import: "System.Collections.Generic"},
+		},
+		Nullable: "%s?",
+	},
+	enum.Python: {
+		Scalars: map[enum.DataType]TypeRef{
+			enum.String:   {Name: "str"},
+			enum.Int:      {Name: "int"},
+			enum.Float:    {Name: "float"},
+			enum.Bool:     {Name: "bool"},
+			enum.DateTime: {Name: "datetime", Import: "datetime.datetime"},
+		},
+		Collections: map[enum.CollectionType]TypeRef{
+			enum.List:  {Name: "list[%s]"},
+			enum.Array: {Name: "list[%s]"},
+			enum.Set:   {Name: "set[%s]"},
+			enum.Map:   {Name: "dict[str, %s]"},
+		},
+		Nullable: "%s | None",
+	},
+}
+
+var defaultDatabaseTypes = map[enum.PreferredDB]DatabaseTypes{
+	enum.Postgres: {
+		Scalars: map[enum.DataType]string{
+			enum.String:   "varchar(255)",
+			enum.Int:      "bigint",
+			enum.Float:    "double precision",
+			enum.Bool:     "boolean",
+			enum.DateTime: "timestamptz",
+		},
+		Enum:       func(values []string) string { return "varchar(50)" },
+		Collection: "jsonb",
+		Reference:  "uuid",
+	},
+	enum.Mysql: {
+		Scalars: map[enum.DataType]string{
+			enum.String:   "varchar(255)",
+			enum.Int:      "bigint",
+			enum.Float:    "double",
+			enum.Bool:     "tinyint(1)",
+			enum.DateTime: "datetime(6)",
+		},
+		Enum:       mysqlEnum,
+		Collection: "json",
+		Reference:  "char(36)",
+	},
+	enum.MongoDB: {
+		Scalars: map[enum.DataType]string{
+			enum.String:   "string",
+			enum.Int:      "long",
+			enum.Float:    "double",
+			enum.Bool:     "bool",
+			enum.DateTime: "date",
+		},
+		Collection:              "array",
+		Reference:               "objectId",
+		EmbedsEntityCollections: true,
+	},
+}
+
+func mysqlEnum(values []string) string {
+	if len(values) == 0 {
+		return "varchar(50)"
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return "ENUM(" + strings.Join(quoted, ",") + ")"
+}
@@ -0,0 +1,501 @@
+package service
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenValidation is a typed InputValidation rule of a field, mapped to the idiom of the generation language:
+// go-playground tags for Go, class-validator for TypeScript, Bean Validation for Java, DataAnnotations for C#
+// and pydantic for Python. CUSTOM rules have no generated check and are left out.
+type GenValidation struct {
+	Type           string // LENGTH, RANGE, PATTERN, EMAIL, URL, ONE_OF, UNIQUE or CROSS_FIELD
+	Description    string
+	Min, Max       string // Bounds of LENGTH and RANGE rules, e.g. 3 or 0.5; empty when unbounded
+	Pattern        string
+	Values         []string // Values of a ONE_OF rule
+	Operator       string   // Comparison of a CROSS_FIELD rule: ==, !=, >, >=, < or <=
+	OtherField     string   // Field a CROSS_FIELD rule compares with
+	Message        string   // CustomErrorMessage, empty for the library's own message
+	AbortOnFailure bool
+
+	Code      string   // The rule on the field declaration, e.g. min=3 or @Size(min = 3); empty when it needs a Check or has no idiom (UNIQUE)
+	Check     string   // Code checking what the declaration can't express, see GenEntity.Checks
+	FieldType string   // Python: type the field takes instead of its own, e.g. EmailStr
+	Imports   []string // Imports Code and Check need
+}
+
+// ruleOperators maps the OperatorType of a CROSS_FIELD rule to its comparison, its go-playground tag and a name
+var ruleOperators = map[enum.OperatorType]struct{ op, tag, name string }{
+	enum.Equals:             {"==", "eqfield", "Equal"},
+	enum.NotEquals:          {"!=", "nefield", "NotEqual"},
+	enum.GreaterThan:        {">", "gtfield", "GreaterThan"},
+	enum.GreaterThanOrEqual: {">=", "gtefield", "GreaterThanOrEqual"},
+	enum.LessThan:           {"<", "ltfield", "LessThan"},
+	enum.LessThanOrEqual:    {"<=", "ltefield", "LessThanOrEqual"},
+}
+
+// BuildValidations maps the typed InputValidations of a field of the entity for the language.
+// Languages without an idiom get the rules without Code or Check.
+func BuildValidations(entity model.Entity, field model.EntityField, language enum.ProgrammingLanguage) []GenValidation {
+	var validations []GenValidation
+	for _, iv := range field.InputValidations {
+		if iv.RuleType == enum.CustomRule {
+			continue
+		}
+		v := GenValidation{
+			Type:           iv.RuleType.String(),
+			Description:    iv.Description,
+			Min:            formatBound(iv.Min),
+			Max:            formatBound(iv.Max),
+			Pattern:        iv.Pattern,
+			Values:         iv.AllowedValues,
+			OtherField:     iv.OtherField,
+			Message:        iv.CustomErrorMessage,
+			AbortOnFailure: iv.AbortOnFailure,
+		}
+		if iv.RuleType == enum.CrossFieldRule {
+			operator, ok := ruleOperators[iv.Operator]
+			if !ok {
+				validations = append(validations, v) // Not a comparison, nothing to generate
+				continue
+			}
+			v.Operator = operator.op
+		}
+
+		r := ruleMapper{entity: entity, field: field, rule: iv, v: &v}
+		switch language {
+		case enum.Golang:
+			r.golang()
+		case enum.TypeScript, enum.JavaScript:
+			r.typeScript()
+		case enum.Java:
+			r.java()
+		case enum.Csharp:
+			r.csharp()
+		case enum.Python:
+			r.python()
+		}
+		validations = append(validations, v)
+	}
+	return validations
+}
+
+// goValidateTag is the gin binding tag of a field: required or omitempty, then the go-playground tags of its rules
+func goValidateTag(field model.EntityField, validations []GenValidation) string {
+	var tags []string
+	for _, v := range validations {
+		if v.Code != "" {
+			tags = append(tags, v.Code)
+		}
+	}
+	switch {
+	case field.IsMandatory:
+		tags = append([]string{"required"}, tags...)
+	case len(tags) > 0:
+		tags = append([]string{"omitempty"}, tags...)
+	default:
+		return ""
+	}
+	return "binding:" + strconv.Quote(strings.Join(tags, ","))
+}
+
+// goOneOfParam writes values as the parameter of go-playground's oneof tag: commas and pipes as their 0x2C and
+// 0x7C escapes, and values with spaces, or empty ones, in single quotes. It reports false when a value cannot be
+// written: oneof drops every single quote, a backquote would end the struct tag, and 0x2C or 0x7C would be unescaped.
+func goOneOfParam(values []string) (string, bool) {
+	params := make([]string, len(values))
+	for i, value := range values {
+		if strings.ContainsAny(value, "'`") || strings.Contains(value, "0x2C") || strings.Contains(value, "0x7C") {
+			return "", false
+		}
+		value = strings.NewReplacer(",", "0x2C", "|", "0x7C").Replace(value)
+		if value == "" || strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+			value = "'" + value + "'"
+		}
+		params[i] = value
+	}
+	return strings.Join(params, " "), true
+}
+
+// ruleMapper fills Code, Check and Imports of one rule
+type ruleMapper struct {
+	entity model.Entity
+	field  model.EntityField
+	rule   model.InputValidation
+	v      *GenValidation
+}
+
+func (r ruleMapper) golang() {
+	iv, v := r.rule, r.v
+	switch iv.RuleType {
+	case enum.LengthRule:
+		if iv.Min != nil && iv.Max != nil && *iv.Min == *iv.Max {
+			v.Code = "len=" + v.Min
+		} else {
+			v.Code = joinNonEmpty(",", prefixed("min=", v.Min), prefixed("max=", v.Max))
+		}
+	case enum.RangeRule:
+		v.Code = joinNonEmpty(",", prefixed("gte=", v.Min), prefixed("lte=", v.Max))
+	case enum.PatternRule:
+		// go-playground has no regular expression tag
+		message := r.message(fmt.Sprintf("%s must match %s", ToCamel(r.field.FieldName), iv.Pattern))
+		v.Check = fmt.Sprintf("if !regexp.MustCompile(%s).MatchString(%s.%s) {\n\treturn errors.New(%s)\n}",
+			goRawString(iv.Pattern), ToCamel(r.entity.EntityName), ToPascal(r.field.FieldName), strconv.Quote(message))
+		v.Imports = []string{"errors", "regexp"}
+	case enum.EmailRule:
+		v.Code = "email"
+	case enum.URLRule:
+		v.Code = "url"
+	case enum.OneOfRule:
+		if values, ok := goOneOfParam(iv.AllowedValues); ok {
+			v.Code = "oneof=" + values
+			break
+		}
+		// The tag cannot carry the values, they are checked in code
+		message := r.message(fmt.Sprintf("%s must be one of %s", ToCamel(r.field.FieldName), strings.Join(iv.AllowedValues, ", ")))
+		v.Check = fmt.Sprintf("if !slices.Contains([]string{%s}, %s.%s) {\n\treturn errors.New(%s)\n}",
+			quoteAll(iv.AllowedValues), ToCamel(r.entity.EntityName), ToPascal(r.field.FieldName), strconv.Quote(message))
+		v.Imports = []string{"errors", "slices"}
+	case enum.CrossFieldRule:
+		v.Code = ruleOperators[iv.Operator].tag + "=" + ToPascal(iv.OtherField)
+	}
+}
+
+func (r ruleMapper) typeScript() {
+	iv, v := r.rule, r.v
+	options := ""
+	if iv.CustomErrorMessage != "" {
+		options = fmt.Sprintf("{ message: %s }", strconv.Quote(iv.CustomErrorMessage))
+	}
+	decorator := func(name string, args ...string) string {
+		v.Imports = append(v.Imports, "class-validator."+name)
+		return "@" + name + "(" + joinNonEmpty(", ", append(args, options)...) + ")"
+	}
+
+	switch iv.RuleType {
+	case enum.LengthRule:
+		switch {
+		case r.field.IsCollection:
+			v.Code = joinNonEmpty(" ", r.when(v.Min, func() string { return decorator("ArrayMinSize", v.Min) }),
+				r.when(v.Max, func() string { return decorator("ArrayMaxSize", v.Max) }))
+		case iv.Min != nil && iv.Max != nil:
+			v.Code = decorator("Length", v.Min, v.Max)
+		case iv.Min != nil:
+			v.Code = decorator("MinLength", v.Min)
+		default:
+			v.Code = decorator("MaxLength", v.Max)
+		}
+	case enum.RangeRule:
+		v.Code = joinNonEmpty(" ", r.when(v.Min, func() string { return decorator("Min", v.Min) }),
+			r.when(v.Max, func() string { return decorator("Max", v.Max) }))
+	case enum.PatternRule:
+		v.Code = decorator("Matches", "/"+escapeSlashes(iv.Pattern)+"/")
+	case enum.EmailRule:
+		v.Code = decorator("IsEmail", r.when(options, func() string { return "{}" }))
+	case enum.URLRule:
+		v.Code = decorator("IsUrl", r.when(options, func() string { return "{}" }))
+	case enum.OneOfRule:
+		v.Code = decorator("IsIn", "["+quoteAll(iv.AllowedValues)+"]")
+	case enum.CrossFieldRule:
+		if options == "" {
+			options = fmt.Sprintf("{ message: %s }", strconv.Quote(r.message(r.crossFieldMessage())))
+		}
+		v.Code = decorator("ValidateBy", fmt.Sprintf("{ name: %s, validator: { validate: (_, args) => { const o = args?.object as any; return %s; } } }",
+			strconv.Quote(ToCamel(r.crossFieldName())), r.crossFieldCondition(enum.TypeScript, "o")))
+	}
+}
+
+func (r ruleMapper) java() {
+	iv, v := r.rule, r.v
+	annotation := func(class, name string, args ...string) string {
+		v.Imports = append(v.Imports, class)
+		if iv.CustomErrorMessage != "" {
+			args = append(args, "message = "+strconv.Quote(iv.CustomErrorMessage))
+		}
+		args = nonEmpty(args)
+		if len(args) == 0 {
+			return "@" + name
+		}
+		return "@" + name + "(" + strings.Join(args, ", ") + ")"
+	}
+	constraint := func(name string, args ...string) string {
+		return annotation("jakarta.validation.constraints."+name, name, args...)
+	}
+	// A lone value argument drops its name unless a message follows
+	valueArg := func(value string) []string {
+		if iv.CustomErrorMessage == "" {
+			return []string{value}
+		}
+		return []string{"value = " + value}
+	}
+
+	switch iv.RuleType {
+	case enum.LengthRule:
+		v.Code = constraint("Size", prefixed("min = ", v.Min), prefixed("max = ", v.Max))
+	case enum.RangeRule:
+		integral := r.field.FieldType == enum.Int && isIntegral(iv.Min) && isIntegral(iv.Max)
+		bound := func(name, value string) string {
+			if value == "" {
+				return ""
+			}
+			if integral {
+				return constraint(name, valueArg(value)...)
+			}
+			return constraint("Decimal"+name, valueArg(strconv.Quote(value))...)
+		}
+		v.Code = joinNonEmpty(" ", bound("Min", v.Min), bound("Max", v.Max))
+	case enum.PatternRule:
+		v.Code = constraint("Pattern", "regexp = "+strconv.Quote(iv.Pattern))
+	case enum.EmailRule:
+		v.Code = constraint("Email")
+	case enum.URLRule:
+		v.Code = annotation("org.hibernate.validator.constraints.URL", "URL")
+	case enum.OneOfRule:
+		alternatives := make([]string, len(iv.AllowedValues))
+		for i, value := range iv.AllowedValues {
+			alternatives[i] = regexp.QuoteMeta(value)
+		}
+		v.Code = constraint("Pattern", "regexp = "+strconv.Quote(strings.Join(alternatives, "|")))
+	case enum.CrossFieldRule:
+		// Bean Validation compares fields in a boolean property of the class
+		v.Check = fmt.Sprintf("@AssertTrue(message = %s)\npublic boolean is%s() {\n\treturn %s;\n}",
+			strconv.Quote(r.message(r.crossFieldMessage())), r.crossFieldName(), r.crossFieldCondition(enum.Java, "this"))
+		v.Imports = []string{"jakarta.validation.constraints.AssertTrue"}
+		if iv.Operator == enum.Equals || iv.Operator == enum.NotEquals {
+			v.Imports = append(v.Imports, "java.util.Objects")
+		}
+	}
+}
+
+func (r ruleMapper) csharp() {
+	iv, v := r.rule, r.v
+	defer func() {
+		if v.Code != "" || v.Check != "" {
+			v.Imports = []string{"System.ComponentModel.DataAnnotations"}
+		}
+	}()
+	attribute := func(name string, args ...string) string {
+		if iv.CustomErrorMessage != "" {
+			args = append(args, "ErrorMessage = "+strconv.Quote(iv.CustomErrorMessage))
+		}
+		args = nonEmpty(args)
+		if len(args) == 0 {
+			return "[" + name + "]"
+		}
+		return "[" + name + "(" + strings.Join(args, ", ") + ")]"
+	}
+
+	switch iv.RuleType {
+	case enum.LengthRule:
+		switch {
+		case !r.field.IsCollection && iv.Max != nil:
+			v.Code = attribute("StringLength", v.Max, r.when(v.Min, func() string { return "MinimumLength = " + v.Min }))
+		default:
+			v.Code = joinNonEmpty(" ", r.when(v.Min, func() string { return attribute("MinLength", v.Min) }),
+				r.when(v.Max, func() string { return attribute("MaxLength", v.Max) }))
+		}
+	case enum.RangeRule:
+		min, max := v.Min, v.Max
+		if min == "" {
+			min = "double.MinValue"
+		}
+		if max == "" {
+			max = "double.MaxValue"
+		}
+		v.Code = attribute("Range", min, max)
+	case enum.PatternRule:
+		v.Code = attribute("RegularExpression", `@"`+strings.ReplaceAll(iv.Pattern, `"`, `""`)+`"`)
+	case enum.EmailRule:
+		v.Code = attribute("EmailAddress")
+	case enum.URLRule:
+		v.Code = attribute("Url")
+	case enum.OneOfRule:
+		v.Code = attribute("AllowedValues", quoteAll(iv.AllowedValues))
+	case enum.CrossFieldRule:
+		if iv.Operator == enum.Equals {
+			v.Code = attribute("Compare", "nameof("+ToPascal(iv.OtherField)+")")
+			break
+		}
+		// Other comparisons are yielded from IValidatableObject.Validate
+		v.Check = fmt.Sprintf("if (!(%s))\n\tyield return new ValidationResult(%s, new[] { nameof(%s) });",
+			r.crossFieldCondition(enum.Csharp, "this"), strconv.Quote(r.message(r.crossFieldMessage())), ToPascal(r.field.FieldName))
+	}
+}
+
+func (r ruleMapper) python() {
+	iv, v := r.rule, r.v
+	if iv.RuleType == enum.CrossFieldRule {
+		v.Check = fmt.Sprintf("@model_validator(mode=\"after\")\ndef check_%s(self):\n\tif not (%s):\n\t\traise ValueError(%s)\n\treturn self",
+			ToSnake(r.crossFieldName()), r.crossFieldCondition(enum.Python, "self"), strconv.Quote(r.message(r.crossFieldMessage())))
+		v.Imports = []string{"pydantic.model_validator"}
+		return
+	}
+
+	// pydantic's own constraints carry no custom message, so a rule with a message is checked by a validator
+	if iv.CustomErrorMessage == "" {
+		switch iv.RuleType {
+		case enum.LengthRule:
+			v.Code = joinNonEmpty(", ", prefixed("min_length=", v.Min), prefixed("max_length=", v.Max))
+		case enum.RangeRule:
+			v.Code = joinNonEmpty(", ", prefixed("ge=", v.Min), prefixed("le=", v.Max))
+		case enum.PatternRule:
+			v.Code = "pattern=" + pyRawString(iv.Pattern)
+		case enum.EmailRule:
+			v.FieldType, v.Imports = "EmailStr", []string{"pydantic.EmailStr"}
+		case enum.URLRule:
+			v.FieldType, v.Imports = "HttpUrl", []string{"pydantic.HttpUrl"}
+		case enum.OneOfRule:
+			v.FieldType, v.Imports = "Literal["+quoteAll(iv.AllowedValues)+"]", []string{"typing.Literal"}
+		}
+		return
+	}
+
+	var failed string
+	switch iv.RuleType {
+	case enum.LengthRule:
+		failed = joinNonEmpty(" or ", prefixed("len(value) < ", v.Min), prefixed("len(value) > ", v.Max))
+	case enum.RangeRule:
+		failed = joinNonEmpty(" or ", prefixed("value < ", v.Min), prefixed("value > ", v.Max))
+	case enum.PatternRule:
+		failed = fmt.Sprintf("re.fullmatch(%s, value) is None", pyRawString(iv.Pattern))
+		v.Imports = []string{"re"}
+	case enum.EmailRule:
+		failed = `re.fullmatch(r"[^@\s]+@[^@\s]+\.[^@\s]+", value) is None`
+		v.Imports = []string{"re"}
+	case enum.URLRule:
+		failed = `urlparse(value).scheme not in ("http", "https") or not urlparse(value).netloc`
+		v.Imports = []string{"urllib.parse.urlparse"}
+	case enum.OneOfRule:
+		failed = "value not in (" + quoteAll(iv.AllowedValues) + ")"
+	default:
+		return
+	}
+	name := ToSnake(r.field.FieldName)
+	v.Check = fmt.Sprintf("@field_validator(%s)\n@classmethod\ndef check_%s_%s(cls, value):\n\tif value is not None and (%s):\n\t\traise ValueError(%s)\n\treturn value",
+		strconv.Quote(name), name, strings.ToLower(iv.RuleType.String()), failed, strconv.Quote(iv.CustomErrorMessage))
+	v.Imports = append(v.Imports, "pydantic.field_validator")
+}
+
+// crossFieldName names a CROSS_FIELD rule, e.g. EndDateGreaterThanStartDate
+func (r ruleMapper) crossFieldName() string {
+	return ToPascal(r.field.FieldName) + ruleOperators[r.rule.Operator].name + ToPascal(r.rule.OtherField)
+}
+
+func (r ruleMapper) crossFieldMessage() string {
+	words := joinWordsLower(ruleOperators[r.rule.Operator].name, " ")
+	return fmt.Sprintf("%s must be %s %s", ToCamel(r.field.FieldName), words, ToCamel(r.rule.OtherField))
+}
+
+// crossFieldCondition is the comparison of a CROSS_FIELD rule in the language, over the receiver.
+// It holds when either side is missing; mandatory numbers, flags and dates can't be.
+func (r ruleMapper) crossFieldCondition(language enum.ProgrammingLanguage, receiver string) string {
+	field, other := r.field.FieldName, r.rule.OtherField
+	condition := fmt.Sprintf("%s %s %s", field, ruleOperators[r.rule.Operator].op, other)
+
+	var guards []string
+	for _, name := range []string{field, other} {
+		for _, f := range r.entity.EntityFields {
+			if strings.EqualFold(f.FieldName, name) && (!f.IsMandatory || f.FieldType == enum.String || f.FieldType == enum.Enum) {
+				guards = append(guards, f.FieldName+" == null")
+			}
+		}
+	}
+	if len(guards) > 0 {
+		condition = strings.Join(guards, " || ") + " || (" + condition + ")"
+	}
+
+	renderer := newConditionRenderer(language, &r.entity, nil)
+	renderer.entityVar = receiver
+	return renderer.Render(condition)
+}
+
+func (r ruleMapper) message(fallback string) string {
+	if r.rule.CustomErrorMessage != "" {
+		return r.rule.CustomErrorMessage
+	}
+	return fallback
+}
+
+// when returns fn() when value is set
+func (r ruleMapper) when(value string, fn func() string) string {
+	if value == "" {
+		return ""
+	}
+	return fn()
+}
+
+func formatBound(bound *float64) string {
+	if bound == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*bound, 'f', -1, 64)
+}
+
+func isIntegral(bound *float64) bool {
+	return bound == nil || *bound == float64(int64(*bound))
+}
+
+func prefixed(prefix, value string) string {
+	if value == "" {
+		return ""
+	}
+	return prefix + value
+}
+
+func nonEmpty(parts []string) []string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	return strings.Join(nonEmpty(parts), sep)
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// goRawString quotes a regular expression as a raw string literal when it can
+func goRawString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// pyRawString quotes a regular expression as a raw string literal when it can
+func pyRawString(s string) string {
+	if strings.Contains(s, `"`) || strings.HasSuffix(s, `\`) {
+		return strconv.Quote(s)
+	}
+	return `r"` + s + `"`
+}
+
+// escapeSlashes escapes the unescaped slashes of a regular expression for a JavaScript literal
+func escapeSlashes(pattern string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range pattern {
+		if c == '/' && !escaped {
+			b.WriteByte('\\')
+		}
+		escaped = c == '\\' && !escaped
+		b.WriteRune(c)
+	}
+	return b.String()
+}
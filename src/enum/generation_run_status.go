@@ -0,0 +1,76 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type GenerationRunStatus int
+
+const (
+	RunQueued GenerationRunStatus = iota
+	RunRunning
+	RunSucceeded
+	RunFailed
+	RunCancelled
+)
+
+// String method for pretty printing
+func (s GenerationRunStatus) String() string {
+	return [...]string{"QUEUED", "RUNNING", "SUCCEEDED", "FAILED", "CANCELLED"}[s]
+}
+
+// IsFinal reports whether the run has stopped and will not change anymore
+func (s GenerationRunStatus) IsFinal() bool {
+	return s == RunSucceeded || s == RunFailed || s == RunCancelled
+}
+
+// MarshalJSON for custom JSON encoding
+func (s GenerationRunStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON for custom JSON decoding
+func (s *GenerationRunStatus) UnmarshalJSON(data []byte) error {
+	var statusStr string
+	if err := json.Unmarshal(data, &statusStr); err != nil {
+		return err
+	}
+	return s.parse(statusStr)
+}
+
+// Implement the driver.Valuer interface
+func (s GenerationRunStatus) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Implement the sql.Scanner interface
+func (s *GenerationRunStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return s.parse(v)
+	case []byte:
+		return s.parse(string(v))
+	default:
+		return fmt.Errorf("unsupported Scan type for GenerationRunStatus: %T", value)
+	}
+}
+
+func (s *GenerationRunStatus) parse(statusStr string) error {
+	switch statusStr {
+	case "QUEUED":
+		*s = RunQueued
+	case "RUNNING":
+		*s = RunRunning
+	case "SUCCEEDED":
+		*s = RunSucceeded
+	case "FAILED":
+		*s = RunFailed
+	case "CANCELLED":
+		*s = RunCancelled
+	default:
+		return fmt.Errorf("invalid GenerationRunStatus: %s", statusStr)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// TemplateScope tells how often a blueprint template file is rendered
+type TemplateScope int
+
+const (
+	ScopeEntity  TemplateScope = iota // Once per entity
+	ScopeProject                      // Once per project, with every entity in .Project
+)
+
+// String method for pretty printing
+func (s TemplateScope) String() string {
+	return [...]string{"ENTITY", "PROJECT"}[s]
+}
+
+// MarshalJSON for custom JSON encoding
+func (s TemplateScope) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON for custom JSON decoding; an empty scope is ENTITY
+func (s *TemplateScope) UnmarshalJSON(data []byte) error {
+	var scopeStr string
+	if err := json.Unmarshal(data, &scopeStr); err != nil {
+		return err
+	}
+	return s.parse(scopeStr)
+}
+
+// Implement the driver.Valuer interface
+func (s TemplateScope) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Implement the sql.Scanner interface
+func (s *TemplateScope) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = ScopeEntity
+		return nil
+	case string:
+		return s.parse(v)
+	case []byte:
+		return s.parse(string(v))
+	default:
+		return fmt.Errorf("unsupported Scan type for TemplateScope: %T", value)
+	}
+}
+
+func (s *TemplateScope) parse(scopeStr string) error {
+	switch scopeStr {
+	case "", "ENTITY":
+		*s = ScopeEntity
+	case "PROJECT":
+		*s = ScopeProject
+	default:
+		return fmt.Errorf("invalid TemplateScope: %s", scopeStr)
+	}
+	return nil
+}
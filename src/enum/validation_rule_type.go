@@ -0,0 +1,91 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ValidationRuleType is the kind of check an input validation rule makes
+type ValidationRuleType int
+
+const (
+	CustomRule     ValidationRuleType = iota // Described in free text only, no generated check
+	LengthRule                               // Min and/or Max length of a string or collection
+	RangeRule                                // Min and/or Max of a number
+	PatternRule                              // Matches a regular expression
+	EmailRule                                // Is an email address
+	URLRule                                  // Is a URL
+	OneOfRule                                // Is one of the listed values
+	UniqueRule                               // Is not used by another record
+	CrossFieldRule                           // Compares with another field of the entity
+)
+
+// String method for pretty printing
+func (r ValidationRuleType) String() string {
+	names := [...]string{"CUSTOM", "LENGTH", "RANGE", "PATTERN", "EMAIL", "URL", "ONE_OF", "UNIQUE", "CROSS_FIELD"}
+	if r < CustomRule || int(r) >= len(names) {
+		return "UNKNOWN"
+	}
+	return names[r]
+}
+
+// MarshalJSON for custom JSON encoding
+func (r ValidationRuleType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON for custom JSON decoding; an empty rule type is CUSTOM
+func (r *ValidationRuleType) UnmarshalJSON(data []byte) error {
+	var ruleStr string
+	if err := json.Unmarshal(data, &ruleStr); err != nil {
+		return err
+	}
+	return r.parse(ruleStr)
+}
+
+// Implement the driver.Valuer interface
+func (r ValidationRuleType) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Implement the sql.Scanner interface
+func (r *ValidationRuleType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*r = CustomRule
+		return nil
+	case string:
+		return r.parse(v)
+	case []byte:
+		return r.parse(string(v))
+	default:
+		return fmt.Errorf("unsupported Scan type for ValidationRuleType: %T", value)
+	}
+}
+
+func (r *ValidationRuleType) parse(ruleStr string) error {
+	switch ruleStr {
+	case "", "CUSTOM":
+		*r = CustomRule
+	case "LENGTH":
+		*r = LengthRule
+	case "RANGE":
+		*r = RangeRule
+	case "PATTERN":
+		*r = PatternRule
+	case "EMAIL":
+		*r = EmailRule
+	case "URL":
+		*r = URLRule
+	case "ONE_OF":
+		*r = OneOfRule
+	case "UNIQUE":
+		*r = UniqueRule
+	case "CROSS_FIELD":
+		*r = CrossFieldRule
+	default:
+		return fmt.Errorf("invalid ValidationRuleType: %s", ruleStr)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package ai
 import (
 	"fmt"
 	"gen-concept-api/domain/service"
+	"strings"
 )
 
 type MockAIProvider struct{}
@@ -11,6 +12,8 @@ func NewMockAIProvider() service.AIProvider {
 	return &MockAIProvider{}
 }
 
+// GenerateContent answers offline with a single comment line naming the prompt's instruction
 func (p *MockAIProvider) GenerateContent(prompt string) (string, error) {
-	return fmt.Sprintf("// [AI GENERATED Content for prompt: %s]", prompt), nil
+	instruction, _, _ := strings.Cut(prompt, "\n")
+	return fmt.Sprintf("// [AI GENERATED Content for prompt: %s]", instruction), nil
 }
@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+var cSharpTypes = &typeSystem{
+	scalars: map[string]enum.DataType{
+		"string": enum.String, "String": enum.String, "char": enum.String, "Char": enum.String, "Guid": enum.String,
+		"int": enum.Int, "long": enum.Int, "short": enum.Int, "byte": enum.Int, "sbyte": enum.Int,
+		"uint": enum.Int, "ulong": enum.Int, "ushort": enum.Int, "Int16": enum.Int, "Int32": enum.Int, "Int64": enum.Int,
+		"UInt16": enum.Int, "UInt32": enum.Int, "UInt64": enum.Int, "Byte": enum.Int, "BigInteger": enum.Int,
+		"double": enum.Float, "float": enum.Float, "decimal": enum.Float, "Double": enum.Float, "Single": enum.Float, "Decimal": enum.Float,
+		"bool": enum.Bool, "Boolean": enum.Bool,
+		"DateTime": enum.DateTime, "DateTimeOffset": enum.DateTime, "DateOnly": enum.DateTime, "TimeOnly": enum.DateTime,
+		"object": enum.String, "Object": enum.String, "dynamic": enum.String,
+	},
+	collections: map[string]enum.CollectionType{
+		"List": enum.List, "IList": enum.List, "IEnumerable": enum.List, "ICollection": enum.List, "IReadOnlyList": enum.List,
+		"IReadOnlyCollection": enum.List, "Collection": enum.List, "ObservableCollection": enum.List,
+		"HashSet": enum.Set, "ISet": enum.Set, "SortedSet": enum.Set, "IReadOnlySet": enum.Set,
+		"Dictionary": enum.Map, "IDictionary": enum.Map, "IReadOnlyDictionary": enum.Map, "SortedDictionary": enum.Map,
+		"ConcurrentDictionary": enum.Map,
+	},
+	arrays:    enum.Array,
+	optionals: map[string]bool{"Nullable": true},
+	nulls:     map[string]bool{},
+}
+
+var cSharpModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "internal": true, "readonly": true, "virtual": true,
+	"override": true, "abstract": true, "sealed": true, "new": true, "volatile": true, "unsafe": true,
+	"partial": true, "extern": true, "init": true,
+}
+
+// cSharpSkipped start members that are not fields, e.g. constants and nested types
+var cSharpSkipped = map[string]bool{
+	"static": true, "const": true, "class": true, "struct": true, "interface": true, "enum": true,
+	"record": true, "delegate": true, "event": true, "operator": true, "implicit": true, "explicit": true,
+}
+
+// CSharpParser reads the properties and fields of the first class, struct or record of a C# source. Nullable
+// types are optional, DataAnnotations attributes are read, and enums declared in the source are resolved.
+type CSharpParser struct{}
+
+func NewCSharpParser() service.CodeParser {
+	return &CSharpParser{}
+}
+
+func (p *CSharpParser) Parse(content string) (service.ParsedMetadata, error) {
+	tokens, err := lexSource(content, sourceSyntax{verbatimStrings: true})
+	if err != nil {
+		return service.ParsedMetadata{}, fmt.Errorf("failed to parse c# code: %v", err)
+	}
+
+	c := newSourceCursor(content, tokens)
+	m := newSourceModel(cSharpTypes)
+	collectEnums(c, m)
+
+	for !c.done() {
+		switch {
+		case c.is("["):
+			c.inside() // Attributes of the type
+		case (c.is("class") || c.is("struct") || c.is("record")) && c.peekAt(1).kind == sourceIdent:
+			c.skip(1)
+			if c.is("class") || c.is("struct") {
+				c.skip(1) // record class and record struct
+			}
+			metadata := service.ParsedMetadata{Name: c.next().text}
+			if c.is("<") {
+				c.inside()
+			}
+			if c.is("(") {
+				for _, parameter := range splitSourceTokens(c.inside(), ",") {
+					if field, ok := p.parameter(c, m, parameter); ok {
+						metadata.Fields = append(metadata.Fields, field)
+					}
+				}
+			}
+			for !c.done() && !c.is("{") && !c.is(";") {
+				c.skip(1) // Base types and constraints
+			}
+			if c.is("{") {
+				metadata.Fields = append(metadata.Fields, p.members(c, m, c.inside())...)
+			}
+			return c.result("c#", metadata, nil)
+		case c.is("namespace") && c.peekAt(2).text == "{":
+			c.skip(3) // Types are looked for inside the namespace block
+		case cSharpModifiers[c.peek().text] && c.peek().kind == sourceIdent:
+			c.skip(1)
+		default:
+			c.skipStatement() // Usings, file-scoped namespaces, enums and interfaces
+			c.accept("}")
+		}
+	}
+	return c.result("c#", service.ParsedMetadata{}, fmt.Errorf("no class, struct or record definition found in code"))
+}
+
+// parameter reads a parameter of a positional record such as [property: Required] string Name
+func (p *CSharpParser) parameter(c *sourceCursor, m *sourceModel, tokens []sourceToken) (service.ParsedField, bool) {
+	sub := c.sub(tokens)
+	var annotations []service.ParsedAnnotation
+	for sub.is("[") {
+		annotations = append(annotations, cSharpAttributes(c, sub.inside())...)
+	}
+	declaration, _ := sub.declaration("=")
+	if len(declaration) < 2 || declaration[len(declaration)-1].kind != sourceIdent {
+		return service.ParsedField{}, false
+	}
+	return p.field(c, m, declaration, annotations, false), true
+}
+
+func (p *CSharpParser) members(c *sourceCursor, m *sourceModel, body []sourceToken) []service.ParsedField {
+	var fields []service.ParsedField
+	b := c.sub(body)
+	var annotations []service.ParsedAnnotation
+	required := false
+	for !b.done() {
+		t := b.peek()
+		switch {
+		case b.accept(";"):
+		case b.is("["):
+			annotations = append(annotations, cSharpAttributes(c, b.inside())...)
+		case b.accept("required"):
+			required = true
+		case cSharpModifiers[t.text] && t.kind == sourceIdent:
+			b.skip(1)
+		case cSharpSkipped[t.text] && t.kind == sourceIdent:
+			b.skipStatement()
+			annotations, required = nil, false
+		default:
+			declaration, end := b.declaration("=", "(", "{", "=>")
+			switch end {
+			case ";", "=", "{":
+				if len(declaration) > 1 && declaration[len(declaration)-1].kind == sourceIdent {
+					fields = append(fields, p.field(c, m, declaration, annotations, required))
+				}
+				if end == "{" {
+					b.inside() // Accessors
+					if b.accept("=") {
+						b.skipStatement()
+					}
+				} else if end == "=" {
+					b.skipStatement()
+				}
+			default:
+				b.skipStatement() // A method, constructor or computed property
+			}
+			annotations, required = nil, false
+		}
+	}
+	return fields
+}
+
+// field reads a declaration ending with the name of the property or field, its type before it
+func (p *CSharpParser) field(c *sourceCursor, m *sourceModel, declaration []sourceToken, annotations []service.ParsedAnnotation, required bool) service.ParsedField {
+	typeTokens := declaration[:len(declaration)-1]
+	f := m.field(declaration[len(declaration)-1].text, c.text(typeTokens), parseTypeExpr(c, typeTokens), annotations, false)
+	if required {
+		f.IsOptional = false
+	}
+	return f
+}
+
+// cSharpAttributes reads the attributes of one attribute list, e.g. [Required, MaxLength(50)]
+func cSharpAttributes(parent *sourceCursor, tokens []sourceToken) []service.ParsedAnnotation {
+	var annotations []service.ParsedAnnotation
+	for _, part := range splitSourceTokens(tokens, ",") {
+		c := parent.sub(part)
+		if c.peekAt(1).kind == sourcePunct && c.peekAt(1).text == ":" {
+			c.skip(2) // A target such as property:
+		}
+		if c.peek().kind != sourceIdent {
+			continue
+		}
+		a := c.annotation()
+		if name := strings.TrimSuffix(a.Name, "Attribute"); name != "" {
+			a.Name = name
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations
+}
@@ -3,11 +3,30 @@ package parser
 import (
 	"fmt"
 	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
+// goTypes maps Go types onto field types. Slices, arrays and maps are named by their syntax.
+var goTypes = &typeSystem{
+	scalars: map[string]enum.DataType{
+		"string": enum.String, "rune": enum.String, "UUID": enum.String, "any": enum.String,
+		"int": enum.Int, "int8": enum.Int, "int16": enum.Int, "int32": enum.Int, "int64": enum.Int, "byte": enum.Int,
+		"uint": enum.Int, "uint8": enum.Int, "uint16": enum.Int, "uint32": enum.Int, "uint64": enum.Int,
+		"float32": enum.Float, "float64": enum.Float, "Decimal": enum.Float,
+		"bool": enum.Bool, "Time": enum.DateTime,
+	},
+	collections: map[string]enum.CollectionType{"[]": enum.List, "[n]": enum.Array, "map": enum.Map},
+	arrays:      enum.List,
+	nulls:       map[string]bool{},
+}
+
 type GoParser struct{}
 
 func NewGoParser() service.CodeParser {
@@ -39,21 +58,12 @@ func (p *GoParser) Parse(content string) (service.ParsedMetadata, error) {
 			if structType, ok := t.Type.(*ast.StructType); ok {
 				metadata.Name = t.Name.Name
 
+				m := newSourceModel(goTypes)
 				for _, field := range structType.Fields.List {
-					if len(field.Names) > 0 {
-						for _, name := range field.Names {
-							fieldName := name.Name
-							fieldType := "unknown"
-							if ident, ok := field.Type.(*ast.Ident); ok {
-								fieldType = ident.Name
-							}
-							// Handle other types like selector expr (time.Time) later if needed for MVP
-
-							metadata.Fields = append(metadata.Fields, service.ParsedField{
-								Name: fieldName,
-								Type: fieldType,
-							})
-						}
+					annotations, omitEmpty := goTagAnnotations(field.Tag)
+					for _, name := range field.Names {
+						metadata.Fields = append(metadata.Fields,
+							m.field(name.Name, types.ExprString(field.Type), goTypeExpr(field.Type), annotations, omitEmpty))
 					}
 				}
 				return false // Stop after finding first struct (MVP assumption: one main struct per snippet)
@@ -68,3 +78,56 @@ func (p *GoParser) Parse(content string) (service.ParsedMetadata, error) {
 
 	return metadata, nil
 }
+
+// goTypeExpr converts a field type; a pointer is nullable
+func goTypeExpr(expr ast.Expr) *typeExpr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return &typeExpr{name: t.Name}
+	case *ast.SelectorExpr:
+		return &typeExpr{name: t.Sel.Name}
+	case *ast.StarExpr:
+		pointed := goTypeExpr(t.X)
+		pointed.nullable = true
+		return pointed
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return &typeExpr{name: "[]", args: []*typeExpr{goTypeExpr(t.Elt)}}
+		}
+		return &typeExpr{name: "[n]", args: []*typeExpr{goTypeExpr(t.Elt)}}
+	case *ast.MapType:
+		return &typeExpr{name: "map", args: []*typeExpr{goTypeExpr(t.Key), goTypeExpr(t.Value)}}
+	case *ast.InterfaceType:
+		return &typeExpr{name: "any"}
+	}
+	return &typeExpr{}
+}
+
+// goTagAnnotations reads the validate and binding tags of a field as annotations whose arguments are their rules,
+// e.g. validate:"required,max=50", and tells whether its json tag has omitempty
+func goTagAnnotations(tag *ast.BasicLit) ([]service.ParsedAnnotation, bool) {
+	if tag == nil {
+		return nil, false
+	}
+	value, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return nil, false
+	}
+	tags := reflect.StructTag(value)
+
+	var annotations []service.ParsedAnnotation
+	for _, key := range []string{"validate", "binding"} {
+		rules, ok := tags.Lookup(key)
+		if !ok {
+			continue
+		}
+		a := service.ParsedAnnotation{Name: key, Arguments: map[string]string{}}
+		for _, rule := range strings.Split(rules, ",") {
+			name, arg, _ := strings.Cut(rule, "=")
+			a.Arguments[name] = arg
+		}
+		annotations = append(annotations, a)
+	}
+	json, _ := tags.Lookup("json")
+	return annotations, strings.Contains(json, ",omitempty")
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+var javaTypes = &typeSystem{
+	scalars: map[string]enum.DataType{
+		"String": enum.String, "CharSequence": enum.String, "char": enum.String, "Character": enum.String, "UUID": enum.String,
+		"int": enum.Int, "long": enum.Int, "short": enum.Int, "byte": enum.Int,
+		"Integer": enum.Int, "Long": enum.Int, "Short": enum.Int, "Byte": enum.Int, "BigInteger": enum.Int,
+		"double": enum.Float, "float": enum.Float, "Double": enum.Float, "Float": enum.Float, "BigDecimal": enum.Float,
+		"boolean": enum.Bool, "Boolean": enum.Bool,
+		"LocalDate": enum.DateTime, "LocalDateTime": enum.DateTime, "LocalTime": enum.DateTime, "OffsetDateTime": enum.DateTime,
+		"ZonedDateTime": enum.DateTime, "Instant": enum.DateTime, "Date": enum.DateTime, "Timestamp": enum.DateTime,
+		"Calendar": enum.DateTime, "Object": enum.String,
+	},
+	collections: map[string]enum.CollectionType{
+		"List": enum.List, "ArrayList": enum.List, "LinkedList": enum.List, "Collection": enum.List, "Iterable": enum.List,
+		"Queue": enum.List, "Deque": enum.List,
+		"Set": enum.Set, "HashSet": enum.Set, "LinkedHashSet": enum.Set, "TreeSet": enum.Set, "SortedSet": enum.Set, "EnumSet": enum.Set,
+		"Map": enum.Map, "HashMap": enum.Map, "LinkedHashMap": enum.Map, "TreeMap": enum.Map, "SortedMap": enum.Map,
+		"ConcurrentHashMap": enum.Map,
+	},
+	arrays:    enum.Array,
+	optionals: map[string]bool{"Optional": true},
+	nulls:     map[string]bool{},
+}
+
+// javaPrimitives are never null; any other field is optional unless annotated e.g. @NotNull
+var javaPrimitives = map[string]bool{
+	"int": true, "long": true, "short": true, "byte": true, "double": true, "float": true, "boolean": true, "char": true,
+}
+
+var javaModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "final": true, "transient": true, "volatile": true,
+	"abstract": true, "synchronized": true, "native": true, "default": true, "strictfp": true, "sealed": true,
+}
+
+// JavaParser reads the fields of the first class, or the components of the first record, of a Java source.
+// Bean Validation and nullability annotations are read, and enums declared in the source are resolved.
+type JavaParser struct{}
+
+func NewJavaParser() service.CodeParser {
+	return &JavaParser{}
+}
+
+func (p *JavaParser) Parse(content string) (service.ParsedMetadata, error) {
+	tokens, err := lexSource(content, sourceSyntax{})
+	if err != nil {
+		return service.ParsedMetadata{}, fmt.Errorf("failed to parse java code: %v", err)
+	}
+
+	c := newSourceCursor(content, tokens)
+	m := newSourceModel(javaTypes)
+	collectEnums(c, m)
+
+	for !c.done() {
+		switch {
+		case c.accept("@"):
+			if c.is("interface") {
+				c.skipStatement() // An annotation declaration
+			} else {
+				c.annotation()
+			}
+		case javaModifiers[c.peek().text] && c.peek().kind == sourceIdent:
+			c.skip(1)
+		case (c.is("class") || c.is("record")) && c.peekAt(1).kind == sourceIdent:
+			record := c.next().text == "record"
+			metadata := service.ParsedMetadata{Name: c.next().text}
+			if c.is("<") {
+				c.inside()
+			}
+			if record && c.is("(") {
+				for _, component := range splitSourceTokens(c.inside(), ",") {
+					if field, ok := p.component(c, m, component); ok {
+						metadata.Fields = append(metadata.Fields, field)
+					}
+				}
+			}
+			for !c.done() && !c.is("{") {
+				c.skip(1) // extends and implements
+			}
+			if c.is("{") {
+				metadata.Fields = append(metadata.Fields, p.members(c, m, c.inside())...)
+			}
+			return c.result("java", metadata, nil)
+		default:
+			c.skipStatement() // Package, imports, enums and interfaces
+			c.accept("}")
+		}
+	}
+	return c.result("java", service.ParsedMetadata{}, fmt.Errorf("no class or record definition found in code"))
+}
+
+// component reads a record component such as @NotNull String name
+func (p *JavaParser) component(c *sourceCursor, m *sourceModel, tokens []sourceToken) (service.ParsedField, bool) {
+	sub := c.sub(tokens)
+	var annotations []service.ParsedAnnotation
+	for sub.accept("@") {
+		annotations = append(annotations, sub.annotation())
+	}
+	sub.accept("final")
+	rest := sub.tokens[sub.pos:]
+	if len(rest) < 2 || rest[len(rest)-1].kind != sourceIdent {
+		return service.ParsedField{}, false
+	}
+	return p.field(c, m, rest[:len(rest)-1], rest[len(rest)-1].text, annotations), true
+}
+
+func (p *JavaParser) members(c *sourceCursor, m *sourceModel, body []sourceToken) []service.ParsedField {
+	var fields []service.ParsedField
+	b := c.sub(body)
+	var annotations []service.ParsedAnnotation
+	for !b.done() {
+		t := b.peek()
+		switch {
+		case b.accept(";"):
+		case b.accept("@"):
+			annotations = append(annotations, b.annotation())
+		case javaModifiers[t.text] && t.kind == sourceIdent:
+			b.skip(1)
+		case b.is("static") || b.is("class") || b.is("interface") || b.is("enum") || b.is("record") || b.is("<"):
+			b.skipStatement() // Constants, nested types, initializers and generic methods are not fields
+			annotations = nil
+		case b.is("{"):
+			b.inside()
+		default:
+			declaration, end := b.declaration("=", "(", "{")
+			if end == ";" || end == "=" {
+				for _, name := range declaratorNames(declaration) {
+					fields = append(fields, p.field(c, m, declaration[:declaratorStart(declaration)], name, annotations))
+				}
+			}
+			if end != ";" {
+				b.skipStatement() // An initializer, or a method or constructor
+			}
+			annotations = nil
+		}
+	}
+	return fields
+}
+
+func (p *JavaParser) field(c *sourceCursor, m *sourceModel, typeTokens []sourceToken, name string, annotations []service.ParsedAnnotation) service.ParsedField {
+	t := parseTypeExpr(c, typeTokens)
+	optional := t.arrays > 0 || !javaPrimitives[t.name]
+	return m.field(name, c.text(typeTokens), t, annotations, optional)
+}
+
+// declaration returns the tokens of a member declaration up to a semicolon or one of the given tokens outside
+// brackets, and the token it stopped at. Only a semicolon is consumed.
+func (c *sourceCursor) declaration(stops ...string) ([]sourceToken, string) {
+	start, depth := c.pos, 0
+	for ; !c.done(); c.skip(1) {
+		t := c.peek()
+		if t.kind != sourcePunct {
+			continue
+		}
+		if depth == 0 && t.text == ";" {
+			c.skip(1)
+			return c.tokens[start : c.pos-1], ";"
+		}
+		if depth == 0 && slices.Contains(stops, t.text) {
+			return c.tokens[start:c.pos], t.text
+		}
+		switch t.text {
+		case "<", "[", "(":
+			depth++
+		case ">", "]", ")":
+			depth--
+		}
+	}
+	return c.tokens[start:], ""
+}
+
+// declaratorNames returns the names declared by e.g. int width, height
+func declaratorNames(declaration []sourceToken) []string {
+	var names []string
+	depth := 0
+	for i, t := range declaration {
+		switch {
+		case t.kind == sourcePunct && t.text == "<":
+			depth++
+		case t.kind == sourcePunct && t.text == ">":
+			depth--
+		case t.kind == sourcePunct && t.text == "," && depth == 0 && i > 0:
+			names = append(names, declaration[i-1].text)
+		}
+	}
+	if last := len(declaration) - 1; last > 0 && declaration[last].kind == sourceIdent {
+		names = append(names, declaration[last].text)
+	}
+	return names
+}
+
+// declaratorStart returns where the first declared name starts, the type being before it
+func declaratorStart(declaration []sourceToken) int {
+	depth := 0
+	for i, t := range declaration {
+		switch {
+		case t.kind == sourcePunct && t.text == "<":
+			depth++
+		case t.kind == sourcePunct && t.text == ">":
+			depth--
+		case t.kind == sourcePunct && t.text == "," && depth == 0 && i > 0:
+			return i - 1
+		}
+	}
+	return len(declaration) - 1
+}
+
+// collectEnums reads every enum of a Java or C# source, nested ones included
+func collectEnums(c *sourceCursor, m *sourceModel) {
+	for i := 0; i+1 < len(c.tokens); i++ {
+		if t := c.tokens[i]; t.kind != sourceIdent || t.text != "enum" || c.tokens[i+1].kind != sourceIdent {
+			continue
+		}
+		sub := c.at(i + 2)
+		for !sub.done() && !sub.is("{") && !sub.is(";") {
+			sub.skip(1) // A C# underlying type, e.g. enum Status : byte
+		}
+		if sub.is("{") {
+			m.enums[c.tokens[i+1].text] = enumConstants(c, sub.inside())
+		}
+	}
+}
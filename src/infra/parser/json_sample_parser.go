@@ -0,0 +1,406 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"io"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// JSONSampleParser infers the entities of a resource from example JSON documents, such as API responses
+type JSONSampleParser struct{}
+
+func NewJSONSampleParser() service.SampleParser {
+	return &JSONSampleParser{}
+}
+
+// jsonObject is a decoded JSON object that keeps its keys in document order
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeJSONValue decodes the next value, with objects as *jsonObject and numbers as json.Number
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := t.(json.Delim)
+	if !ok {
+		return t, nil
+	}
+	switch delim {
+	case '{':
+		obj := &jsonObject{values: map[string]interface{}{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, seen := obj.values[key.(string)]; !seen {
+				obj.keys = append(obj.keys, key.(string))
+			}
+			obj.values[key.(string)] = value
+		}
+		_, err := dec.Token()
+		return obj, err
+	case '[':
+		items := []interface{}{}
+		for dec.More() {
+			item, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return nil, fmt.Errorf("unexpected %v", delim)
+}
+
+// sampleEntity collects what the samples show of one entity
+type sampleEntity struct {
+	name        string
+	occurrences int // Objects seen, the samples of the resource or the nested objects of this entity
+	keys        []string
+	fields      map[string]*sampleField
+}
+
+// sampleField collects the values a key takes across the samples, or the items of its arrays
+type sampleField struct {
+	present  int
+	nulls    int
+	kinds    map[string]int // string, int, float, bool, object or array
+	strings  []string       // Distinct string values, up to maxSampleStrings
+	overflow bool           // More distinct strings than kept
+	texts    int            // String values seen
+	dates    int
+	emails   int
+	urls     int
+	example  interface{}
+	entity   string       // Entity of the objects it holds
+	items    *sampleField // Items of the arrays it holds
+}
+
+const maxSampleStrings = 50
+
+// sampleInference gathers samples into entities, nested objects first seen being named after their key
+type sampleInference struct {
+	entities []*sampleEntity
+	byName   map[string]*sampleEntity
+	unmapped []string
+}
+
+func (p *JSONSampleParser) ParseSamples(resource string, samples []string) (service.ParsedSchema, error) {
+	root := entityName(service.ToSnake(resource))
+	if root == "" {
+		return service.ParsedSchema{}, fmt.Errorf("the resource needs a name")
+	}
+	s := &sampleInference{byName: map[string]*sampleEntity{}}
+	s.entity(root)
+
+	for n, sample := range samples {
+		dec := json.NewDecoder(strings.NewReader(sample))
+		dec.UseNumber()
+		value, err := decodeJSONValue(dec)
+		if err != nil {
+			return service.ParsedSchema{}, fmt.Errorf("sample %d is not valid JSON: %v", n+1, err)
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return service.ParsedSchema{}, fmt.Errorf("sample %d has more than one JSON value", n+1)
+		}
+
+		// A list response holds one sample per item
+		objects := []interface{}{value}
+		if items, ok := value.([]interface{}); ok {
+			objects = items
+		}
+		for _, object := range objects {
+			obj, ok := object.(*jsonObject)
+			if !ok {
+				return service.ParsedSchema{}, fmt.Errorf("sample %d is not a JSON object or a list of objects", n+1)
+			}
+			s.object(root, obj)
+		}
+	}
+	if s.byName[root].occurrences == 0 {
+		return service.ParsedSchema{}, fmt.Errorf("no sample of %s given", root)
+	}
+
+	var entities []model.Entity
+	for _, e := range s.entities {
+		entities = append(entities, s.build(e))
+	}
+	return service.ParsedSchema{Entities: entities, Unmapped: s.unmapped}, nil
+}
+
+func (s *sampleInference) note(format string, args ...interface{}) {
+	s.unmapped = append(s.unmapped, fmt.Sprintf(format, args...))
+}
+
+func (s *sampleInference) entity(name string) *sampleEntity {
+	if e, ok := s.byName[name]; ok {
+		return e
+	}
+	e := &sampleEntity{name: name, fields: map[string]*sampleField{}}
+	s.byName[name] = e
+	s.entities = append(s.entities, e)
+	return e
+}
+
+func (s *sampleInference) object(name string, obj *jsonObject) {
+	e := s.entity(name)
+	e.occurrences++
+	for _, key := range obj.keys {
+		f, ok := e.fields[key]
+		if !ok {
+			f = &sampleField{kinds: map[string]int{}}
+			e.fields[key] = f
+			e.keys = append(e.keys, key)
+		}
+		f.present++
+		s.value(f, key, obj.values[key])
+	}
+}
+
+func (s *sampleInference) value(f *sampleField, key string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		f.nulls++
+		return
+	case bool:
+		f.kinds["bool"]++
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			f.kinds["int"]++
+		} else {
+			f.kinds["float"]++
+		}
+	case string:
+		f.kinds["string"]++
+		f.text(v)
+	case *jsonObject:
+		f.kinds["object"]++
+		if f.entity == "" {
+			f.entity = entityName(service.ToSnake(key))
+		}
+		s.object(f.entity, v)
+		return
+	case []interface{}:
+		f.kinds["array"]++
+		if len(v) == 0 {
+			return
+		}
+		if f.items == nil {
+			f.items = &sampleField{kinds: map[string]int{}}
+		}
+		for _, item := range v {
+			f.items.present++
+			s.value(f.items, service.Singularize(key), item)
+		}
+	}
+	if f.example == nil {
+		f.example = value
+	}
+}
+
+func (f *sampleField) text(v string) {
+	f.texts++
+	if isSampleDate(v) {
+		f.dates++
+	}
+	if a, err := mail.ParseAddress(v); err == nil && a.Address == v {
+		f.emails++
+	}
+	if u, err := url.Parse(v); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		f.urls++
+	}
+	for _, known := range f.strings {
+		if known == v {
+			return
+		}
+	}
+	if len(f.strings) < maxSampleStrings {
+		f.strings = append(f.strings, v)
+	} else {
+		f.overflow = true
+	}
+}
+
+var sampleDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", "15:04:05"}
+
+func isSampleDate(v string) bool {
+	for _, layout := range sampleDateLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// build turns what the samples show of an entity into its fields and relations. A nested object holding
+// an id is taken for another resource (ManyToOne); one without is a value of its owner (OneToOne).
+func (s *sampleInference) build(e *sampleEntity) model.Entity {
+	entity := model.Entity{EntityName: e.name}
+	for _, key := range e.keys {
+		f := e.fields[key]
+		where := e.name + "." + key
+		fieldName := service.ToPascal(key)
+
+		if target, ok := s.relation(f); ok {
+			relation := enum.OneToOne
+			switch {
+			case target == e.name:
+				relation = enum.SelfReferencing
+			case f.kinds["array"] > 0:
+				relation = enum.OneToMany
+			case s.byName[target].hasID():
+				relation = enum.ManyToOne
+			}
+			addRelation(&entity, model.DependsOnEntity{EntityName: target, FieldName: fieldName, RelationType: relation})
+			continue
+		}
+
+		field := model.EntityField{
+			FieldName:                fieldName,
+			IsMandatory:              f.present == e.occurrences && f.nulls == 0,
+			IsEditable:               true,
+			CollectionType:           enum.None,
+			CollectionItemType:       enum.NoType,
+			NestedCollectionItemType: enum.NoType,
+			SampleData:               sampleData(f.example),
+		}
+		switch {
+		case f.kinds["array"] > 0 && len(f.kinds) == 1:
+			field.FieldType, field.IsCollection, field.CollectionType = enum.Collection, true, enum.List
+			if f.items == nil {
+				s.note("%s: only empty lists, imported as a list of String", where)
+				field.CollectionItemType = enum.StringType
+				break
+			}
+			if f.items.kinds["array"] > 0 {
+				field.CollectionItemType = enum.NestedCollectionType
+				field.NestedCollectionItemType = enum.StringType
+				if f.items.items != nil {
+					field.NestedCollectionItemType = collectionItemTypes[s.scalarType(f.items.items, key, where)]
+				}
+				break
+			}
+			itemType := s.scalarType(f.items, key, where)
+			field.CollectionItemType = collectionItemTypes[itemType]
+			if itemType == enum.Enum {
+				field.IsEnum, field.EnumValues = true, f.items.strings
+			}
+		default:
+			field.FieldType = s.scalarType(f, key, where)
+			if field.FieldType == enum.Enum {
+				field.IsEnum, field.EnumValues = true, f.strings
+			}
+			if f.texts > 0 && f.emails == f.texts {
+				field.InputValidations = append(field.InputValidations, model.InputValidation{RuleType: enum.EmailRule})
+			} else if f.texts > 0 && f.urls == f.texts {
+				field.InputValidations = append(field.InputValidations, model.InputValidation{RuleType: enum.URLRule})
+			}
+		}
+		entity.EntityFields = append(entity.EntityFields, field)
+	}
+	return entity
+}
+
+func (e *sampleEntity) hasID() bool {
+	for _, key := range []string{"id", "_id", "uuid"} {
+		if e.fields[key] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// relation returns the entity a field holds, as an object or a list of objects
+func (s *sampleInference) relation(f *sampleField) (string, bool) {
+	if f.kinds["object"] > 0 && len(f.kinds) == 1 {
+		return f.entity, true
+	}
+	if f.kinds["array"] > 0 && len(f.kinds) == 1 && f.items != nil && f.items.kinds["object"] > 0 && len(f.items.kinds) == 1 {
+		return f.items.entity, true
+	}
+	return "", false
+}
+
+// scalarType infers the type of the values a key takes. Integers mixed with decimals are Float; other
+// mixes, and keys that are always null, are reported and imported as String.
+func (s *sampleInference) scalarType(f *sampleField, key, where string) enum.DataType {
+	kinds := make([]string, 0, len(f.kinds))
+	for kind := range f.kinds {
+		kinds = append(kinds, kind)
+	}
+	switch {
+	case len(kinds) == 0:
+		s.note("%s: always null, imported as String", where)
+		return enum.String
+	case len(kinds) == 2 && f.kinds["int"] > 0 && f.kinds["float"] > 0:
+		return enum.Float
+	case len(kinds) > 1:
+		s.note("%s: values of several types, imported as String", where)
+		return enum.String
+	}
+	switch kinds[0] {
+	case "bool":
+		return enum.Bool
+	case "int":
+		return enum.Int
+	case "float":
+		return enum.Float
+	case "string":
+		if f.dates == f.texts {
+			return enum.DateTime
+		}
+		if likelyEnum(key, f) {
+			return enum.Enum
+		}
+		return enum.String
+	}
+	s.note("%s: values of several types, imported as String", where)
+	return enum.String
+}
+
+// Keys whose values are usually codes from a fixed set
+var sampleEnumKeys = map[string]bool{
+	"status": true, "state": true, "type": true, "kind": true, "category": true, "role": true, "level": true,
+	"tier": true, "priority": true, "gender": true, "stage": true, "mode": true, "plan": true, "currency": true,
+}
+
+const maxSampleEnumValues = 10
+
+var sampleEnumValue = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,39}$`)
+
+// likelyEnum tells codes such as statuses from free text: a few short words that either repeat across
+// the samples or belong to a key named like a category (status, type, role...)
+func likelyEnum(key string, f *sampleField) bool {
+	if f.overflow || len(f.strings) == 0 || len(f.strings) > maxSampleEnumValues || f.emails > 0 || f.urls > 0 {
+		return false
+	}
+	for _, v := range f.strings {
+		if !sampleEnumValue.MatchString(v) {
+			return false
+		}
+	}
+	words := service.SplitWords(service.Singularize(key))
+	if len(words) > 0 && sampleEnumKeys[strings.ToLower(words[len(words)-1])] {
+		return true
+	}
+	return f.texts >= 3 && len(f.strings)*2 <= f.texts
+}
@@ -0,0 +1,904 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIParser reads the schemas and operations of an OpenAPI 3 document, written in YAML or JSON
+type OpenAPIParser struct{}
+
+func NewOpenAPIParser() service.SchemaParser {
+	return &OpenAPIParser{}
+}
+
+// orderedMap keeps the entries of a mapping in document order, so fields and operations are imported as written
+type orderedMap[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (m *orderedMap[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	m.values = make(map[string]T, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		key := node.Content[i].Value
+		m.keys = append(m.keys, key)
+		m.values[key] = value
+	}
+	return nil
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                      `yaml:"openapi"`
+	Swagger    string                      `yaml:"swagger"`
+	Security   []map[string][]string       `yaml:"security"`
+	Paths      orderedMap[openAPIPathItem] `yaml:"paths"`
+	Components struct {
+		Schemas       orderedMap[*openAPISchema]   `yaml:"schemas"`
+		Parameters    map[string]*openAPIParameter `yaml:"parameters"`
+		RequestBodies map[string]*openAPIBody      `yaml:"requestBodies"`
+		Responses     map[string]*openAPIBody      `yaml:"responses"`
+	} `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation   `yaml:"get"`
+	Post       *openAPIOperation   `yaml:"post"`
+	Put        *openAPIOperation   `yaml:"put"`
+	Patch      *openAPIOperation   `yaml:"patch"`
+	Delete     *openAPIOperation   `yaml:"delete"`
+	Head       *openAPIOperation   `yaml:"head"`
+	Options    *openAPIOperation   `yaml:"options"`
+	Trace      *openAPIOperation   `yaml:"trace"`
+}
+
+type openAPIOperation struct {
+	OperationID string                   `yaml:"operationId"`
+	Summary     string                   `yaml:"summary"`
+	Description string                   `yaml:"description"`
+	Parameters  []*openAPIParameter      `yaml:"parameters"`
+	RequestBody *openAPIBody             `yaml:"requestBody"`
+	Responses   orderedMap[*openAPIBody] `yaml:"responses"`
+	Security    *[]map[string][]string   `yaml:"security"` // Overrides the document's security when set, even to []
+}
+
+type openAPIParameter struct {
+	Ref    string         `yaml:"$ref"`
+	Name   string         `yaml:"name"`
+	In     string         `yaml:"in"`
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+// openAPIBody is a request body or a response
+type openAPIBody struct {
+	Ref     string                       `yaml:"$ref"`
+	Content orderedMap[openAPIMediaType] `yaml:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                     `yaml:"$ref"`
+	Type                 openAPIType                `yaml:"type"`
+	Format               string                     `yaml:"format"`
+	Description          string                     `yaml:"description"`
+	Enum                 []interface{}              `yaml:"enum"`
+	Items                *openAPISchema             `yaml:"items"`
+	Properties           orderedMap[*openAPISchema] `yaml:"properties"`
+	AdditionalProperties yaml.Node                  `yaml:"additionalProperties"` // A schema, or true for any value
+	Required             []string                   `yaml:"required"`
+	AllOf                []*openAPISchema           `yaml:"allOf"`
+	OneOf                []*openAPISchema           `yaml:"oneOf"`
+	AnyOf                []*openAPISchema           `yaml:"anyOf"`
+	Nullable             bool                       `yaml:"nullable"`
+	ReadOnly             bool                       `yaml:"readOnly"`
+	WriteOnly            bool                       `yaml:"writeOnly"`
+	UniqueItems          bool                       `yaml:"uniqueItems"`
+	Example              interface{}                `yaml:"example"`
+	MinLength            *float64                   `yaml:"minLength"`
+	MaxLength            *float64                   `yaml:"maxLength"`
+	Minimum              *float64                   `yaml:"minimum"`
+	Maximum              *float64                   `yaml:"maximum"`
+	Pattern              string                     `yaml:"pattern"`
+}
+
+// openAPIType is the type of a schema: a name in OpenAPI 3.0, or a list such as [string, "null"] in 3.1
+type openAPIType struct {
+	name     string
+	nullable bool
+}
+
+func (t *openAPIType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		t.name = node.Value
+		return nil
+	}
+	var names []string
+	if err := node.Decode(&names); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == "null" {
+			t.nullable = true
+		} else if t.name == "" {
+			t.name = name
+		}
+	}
+	return nil
+}
+
+// openAPIImport maps a document to entities and entity journeys
+type openAPIImport struct {
+	doc      *openAPIDocument
+	entities []model.Entity
+	index    map[string]int // Entity position by schema name
+	journeys []model.EntityJourney
+	unmapped []string
+}
+
+func (p *OpenAPIParser) ParseSchema(content string) (service.ParsedSchema, error) {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return service.ParsedSchema{}, fmt.Errorf("failed to parse openapi document: %v", err)
+	}
+	if doc.Swagger != "" {
+		return service.ParsedSchema{}, fmt.Errorf("swagger %s documents are not supported, convert them to OpenAPI 3", doc.Swagger)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return service.ParsedSchema{}, fmt.Errorf("not an OpenAPI 3 document: openapi is %q", doc.OpenAPI)
+	}
+
+	i := &openAPIImport{doc: &doc, index: map[string]int{}}
+	i.schemas()
+	if len(i.entities) == 0 {
+		return service.ParsedSchema{}, fmt.Errorf("no object schema found in components.schemas")
+	}
+	i.paths()
+	return service.ParsedSchema{Entities: i.entities, Journeys: i.journeys, Unmapped: i.unmapped}, nil
+}
+
+func (i *openAPIImport) note(format string, args ...interface{}) {
+	i.unmapped = append(i.unmapped, fmt.Sprintf(format, args...))
+}
+
+// schemas maps the object schemas of the components to entities. Enum, primitive and array schemas
+// are not entities; the properties referencing them take their type.
+func (i *openAPIImport) schemas() {
+	schemas := i.doc.Components.Schemas
+	for _, name := range schemas.keys {
+		if i.isEntity(schemas.values[name]) {
+			i.index[name] = len(i.entities)
+			i.entities = append(i.entities, model.Entity{EntityName: service.ToPascal(name)})
+		} else if s := schemas.values[name]; s != nil && s.Type.name == "object" && len(s.Enum) == 0 {
+			i.note("schema %s has no properties", name)
+		}
+	}
+	for _, name := range schemas.keys {
+		idx, ok := i.index[name]
+		if !ok {
+			continue
+		}
+		entity := i.entities[idx] // Inline objects append entities, so the entity is built aside
+		entity.EntityDescription = schemas.values[name].Description
+		i.properties(&entity, name, schemas.values[name], map[string]bool{name: true})
+		i.entities[idx] = entity
+	}
+}
+
+func (i *openAPIImport) isEntity(s *openAPISchema) bool {
+	if s == nil || s.Ref != "" {
+		return false
+	}
+	if len(s.Properties.keys) > 0 {
+		return true
+	}
+	for _, part := range s.AllOf {
+		if name, ok := schemaRef(part.Ref); ok && i.isEntity(i.doc.Components.Schemas.values[name]) {
+			return true
+		}
+		if i.isEntity(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaRef returns the schema name of a local reference such as #/components/schemas/Pet
+func schemaRef(ref string) (string, bool) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	return name, ok && name != ""
+}
+
+// properties adds the fields and relations of an object schema, flattening allOf. A referenced parent
+// schema that is an entity is also recorded as an Inheritance relation.
+func (i *openAPIImport) properties(entity *model.Entity, schemaName string, s *openAPISchema, seen map[string]bool) {
+	for _, part := range s.AllOf {
+		if name, ok := schemaRef(part.Ref); ok {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if parent, ok := i.index[name]; ok {
+				entity.DependsOnEntities = append(entity.DependsOnEntities, model.DependsOnEntity{
+					EntityName: i.entities[parent].EntityName, FieldName: i.entities[parent].EntityName, RelationType: enum.Inheritance,
+				})
+			}
+			if resolved := i.doc.Components.Schemas.values[name]; resolved != nil {
+				i.properties(entity, name, resolved, seen)
+			}
+			continue
+		}
+		i.properties(entity, schemaName, part, seen)
+	}
+
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, name := range s.Properties.keys {
+		i.property(entity, schemaName, name, s.Properties.values[name], required[name])
+	}
+}
+
+// property maps a property to a field, or to a relation when it holds entities
+func (i *openAPIImport) property(entity *model.Entity, schemaName, name string, s *openAPISchema, required bool) {
+	if s == nil {
+		return
+	}
+	where := entity.EntityName + "." + name
+	field := model.EntityField{
+		FieldName:                service.ToPascal(name),
+		FieldDescription:         s.Description,
+		IsMandatory:              required && !s.Nullable && !s.Type.nullable,
+		IsReadOnly:               s.ReadOnly,
+		IsEditable:               !s.ReadOnly,
+		IsSensitive:              s.WriteOnly || s.Format == "password",
+		CollectionType:           enum.None,
+		CollectionItemType:       enum.NoType,
+		NestedCollectionItemType: enum.NoType,
+		SampleData:               sampleData(s.Example),
+	}
+
+	// A $ref wrapped in allOf to give it a description is the same reference
+	if s.Ref == "" && len(s.AllOf) == 1 && len(s.Properties.keys) == 0 && s.AllOf[0].Ref != "" {
+		s = &openAPISchema{Ref: s.AllOf[0].Ref}
+	}
+
+	if s.Ref != "" {
+		target, ok := i.resolve(s.Ref, where)
+		if !ok {
+			return
+		}
+		if idx, isEntity := i.index[target]; isEntity {
+			relation := enum.ManyToOne
+			if single, _ := i.refersBack(target, schemaName); single {
+				relation = enum.OneToOne
+			}
+			addRelation(entity, model.DependsOnEntity{EntityName: i.entities[idx].EntityName, FieldName: field.FieldName, RelationType: relation})
+			return
+		}
+		resolved := *i.doc.Components.Schemas.values[target]
+		if field.FieldDescription == "" {
+			field.FieldDescription = resolved.Description
+		}
+		if field.SampleData == "" {
+			field.SampleData = sampleData(resolved.Example)
+		}
+		s = &resolved
+	}
+
+	switch {
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		i.note("%s: oneOf/anyOf, imported as String", where)
+		field.FieldType = enum.String
+	case s.Type.name == "array" || s.Items != nil:
+		if !i.collection(entity, schemaName, &field, s.Items, where) {
+			return
+		}
+		field.CollectionType = enum.List
+		if s.UniqueItems {
+			field.CollectionType = enum.Set
+		}
+		field.InputValidations = lengthValidations(s.MinLength, s.MaxLength)
+	case i.isEntity(s):
+		// An inline object is an entity of its own, owned by the property
+		target := i.inlineEntity(entity.EntityName+field.FieldName, s)
+		addRelation(entity, model.DependsOnEntity{EntityName: target, FieldName: field.FieldName, RelationType: enum.OneToOne})
+		return
+	case s.Type.name == "object":
+		if s.AdditionalProperties.Kind == 0 {
+			i.note("%s: free-form object, imported as String", where)
+			field.FieldType = enum.String
+			break
+		}
+		var values *openAPISchema
+		if s.AdditionalProperties.Kind == yaml.MappingNode {
+			values = &openAPISchema{}
+			if err := s.AdditionalProperties.Decode(values); err != nil {
+				values = nil
+			}
+		}
+		field.FieldType, field.IsCollection, field.CollectionType = enum.Collection, true, enum.Map
+		field.CollectionItemType = enum.StringType
+		if values != nil {
+			if itemType, ok := collectionItemTypes[openAPIDataType(values.Type.name, values.Format)]; ok && values.Type.name != "" {
+				field.CollectionItemType = itemType
+			}
+		}
+	default:
+		field.FieldType = openAPIDataType(s.Type.name, s.Format)
+		if len(s.Enum) > 0 {
+			field.FieldType, field.IsEnum, field.EnumValues = enum.Enum, true, enumStrings(s.Enum)
+		}
+		field.InputValidations = openAPIValidations(s, field.FieldType)
+	}
+	entity.EntityFields = append(entity.EntityFields, field)
+}
+
+// collection fills an array field, or adds a to-many relation when its items are entities.
+// It reports whether the field itself is kept.
+func (i *openAPIImport) collection(entity *model.Entity, schemaName string, field *model.EntityField, items *openAPISchema, where string) bool {
+	if items == nil {
+		i.note("%s: array without items, imported as a list of String", where)
+		items = &openAPISchema{Type: openAPIType{name: "string"}}
+	}
+	if items.Ref != "" {
+		target, ok := i.resolve(items.Ref, where)
+		if !ok {
+			return false
+		}
+		if idx, isEntity := i.index[target]; isEntity {
+			relation := enum.OneToMany
+			if _, many := i.refersBack(target, schemaName); many {
+				relation = enum.ManyToMany
+			}
+			addRelation(entity, model.DependsOnEntity{EntityName: i.entities[idx].EntityName, FieldName: field.FieldName, RelationType: relation})
+			return false
+		}
+		resolved := *i.doc.Components.Schemas.values[target]
+		items = &resolved
+	}
+	if i.isEntity(items) {
+		target := i.inlineEntity(entity.EntityName+service.Singularize(field.FieldName), items)
+		addRelation(entity, model.DependsOnEntity{EntityName: target, FieldName: field.FieldName, RelationType: enum.OneToMany})
+		return false
+	}
+
+	field.FieldType, field.IsCollection = enum.Collection, true
+	switch {
+	case items.Type.name == "array":
+		field.CollectionItemType = enum.NestedCollectionType
+		field.NestedCollectionItemType = enum.StringType
+		if items.Items != nil {
+			field.NestedCollectionItemType = collectionItemTypes[openAPIDataType(items.Items.Type.name, items.Items.Format)]
+		}
+	case len(items.Enum) > 0:
+		field.CollectionItemType = enum.EnumType
+		field.IsEnum, field.EnumValues = true, enumStrings(items.Enum)
+	case items.Type.name == "object" || len(items.OneOf) > 0 || len(items.AnyOf) > 0:
+		i.note("%s: array of free-form or polymorphic items, imported as a list of String", where)
+		field.CollectionItemType = enum.StringType
+	default:
+		field.CollectionItemType = collectionItemTypes[openAPIDataType(items.Type.name, items.Format)]
+	}
+	return true
+}
+
+// inlineEntity adds the entity of an object schema written inline, named after its owner and property
+func (i *openAPIImport) inlineEntity(name string, s *openAPISchema) string {
+	for _, e := range i.entities {
+		if strings.EqualFold(e.EntityName, name) {
+			name += "Item"
+		}
+	}
+	entity := model.Entity{EntityName: name, EntityDescription: s.Description}
+	i.properties(&entity, "", s, map[string]bool{})
+	i.entities = append(i.entities, entity)
+	return name
+}
+
+// resolve returns the schema name of a reference, reporting references to other documents and unknown schemas
+func (i *openAPIImport) resolve(ref, where string) (string, bool) {
+	name, ok := schemaRef(ref)
+	if !ok {
+		i.note("%s: reference %s to another document", where, ref)
+		return "", false
+	}
+	if i.doc.Components.Schemas.values[name] == nil {
+		i.note("%s: reference to unknown schema %s", where, name)
+		return "", false
+	}
+	return name, true
+}
+
+// refersBack reports whether the target schema has a property holding one, or many, of the owner schema
+func (i *openAPIImport) refersBack(target, owner string) (single bool, many bool) {
+	s := i.doc.Components.Schemas.values[target]
+	if s == nil || owner == "" {
+		return false, false
+	}
+	for _, name := range s.Properties.keys {
+		p := s.Properties.values[name]
+		if p == nil {
+			continue
+		}
+		if ref, ok := schemaRef(p.Ref); ok && ref == owner {
+			single = true
+		}
+		if p.Items != nil {
+			if ref, ok := schemaRef(p.Items.Ref); ok && ref == owner {
+				many = true
+			}
+		}
+	}
+	return single, many
+}
+
+// openAPIDataType maps a schema type and format to a field type
+func openAPIDataType(typeName, format string) enum.DataType {
+	switch typeName {
+	case "integer":
+		return enum.Int
+	case "number":
+		return enum.Float
+	case "boolean":
+		return enum.Bool
+	case "string":
+		if format == "date" || format == "date-time" || format == "time" {
+			return enum.DateTime
+		}
+	}
+	return enum.String
+}
+
+// openAPIValidations maps the constraints of a schema to input validation rules
+func openAPIValidations(s *openAPISchema, dataType enum.DataType) []model.InputValidation {
+	validations := lengthValidations(s.MinLength, s.MaxLength)
+	if (s.Minimum != nil || s.Maximum != nil) && (dataType == enum.Int || dataType == enum.Float) {
+		validations = append(validations, model.InputValidation{RuleType: enum.RangeRule, Min: s.Minimum, Max: s.Maximum})
+	}
+	if dataType != enum.String {
+		return validations
+	}
+	if s.Pattern != "" {
+		validations = append(validations, model.InputValidation{RuleType: enum.PatternRule, Pattern: s.Pattern})
+	}
+	switch s.Format {
+	case "email":
+		validations = append(validations, model.InputValidation{RuleType: enum.EmailRule})
+	case "uri", "url":
+		validations = append(validations, model.InputValidation{RuleType: enum.URLRule})
+	}
+	return validations
+}
+
+func lengthValidations(min, max *float64) []model.InputValidation {
+	if min == nil && max == nil {
+		return nil
+	}
+	return []model.InputValidation{{RuleType: enum.LengthRule, Min: min, Max: max}}
+}
+
+func enumStrings(values []interface{}) []string {
+	var result []string
+	for _, v := range values {
+		if v != nil {
+			result = append(result, fmt.Sprint(v))
+		}
+	}
+	return result
+}
+
+// sampleData renders an example as text: strings as they are, anything else as JSON
+func sampleData(example interface{}) string {
+	switch v := example.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	data, err := json.Marshal(example)
+	if err != nil {
+		return fmt.Sprint(example)
+	}
+	return string(data)
+}
+
+// paths maps every operation to an operation of the entity it works on
+func (i *openAPIImport) paths() {
+	for _, path := range i.doc.Paths.keys {
+		item := i.doc.Paths.values[path]
+		for _, method := range []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"} {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+			label := strings.ToUpper(method) + " " + path
+			if method == "head" || method == "options" || method == "trace" {
+				i.note("%s: %s operations have no operation type", label, strings.ToUpper(method))
+				continue
+			}
+			i.operation(label, path, method, item, op)
+		}
+	}
+}
+
+func (item openAPIPathItem) operation(method string) *openAPIOperation {
+	return map[string]*openAPIOperation{
+		"get": item.Get, "post": item.Post, "put": item.Put, "patch": item.Patch, "delete": item.Delete,
+		"head": item.Head, "options": item.Options, "trace": item.Trace,
+	}[method]
+}
+
+func (i *openAPIImport) operation(label, path, method string, item openAPIPathItem, op *openAPIOperation) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	idx, ok := i.operationEntity(segments, op)
+	if !ok {
+		i.note("%s: no entity found in the path or its schemas", label)
+		return
+	}
+	entity := &i.entities[idx]
+
+	operation := model.Operation{
+		Type:        operationType(method, segments, entity.EntityName),
+		Name:        op.OperationID,
+		Description: op.Summary,
+	}
+	if operation.Name == "" {
+		operation.Name = operationName(method, segments)
+	}
+	if operation.Description == "" {
+		operation.Description = op.Description
+	}
+
+	security := i.doc.Security
+	if op.Security != nil {
+		security = *op.Security
+	}
+	if requiresAuthentication(security) {
+		entity.IsAuthenticationRequired = true
+	}
+
+	for _, p := range i.parameters(item.Parameters, op.Parameters) {
+		switch p.In {
+		case "path":
+		case "query":
+			i.queryParameter(&operation, *entity, p, label)
+		default:
+			i.note("%s: %s parameter %s", label, p.In, p.Name)
+		}
+	}
+
+	for j := range i.journeys {
+		if i.journeys[j].EntityName == entity.EntityName {
+			i.journeys[j].Operations = append(i.journeys[j].Operations, operation)
+			return
+		}
+	}
+	i.journeys = append(i.journeys, model.EntityJourney{EntityName: entity.EntityName, Operations: []model.Operation{operation}})
+}
+
+// operationEntity finds the entity an operation works on: the last path segment naming an entity,
+// else the entity its successful response or its request body holds
+func (i *openAPIImport) operationEntity(segments []string, op *openAPIOperation) (int, bool) {
+	for j := len(segments) - 1; j >= 0; j-- {
+		if isPathParameter(segments[j]) {
+			continue
+		}
+		if idx, ok := i.entityNamed(entityName(segments[j])); ok {
+			return idx, true
+		}
+	}
+	var bodies []*openAPIBody
+	for _, code := range op.Responses.keys {
+		if strings.HasPrefix(code, "2") {
+			bodies = append(bodies, i.body(op.Responses.values[code]))
+		}
+	}
+	bodies = append(bodies, i.body(op.RequestBody))
+	for _, body := range bodies {
+		if body == nil {
+			continue
+		}
+		for _, mediaType := range body.Content.keys {
+			if name, ok := i.schemaEntity(body.Content.values[mediaType].Schema); ok {
+				return i.index[name], true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (i *openAPIImport) entityNamed(name string) (int, bool) {
+	for idx, e := range i.entities {
+		if strings.EqualFold(e.EntityName, name) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// schemaEntity finds the entity a body schema holds: a reference, an array of them, or a page
+// object with an array of them such as {data: [Pet], total: 10}
+func (i *openAPIImport) schemaEntity(s *openAPISchema) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	if name, ok := schemaRef(s.Ref); ok {
+		if _, isEntity := i.index[name]; isEntity {
+			return name, true
+		}
+		return i.schemaEntity(i.doc.Components.Schemas.values[name])
+	}
+	if s.Items != nil {
+		return i.schemaEntity(s.Items)
+	}
+	for _, name := range s.Properties.keys {
+		if p := s.Properties.values[name]; p != nil && p.Items != nil {
+			if entity, ok := i.schemaEntity(p.Items); ok {
+				return entity, true
+			}
+		}
+	}
+	return "", false
+}
+
+// body resolves a reference to the request bodies or responses of the components
+func (i *openAPIImport) body(b *openAPIBody) *openAPIBody {
+	if b == nil || b.Ref == "" {
+		return b
+	}
+	if name, ok := strings.CutPrefix(b.Ref, "#/components/requestBodies/"); ok {
+		return i.doc.Components.RequestBodies[name]
+	}
+	if name, ok := strings.CutPrefix(b.Ref, "#/components/responses/"); ok {
+		return i.doc.Components.Responses[name]
+	}
+	return nil
+}
+
+// parameters resolves the parameters of a path and its operation; the operation's override the path's
+func (i *openAPIImport) parameters(pathParams, opParams []*openAPIParameter) []*openAPIParameter {
+	var result []*openAPIParameter
+	add := func(p *openAPIParameter) {
+		if p != nil && p.Ref != "" {
+			p = i.doc.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+		}
+		if p == nil {
+			return
+		}
+		for j, existing := range result {
+			if existing.Name == p.Name && existing.In == p.In {
+				result[j] = p
+				return
+			}
+		}
+		result = append(result, p)
+	}
+	for _, p := range pathParams {
+		add(p)
+	}
+	for _, p := range opParams {
+		add(p)
+	}
+	return result
+}
+
+func requiresAuthentication(security []map[string][]string) bool {
+	for _, requirement := range security {
+		if len(requirement) == 0 {
+			return false // {} makes authentication optional
+		}
+	}
+	return len(security) > 0
+}
+
+func isPathParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// operationType infers the type from the method and the path: /pets and /pets/{id} are the collection and
+// an item of the entity, while a last segment naming something else, such as /orders/{id}/cancel, is an action
+func operationType(method string, segments []string, entity string) enum.OperationType {
+	last := segments[len(segments)-1]
+	byId := isPathParameter(last)
+	if !byId && !strings.EqualFold(entityName(last), entity) {
+		return enum.CustomAPI
+	}
+	switch method {
+	case "get":
+		if byId {
+			return enum.ReadById
+		}
+		return enum.Read
+	case "post":
+		if !byId {
+			return enum.Create
+		}
+	case "put", "patch":
+		return enum.Update
+	case "delete":
+		return enum.Delete
+	}
+	return enum.CustomAPI
+}
+
+// operationName names an operation without operationId after its method and path: GET /pets/{petId} -> getPetsByPetId
+func operationName(method string, segments []string) string {
+	words := []string{method}
+	for _, segment := range segments {
+		if isPathParameter(segment) {
+			words = append(words, "by", strings.Trim(segment, "{}"))
+		} else {
+			words = append(words, segment)
+		}
+	}
+	return service.ToCamel(strings.Join(words, "_"))
+}
+
+// Query parameters and request fields that page through results rather than filter them
+var pagingParameters = map[string]bool{
+	"page": true, "limit": true, "offset": true, "size": true, "per_page": true, "perpage": true,
+	"page_size": true, "pagesize": true, "page_token": true, "pagetoken": true, "cursor": true, "skip": true, "take": true,
+}
+
+var sortParameters = map[string]bool{"sort": true, "sort_by": true, "sortby": true, "order_by": true, "orderby": true, "order": true}
+
+var searchParameters = map[string]bool{"q": true, "search": true, "query": true, "keyword": true, "term": true}
+
+// rangeAffixes mark the bounds of a range filter, e.g. price_gte, createdAfter or minPrice
+var rangeAffixes = []struct {
+	suffix, prefix string
+	operator       enum.OperatorType
+}{
+	{suffix: "_gte", operator: enum.GreaterThanOrEqual}, {suffix: "_lte", operator: enum.LessThanOrEqual},
+	{suffix: "_gt", operator: enum.GreaterThan}, {suffix: "_lt", operator: enum.LessThan},
+	{suffix: "[gte]", operator: enum.GreaterThanOrEqual}, {suffix: "[lte]", operator: enum.LessThanOrEqual},
+	{suffix: "[gt]", operator: enum.GreaterThan}, {suffix: "[lt]", operator: enum.LessThan},
+	{suffix: "_from", operator: enum.GreaterThanOrEqual}, {suffix: "_to", operator: enum.LessThanOrEqual},
+	{suffix: "_after", operator: enum.GreaterThan}, {suffix: "_before", operator: enum.LessThan},
+	{suffix: "_min", operator: enum.GreaterThanOrEqual}, {suffix: "_max", operator: enum.LessThanOrEqual},
+	{suffix: "From", operator: enum.GreaterThanOrEqual}, {suffix: "To", operator: enum.LessThanOrEqual},
+	{suffix: "After", operator: enum.GreaterThan}, {suffix: "Before", operator: enum.LessThan},
+	{suffix: "Min", operator: enum.GreaterThanOrEqual}, {suffix: "Max", operator: enum.LessThanOrEqual},
+	{prefix: "min_", operator: enum.GreaterThanOrEqual}, {prefix: "max_", operator: enum.LessThanOrEqual},
+	{prefix: "min", operator: enum.GreaterThanOrEqual}, {prefix: "max", operator: enum.LessThanOrEqual},
+}
+
+// queryParameter maps a query parameter to a filter or to sort fields of the operation
+func (i *openAPIImport) queryParameter(operation *model.Operation, entity model.Entity, p *openAPIParameter, label string) {
+	name := strings.ToLower(p.Name)
+	switch {
+	case pagingParameters[name]:
+		return
+	case sortParameters[name]:
+		operation.Sort = append(operation.Sort, sortFields(entity, parameterValues(p))...)
+		if len(operation.Sort) == 0 {
+			i.note("%s: sort parameter %s does not list fields of %s", label, p.Name, entity.EntityName)
+		}
+		return
+	case searchParameters[name]:
+		operation.Filters = append(operation.Filters, model.Filter{
+			Name: p.Name, Type: enum.TextSearch, FieldID: p.Name, Operator: enum.Contains,
+		})
+		return
+	}
+
+	isList := p.Schema != nil && p.Schema.Type.name == "array"
+	filter, ok := fieldFilter(entity, p.Name, isList, p.Schema != nil && len(p.Schema.Enum) > 0)
+	if !ok {
+		i.note("%s: query parameter %s matches no field of %s", label, p.Name, entity.EntityName)
+		return
+	}
+	operation.Filters = append(operation.Filters, filter)
+}
+
+// fieldFilter matches a query parameter or request field to a filter on a field of the entity. Range bounds are
+// read from affixes such as price_gte or createdAfter, and a list of values filters with In.
+func fieldFilter(entity model.Entity, name string, isList, isEnum bool) (model.Filter, bool) {
+	fieldName, ok := entityField(entity, name)
+	operator := enum.Equals
+	for _, affix := range rangeAffixes {
+		if ok {
+			break
+		}
+		base := ""
+		switch {
+		case affix.suffix != "" && strings.HasSuffix(name, affix.suffix):
+			base = strings.TrimSuffix(name, affix.suffix)
+		case affix.prefix != "" && strings.HasPrefix(name, affix.prefix):
+			base = strings.TrimPrefix(name, affix.prefix)
+		}
+		if base != "" {
+			fieldName, ok = entityField(entity, base)
+			operator = affix.operator
+		}
+	}
+	if !ok {
+		return model.Filter{}, false
+	}
+
+	filter := model.Filter{Name: name, Type: enum.FieldFilter, FieldID: fieldName, Operator: operator}
+	field := fieldNamed(entity, fieldName)
+	isRange := operator != enum.Equals
+	switch {
+	case isList:
+		filter.Operator = enum.In
+		if field.IsEnum {
+			filter.Type = enum.EnumFilter
+		}
+	case isRange && field.FieldType == enum.DateTime:
+		filter.Type = enum.DateRange
+	case isRange:
+		filter.Type = enum.NumericRange
+	case field.IsEnum || isEnum:
+		filter.Type = enum.EnumFilter
+	}
+	return filter, true
+}
+
+// sortFields maps sort values such as -created_at or name:asc to the fields of the entity they order by
+func sortFields(entity model.Entity, values []string) []model.Sort {
+	var sort []model.Sort
+	for _, value := range values {
+		value = strings.TrimLeft(value, "+-")
+		value, _, _ = strings.Cut(value, ":")
+		value, _, _ = strings.Cut(value, " ")
+		if field, ok := entityField(entity, value); ok {
+			sort = append(sort, model.Sort{FieldID: field})
+		}
+	}
+	return sort
+}
+
+// parameterValues returns the allowed values of a parameter or of its items
+func parameterValues(p *openAPIParameter) []string {
+	if p.Schema == nil {
+		return nil
+	}
+	if p.Schema.Items != nil && len(p.Schema.Items.Enum) > 0 {
+		return enumStrings(p.Schema.Items.Enum)
+	}
+	return enumStrings(p.Schema.Enum)
+}
+
+// entityField matches a parameter to a field of the entity, or to the key of one of its relations (customerId)
+func entityField(entity model.Entity, name string) (string, bool) {
+	pascal := service.ToPascal(name)
+	for _, f := range entity.EntityFields {
+		if strings.EqualFold(f.FieldName, pascal) {
+			return f.FieldName, true
+		}
+	}
+	for _, d := range entity.DependsOnEntities {
+		if strings.EqualFold(d.FieldName+"Id", pascal) {
+			return pascal, true
+		}
+	}
+	return "", false
+}
+
+func fieldNamed(entity model.Entity, name string) model.EntityField {
+	for _, f := range entity.EntityFields {
+		if f.FieldName == name {
+			return f
+		}
+	}
+	return model.EntityField{}
+}
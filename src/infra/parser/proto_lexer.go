@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+type protoTokenKind int
+
+const (
+	protoIdent protoTokenKind = iota // Names, keywords and dotted type names such as .google.protobuf.Timestamp
+	protoString
+	protoNumber
+	protoPunct
+)
+
+type protoToken struct {
+	kind     protoTokenKind
+	text     string // Strings are unquoted
+	line     int
+	comments []protoComment // Comments since the previous token
+}
+
+type protoComment struct {
+	text string
+	line int
+}
+
+// doc returns the comments before a token, which document the declaration it starts
+func (t protoToken) doc() string {
+	var texts []string
+	for _, c := range t.comments {
+		texts = append(texts, c.text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// lexProto splits a .proto file into tokens, attaching comments to the token that follows them
+func lexProto(src string) ([]protoToken, error) {
+	var tokens []protoToken
+	var comments []protoComment
+	line := 1
+
+	addComment := func(text string, at int) {
+		if text = strings.TrimSpace(text); text != "" {
+			comments = append(comments, protoComment{text: text, line: at})
+		}
+	}
+	emit := func(kind protoTokenKind, text string) {
+		tokens = append(tokens, protoToken{kind: kind, text: text, line: line, comments: comments})
+		comments = nil
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			addComment(strings.TrimLeft(src[i+2:i+end], "/"), line)
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			body := src[i+2 : i+2+end]
+			var text []string
+			for _, l := range strings.Split(body, "\n") {
+				text = append(text, strings.TrimLeft(strings.TrimSpace(l), "*"))
+			}
+			addComment(strings.Join(strings.Fields(strings.Join(text, " ")), " "), line)
+			line += strings.Count(body, "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			text, n, ok := readQuoted(src[i:], c, true)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			emit(protoString, text)
+			i += n
+		case isProtoIdentStart(c) || (c == '.' && i+1 < len(src) && isProtoIdentStart(src[i+1])):
+			start := i
+			for i < len(src) && (isProtoIdentStart(src[i]) || isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			emit(protoIdent, src[start:i])
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			i++
+			for i < len(src) && (isDigit(src[i]) || isProtoIdentStart(src[i]) || src[i] == '.' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			emit(protoNumber, src[start:i])
+		default:
+			emit(protoPunct, string(c))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isProtoIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
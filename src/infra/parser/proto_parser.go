@@ -0,0 +1,981 @@
+package parser
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"path"
+	"strings"
+)
+
+// ProtoParser reads Protocol Buffers files. Their messages, enums and services are imported as a project,
+// and the first message of a file as a blueprint. Imports are resolved among the files given, so nothing
+// is fetched; well-known types such as google.protobuf.Timestamp are built in.
+type ProtoParser struct{}
+
+func NewProtoParser() *ProtoParser {
+	return &ProtoParser{}
+}
+
+type protoFile struct {
+	name     string
+	pkg      string
+	imports  []string
+	messages []*protoMessage // Top-level messages; nested ones belong to their parent
+	enums    []*protoEnum
+	services []*protoService
+}
+
+type protoMessage struct {
+	name     string
+	fullName string // Package and parent messages included, e.g. shop.v1.Order.LineItem
+	comment  string
+	parent   *protoMessage
+	file     *protoFile
+	fields   []*protoField
+	messages []*protoMessage
+	enums    []*protoEnum
+}
+
+type protoField struct {
+	name     string
+	typeName string // Type, or the value type of a map field
+	keyType  string // Key type of a map field
+	label    string // repeated, optional, required or none
+	oneof    string
+	comment  string
+	options  map[string][]string
+}
+
+type protoEnum struct {
+	name     string
+	fullName string
+	values   []string
+}
+
+type protoService struct {
+	name string
+	rpcs []*protoRPC
+}
+
+type protoRPC struct {
+	name         string
+	comment      string
+	request      string
+	response     string
+	clientStream bool
+	serverStream bool
+}
+
+// protoCursor walks the tokens of one file, collecting what is skipped as unmapped
+type protoCursor struct {
+	file   *protoFile
+	tokens []protoToken
+	pos    int
+	notes  []string
+}
+
+func (c *protoCursor) done() bool {
+	return c.pos >= len(c.tokens)
+}
+
+func (c *protoCursor) peek() protoToken {
+	if c.done() {
+		return protoToken{kind: protoPunct}
+	}
+	return c.tokens[c.pos]
+}
+
+func (c *protoCursor) next() protoToken {
+	t := c.peek()
+	c.pos++
+	return t
+}
+
+// accept consumes the next token when it is the given keyword or punctuation
+func (c *protoCursor) accept(text string) bool {
+	if t := c.peek(); t.kind != protoString && t.text == text && !c.done() {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *protoCursor) errorf(format string, args ...interface{}) error {
+	line := 0
+	if c.done() && len(c.tokens) > 0 {
+		line = c.tokens[len(c.tokens)-1].line
+	} else if !c.done() {
+		line = c.peek().line
+	}
+	return fmt.Errorf("%s:%d: %s", c.file.name, line, fmt.Sprintf(format, args...))
+}
+
+func (c *protoCursor) expect(text string) error {
+	if !c.accept(text) {
+		if c.done() {
+			return c.errorf("expected %q, got end of file", text)
+		}
+		return c.errorf("expected %q, got %q", text, c.peek().text)
+	}
+	return nil
+}
+
+func (c *protoCursor) ident() (string, error) {
+	t := c.peek()
+	if t.kind != protoIdent || c.done() {
+		return "", c.errorf("expected a name, got %q", t.text)
+	}
+	c.pos++
+	return t.text, nil
+}
+
+func (c *protoCursor) note(format string, args ...interface{}) {
+	c.notes = append(c.notes, fmt.Sprintf(format, args...))
+}
+
+// skipStatement skips to the end of a statement such as an option, including any aggregate value it holds
+func (c *protoCursor) skipStatement() {
+	depth := 0
+	for !c.done() {
+		t := c.next()
+		if t.kind != protoPunct {
+			continue
+		}
+		switch t.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		case ";":
+			if depth <= 0 {
+				return
+			}
+		}
+	}
+}
+
+// skipBody skips a declaration up to the end of its { } body
+func (c *protoCursor) skipBody() {
+	for !c.done() && !c.accept("{") {
+		c.next()
+	}
+	depth := 1
+	for !c.done() && depth > 0 {
+		t := c.next()
+		if t.kind == protoPunct && t.text == "{" {
+			depth++
+		} else if t.kind == protoPunct && t.text == "}" {
+			depth--
+		}
+	}
+}
+
+// option reads name = value into the options; the fields of an aggregate value are added as name.field
+func (c *protoCursor) option(options map[string][]string) error {
+	var name strings.Builder
+	for !c.done() && !c.accept("=") {
+		if t := c.next(); t.text != "(" && t.text != ")" {
+			name.WriteString(t.text)
+		}
+	}
+	return c.optionValue(name.String(), options)
+}
+
+func (c *protoCursor) optionValue(name string, options map[string][]string) error {
+	switch {
+	case c.accept("{"):
+		for !c.accept("}") {
+			if c.done() {
+				return c.errorf("unterminated option %s", name)
+			}
+			key := c.next().text
+			if key == "[" {
+				for !c.done() && !c.accept("]") {
+					key += c.next().text
+				}
+			}
+			c.accept(":")
+			if err := c.optionValue(name+"."+key, options); err != nil {
+				return err
+			}
+			if !c.accept(",") {
+				c.accept(";")
+			}
+		}
+	case c.accept("["):
+		for !c.accept("]") {
+			if c.done() {
+				return c.errorf("unterminated option %s", name)
+			}
+			if err := c.optionValue(name, options); err != nil {
+				return err
+			}
+			c.accept(",")
+		}
+	default:
+		if c.done() {
+			return c.errorf("expected a value for option %s", name)
+		}
+		options[name] = append(options[name], c.next().text)
+	}
+	return nil
+}
+
+// parseProtoFile reads the declarations of one file
+func parseProtoFile(name, content string) (*protoFile, []string, error) {
+	tokens, err := lexProto(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", name, err)
+	}
+	c := &protoCursor{file: &protoFile{name: name}, tokens: tokens}
+	for !c.done() {
+		t := c.next()
+		switch t.text {
+		case ";":
+		case "syntax", "edition", "option":
+			c.skipStatement()
+		case "package":
+			pkg, err := c.ident()
+			if err != nil {
+				return nil, nil, err
+			}
+			c.file.pkg = pkg
+			if err := c.expect(";"); err != nil {
+				return nil, nil, err
+			}
+		case "import":
+			if !c.accept("public") {
+				c.accept("weak")
+			}
+			if imported := c.next(); imported.kind == protoString {
+				c.file.imports = append(c.file.imports, imported.text)
+			}
+			if err := c.expect(";"); err != nil {
+				return nil, nil, err
+			}
+		case "message":
+			m, err := c.message(nil, c.file.pkg, t.doc())
+			if err != nil {
+				return nil, nil, err
+			}
+			c.file.messages = append(c.file.messages, m)
+		case "enum":
+			e, err := c.enum(c.file.pkg)
+			if err != nil {
+				return nil, nil, err
+			}
+			c.file.enums = append(c.file.enums, e)
+		case "service":
+			s, err := c.service()
+			if err != nil {
+				return nil, nil, err
+			}
+			c.file.services = append(c.file.services, s)
+		case "extend":
+			target, _ := c.ident()
+			c.note("%s: extension of %s", name, target)
+			c.skipBody()
+		default:
+			c.pos--
+			return nil, nil, c.errorf("unexpected %q", t.text)
+		}
+	}
+	return c.file, c.notes, nil
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (c *protoCursor) message(parent *protoMessage, scope, comment string) (*protoMessage, error) {
+	name, err := c.ident()
+	if err != nil {
+		return nil, err
+	}
+	m := &protoMessage{name: name, fullName: qualify(scope, name), comment: comment, parent: parent, file: c.file}
+	if err := c.expect("{"); err != nil {
+		return nil, err
+	}
+	for !c.accept("}") {
+		if c.done() {
+			return nil, c.errorf("message %s is not closed", name)
+		}
+		t := c.peek()
+		switch t.text {
+		case ";":
+			c.next()
+		case "message":
+			c.next()
+			nested, err := c.message(m, m.fullName, t.doc())
+			if err != nil {
+				return nil, err
+			}
+			m.messages = append(m.messages, nested)
+		case "enum":
+			c.next()
+			e, err := c.enum(m.fullName)
+			if err != nil {
+				return nil, err
+			}
+			m.enums = append(m.enums, e)
+		case "option", "reserved", "extensions":
+			c.skipStatement()
+		case "extend":
+			c.next()
+			target, _ := c.ident()
+			c.note("%s: extension of %s", m.fullName, target)
+			c.skipBody()
+		case "oneof":
+			c.next()
+			oneof, err := c.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err := c.expect("{"); err != nil {
+				return nil, err
+			}
+			for !c.accept("}") {
+				if c.done() {
+					return nil, c.errorf("oneof %s is not closed", oneof)
+				}
+				if c.peek().text == "option" {
+					c.skipStatement()
+					continue
+				}
+				if err := c.field(m, oneof); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if err := c.field(m, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+func (c *protoCursor) field(m *protoMessage, oneof string) error {
+	f := &protoField{oneof: oneof, comment: c.peek().doc(), options: map[string][]string{}}
+	if label := c.peek().text; label == "repeated" || label == "optional" || label == "required" {
+		f.label = label
+		c.next()
+	}
+
+	var err error
+	if c.accept("map") {
+		if err := c.expect("<"); err != nil {
+			return err
+		}
+		if f.keyType, err = c.ident(); err != nil {
+			return err
+		}
+		if err := c.expect(","); err != nil {
+			return err
+		}
+		if f.typeName, err = c.ident(); err != nil {
+			return err
+		}
+		if err := c.expect(">"); err != nil {
+			return err
+		}
+	} else if f.typeName, err = c.ident(); err != nil {
+		return err
+	}
+
+	if f.name, err = c.ident(); err != nil {
+		return err
+	}
+	if f.typeName == "group" {
+		c.note("%s.%s: proto2 group", m.fullName, f.name)
+		c.skipBody()
+		return nil
+	}
+	if err := c.expect("="); err != nil {
+		return err
+	}
+	if t := c.next(); t.kind != protoNumber {
+		return c.errorf("expected the number of field %s", f.name)
+	}
+	if c.accept("[") {
+		for !c.accept("]") {
+			if c.done() {
+				return c.errorf("options of field %s are not closed", f.name)
+			}
+			if err := c.option(f.options); err != nil {
+				return err
+			}
+			c.accept(",")
+		}
+	}
+	line := c.peek().line
+	if err := c.expect(";"); err != nil {
+		return err
+	}
+
+	// A comment after the field on the same line documents it too
+	if !c.done() {
+		next := &c.tokens[c.pos]
+		for len(next.comments) > 0 && next.comments[0].line == line {
+			f.comment = strings.TrimSpace(f.comment + " " + next.comments[0].text)
+			next.comments = next.comments[1:]
+		}
+	}
+	m.fields = append(m.fields, f)
+	return nil
+}
+
+func (c *protoCursor) enum(scope string) (*protoEnum, error) {
+	name, err := c.ident()
+	if err != nil {
+		return nil, err
+	}
+	e := &protoEnum{name: name, fullName: qualify(scope, name)}
+	if err := c.expect("{"); err != nil {
+		return nil, err
+	}
+	for !c.accept("}") {
+		if c.done() {
+			return nil, c.errorf("enum %s is not closed", name)
+		}
+		switch c.peek().text {
+		case ";":
+			c.next()
+		case "option", "reserved":
+			c.skipStatement()
+		default:
+			value, err := c.ident()
+			if err != nil {
+				return nil, err
+			}
+			e.values = append(e.values, value)
+			c.skipStatement()
+		}
+	}
+	return e, nil
+}
+
+func (c *protoCursor) service() (*protoService, error) {
+	name, err := c.ident()
+	if err != nil {
+		return nil, err
+	}
+	s := &protoService{name: name}
+	if err := c.expect("{"); err != nil {
+		return nil, err
+	}
+	for !c.accept("}") {
+		if c.done() {
+			return nil, c.errorf("service %s is not closed", name)
+		}
+		t := c.next()
+		switch t.text {
+		case ";":
+		case "option":
+			c.skipStatement()
+		case "rpc":
+			rpc, err := c.rpc(t.doc())
+			if err != nil {
+				return nil, err
+			}
+			s.rpcs = append(s.rpcs, rpc)
+		default:
+			c.pos--
+			return nil, c.errorf("unexpected %q in service %s", t.text, name)
+		}
+	}
+	return s, nil
+}
+
+func (c *protoCursor) rpc(comment string) (*protoRPC, error) {
+	rpc := &protoRPC{comment: comment}
+	var err error
+	if rpc.name, err = c.ident(); err != nil {
+		return nil, err
+	}
+	if err := c.expect("("); err != nil {
+		return nil, err
+	}
+	rpc.clientStream = c.accept("stream")
+	if rpc.request, err = c.ident(); err != nil {
+		return nil, err
+	}
+	if err := c.expect(")"); err != nil {
+		return nil, err
+	}
+	if err := c.expect("returns"); err != nil {
+		return nil, err
+	}
+	if err := c.expect("("); err != nil {
+		return nil, err
+	}
+	rpc.serverStream = c.accept("stream")
+	if rpc.response, err = c.ident(); err != nil {
+		return nil, err
+	}
+	if err := c.expect(")"); err != nil {
+		return nil, err
+	}
+	if c.peek().text == "{" {
+		c.skipBody()
+		c.accept(";")
+		return rpc, nil
+	}
+	return rpc, c.expect(";")
+}
+
+// Parse reads the first message of a file as blueprint metadata
+func (p *ProtoParser) Parse(content string) (service.ParsedMetadata, error) {
+	file, _, err := parseProtoFile("input.proto", content)
+	if err != nil {
+		return service.ParsedMetadata{}, fmt.Errorf("failed to parse proto file: %v", err)
+	}
+	if len(file.messages) == 0 {
+		return service.ParsedMetadata{}, fmt.Errorf("no message definition found in proto file")
+	}
+
+	m := file.messages[0]
+	metadata := service.ParsedMetadata{Name: m.name}
+	for _, f := range m.fields {
+		fieldType := f.typeName
+		if f.keyType != "" {
+			fieldType = fmt.Sprintf("map<%s, %s>", f.keyType, f.typeName)
+		}
+		if f.label != "" {
+			fieldType = f.label + " " + fieldType
+		}
+		metadata.Fields = append(metadata.Fields, service.ParsedField{Name: f.name, Type: fieldType})
+	}
+	return metadata, nil
+}
+
+func (p *ProtoParser) ParseSchema(content string) (service.ParsedSchema, error) {
+	return p.ParseFiles([]service.SchemaFile{{Name: "schema.proto", Content: content}})
+}
+
+// protoImport maps the messages of a set of files to entities, and their services to entity journeys
+type protoImport struct {
+	files    []*protoFile
+	symbols  map[string]interface{} // *protoMessage or *protoEnum by full name
+	wrappers map[*protoMessage]bool // Request and response messages of RPCs, which are not entities
+	entities []model.Entity
+	entityOf map[*protoMessage]int
+	journeys []model.EntityJourney
+	unmapped []string
+}
+
+// ParseFiles reads files that may import each other. An import of a file that was not given is reported,
+// as are the types it would have declared.
+func (p *ProtoParser) ParseFiles(files []service.SchemaFile) (service.ParsedSchema, error) {
+	i := &protoImport{symbols: map[string]interface{}{}, wrappers: map[*protoMessage]bool{}, entityOf: map[*protoMessage]int{}}
+	for _, f := range files {
+		file, notes, err := parseProtoFile(f.Name, f.Content)
+		if err != nil {
+			return service.ParsedSchema{}, err
+		}
+		i.files = append(i.files, file)
+		i.unmapped = append(i.unmapped, notes...)
+	}
+
+	for _, file := range i.files {
+		for _, imported := range file.imports {
+			if !i.isImported(imported) {
+				i.note("%s: imported file %s was not given", file.name, imported)
+			}
+		}
+		i.declare(file.messages, file.enums)
+	}
+
+	i.messages()
+	if len(i.entities) == 0 {
+		return service.ParsedSchema{}, fmt.Errorf("no message definition found")
+	}
+	i.services()
+	return service.ParsedSchema{Entities: i.entities, Journeys: i.journeys, Unmapped: i.unmapped}, nil
+}
+
+func (i *protoImport) note(format string, args ...interface{}) {
+	i.unmapped = append(i.unmapped, fmt.Sprintf(format, args...))
+}
+
+// isImported reports whether an import is one of the files given or a package whose types are built in
+func (i *protoImport) isImported(imported string) bool {
+	for _, prefix := range []string{"google/protobuf/", "google/api/", "google/type/"} {
+		if strings.HasPrefix(imported, prefix) {
+			return true
+		}
+	}
+	for _, file := range i.files {
+		name := path.Clean(strings.ReplaceAll(file.name, "\\", "/"))
+		if name == imported || strings.HasSuffix(name, "/"+imported) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *protoImport) declare(messages []*protoMessage, enums []*protoEnum) {
+	for _, m := range messages {
+		i.symbols[m.fullName] = m
+		i.declare(m.messages, m.enums)
+	}
+	for _, e := range enums {
+		i.symbols[e.fullName] = e
+	}
+}
+
+// resolve finds the declaration a type name refers to, looking in the scope it is used in and then
+// its enclosing scopes, as protoc does. The full name is returned even when nothing declares it.
+func (i *protoImport) resolve(name, scope string) (string, interface{}) {
+	if strings.HasPrefix(name, ".") {
+		return name[1:], i.symbols[name[1:]]
+	}
+	for {
+		if symbol, ok := i.symbols[qualify(scope, name)]; ok {
+			return qualify(scope, name), symbol
+		}
+		if scope == "" {
+			return name, nil
+		}
+		if dot := strings.LastIndexByte(scope, '.'); dot >= 0 {
+			scope = scope[:dot]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+func (i *protoImport) message(name, scope string) *protoMessage {
+	_, symbol := i.resolve(name, scope)
+	m, _ := symbol.(*protoMessage)
+	return m
+}
+
+// messages adds an entity for every message but the requests and responses of RPCs, which are named so or
+// empty. A message is named
+// after itself, or after its parents or package when several messages have that name.
+func (i *protoImport) messages() {
+	for _, file := range i.files {
+		for _, s := range file.services {
+			for _, rpc := range s.rpcs {
+				for _, name := range []string{rpc.request, rpc.response} {
+					if m := i.message(name, file.pkg); m != nil && (strings.HasSuffix(m.name, "Request") ||
+						strings.HasSuffix(m.name, "Response") || len(m.fields) == 0) {
+						i.wrappers[m] = true
+					}
+				}
+			}
+		}
+	}
+
+	var all []*protoMessage
+	var collect func(messages []*protoMessage)
+	collect = func(messages []*protoMessage) {
+		for _, m := range messages {
+			if !i.wrappers[m] {
+				all = append(all, m)
+			}
+			collect(m.messages)
+		}
+	}
+	for _, file := range i.files {
+		collect(file.messages)
+	}
+
+	count := map[string]int{}
+	for _, m := range all {
+		count[service.ToPascal(m.name)]++
+	}
+	for _, m := range all {
+		name := service.ToPascal(m.name)
+		if count[name] > 1 {
+			switch {
+			case m.parent != nil:
+				name = service.ToPascal(m.parent.name) + name
+			case m.file.pkg != "":
+				name = service.ToPascal(m.file.pkg[strings.LastIndexByte(m.file.pkg, '.')+1:]) + name
+			}
+		}
+		i.entityOf[m] = len(i.entities)
+		i.entities = append(i.entities, model.Entity{EntityName: name, EntityDescription: m.comment})
+	}
+
+	for _, m := range all {
+		entity := &i.entities[i.entityOf[m]]
+		oneofs := map[string]bool{}
+		for _, f := range m.fields {
+			if f.oneof != "" && !oneofs[f.oneof] {
+				oneofs[f.oneof] = true
+				i.note("%s.%s: oneof, its fields are imported as optional", entity.EntityName, f.oneof)
+			}
+			i.field(entity, m, f)
+		}
+	}
+}
+
+// protoScalarTypes maps the scalar value types to field types
+var protoScalarTypes = map[string]enum.DataType{
+	"double": enum.Float, "float": enum.Float,
+	"int32": enum.Int, "int64": enum.Int, "uint32": enum.Int, "uint64": enum.Int, "sint32": enum.Int, "sint64": enum.Int,
+	"fixed32": enum.Int, "fixed64": enum.Int, "sfixed32": enum.Int, "sfixed64": enum.Int,
+	"bool": enum.Bool, "string": enum.String, "bytes": enum.String,
+}
+
+// protoWellKnownTypes maps the well-known messages holding a single value to field types
+var protoWellKnownTypes = map[string]enum.DataType{
+	"google.protobuf.Timestamp": enum.DateTime, "google.type.Date": enum.DateTime, "google.type.DateTime": enum.DateTime,
+	"google.type.TimeOfDay": enum.DateTime, "google.protobuf.StringValue": enum.String, "google.protobuf.BoolValue": enum.Bool,
+	"google.protobuf.Int32Value": enum.Int, "google.protobuf.Int64Value": enum.Int, "google.protobuf.UInt32Value": enum.Int,
+	"google.protobuf.UInt64Value": enum.Int, "google.protobuf.DoubleValue": enum.Float, "google.protobuf.FloatValue": enum.Float,
+}
+
+func hasOption(f *protoField, name, value string) bool {
+	for _, v := range f.options[name] {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// field maps a field to an entity field, or to a relation when it holds messages
+func (i *protoImport) field(entity *model.Entity, m *protoMessage, f *protoField) {
+	where := entity.EntityName + "." + f.name
+	outputOnly := hasOption(f, "google.api.field_behavior", "OUTPUT_ONLY")
+	field := model.EntityField{
+		FieldName:        service.ToPascal(f.name),
+		FieldDescription: f.comment,
+		IsMandatory: f.oneof == "" && (f.label == "required" || hasOption(f, "google.api.field_behavior", "REQUIRED") ||
+			hasOption(f, "features.field_presence", "LEGACY_REQUIRED")),
+		IsReadOnly:               outputOnly,
+		IsEditable:               !outputOnly && !hasOption(f, "google.api.field_behavior", "IMMUTABLE"),
+		CollectionType:           enum.None,
+		CollectionItemType:       enum.NoType,
+		NestedCollectionItemType: enum.NoType,
+	}
+
+	fullName, symbol := i.resolve(f.typeName, m.fullName)
+	dataType, known := protoScalarTypes[f.typeName]
+	switch symbol := symbol.(type) {
+	case *protoMessage:
+		if target, ok := i.entityOf[symbol]; ok {
+			addRelation(entity, model.DependsOnEntity{
+				EntityName: i.entities[target].EntityName, FieldName: field.FieldName, RelationType: i.relationType(m, symbol, f),
+			})
+			return
+		}
+		i.note("%s: %s is a request or response message, imported as String", where, symbol.name)
+		dataType = enum.String
+	case *protoEnum:
+		dataType = enum.Enum
+		field.IsEnum, field.EnumValues = true, protoEnumValues(symbol)
+	default:
+		if wellKnown, ok := protoWellKnownTypes[fullName]; ok {
+			dataType = wellKnown
+		} else if !known {
+			i.note("%s: unknown type %s, imported as String", where, f.typeName)
+			dataType = enum.String
+		} else if f.typeName == "bytes" {
+			i.note("%s: bytes, imported as String", where)
+		}
+	}
+
+	switch {
+	case f.keyType != "":
+		field.FieldType, field.IsCollection, field.CollectionType = enum.Collection, true, enum.Map
+		field.CollectionItemType = collectionItemTypes[dataType]
+	case f.label == "repeated":
+		field.FieldType, field.IsCollection, field.CollectionType = enum.Collection, true, enum.List
+		field.CollectionItemType = collectionItemTypes[dataType]
+	default:
+		field.FieldType = dataType
+	}
+	entity.EntityFields = append(entity.EntityFields, field)
+}
+
+// relationType relates a message to the message a field holds: messages declared inside the message are owned
+// by it, while top-level ones are referenced and may refer back
+func (i *protoImport) relationType(m, target *protoMessage, f *protoField) enum.RelationType {
+	if target == m {
+		return enum.SelfReferencing
+	}
+	single, many := false, false
+	for _, back := range target.fields {
+		if backType := i.message(back.typeName, target.fullName); backType == m {
+			if back.label == "repeated" || back.keyType != "" {
+				many = true
+			} else {
+				single = true
+			}
+		}
+	}
+	switch {
+	case f.label == "repeated" || f.keyType != "":
+		if many && target.parent != m {
+			return enum.ManyToMany
+		}
+		return enum.OneToMany
+	case target.parent == m || single:
+		return enum.OneToOne
+	}
+	return enum.ManyToOne
+}
+
+// protoEnumValues returns the values of an enum without the prefix naming the enum and without the
+// zero value marking it unset: ORDER_STATUS_UNSPECIFIED, ORDER_STATUS_PLACED -> PLACED
+func protoEnumValues(e *protoEnum) []string {
+	prefix := service.ToScreamingSnake(e.name) + "_"
+	strip := true
+	for _, v := range e.values {
+		if !strings.HasPrefix(v, prefix) || v == prefix {
+			strip = false
+		}
+	}
+	var values []string
+	for j, v := range e.values {
+		if j == 0 && strings.HasSuffix(v, "UNSPECIFIED") {
+			continue
+		}
+		if strip {
+			v = strings.TrimPrefix(v, prefix)
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// protoVerbs give the operation type of an RPC from the verb its name starts with, following the API
+// design guide: GetBook, ListBooks, CreateBook, UpdateBook, DeleteBook
+var protoVerbs = []struct {
+	verb      string
+	operation enum.OperationType
+}{
+	{"BatchGet", enum.Read}, {"Get", enum.ReadById}, {"List", enum.Read}, {"Search", enum.Read}, {"Find", enum.Read},
+	{"Query", enum.Read}, {"Create", enum.Create}, {"Add", enum.Create}, {"Insert", enum.Create},
+	{"Update", enum.Update}, {"Patch", enum.Update}, {"Replace", enum.Update}, {"Delete", enum.Delete},
+	{"Remove", enum.Delete},
+}
+
+// rpcVerb splits an RPC name into its verb and the resource it names
+func rpcVerb(name string) (string, enum.OperationType, string) {
+	for _, v := range protoVerbs {
+		rest := strings.TrimPrefix(name, v.verb)
+		if rest != name && (rest == "" || (rest[0] >= 'A' && rest[0] <= 'Z')) {
+			return v.verb, v.operation, rest
+		}
+	}
+	return "", enum.CustomAPI, name
+}
+
+// services maps every RPC to an operation of the entity it works on
+func (i *protoImport) services() {
+	for _, file := range i.files {
+		for _, s := range file.services {
+			for _, rpc := range s.rpcs {
+				i.rpc(file, s.name+"."+rpc.name, rpc)
+			}
+		}
+	}
+}
+
+func (i *protoImport) rpc(file *protoFile, label string, rpc *protoRPC) {
+	request, response := i.message(rpc.request, file.pkg), i.message(rpc.response, file.pkg)
+	idx, ok := i.rpcEntity(rpc, request, response)
+	if !ok {
+		i.note("%s: no entity found in its name or messages", label)
+		return
+	}
+	entity := &i.entities[idx]
+
+	verb, operationType, rest := rpcVerb(rpc.name)
+	if rest != "" && !strings.EqualFold(entityName(service.ToSnake(rest)), entity.EntityName) {
+		operationType = enum.CustomAPI
+	}
+	if verb == "Get" && response != nil && i.wrappers[response] {
+		operationType = enum.Read // GetBooks returning a GetBooksResponse with a list
+	}
+	operation := model.Operation{Type: operationType, Name: service.ToCamel(rpc.name), Description: rpc.comment}
+	if rpc.clientStream || rpc.serverStream {
+		i.note("%s: streaming, imported as a single call", label)
+	}
+	if operationType == enum.Read && request != nil && i.wrappers[request] {
+		i.requestFilters(&operation, *entity, request, label)
+	}
+
+	for j := range i.journeys {
+		if i.journeys[j].EntityName == entity.EntityName {
+			i.journeys[j].Operations = append(i.journeys[j].Operations, operation)
+			return
+		}
+	}
+	i.journeys = append(i.journeys, model.EntityJourney{EntityName: entity.EntityName, Operations: []model.Operation{operation}})
+}
+
+// rpcEntity finds the entity an RPC works on: the message it returns or takes, the resource its name ends
+// with (ListBooks -> Book), or else the first entity its response or request holds
+func (i *protoImport) rpcEntity(rpc *protoRPC, request, response *protoMessage) (int, bool) {
+	for _, m := range []*protoMessage{response, request} {
+		if idx, ok := i.entityOf[m]; ok && m != nil {
+			return idx, true
+		}
+	}
+	_, _, rest := rpcVerb(rpc.name)
+	for idx, e := range i.entities {
+		if strings.EqualFold(e.EntityName, entityName(service.ToSnake(rest))) {
+			return idx, true
+		}
+	}
+	for _, m := range []*protoMessage{response, request} {
+		if m == nil {
+			continue
+		}
+		for _, f := range m.fields {
+			if idx, ok := i.entityOf[i.message(f.typeName, m.fullName)]; ok {
+				return idx, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Request fields of list RPCs that name the collection or the view rather than filter it
+var protoListFields = map[string]bool{"parent": true, "read_mask": true, "view": true, "show_deleted": true}
+
+// requestFilters maps the fields of a list request to the filters and sort of the operation
+func (i *protoImport) requestFilters(operation *model.Operation, entity model.Entity, request *protoMessage, label string) {
+	for _, f := range request.fields {
+		name := strings.ToLower(f.name)
+		_, symbol := i.resolve(f.typeName, request.fullName)
+		values, isEnum := symbol.(*protoEnum)
+		switch {
+		case pagingParameters[name] || protoListFields[name]:
+		case sortParameters[name]:
+			if isEnum {
+				operation.Sort = append(operation.Sort, sortFields(entity, protoEnumValues(values))...)
+			}
+			if len(operation.Sort) == 0 {
+				i.note("%s: %s orders by an expression, not by fields of %s", label, f.name, entity.EntityName)
+			}
+		case name == "filter":
+			operation.Filters = append(operation.Filters, model.Filter{
+				Name: f.name, Type: enum.CustomFilter, FieldID: f.name, Operator: enum.Equals,
+			})
+		case searchParameters[name]:
+			operation.Filters = append(operation.Filters, model.Filter{
+				Name: f.name, Type: enum.TextSearch, FieldID: f.name, Operator: enum.Contains,
+			})
+		default:
+			filter, ok := fieldFilter(entity, f.name, f.label == "repeated", isEnum)
+			if !ok {
+				i.note("%s: request field %s matches no field of %s", label, f.name, entity.EntityName)
+				continue
+			}
+			operation.Filters = append(operation.Filters, filter)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+var pythonTypes = &typeSystem{
+	scalars: map[string]enum.DataType{
+		"str": enum.String, "bytes": enum.String, "UUID": enum.String, "Path": enum.String, "SecretStr": enum.String,
+		"EmailStr": enum.String, "HttpUrl": enum.String, "AnyUrl": enum.String, "AnyHttpUrl": enum.String, "constr": enum.String,
+		"int": enum.Int, "conint": enum.Int, "PositiveInt": enum.Int, "NonNegativeInt": enum.Int, "NegativeInt": enum.Int,
+		"float": enum.Float, "Decimal": enum.Float, "confloat": enum.Float, "condecimal": enum.Float, "PositiveFloat": enum.Float,
+		"bool": enum.Bool, "StrictBool": enum.Bool,
+		"datetime": enum.DateTime, "date": enum.DateTime, "time": enum.DateTime, "AwareDatetime": enum.DateTime,
+		"NaiveDatetime": enum.DateTime, "Any": enum.String, "object": enum.String,
+	},
+	collections: map[string]enum.CollectionType{
+		"list": enum.List, "List": enum.List, "Sequence": enum.List, "MutableSequence": enum.List, "Iterable": enum.List,
+		"tuple": enum.List, "Tuple": enum.List, "deque": enum.List, "conlist": enum.List,
+		"set": enum.Set, "Set": enum.Set, "frozenset": enum.Set, "FrozenSet": enum.Set, "AbstractSet": enum.Set, "conset": enum.Set,
+		"dict": enum.Map, "Dict": enum.Map, "Mapping": enum.Map, "MutableMapping": enum.Map, "OrderedDict": enum.Map,
+		"DefaultDict": enum.Map, "defaultdict": enum.Map,
+	},
+	arrays:    enum.List,
+	optionals: map[string]bool{"Optional": true, "NotRequired": true},
+	nulls:     map[string]bool{"None": true, "NoneType": true},
+	rules: map[string]enum.ValidationRuleType{
+		"EmailStr": enum.EmailRule, "HttpUrl": enum.URLRule, "AnyUrl": enum.URLRule, "AnyHttpUrl": enum.URLRule,
+	},
+	quotedNames: true,
+}
+
+var pythonEnumBases = map[string]bool{"Enum": true, "StrEnum": true, "IntEnum": true}
+
+// PythonParser reads the annotated fields of the first class of a Python source that has any, such as a dataclass,
+// a pydantic model or a TypedDict. Field(...) arguments and constrained types are read as annotations, and enums
+// declared in the source are resolved.
+type PythonParser struct{}
+
+func NewPythonParser() service.CodeParser {
+	return &PythonParser{}
+}
+
+// pythonClass is a class statement: its name, bases and the statements of its body
+type pythonClass struct {
+	name       string
+	bases      []string
+	statements [][]sourceToken
+}
+
+func (p *PythonParser) Parse(content string) (service.ParsedMetadata, error) {
+	tokens, err := lexSource(content, sourceSyntax{python: true})
+	if err != nil {
+		return service.ParsedMetadata{}, fmt.Errorf("failed to parse python code: %v", err)
+	}
+
+	c := newSourceCursor(content, tokens)
+	classes := pythonClasses(c)
+	m := newSourceModel(pythonTypes)
+	for _, class := range classes {
+		if class.isEnum() {
+			m.enums[class.name] = class.enumValues()
+		}
+	}
+
+	for _, class := range classes {
+		if class.isEnum() {
+			continue
+		}
+		metadata := service.ParsedMetadata{Name: class.name}
+		for _, statement := range class.statements {
+			if field, ok := p.field(c, m, statement); ok {
+				metadata.Fields = append(metadata.Fields, field)
+			}
+		}
+		if len(metadata.Fields) > 0 {
+			return c.result("python", metadata, nil)
+		}
+	}
+	return c.result("python", service.ParsedMetadata{}, fmt.Errorf("no class with annotated fields found in code"))
+}
+
+// field reads an annotated assignment such as name: str = Field(..., max_length=50)
+func (p *PythonParser) field(c *sourceCursor, m *sourceModel, statement []sourceToken) (service.ParsedField, bool) {
+	if len(statement) < 3 || statement[0].kind != sourceIdent || statement[1].kind != sourcePunct || statement[1].text != ":" ||
+		strings.HasPrefix(statement[0].text, "_") || statement[0].text == "model_config" {
+		return service.ParsedField{}, false
+	}
+	s := c.sub(statement).at(2)
+	typeTokens, _ := s.declaration("=")
+	t := parseTypeExpr(c, typeTokens)
+	if simpleName(t.name) == "ClassVar" {
+		return service.ParsedField{}, false
+	}
+
+	var annotations []service.ParsedAnnotation
+	optional := false
+	if s.accept("=") {
+		if d := s.peek(); d.kind == sourceIdent && (simpleName(d.text) == "Field" || simpleName(d.text) == "field") && s.peekAt(1).text == "(" {
+			annotations = append(annotations, s.annotation())
+		} else {
+			optional = true // A default value
+		}
+	}
+	return m.field(statement[0].text, c.text(typeTokens), t, annotations, optional), true
+}
+
+// pythonClasses reads the class statements of a source, nested ones included. A statement starts with a token
+// indented outside brackets; the body of a class is the statements indented deeper than it, and its members are
+// those at the indentation of the first.
+func pythonClasses(source *sourceCursor) []pythonClass {
+	tokens := source.tokens
+	var classes []pythonClass
+	for i, t := range tokens {
+		if t.indent < 0 || t.kind != sourceIdent || t.text != "class" || i+1 == len(tokens) {
+			continue
+		}
+		c := source.at(i + 1)
+		class := pythonClass{name: c.next().text}
+		if c.is("(") {
+			for _, base := range splitSourceTokens(c.inside(), ",") {
+				if len(base) > 0 {
+					class.bases = append(class.bases, simpleName(base[0].text))
+				}
+			}
+		}
+		if !c.accept(":") {
+			continue
+		}
+
+		memberIndent := -1
+		start := -1
+		for ; !c.done(); c.skip(1) {
+			indent := c.peek().indent
+			if indent < 0 {
+				continue
+			}
+			if indent <= t.indent {
+				break
+			}
+			if memberIndent < 0 {
+				memberIndent = indent
+			}
+			if start >= 0 {
+				class.statements = append(class.statements, tokens[start:c.pos])
+				start = -1
+			}
+			if indent == memberIndent {
+				start = c.pos
+			}
+		}
+		if start >= 0 {
+			class.statements = append(class.statements, tokens[start:c.pos])
+		}
+		classes = append(classes, class)
+	}
+	return classes
+}
+
+func (class pythonClass) isEnum() bool {
+	for _, base := range class.bases {
+		if pythonEnumBases[base] {
+			return true
+		}
+	}
+	return false
+}
+
+// enumValues reads the members of an enum class, preferring their string values, e.g. ACTIVE = "active"
+func (class pythonClass) enumValues() []string {
+	var values []string
+	for _, statement := range class.statements {
+		if len(statement) < 3 || statement[0].kind != sourceIdent || statement[1].text != "=" {
+			continue
+		}
+		value := statement[0].text
+		if len(statement) == 3 && statement[2].kind == sourceString {
+			value = statement[2].text
+		}
+		values = append(values, value)
+	}
+	return values
+}
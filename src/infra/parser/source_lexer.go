@@ -0,0 +1,266 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+type sourceTokenKind int
+
+const (
+	sourceIdent sourceTokenKind = iota // Names and keywords; dotted names such as java.util.List are one token
+	sourceString
+	sourceNumber
+	sourceRegex // TypeScript /.../ literal
+	sourcePunct
+)
+
+type sourceToken struct {
+	kind   sourceTokenKind
+	text   string // Strings are unquoted and unescaped, regular expressions are without slashes and flags
+	line   int
+	indent int // Column of a token starting a line outside brackets, else -1
+	start  int // Offsets in the source, to quote types as written
+	end    int
+}
+
+// sourceSyntax tells the lexical differences between the languages whose classes are parsed
+type sourceSyntax struct {
+	python          bool // # comments, triple-quoted and prefixed strings
+	regexLiterals   bool // TypeScript /.../ literals
+	backtickStrings bool // TypeScript template strings
+	verbatimStrings bool // C# @"..." and $"..." strings
+}
+
+// lexSource splits the source of a class-based language into tokens, dropping comments
+func lexSource(src string, syntax sourceSyntax) ([]sourceToken, error) {
+	var tokens []sourceToken
+	line, lineStart, depth := 1, 0, 0
+	atLineStart := true
+
+	emit := func(kind sourceTokenKind, text string, start, end int) {
+		indent := -1
+		if atLineStart && depth == 0 {
+			indent = start - lineStart
+		}
+		atLineStart = false
+		tokens = append(tokens, sourceToken{kind: kind, text: text, line: line, indent: indent, start: start, end: end})
+	}
+	newlines := func(s string, from int) {
+		if n := strings.Count(s, "\n"); n > 0 {
+			line += n
+			lineStart = from + strings.LastIndexByte(s, '\n') + 1
+		}
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			lineStart, atLineStart = i, true
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+		case syntax.python && c == '#', !syntax.python && strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case !syntax.python && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			newlines(src[i:i+end+4], i)
+			i += end + 4
+		case syntax.python && (strings.HasPrefix(src[i:], `"""`) || strings.HasPrefix(src[i:], "'''")):
+			end := strings.Index(src[i+3:], src[i:i+3])
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			emit(sourceString, src[i+3:i+3+end], i, i+end+6)
+			newlines(src[i:i+end+6], i)
+			i += end + 6
+		case c == '"' || c == '\'' || (c == '`' && syntax.backtickStrings):
+			raw, n, ok := sourceQuoted(src[i:], c, true)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			emit(sourceString, unescapeSource(raw), i, i+n)
+			newlines(src[i:i+n], i)
+			i += n
+		case syntax.verbatimStrings && (c == '@' || c == '$') && sourcePrefixedString(src[i:]) > 0:
+			prefix := sourcePrefixedString(src[i:])
+			verbatim := strings.Contains(src[i:i+prefix], "@")
+			raw, n, ok := sourceQuoted(src[i+prefix:], '"', !verbatim)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			if verbatim {
+				raw = strings.ReplaceAll(raw, `""`, `"`)
+			} else {
+				raw = unescapeSource(raw)
+			}
+			emit(sourceString, raw, i, i+prefix+n)
+			newlines(src[i:i+prefix+n], i)
+			i += prefix + n
+		case c == '/' && syntax.regexLiterals && regexAllowed(tokens) && i+1 < len(src) && src[i+1] != '/' && src[i+1] != '*':
+			end, ok := regexEnd(src[i:])
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated regular expression", line)
+			}
+			pattern := src[i+1 : i+end]
+			for end++; i+end < len(src) && isSourceIdentByte(src[i+end]); end++ {
+			}
+			emit(sourceRegex, pattern, i, i+end)
+			i += end
+		case isSourceIdentStart(c):
+			start := i
+			for i < len(src) && (isSourceIdentByte(src[i]) || (src[i] == '.' && i+1 < len(src) && isSourceIdentStart(src[i+1]))) {
+				i++
+			}
+			word := src[start:i]
+			if syntax.python && i < len(src) && (src[i] == '"' || src[i] == '\'') && isPythonStringPrefix(word) {
+				// A prefixed string such as r"^\d+$"; raw strings keep their backslashes
+				raw, n, ok := sourceQuoted(src[i:], src[i], true)
+				if !ok {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				if !strings.ContainsAny(word, "rR") {
+					raw = unescapeSource(raw)
+				}
+				emit(sourceString, raw, start, i+n)
+				i += n
+				continue
+			}
+			emit(sourceIdent, word, start, i)
+		case isDigit(c):
+			start := i
+			for i < len(src) && (isSourceIdentByte(src[i]) || src[i] == '.' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			emit(sourceNumber, src[start:i], start, i)
+		case strings.HasPrefix(src[i:], "..."):
+			emit(sourcePunct, "...", i, i+3)
+			i += 3
+		case strings.HasPrefix(src[i:], "=>"):
+			emit(sourcePunct, "=>", i, i+2)
+			i += 2
+		default:
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+			emit(sourcePunct, string(c), i, i+1)
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isSourceIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isSourceIdentByte(c byte) bool {
+	return isSourceIdentStart(c) || isDigit(c)
+}
+
+func isPythonStringPrefix(word string) bool {
+	switch strings.ToLower(word) {
+	case "r", "b", "f", "u", "rb", "br", "fr", "rf":
+		return true
+	}
+	return false
+}
+
+// sourcePrefixedString returns the length of a C# @, $ or $@ prefix opening a string, or 0
+func sourcePrefixedString(s string) int {
+	for _, prefix := range []string{`$@"`, `@$"`, `@"`, `$"`} {
+		if strings.HasPrefix(s, prefix) {
+			return len(prefix) - 1
+		}
+	}
+	return 0
+}
+
+// sourceQuoted reads a string opened by the quote at the start of s, returning its text as written
+// and the length read including the quotes
+func sourceQuoted(s string, quote byte, backslashEscapes bool) (string, int, bool) {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && backslashEscapes:
+			i++
+		case s[i] == quote && quote == '"' && !backslashEscapes && i+1 < len(s) && s[i+1] == '"':
+			i++ // "" in a verbatim string
+		case s[i] == quote:
+			return s[1:i], i + 1, true
+		case s[i] == '\n' && quote != '`' && backslashEscapes:
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+// unescapeSource resolves the common backslash escapes; others are kept as written
+func unescapeSource(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"', '\'', '`':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// regexAllowed tells a regular expression literal from a division by the token before it
+func regexAllowed(tokens []sourceToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == sourcePunct && strings.Contains("(,=:[!&|?{};", last.text)
+}
+
+// regexEnd returns the offset of the slash closing the regular expression literal at the start of s
+func regexEnd(s string) (int, bool) {
+	inClass := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				return i, true
+			}
+		case '\n':
+			return 0, false
+		}
+	}
+	return 0, false
+}
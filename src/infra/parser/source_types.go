@@ -0,0 +1,681 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+// sourceCursor walks the tokens of a TypeScript, Java, C# or Python source. It never moves past the end of its
+// tokens; unclosed brackets and missing names are kept as the first syntax error of the source.
+type sourceCursor struct {
+	src    string
+	tokens []sourceToken
+	pos    int
+	err    *error // Shared by the cursors walking parts of the same source
+}
+
+func newSourceCursor(src string, tokens []sourceToken) *sourceCursor {
+	return &sourceCursor{src: src, tokens: tokens, err: new(error)}
+}
+
+// sub walks part of the tokens of c, reporting its syntax errors with those of c
+func (c *sourceCursor) sub(tokens []sourceToken) *sourceCursor {
+	return &sourceCursor{src: c.src, tokens: tokens, err: c.err}
+}
+
+// at returns a cursor over the same tokens from the given position
+func (c *sourceCursor) at(pos int) *sourceCursor {
+	sub := c.sub(c.tokens)
+	sub.skip(pos)
+	return sub
+}
+
+func (c *sourceCursor) fail(format string, args ...interface{}) {
+	if *c.err != nil {
+		return
+	}
+	line := 1
+	if len(c.tokens) > 0 {
+		line = c.tokens[min(c.pos, len(c.tokens)-1)].line
+	}
+	*c.err = fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// syntaxError returns the first syntax error met while walking the source
+func (c *sourceCursor) syntaxError() error {
+	return *c.err
+}
+
+// result returns the metadata read from the source, or the first syntax error met reading it before err
+func (c *sourceCursor) result(language string, metadata service.ParsedMetadata, err error) (service.ParsedMetadata, error) {
+	if syntaxErr := c.syntaxError(); syntaxErr != nil {
+		return service.ParsedMetadata{}, fmt.Errorf("failed to parse %s code: %v", language, syntaxErr)
+	}
+	if err != nil {
+		return service.ParsedMetadata{}, err
+	}
+	return metadata, nil
+}
+
+func (c *sourceCursor) done() bool {
+	return c.pos >= len(c.tokens)
+}
+
+func (c *sourceCursor) skip(n int) {
+	c.pos = min(c.pos+n, len(c.tokens))
+}
+
+func (c *sourceCursor) peekAt(n int) sourceToken {
+	if c.pos+n < len(c.tokens) {
+		return c.tokens[c.pos+n]
+	}
+	return sourceToken{kind: sourcePunct, indent: -1}
+}
+
+func (c *sourceCursor) peek() sourceToken {
+	return c.peekAt(0)
+}
+
+func (c *sourceCursor) next() sourceToken {
+	t := c.peek()
+	c.skip(1)
+	return t
+}
+
+// is tells whether the token at the cursor is the given keyword or punctuation, strings never match
+func (c *sourceCursor) is(text string) bool {
+	t := c.peek()
+	return !c.done() && t.text == text && t.kind != sourceString && t.kind != sourceRegex
+}
+
+func (c *sourceCursor) accept(text string) bool {
+	if c.is(text) {
+		c.skip(1)
+		return true
+	}
+	return false
+}
+
+// describe names the token at the cursor for an error message
+func (c *sourceCursor) describe() string {
+	if c.done() {
+		return "end of code"
+	}
+	return strconv.Quote(c.peek().text)
+}
+
+var closingBrackets = map[string]string{"(": ")", "[": "]", "{": "}", "<": ">"}
+
+// inside returns the tokens between the bracket at the cursor and the one closing it, moving past both
+func (c *sourceCursor) inside() []sourceToken {
+	open := c.peek()
+	closing, ok := closingBrackets[open.text]
+	if !ok || open.kind != sourcePunct || c.done() {
+		c.fail("expected a bracket, found %s", c.describe())
+		return nil
+	}
+	c.skip(1)
+	start, depth := c.pos, 1
+	for !c.done() {
+		t := c.next()
+		if t.kind != sourcePunct {
+			continue
+		}
+		switch t.text {
+		case open.text:
+			depth++
+		case closing:
+			if depth--; depth == 0 {
+				return c.tokens[start : c.pos-1]
+			}
+		}
+	}
+	c.fail("%s opened on line %d is not closed", open.text, open.line)
+	return c.tokens[start:]
+}
+
+// skipStatement moves past the member at the cursor, up to its semicolon or past its body such as a method's.
+// It stops before the brace closing the enclosing block.
+func (c *sourceCursor) skipStatement() {
+	for !c.done() {
+		switch {
+		case c.is(";"):
+			c.skip(1)
+			return
+		case c.is("}"):
+			return
+		case c.is("{"):
+			c.inside()
+			return
+		case c.is("(") || c.is("["):
+			c.inside()
+		default:
+			c.skip(1)
+		}
+	}
+}
+
+// annotation reads an annotation or decorator such as @Size(max = 10), the cursor being on its name
+func (c *sourceCursor) annotation() service.ParsedAnnotation {
+	if c.peek().kind != sourceIdent {
+		c.fail("expected an annotation name, found %s", c.describe())
+		return service.ParsedAnnotation{}
+	}
+	a := service.ParsedAnnotation{Name: simpleName(c.next().text)}
+	if c.is("(") {
+		a.Arguments = parseArguments(c.inside())
+	}
+	return a
+}
+
+// text returns the source of the tokens as written, with its spacing collapsed
+func (c *sourceCursor) text(tokens []sourceToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(c.src[tokens[0].start:tokens[len(tokens)-1].end]), " ")
+}
+
+// splitSourceTokens splits tokens on a punctuation outside brackets, e.g. the arguments of a call on commas
+func splitSourceTokens(tokens []sourceToken, separator string) [][]sourceToken {
+	var parts [][]sourceToken
+	start, depth := 0, 0
+	for i, t := range tokens {
+		if t.kind != sourcePunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case separator:
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// parseArguments reads the arguments of an annotation or call, positional ones keyed by their position
+func parseArguments(tokens []sourceToken) map[string]string {
+	args := map[string]string{}
+	position := 0
+	for _, part := range splitSourceTokens(tokens, ",") {
+		switch {
+		case len(part) == 0:
+		case len(part) > 2 && part[0].kind == sourceIdent && part[1].kind == sourcePunct && part[1].text == "=":
+			args[part[0].text] = argumentText(part[2:])
+		default:
+			args[strconv.Itoa(position)] = argumentText(part)
+			position++
+		}
+	}
+	return args
+}
+
+func argumentText(tokens []sourceToken) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+func simpleName(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+// typeExpr is a type as written in a field declaration, e.g. Map<String, List<Item>>, string | null or Optional[int]
+type typeExpr struct {
+	name     string // Possibly qualified, e.g. java.util.List; empty for unions, literals and unreadable types
+	args     []*typeExpr
+	arrays   int  // Array suffixes, e.g. 2 for int[][]
+	nullable bool // C# int?
+	union    []*typeExpr
+	literal  *string                   // Value of a literal type such as 'draft' in TypeScript
+	call     *service.ParsedAnnotation // A type that is a call, e.g. constr(max_length=5), or Field(...) inside Annotated
+}
+
+func parseTypeExpr(c *sourceCursor, tokens []sourceToken) *typeExpr {
+	sub := c.sub(tokens)
+	return sub.typeUnion()
+}
+
+func (c *sourceCursor) typeUnion() *typeExpr {
+	c.accept("|") // A union written over several lines may start with a bar
+	union := []*typeExpr{c.typePostfix()}
+	for {
+		switch {
+		case c.accept("|"):
+			union = append(union, c.typePostfix())
+		case c.accept("&"):
+			c.typePostfix() // An intersection is read as its first type
+		case len(union) == 1:
+			return union[0]
+		default:
+			return &typeExpr{union: union}
+		}
+	}
+}
+
+func (c *sourceCursor) typePostfix() *typeExpr {
+	t := c.typePrimary()
+	for {
+		switch {
+		case c.is("[") && c.peekAt(1).text == "]":
+			c.skip(2)
+			t.arrays++
+		case c.accept("?"):
+			t.nullable = true
+		default:
+			return t
+		}
+	}
+}
+
+func (c *sourceCursor) typePrimary() *typeExpr {
+	if c.done() {
+		return &typeExpr{}
+	}
+	switch t := c.peek(); {
+	case t.kind == sourceString || t.kind == sourceNumber:
+		c.skip(1)
+		return &typeExpr{literal: &t.text}
+	case c.is("("):
+		inner := c.inside()
+		if c.accept("=>") {
+			c.typePostfix() // A function type
+			return &typeExpr{}
+		}
+		return parseTypeExpr(c, inner)
+	case c.is("{"):
+		c.inside()
+		return &typeExpr{name: "object"}
+	case c.is("["):
+		c.inside()
+		return &typeExpr{name: "Array"} // A TypeScript tuple
+	case c.accept("?"):
+		if c.accept("extends") || c.accept("super") {
+			return c.typePostfix()
+		}
+		return &typeExpr{name: "Object"}
+	case c.accept("@"):
+		c.annotation() // Java annotations on a type argument
+		return c.typePrimary()
+	case t.kind == sourceIdent && (t.text == "readonly" || t.text == "keyof" || t.text == "typeof" || t.text == "unique"):
+		c.skip(1)
+		return c.typePostfix()
+	case t.kind == sourceIdent:
+		c.skip(1)
+		expr := &typeExpr{name: t.text}
+		switch {
+		case c.accept("<"):
+			expr.args = c.typeArgs(">")
+		case c.is("[") && c.peekAt(1).text != "]":
+			c.skip(1)
+			expr.args = c.typeArgs("]")
+		case c.is("("):
+			expr.call = &service.ParsedAnnotation{Name: simpleName(t.text), Arguments: parseArguments(c.inside())}
+		}
+		return expr
+	}
+	c.skip(1)
+	return &typeExpr{}
+}
+
+func (c *sourceCursor) typeArgs(closing string) []*typeExpr {
+	var args []*typeExpr
+	for !c.done() && !c.accept(closing) {
+		if c.accept(",") {
+			continue
+		}
+		args = append(args, c.typeUnion())
+	}
+	return args
+}
+
+// typeSystem maps the type names of a language onto field types
+type typeSystem struct {
+	scalars     map[string]enum.DataType
+	collections map[string]enum.CollectionType
+	arrays      enum.CollectionType // What T[] is: an array in Java and C#, a list in TypeScript
+	optionals   map[string]bool     // Wrappers of a type that may be missing, e.g. Optional<T>
+	nulls       map[string]bool     // Types of a missing value, e.g. null | undefined
+	rules       map[string]enum.ValidationRuleType
+	quotedNames bool // Quoted types are forward references, not literals, as in Python
+}
+
+// fieldShape is what a field type maps onto
+type fieldShape struct {
+	dataType       enum.DataType
+	collection     enum.CollectionType
+	itemType       enum.CollectionItemType
+	nestedItemType enum.CollectionItemType
+	reference      string
+	enumValues     []string
+	optional       bool
+	annotations    []service.ParsedAnnotation // Carried by the type, e.g. Annotated[str, Field(max_length=5)]
+	validations    []model.InputValidation    // Implied by the type, e.g. pydantic's EmailStr
+}
+
+func plainShape(dataType enum.DataType) fieldShape {
+	return fieldShape{dataType: dataType, collection: enum.None, itemType: enum.NoType, nestedItemType: enum.NoType}
+}
+
+// maxTypeDepth bounds the resolution of nested types and aliases, which may refer to themselves
+const maxTypeDepth = 16
+
+// sourceModel is what a source declares besides the class being read: its enums and type aliases
+type sourceModel struct {
+	system  *typeSystem
+	enums   map[string][]string
+	aliases map[string]*typeExpr
+}
+
+func newSourceModel(system *typeSystem) *sourceModel {
+	return &sourceModel{system: system, enums: map[string][]string{}, aliases: map[string]*typeExpr{}}
+}
+
+// field maps a declaration onto a parsed field. Optional is what the declaration itself tells, such as
+// TypeScript's name?: or a Python default; the type and the annotations may still change it.
+func (m *sourceModel) field(name, typeText string, t *typeExpr, annotations []service.ParsedAnnotation, optional bool) service.ParsedField {
+	s := m.resolve(t, 0)
+	f := service.ParsedField{
+		Name:                     name,
+		Type:                     typeText,
+		FieldType:                s.dataType,
+		IsOptional:               optional || s.optional,
+		IsCollection:             s.collection != enum.None,
+		CollectionType:           s.collection,
+		CollectionItemType:       s.itemType,
+		NestedCollectionItemType: s.nestedItemType,
+		Reference:                s.reference,
+		EnumValues:               s.enumValues,
+		Annotations:              append(s.annotations, annotations...),
+		InputValidations:         s.validations,
+	}
+	applyAnnotations(&f)
+	return f
+}
+
+func (m *sourceModel) resolve(t *typeExpr, depth int) fieldShape {
+	s := plainShape(enum.String)
+	switch {
+	case t == nil || depth > maxTypeDepth:
+		return s
+	case t.arrays > 0:
+		item := *t
+		item.arrays, item.nullable = item.arrays-1, false
+		s = m.collection(m.system.arrays, m.resolve(&item, depth+1))
+	case t.union != nil:
+		s = m.union(t.union, depth)
+	case t.literal != nil && m.system.quotedNames:
+		s = m.resolve(&typeExpr{name: *t.literal}, depth+1)
+	case t.literal != nil:
+		s.dataType, s.enumValues = enum.Enum, []string{*t.literal}
+	default:
+		s = m.named(t, depth)
+	}
+	if t.nullable {
+		s.optional = true
+	}
+	return s
+}
+
+// union reads null members as optionality and unions of literals as enums
+func (m *sourceModel) union(members []*typeExpr, depth int) fieldShape {
+	var rest []*typeExpr
+	optional := false
+	for _, member := range members {
+		if member.literal == nil && m.system.nulls[member.name] {
+			optional = true
+		} else {
+			rest = append(rest, member)
+		}
+	}
+
+	s := plainShape(enum.String) // A union of different types, e.g. string | number
+	if values, ok := literalValues(rest); ok && len(rest) > 1 {
+		s.dataType, s.enumValues = enum.Enum, values
+	} else if len(rest) == 1 {
+		s = m.resolve(rest[0], depth+1)
+	}
+	s.optional = s.optional || optional
+	return s
+}
+
+func (m *sourceModel) named(t *typeExpr, depth int) fieldShape {
+	name := simpleName(t.name)
+	var first *typeExpr
+	if len(t.args) > 0 {
+		first = t.args[0]
+	}
+
+	switch {
+	case m.system.optionals[name]:
+		s := m.resolve(first, depth+1)
+		s.optional = true
+		return s
+	case name == "Union":
+		return m.union(t.args, depth)
+	case name == "Literal":
+		s := plainShape(enum.Enum)
+		s.enumValues, _ = literalValues(t.args)
+		return s
+	case name == "Annotated":
+		s := m.resolve(first, depth+1)
+		for _, arg := range t.args[min(1, len(t.args)):] {
+			if arg.call != nil {
+				s.annotations = append(s.annotations, *arg.call)
+			}
+		}
+		return s
+	}
+
+	if collection, ok := m.system.collections[name]; ok {
+		item := first
+		if collection == enum.Map && len(t.args) > 1 {
+			item = t.args[len(t.args)-1]
+		}
+		s := m.collection(collection, m.resolve(item, depth+1))
+		if t.call != nil {
+			s.annotations = []service.ParsedAnnotation{*t.call}
+		}
+		return s
+	}
+	if values, ok := m.enums[name]; ok {
+		s := plainShape(enum.Enum)
+		s.enumValues = values
+		return s
+	}
+	if alias, ok := m.aliases[name]; ok {
+		return m.resolve(alias, depth+1)
+	}
+
+	s := plainShape(enum.String)
+	if dataType, ok := m.system.scalars[name]; ok || name == "" {
+		s.dataType = dataType
+		if rule, ok := m.system.rules[name]; ok {
+			s.validations = []model.InputValidation{{RuleType: rule}}
+		}
+		if t.call != nil {
+			s.annotations = []service.ParsedAnnotation{*t.call}
+		}
+		return s
+	}
+	s.dataType, s.reference = enum.Entity, name
+	return s
+}
+
+func (m *sourceModel) collection(collection enum.CollectionType, item fieldShape) fieldShape {
+	s := plainShape(enum.Collection)
+	s.collection, s.reference = collection, item.reference
+	switch {
+	case item.collection != enum.None:
+		s.itemType, s.nestedItemType = enum.NestedCollectionType, item.itemType
+	case item.dataType == enum.Entity:
+		s.itemType = enum.OtherEntityType
+	default:
+		s.itemType, s.enumValues = collectionItemTypes[item.dataType], item.enumValues
+	}
+	return s
+}
+
+func literalValues(types []*typeExpr) ([]string, bool) {
+	var values []string
+	for _, t := range types {
+		if t.literal == nil {
+			return nil, false
+		}
+		values = append(values, *t.literal)
+	}
+	return values, len(values) > 0
+}
+
+// applyAnnotations reads optionality and input validations from the annotations of a field: Bean Validation,
+// DataAnnotations, class-validator, pydantic's Field and go-playground validator tags
+func applyAnnotations(f *service.ParsedField) {
+	for _, a := range f.Annotations {
+		args := a.Arguments
+		switch a.Name {
+		case "NotNull", "NonNull", "Nonnull", "Required", "IsNotEmpty", "IsDefined":
+			f.IsOptional = false
+		case "NotBlank", "NotEmpty":
+			f.IsOptional = false
+			addValidation(f, enum.LengthRule, parseNumber("1"), nil)
+		case "Nullable", "IsOptional":
+			f.IsOptional = true
+		case "Size", "Length":
+			addValidation(f, enum.LengthRule, argNumber(args, "min", "0"), argNumber(args, "max", "1"))
+		case "StringLength":
+			addValidation(f, enum.LengthRule, argNumber(args, "MinimumLength"), argNumber(args, "0", "MaximumLength"))
+		case "MinLength", "ArrayMinSize":
+			addValidation(f, enum.LengthRule, argNumber(args, "0", "value", "length"), nil)
+		case "MaxLength", "ArrayMaxSize":
+			addValidation(f, enum.LengthRule, nil, argNumber(args, "0", "value", "length"))
+		case "Min", "DecimalMin":
+			addValidation(f, enum.RangeRule, argNumber(args, "0", "value"), nil)
+		case "Max", "DecimalMax":
+			addValidation(f, enum.RangeRule, nil, argNumber(args, "0", "value"))
+		case "Range":
+			addValidation(f, enum.RangeRule, argNumber(args, "min", "0", "Minimum"), argNumber(args, "max", "1", "Maximum"))
+		case "Pattern", "RegularExpression", "Matches":
+			if pattern := argText(args, "regexp", "0", "Pattern"); pattern != "" {
+				addRule(f, model.InputValidation{RuleType: enum.PatternRule, Pattern: pattern})
+			}
+		case "Email", "EmailAddress", "IsEmail":
+			addRule(f, model.InputValidation{RuleType: enum.EmailRule})
+		case "URL", "Url", "IsUrl", "IsURL":
+			addRule(f, model.InputValidation{RuleType: enum.URLRule})
+		case "Field", "field", "constr", "conint", "confloat", "condecimal", "conlist", "conset":
+			applyPydanticArguments(f, args)
+		case "validate", "binding":
+			applyValidatorTag(f, args)
+		}
+	}
+}
+
+// applyPydanticArguments reads the arguments of pydantic's Field and constrained types, and of dataclass fields
+func applyPydanticArguments(f *service.ParsedField, args map[string]string) {
+	if value, ok := args["0"]; ok {
+		f.IsOptional = value != "..." // Field(...) is required, Field(value) has a default
+	}
+	if _, ok := args["default"]; ok {
+		f.IsOptional = true
+	}
+	if _, ok := args["default_factory"]; ok {
+		f.IsOptional = true
+	}
+	addValidation(f, enum.LengthRule, argNumber(args, "min_length", "min_items"), argNumber(args, "max_length", "max_items"))
+	addValidation(f, enum.RangeRule, argNumber(args, "ge", "gt"), argNumber(args, "le", "lt"))
+	if pattern := argText(args, "pattern", "regex"); pattern != "" {
+		addRule(f, model.InputValidation{RuleType: enum.PatternRule, Pattern: pattern})
+	}
+}
+
+// applyValidatorTag reads a validate or binding struct tag, whose rules are the annotation's arguments. Bounds are
+// lengths for strings and collections and values for numbers.
+func applyValidatorTag(f *service.ParsedField, args map[string]string) {
+	bounds := enum.RangeRule
+	if f.FieldType == enum.String || f.IsCollection {
+		bounds = enum.LengthRule
+	}
+	if _, ok := args["required"]; ok {
+		f.IsOptional = false
+	}
+	addValidation(f, bounds, argNumber(args, "min", "gte", "gt", "len"), argNumber(args, "max", "lte", "lt", "len"))
+	if _, ok := args["email"]; ok {
+		addRule(f, model.InputValidation{RuleType: enum.EmailRule})
+	}
+	if _, ok := args["url"]; ok {
+		addRule(f, model.InputValidation{RuleType: enum.URLRule})
+	}
+	if values := strings.Fields(args["oneof"]); len(values) > 0 {
+		addRule(f, model.InputValidation{RuleType: enum.OneOfRule, AllowedValues: values})
+	}
+}
+
+// addValidation adds a length or range rule, merging it with one already read so that @Min and @Max make one range
+func addValidation(f *service.ParsedField, ruleType enum.ValidationRuleType, min, max *float64) {
+	if min == nil && max == nil {
+		return
+	}
+	for i := range f.InputValidations {
+		if rule := &f.InputValidations[i]; rule.RuleType == ruleType {
+			if min != nil {
+				rule.Min = min
+			}
+			if max != nil {
+				rule.Max = max
+			}
+			return
+		}
+	}
+	f.InputValidations = append(f.InputValidations, model.InputValidation{RuleType: ruleType, Min: min, Max: max})
+}
+
+// addRule adds a rule unless the field already has one of its type, e.g. from EmailStr and @Email
+func addRule(f *service.ParsedField, rule model.InputValidation) {
+	for _, existing := range f.InputValidations {
+		if existing.RuleType == rule.RuleType {
+			return
+		}
+	}
+	f.InputValidations = append(f.InputValidations, rule)
+}
+
+func argText(args map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := args[key]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func argNumber(args map[string]string, keys ...string) *float64 {
+	for _, key := range keys {
+		if value, ok := args[key]; ok {
+			return parseNumber(value)
+		}
+	}
+	return nil
+}
+
+// parseNumber reads a number literal of any of the languages, e.g. 10L, 0.5m or 1_000
+func parseNumber(s string) *float64 {
+	s = strings.TrimRight(strings.ReplaceAll(s, "_", ""), "lLfFdDmM")
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
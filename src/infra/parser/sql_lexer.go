@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+type sqlTokenKind int
+
+const (
+	sqlWord   sqlTokenKind = iota // Keyword or bare identifier
+	sqlQuoted                     // "identifier" or `identifier`
+	sqlString                     // 'literal'
+	sqlNumber
+	sqlPunct // ( ) , ; . and operators
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string // Unquoted text of identifiers and literals
+	line int
+}
+
+// is reports whether the token is the keyword, ignoring case
+func (t sqlToken) is(keyword string) bool {
+	return t.kind == sqlWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t sqlToken) isPunct(p string) bool {
+	return t.kind == sqlPunct && t.text == p
+}
+
+// isIdent reports whether the token can name a table or column
+func (t sqlToken) isIdent() bool {
+	return t.kind == sqlWord || t.kind == sqlQuoted
+}
+
+// lexSQL splits a script into tokens, dropping -- and /* */ comments. MySQL also has # comments
+// and backslash escapes in string literals.
+func lexSQL(src string, mysql bool) ([]sqlToken, error) {
+	var tokens []sqlToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(src[i:], "--"), c == '#' && mysql:
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			text, n, ok := readQuoted(src[i:], c, mysql && c == '\'')
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated %c", line, c)
+			}
+			kind := sqlQuoted
+			if c == '\'' {
+				kind = sqlString
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: text, line: line})
+			line += strings.Count(src[i:i+n], "\n")
+			i += n
+		case c == '$' && !mysql && dollarTag(src[i:]) != "":
+			// Postgres dollar quoting of function bodies, which may hold semicolons
+			tag := dollarTag(src[i:])
+			end := strings.Index(src[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated %s", line, tag)
+			}
+			text := src[i+len(tag) : i+len(tag)+end]
+			tokens = append(tokens, sqlToken{kind: sqlString, text: text, line: line})
+			line += strings.Count(text, "\n")
+			i += len(tag) + end + len(tag)
+		case (c == 'E' || c == 'e' || c == 'N' || c == 'n') && i+1 < len(src) && src[i+1] == '\'':
+			// Postgres escape strings and national character literals
+			i++
+		case isWordByte(c):
+			start := i
+			for i < len(src) && (isWordByte(src[i]) || (src[i] >= '0' && src[i] <= '9') || src[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: src[start:i], line: line})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == 'e' || src[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlNumber, text: src[start:i], line: line})
+		default:
+			p := string(c)
+			for _, op := range []string{"::", "<=", ">=", "<>", "!=", "||"} {
+				if strings.HasPrefix(src[i:], op) {
+					p = op
+					break
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: sqlPunct, text: p, line: line})
+			i += len(p)
+		}
+	}
+	return tokens, nil
+}
+
+// readQuoted reads a quoted text starting at s[0]; a doubled closing character escapes it
+func readQuoted(s string, closing byte, backslashEscapes bool) (string, int, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' && backslashEscapes && i+1 < len(s) {
+			b.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if s[i] == closing {
+			if i+1 < len(s) && s[i+1] == closing {
+				b.WriteByte(closing)
+				i++
+				continue
+			}
+			return b.String(), i + 1, true
+		}
+		b.WriteByte(s[i])
+	}
+	return "", 0, false
+}
+
+// dollarTag returns the opening $tag$ or $$ at the start of s, or "" when there is none
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1]
+		}
+		if !isWordByte(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// splitStatements splits tokens at the semicolons ending each statement
+func splitStatements(tokens []sqlToken) [][]sqlToken {
+	var statements [][]sqlToken
+	start := 0
+	for i, t := range tokens {
+		if t.isPunct(";") {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+	return statements
+}
+
+// splitTopLevel splits tokens at the commas outside parentheses
+func splitTopLevel(tokens []sqlToken) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, start := 0, 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case t.isPunct(",") && depth == 0:
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// parenthesized returns the tokens inside the parentheses opening at tokens[0], and the index after them
+func parenthesized(tokens []sqlToken) ([]sqlToken, int, bool) {
+	if len(tokens) == 0 || !tokens[0].isPunct("(") {
+		return nil, 0, false
+	}
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+			if depth == 0 {
+				return tokens[1:i], i + 1, true
+			}
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,1110 @@
+package parser
+
+import (
+	"fmt"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"strings"
+)
+
+// SQLParser reads the CREATE TABLE scripts of a Postgres or MySQL database into entities
+type SQLParser struct {
+	dialect enum.PreferredDB
+}
+
+// NewSQLParser creates a parser for scripts of the dialect, enum.Postgres or enum.Mysql
+func NewSQLParser(dialect enum.PreferredDB) service.SchemaParser {
+	return &SQLParser{dialect: dialect}
+}
+
+type sqlType struct {
+	name  string   // Lower case without arguments, e.g. varchar or double precision
+	args  []string // Literal arguments, e.g. 255 of varchar(255) or the values of enum('a','b')
+	array bool
+}
+
+type sqlColumn struct {
+	name      string
+	dataType  sqlType
+	notNull   bool
+	unique    bool
+	generated bool // Filled by the database: serial, identity, AUTO_INCREMENT or a generated column
+	computed  bool // Generated from other columns
+	comment   string
+	values    []string // Allowed values of a CHECK (column IN (...)) constraint
+}
+
+type sqlForeignKey struct {
+	column string
+	table  string
+}
+
+type sqlTable struct {
+	name        string
+	comment     string
+	columns     []*sqlColumn
+	primaryKey  []string
+	uniques     [][]string
+	foreignKeys []sqlForeignKey
+	checks      [][]sqlToken
+}
+
+// sqlSchema collects the tables and types of a script. Keys and checks are resolved once the whole
+// script is read, so they may be declared before the columns they constrain.
+type sqlSchema struct {
+	mysql    bool
+	tables   []*sqlTable
+	enums    map[string][]string // Postgres enum types by lower case name
+	unmapped []string
+}
+
+func (p *SQLParser) ParseSchema(content string) (service.ParsedSchema, error) {
+	schema := &sqlSchema{mysql: p.dialect == enum.Mysql, enums: map[string][]string{}}
+	tokens, err := lexSQL(content, schema.mysql)
+	if err != nil {
+		return service.ParsedSchema{}, fmt.Errorf("failed to parse sql: %v", err)
+	}
+	for _, statement := range splitStatements(tokens) {
+		if err := schema.statement(&sqlCursor{tokens: statement}); err != nil {
+			return service.ParsedSchema{}, fmt.Errorf("failed to parse sql at line %d: %v", statement[0].line, err)
+		}
+	}
+	if len(schema.tables) == 0 {
+		return service.ParsedSchema{}, fmt.Errorf("no CREATE TABLE statement found in sql")
+	}
+
+	entities := schema.entities(p.dialect)
+	return service.ParsedSchema{Entities: entities, Unmapped: schema.unmapped}, nil
+}
+
+// ignoredStatements change data, sessions or permissions rather than the shape of the tables
+var ignoredStatements = []string{
+	"SET", "USE", "BEGIN", "START", "COMMIT", "ROLLBACK", "END", "DROP", "INSERT", "UPDATE", "DELETE",
+	"SELECT", "GRANT", "REVOKE", "LOCK", "UNLOCK", "TRUNCATE", "ANALYZE", "VACUUM", "DELIMITER",
+}
+
+func (s *sqlSchema) statement(c *sqlCursor) error {
+	first := c.peek()
+	for _, keyword := range ignoredStatements {
+		if first.is(keyword) {
+			return nil
+		}
+	}
+
+	switch {
+	case c.accept("CREATE"):
+		c.accept("OR", "REPLACE")
+		for c.accept("TEMPORARY") || c.accept("TEMP") || c.accept("UNLOGGED") || c.accept("GLOBAL") || c.accept("LOCAL") {
+		}
+		switch {
+		case c.accept("TABLE"):
+			return s.createTable(c)
+		case c.accept("TYPE"):
+			return s.createType(c)
+		case c.accept("UNIQUE", "INDEX"):
+			return s.createUniqueIndex(c)
+		case c.accept("INDEX"), c.accept("EXTENSION"), c.accept("SEQUENCE"), c.accept("SCHEMA"), c.accept("DATABASE"):
+			return nil
+		}
+	case c.accept("ALTER", "TABLE"):
+		return s.alterTable(c)
+	case c.accept("ALTER"):
+		if c.accept("SEQUENCE") || c.accept("SCHEMA") || c.accept("DATABASE") || c.accept("EXTENSION") ||
+			c.accept("TYPE") || c.accept("FUNCTION") {
+			return nil
+		}
+	case c.accept("COMMENT", "ON"):
+		return s.commentOn(c)
+	}
+	kind := strings.ToUpper(first.text)
+	if first.is("CREATE") || first.is("ALTER") {
+		kind += " " + strings.ToUpper(c.peek().text)
+	}
+	s.note("%s statement at line %d", kind, first.line)
+	return nil
+}
+
+func (s *sqlSchema) createTable(c *sqlCursor) error {
+	c.accept("IF", "NOT", "EXISTS")
+	name, err := c.name()
+	if err != nil {
+		return err
+	}
+	if !c.peek().isPunct("(") {
+		s.note("table %s is not declared with columns (CREATE TABLE AS, LIKE or PARTITION OF)", name)
+		return nil
+	}
+	if s.table(name) != nil {
+		return fmt.Errorf("table %s is created twice", name)
+	}
+	body, err := c.group()
+	if err != nil {
+		return err
+	}
+
+	table := &sqlTable{name: name}
+	s.tables = append(s.tables, table)
+	for _, element := range splitTopLevel(body) {
+		if len(element) == 0 {
+			continue
+		}
+		if err := s.tableElement(table, &sqlCursor{tokens: element}); err != nil {
+			return err
+		}
+	}
+
+	// Table options: MySQL's ENGINE, CHARSET and COMMENT, Postgres' INHERITS, WITH and TABLESPACE
+	for !c.done() {
+		switch {
+		case c.accept("COMMENT"):
+			c.acceptPunct("=")
+			if t := c.next(); t.kind == sqlString {
+				table.comment = t.text
+			}
+		case c.accept("INHERITS"):
+			parents, err := c.group()
+			if err != nil {
+				return err
+			}
+			s.note("table %s inherits from %s", name, tokenTexts(parents))
+		default:
+			c.next()
+		}
+	}
+	return nil
+}
+
+// tableElement reads a column definition or a table constraint
+func (s *sqlSchema) tableElement(table *sqlTable, c *sqlCursor) error {
+	if c.accept("CONSTRAINT") {
+		if _, err := c.name(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case c.accept("PRIMARY", "KEY"):
+		columns, err := c.columns()
+		if err != nil {
+			return err
+		}
+		table.primaryKey = columns
+	case c.accept("UNIQUE"):
+		if !c.accept("KEY") {
+			c.accept("INDEX")
+		}
+		if !c.peek().isPunct("(") {
+			if _, err := c.name(); err != nil {
+				return err
+			}
+		}
+		columns, err := c.columns()
+		if err != nil {
+			return err
+		}
+		table.uniques = append(table.uniques, columns)
+	case c.accept("FOREIGN", "KEY"):
+		if !c.peek().isPunct("(") {
+			if _, err := c.name(); err != nil {
+				return err
+			}
+		}
+		columns, err := c.columns()
+		if err != nil {
+			return err
+		}
+		return s.references(table, columns, c)
+	case c.accept("CHECK"):
+		check, err := c.group()
+		if err != nil {
+			return err
+		}
+		table.checks = append(table.checks, check)
+	case c.accept("KEY"), c.accept("INDEX"), c.accept("FULLTEXT"), c.accept("SPATIAL"):
+		// MySQL secondary indexes speed up queries without constraining the fields
+	case c.accept("EXCLUDE"), c.accept("LIKE"):
+		s.note("%s clause of table %s", strings.ToUpper(c.tokens[c.pos-1].text), table.name)
+	default:
+		return s.column(table, c)
+	}
+	return nil
+}
+
+// references reads the REFERENCES clause of a foreign key on the columns
+func (s *sqlSchema) references(table *sqlTable, columns []string, c *sqlCursor) error {
+	if !c.accept("REFERENCES") {
+		return fmt.Errorf("expected REFERENCES after the foreign key of table %s, found %q", table.name, c.peek().text)
+	}
+	target, err := c.name()
+	if err != nil {
+		return err
+	}
+	if c.peek().isPunct("(") {
+		if _, err := c.columns(); err != nil {
+			return err
+		}
+	}
+options:
+	for {
+		switch {
+		case c.accept("ON", "DELETE"), c.accept("ON", "UPDATE"):
+			if !c.accept("NO", "ACTION") && !c.accept("SET", "NULL") && !c.accept("SET", "DEFAULT") {
+				c.next()
+			}
+		case c.accept("MATCH"), c.accept("INITIALLY"):
+			c.next()
+		case c.accept("DEFERRABLE"), c.accept("NOT", "DEFERRABLE"):
+		default:
+			break options
+		}
+	}
+
+	if len(columns) != 1 {
+		s.note("composite foreign key %s (%s) referencing %s", table.name, strings.Join(columns, ", "), target)
+		return nil
+	}
+	table.foreignKeys = append(table.foreignKeys, sqlForeignKey{column: columns[0], table: target})
+	return nil
+}
+
+// column reads a column definition with its type and constraints
+func (s *sqlSchema) column(table *sqlTable, c *sqlCursor) error {
+	name, err := c.name()
+	if err != nil {
+		return err
+	}
+	dataType, err := c.dataType()
+	if err != nil {
+		return fmt.Errorf("column %s.%s: %v", table.name, name, err)
+	}
+	column := &sqlColumn{name: name, dataType: dataType}
+	if strings.Contains(dataType.name, "serial") {
+		column.generated = true
+	}
+	table.columns = append(table.columns, column)
+
+	for !c.done() {
+		switch {
+		case c.accept("NOT", "NULL"):
+			column.notNull = true
+		case c.accept("CONSTRAINT"):
+			c.next()
+		case c.accept("PRIMARY", "KEY"):
+			table.primaryKey = []string{name}
+		case c.accept("UNIQUE"):
+			c.accept("KEY")
+			column.unique = true
+		case c.peek().is("REFERENCES"):
+			if err := s.references(table, []string{name}, c); err != nil {
+				return err
+			}
+		case c.accept("CHECK"):
+			check, err := c.group()
+			if err != nil {
+				return err
+			}
+			table.checks = append(table.checks, check)
+		case c.accept("DEFAULT"):
+			if c.peek().is("nextval") {
+				column.generated = true
+			}
+			c.skipExpression()
+		case c.accept("ON", "UPDATE"):
+			c.skipExpression() // MySQL ON UPDATE CURRENT_TIMESTAMP
+		case c.accept("AUTO_INCREMENT"), c.accept("IDENTITY"):
+			column.generated = true
+		case c.accept("GENERATED", "ALWAYS", "AS", "IDENTITY"), c.accept("GENERATED", "BY", "DEFAULT", "AS", "IDENTITY"):
+			column.generated = true
+			c.skipGroup()
+		case c.accept("GENERATED", "ALWAYS", "AS"), c.accept("AS"):
+			column.generated, column.computed = true, true
+			c.skipGroup()
+		case c.accept("COMMENT"):
+			if t := c.next(); t.kind == sqlString {
+				column.comment = t.text
+			}
+		case c.accept("COLLATE"), c.accept("CHARACTER", "SET"), c.accept("CHARSET"):
+			c.next()
+		default:
+			c.next() // NULL, VISIBLE, STORED and other options that do not shape the field
+		}
+	}
+	return nil
+}
+
+// createType reads a Postgres enum type; composite and range types have no counterpart
+func (s *sqlSchema) createType(c *sqlCursor) error {
+	name, err := c.name()
+	if err != nil {
+		return err
+	}
+	if !c.accept("AS", "ENUM") {
+		s.note("type %s is not an enum", name)
+		return nil
+	}
+	values, err := c.group()
+	if err != nil {
+		return err
+	}
+	s.enums[strings.ToLower(name)] = literals(values)
+	return nil
+}
+
+// createUniqueIndex reads a unique index on plain columns; partial and expression indexes are reported
+func (s *sqlSchema) createUniqueIndex(c *sqlCursor) error {
+	c.accept("CONCURRENTLY")
+	c.accept("IF", "NOT", "EXISTS")
+	if !c.peek().is("ON") {
+		if _, err := c.name(); err != nil {
+			return err
+		}
+	}
+	if !c.accept("ON") {
+		return fmt.Errorf("expected ON in CREATE UNIQUE INDEX, found %q", c.peek().text)
+	}
+	c.accept("ONLY")
+	name, err := c.name()
+	if err != nil {
+		return err
+	}
+	if c.accept("USING") {
+		c.next()
+	}
+	tokens, err := c.group()
+	if err != nil {
+		return err
+	}
+
+	table := s.table(name)
+	columns, plain := columnNames(tokens)
+	switch {
+	case table == nil:
+		s.note("unique index on unknown table %s", name)
+	case !plain:
+		s.note("unique index on expression %s of table %s", tokenTexts(tokens), name)
+	case c.accept("WHERE"):
+		s.note("partial unique index on %s (%s)", name, strings.Join(columns, ", "))
+	default:
+		table.uniques = append(table.uniques, columns)
+	}
+	return nil
+}
+
+// alterTable reads the constraints and columns added to a table, ignoring ownership and storage changes
+func (s *sqlSchema) alterTable(c *sqlCursor) error {
+	c.accept("IF", "EXISTS")
+	c.accept("ONLY")
+	name, err := c.name()
+	if err != nil {
+		return err
+	}
+	table := s.table(name)
+	if table == nil {
+		s.note("ALTER TABLE of unknown table %s", name)
+		return nil
+	}
+
+	for _, action := range splitTopLevel(c.tokens[c.pos:]) {
+		a := &sqlCursor{tokens: action}
+		switch {
+		case a.accept("ADD"):
+			next := a.peek()
+			if next.is("CONSTRAINT") || next.is("PRIMARY") || next.is("UNIQUE") || next.is("FOREIGN") ||
+				next.is("CHECK") || next.is("KEY") || next.is("INDEX") {
+				err = s.tableElement(table, a)
+			} else {
+				a.accept("COLUMN")
+				a.accept("IF", "NOT", "EXISTS")
+				err = s.column(table, a)
+			}
+			if err != nil {
+				return err
+			}
+		case a.accept("ALTER"):
+			a.accept("COLUMN")
+			columnName, err := a.name()
+			if err != nil {
+				return err
+			}
+			column := table.column(columnName)
+			if column == nil {
+				s.note("ALTER COLUMN of unknown column %s.%s", name, columnName)
+				continue
+			}
+			switch {
+			case a.accept("SET", "NOT", "NULL"):
+				column.notNull = true
+			case a.accept("DROP", "NOT", "NULL"):
+				column.notNull = false
+			case a.accept("SET", "DEFAULT"):
+				column.generated = column.generated || a.peek().is("nextval")
+			case a.accept("ADD", "GENERATED"):
+				column.generated = true
+			}
+		case a.accept("OWNER"), a.accept("SET"), a.accept("ENABLE"), a.accept("DISABLE"), a.accept("REPLICA"),
+			a.accept("CLUSTER"), a.accept("ATTACH"):
+		default:
+			s.note("ALTER TABLE %s %s", name, tokenTexts(action))
+		}
+	}
+	return nil
+}
+
+// commentOn reads the descriptions of tables and columns
+func (s *sqlSchema) commentOn(c *sqlCursor) error {
+	isTable := c.accept("TABLE")
+	if !isTable && !c.accept("COLUMN") {
+		return nil
+	}
+	path, err := c.path()
+	if err != nil {
+		return err
+	}
+	if !c.accept("IS") {
+		return fmt.Errorf("expected IS in COMMENT ON, found %q", c.peek().text)
+	}
+	text := c.next()
+	if text.kind != sqlString {
+		return nil
+	}
+
+	if isTable {
+		if table := s.table(path[len(path)-1]); table != nil {
+			table.comment = text.text
+		}
+		return nil
+	}
+	if len(path) < 2 {
+		return fmt.Errorf("COMMENT ON COLUMN needs table.column, found %s", strings.Join(path, "."))
+	}
+	if table := s.table(path[len(path)-2]); table != nil {
+		if column := table.column(path[len(path)-1]); column != nil {
+			column.comment = text.text
+		}
+	}
+	return nil
+}
+
+func (s *sqlSchema) table(name string) *sqlTable {
+	for _, t := range s.tables {
+		if strings.EqualFold(t.name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+func (t *sqlTable) column(name string) *sqlColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (t *sqlTable) foreignKey(column string) (sqlForeignKey, bool) {
+	for _, fk := range t.foreignKeys {
+		if strings.EqualFold(fk.column, column) {
+			return fk, true
+		}
+	}
+	return sqlForeignKey{}, false
+}
+
+func (s *sqlSchema) note(format string, args ...interface{}) {
+	s.unmapped = append(s.unmapped, fmt.Sprintf(format, args...))
+}
+
+// resolveConstraints applies the keys and checks of a table to its columns
+func (s *sqlSchema) resolveConstraints(table *sqlTable) {
+	if len(table.primaryKey) == 1 {
+		if column := table.column(table.primaryKey[0]); column != nil {
+			column.notNull, column.unique = true, true
+		}
+	}
+	for _, name := range table.primaryKey {
+		if column := table.column(name); column != nil {
+			column.notNull = true
+		}
+	}
+	for _, columns := range table.uniques {
+		if len(columns) > 1 {
+			s.note("composite unique key %s (%s)", table.name, strings.Join(columns, ", "))
+			continue
+		}
+		if column := table.column(columns[0]); column != nil {
+			column.unique = true
+		}
+	}
+	for _, check := range table.checks {
+		name, values, ok := enumCheck(check)
+		column := table.column(name)
+		if !ok || column == nil {
+			s.note("check constraint of table %s: %s", table.name, tokenTexts(check))
+			continue
+		}
+		column.values = values
+	}
+}
+
+// isJoinTable reports whether the table only links two others: two foreign keys, and at most
+// a surrogate key and timestamps besides them
+func (s *sqlSchema) isJoinTable(table *sqlTable) bool {
+	if len(table.foreignKeys) != 2 {
+		return false
+	}
+	for _, fk := range table.foreignKeys {
+		if s.table(fk.table) == nil {
+			return false
+		}
+	}
+	for _, column := range table.columns {
+		if _, ok := table.foreignKey(column.name); ok {
+			continue
+		}
+		if isSurrogateKey(table, column) {
+			continue
+		}
+		if t, _, _ := columnDataType(column.dataType, s); t == enum.DateTime {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// isSurrogateKey reports whether the column is the table's own single-column key, id or <table>_id,
+// which every generated entity has anyway
+func isSurrogateKey(table *sqlTable, column *sqlColumn) bool {
+	if len(table.primaryKey) != 1 || !strings.EqualFold(table.primaryKey[0], column.name) {
+		return false
+	}
+	if _, ok := table.foreignKey(column.name); ok {
+		return false
+	}
+	name := strings.ToLower(column.name)
+	return name == "id" || name == service.ToSnake(service.Singularize(table.name))+"_id"
+}
+
+// entities maps the tables to entities. Join tables become many-to-many relations of the two tables they link.
+func (s *sqlSchema) entities(dialect enum.PreferredDB) []model.Entity {
+	for _, table := range s.tables {
+		s.resolveConstraints(table)
+	}
+
+	var entities []model.Entity
+	var joins []*sqlTable
+	index := map[string]int{} // Entity position by lower case table name
+	for _, table := range s.tables {
+		if s.isJoinTable(table) {
+			joins = append(joins, table)
+			continue
+		}
+		if len(table.primaryKey) > 1 {
+			s.note("composite primary key %s (%s)", table.name, strings.Join(table.primaryKey, ", "))
+		}
+		index[strings.ToLower(table.name)] = len(entities)
+		entities = append(entities, model.Entity{
+			EntityName:          entityName(table.name),
+			EntityDescription:   table.comment,
+			PreferredDB:         dialect,
+			IsIndependentEntity: len(table.foreignKeys) == 0,
+			EntityFields:        s.fields(table),
+		})
+	}
+
+	for _, table := range s.tables {
+		i, ok := index[strings.ToLower(table.name)]
+		if !ok {
+			continue
+		}
+		for _, fk := range table.foreignKeys {
+			s.foreignKeyRelation(entities, index, table, i, fk)
+		}
+	}
+	for _, table := range joins {
+		s.joinRelation(entities, index, table)
+	}
+	return entities
+}
+
+// fields maps the columns of a table to entity fields, leaving out its surrogate key
+func (s *sqlSchema) fields(table *sqlTable) []model.EntityField {
+	var fields []model.EntityField
+	for _, column := range table.columns {
+		if isSurrogateKey(table, column) {
+			continue
+		}
+		dataType, itemType, ok := columnDataType(column.dataType, s)
+		if !ok {
+			s.note("type %s of column %s.%s, imported as String", column.dataType.name, table.name, column.name)
+		}
+
+		field := model.EntityField{
+			FieldName:                service.ToPascal(column.name),
+			FieldDescription:         column.comment,
+			FieldType:                dataType,
+			IsMandatory:              column.notNull && !column.generated,
+			IsUnique:                 column.unique,
+			IsReadOnly:               column.generated,
+			IsEditable:               !column.generated,
+			CollectionType:           enum.None,
+			CollectionItemType:       enum.NoType,
+			NestedCollectionItemType: enum.NoType,
+		}
+		if fk, ok := table.foreignKey(column.name); ok && relationName(fk.column) == service.ToPascal(fk.column) {
+			// A key without an id suffix, e.g. parent, keeps the name for the relation
+			field.FieldName += "Id"
+		}
+
+		values := column.values
+		if len(values) == 0 && (dataType == enum.Enum || itemType == enum.EnumType) {
+			values = s.enumValues(column.dataType)
+		}
+		if len(values) > 0 {
+			field.IsEnum, field.EnumValues = true, values
+			if dataType != enum.Collection {
+				field.FieldType = enum.Enum
+			}
+		}
+
+		if dataType == enum.Collection {
+			field.IsCollection = true
+			field.CollectionType = enum.Array
+			field.CollectionItemType = itemType
+			if s.mysql && column.dataType.name == "set" {
+				field.CollectionType = enum.Set
+			}
+		}
+		if column.computed {
+			s.note("expression of generated column %s.%s", table.name, column.name)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// foreignKeyRelation relates the entity holding the foreign key to the referenced one and back
+func (s *sqlSchema) foreignKeyRelation(entities []model.Entity, index map[string]int, table *sqlTable, holder int, fk sqlForeignKey) {
+	name := relationName(fk.column)
+	target := entityName(fk.table)
+	column := table.column(fk.column)
+	if column == nil {
+		s.note("foreign key on unknown column %s.%s", table.name, fk.column)
+		return
+	}
+
+	relationType := enum.ManyToOne
+	switch {
+	case strings.EqualFold(fk.table, table.name):
+		relationType = enum.SelfReferencing
+	case column.unique:
+		relationType = enum.OneToOne
+	}
+	addRelation(&entities[holder], model.DependsOnEntity{EntityName: target, FieldName: name, RelationType: relationType})
+
+	// The referenced table may be in another script; the importer reports it when it is missing from the project
+	parent, ok := index[strings.ToLower(fk.table)]
+	if !ok {
+		return
+	}
+	back := model.DependsOnEntity{EntityName: entities[holder].EntityName, RelationType: enum.OneToMany}
+	switch {
+	case relationType == enum.OneToOne:
+		back.RelationType = enum.OneToOne
+		back.FieldName = entities[holder].EntityName
+	case relationType == enum.SelfReferencing && name == "Parent":
+		back.FieldName = "Children"
+	default:
+		back.FieldName = service.Pluralize(entities[holder].EntityName)
+	}
+	if hasRelationOrField(entities[parent], back.FieldName) {
+		back.FieldName = name + back.FieldName
+	}
+	addRelation(&entities[parent], back)
+}
+
+// joinRelation relates the two tables a join table links as many-to-many, named after the join columns
+func (s *sqlSchema) joinRelation(entities []model.Entity, index map[string]int, table *sqlTable) {
+	a, b := table.foreignKeys[0], table.foreignKeys[1]
+	left, okLeft := index[strings.ToLower(a.table)]
+	right, okRight := index[strings.ToLower(b.table)]
+	if !okLeft || !okRight {
+		s.note("join table %s links a table that is not imported", table.name)
+		return
+	}
+	if left == right {
+		// Self-referencing link such as user_followers (user_id, follower_id): the column not named after the table names it
+		link := b
+		if !strings.EqualFold(relationName(a.column), entities[left].EntityName) {
+			link = a
+		}
+		addRelation(&entities[left], model.DependsOnEntity{
+			EntityName: entities[left].EntityName, FieldName: service.Pluralize(relationName(link.column)), RelationType: enum.ManyToMany,
+		})
+		return
+	}
+	addRelation(&entities[left], model.DependsOnEntity{
+		EntityName: entities[right].EntityName, FieldName: service.Pluralize(relationName(b.column)), RelationType: enum.ManyToMany,
+	})
+	addRelation(&entities[right], model.DependsOnEntity{
+		EntityName: entities[left].EntityName, FieldName: service.Pluralize(relationName(a.column)), RelationType: enum.ManyToMany,
+	})
+}
+
+func addRelation(entity *model.Entity, relation model.DependsOnEntity) {
+	entity.DependsOnEntities = append(entity.DependsOnEntities, relation)
+}
+
+func hasRelationOrField(entity model.Entity, name string) bool {
+	for _, d := range entity.DependsOnEntities {
+		if strings.EqualFold(d.FieldName, name) {
+			return true
+		}
+	}
+	for _, f := range entity.EntityFields {
+		if strings.EqualFold(f.FieldName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// entityName names the entity of a table: order_items -> OrderItem
+func entityName(table string) string {
+	return service.ToPascal(service.Singularize(table))
+}
+
+// relationName names the relation held in a foreign key column: customer_id -> Customer
+func relationName(column string) string {
+	name := column
+	for _, suffix := range []string{"_id", "_uuid", "_fk", "Id", "ID"} {
+		if len(name) > len(suffix) && strings.HasSuffix(name, suffix) {
+			name = name[:len(name)-len(suffix)]
+			break
+		}
+	}
+	return service.ToPascal(name)
+}
+
+// enumValues returns the values of a Postgres enum type or a MySQL enum or set column
+func (s *sqlSchema) enumValues(t sqlType) []string {
+	if values, ok := s.enums[t.name]; ok {
+		return values
+	}
+	return t.args
+}
+
+// sqlDataTypes maps the built-in types of both dialects to field types
+var sqlDataTypes = map[string]enum.DataType{
+	"char": enum.String, "character": enum.String, "varchar": enum.String, "character varying": enum.String,
+	"nchar": enum.String, "nvarchar": enum.String, "text": enum.String, "tinytext": enum.String,
+	"mediumtext": enum.String, "longtext": enum.String, "citext": enum.String, "uuid": enum.String,
+	"json": enum.String, "jsonb": enum.String, "xml": enum.String, "inet": enum.String, "cidr": enum.String,
+	"macaddr": enum.String,
+
+	"smallint": enum.Int, "int": enum.Int, "integer": enum.Int, "bigint": enum.Int, "tinyint": enum.Int,
+	"mediumint": enum.Int, "int2": enum.Int, "int4": enum.Int, "int8": enum.Int, "serial": enum.Int,
+	"smallserial": enum.Int, "bigserial": enum.Int, "serial4": enum.Int, "serial8": enum.Int, "year": enum.Int,
+
+	"real": enum.Float, "float": enum.Float, "float4": enum.Float, "float8": enum.Float, "double": enum.Float,
+	"double precision": enum.Float, "decimal": enum.Float, "numeric": enum.Float, "dec": enum.Float,
+	"money": enum.Float,
+
+	"boolean": enum.Bool, "bool": enum.Bool,
+
+	"date": enum.DateTime, "time": enum.DateTime, "timestamp": enum.DateTime, "timestamptz": enum.DateTime,
+	"datetime": enum.DateTime, "timetz": enum.DateTime, "timestamp with time zone": enum.DateTime,
+	"timestamp without time zone": enum.DateTime, "time with time zone": enum.DateTime,
+	"time without time zone": enum.DateTime,
+
+	"enum": enum.Enum, "set": enum.Collection,
+}
+
+var collectionItemTypes = map[enum.DataType]enum.CollectionItemType{
+	enum.String: enum.StringType, enum.Int: enum.IntType, enum.Float: enum.FloatType,
+	enum.Bool: enum.BoolType, enum.DateTime: enum.DateTimeType, enum.Enum: enum.EnumType,
+}
+
+// columnDataType maps a column type to a field type, and the item type of arrays and MySQL sets.
+// Types without a counterpart, such as bytea or geometry, are reported as not ok and imported as String.
+func columnDataType(t sqlType, s *sqlSchema) (enum.DataType, enum.CollectionItemType, bool) {
+	dataType, ok := sqlDataTypes[t.name]
+	switch {
+	case s.mysql && (t.name == "tinyint" || t.name == "bit") && len(t.args) == 1 && t.args[0] == "1":
+		dataType, ok = enum.Bool, true
+	case t.name == "set" && !s.mysql:
+		dataType, ok = enum.String, false
+	case !ok:
+		if _, isEnum := s.enums[t.name]; isEnum {
+			dataType, ok = enum.Enum, true
+		}
+	}
+
+	switch {
+	case t.name == "set" && ok:
+		return enum.Collection, enum.EnumType, true
+	case t.array:
+		return enum.Collection, collectionItemTypes[dataType], ok
+	}
+	return dataType, enum.NoType, ok
+}
+
+// enumCheck recognizes CHECK (column IN ('a', 'b')) and Postgres' normalized
+// CHECK ((column)::text = ANY ((ARRAY['a'::character varying, 'b'::character varying])::text[]))
+func enumCheck(check []sqlToken) (string, []string, bool) {
+	split := -1
+	for i, t := range check {
+		switch {
+		case t.is("AND"), t.is("OR"), t.is("NOT"), t.is("SELECT"):
+			return "", nil, false
+		case split < 0 && t.is("IN"):
+			split = i
+		case split < 0 && t.isPunct("=") && i+1 < len(check) && check[i+1].is("ANY"):
+			split = i
+		}
+	}
+	if split < 0 {
+		return "", nil, false
+	}
+
+	var column string
+	for i := 0; i < split; i++ {
+		t := check[i]
+		switch {
+		case t.isPunct("(") || t.isPunct(")"):
+		case t.isPunct("::"):
+			i++ // The cast's type
+			for i+1 < split && check[i+1].kind == sqlWord {
+				i++
+			}
+		case t.isIdent() && column == "":
+			column = t.text
+		default:
+			return "", nil, false
+		}
+	}
+
+	var values []string
+	rest := check[split+1:]
+	for i, t := range rest {
+		isLength := i > 1 && rest[i-1].isPunct("(") && rest[i-2].kind == sqlWord // varchar(20) of a cast
+		if t.kind == sqlString || (t.kind == sqlNumber && !isLength) {
+			values = append(values, t.text)
+		}
+	}
+	return column, values, column != "" && len(values) > 0
+}
+
+// literals returns the string and number literals of a comma separated list
+func literals(tokens []sqlToken) []string {
+	var values []string
+	for _, part := range splitTopLevel(tokens) {
+		if len(part) > 0 && (part[0].kind == sqlString || part[0].kind == sqlNumber) {
+			values = append(values, part[0].text)
+		}
+	}
+	return values
+}
+
+// columnNames reads a list of plain columns, allowing MySQL prefix lengths and sort orders
+func columnNames(tokens []sqlToken) ([]string, bool) {
+	var names []string
+	for _, part := range splitTopLevel(tokens) {
+		if len(part) == 0 || !part[0].isIdent() {
+			return nil, false
+		}
+		rest := part[1:]
+		if inner, n, ok := parenthesized(rest); ok && len(inner) == 1 && inner[0].kind == sqlNumber {
+			rest = rest[n:]
+		}
+		for _, t := range rest {
+			if !t.is("ASC") && !t.is("DESC") && !t.is("NULLS") && !t.is("FIRST") && !t.is("LAST") {
+				return nil, false
+			}
+		}
+		names = append(names, part[0].text)
+	}
+	return names, len(names) > 0
+}
+
+// tokenTexts renders tokens back as SQL for reports
+func tokenTexts(tokens []sqlToken) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && !t.isPunct(")") && !t.isPunct(",") && !t.isPunct("::") && !tokens[i-1].isPunct("(") && !tokens[i-1].isPunct("::") {
+			b.WriteByte(' ')
+		}
+		switch t.kind {
+		case sqlString:
+			b.WriteString("'" + strings.ReplaceAll(t.text, "'", "''") + "'")
+		case sqlQuoted:
+			b.WriteString(`"` + t.text + `"`)
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// sqlCursor walks the tokens of a statement
+type sqlCursor struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (c *sqlCursor) done() bool {
+	return c.pos >= len(c.tokens)
+}
+
+// peek returns the next token, or an empty punctuation token at the end
+func (c *sqlCursor) peek() sqlToken {
+	if c.done() {
+		return sqlToken{kind: sqlPunct}
+	}
+	return c.tokens[c.pos]
+}
+
+func (c *sqlCursor) next() sqlToken {
+	t := c.peek()
+	if !c.done() {
+		c.pos++
+	}
+	return t
+}
+
+// accept consumes the keywords when the next tokens are all of them
+func (c *sqlCursor) accept(keywords ...string) bool {
+	for i, keyword := range keywords {
+		if c.pos+i >= len(c.tokens) || !c.tokens[c.pos+i].is(keyword) {
+			return false
+		}
+	}
+	c.pos += len(keywords)
+	return true
+}
+
+func (c *sqlCursor) acceptPunct(p string) bool {
+	if c.peek().isPunct(p) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+// path reads a dotted name such as public.orders.status
+func (c *sqlCursor) path() ([]string, error) {
+	var parts []string
+	for {
+		t := c.next()
+		if !t.isIdent() {
+			return nil, fmt.Errorf("expected a name, found %q", t.text)
+		}
+		parts = append(parts, t.text)
+		if !c.acceptPunct(".") {
+			return parts, nil
+		}
+	}
+}
+
+// name reads a possibly schema-qualified name, returning its last part
+func (c *sqlCursor) name() (string, error) {
+	parts, err := c.path()
+	if err != nil {
+		return "", err
+	}
+	return parts[len(parts)-1], nil
+}
+
+// group reads the tokens inside the parentheses at the cursor
+func (c *sqlCursor) group() ([]sqlToken, error) {
+	inner, n, ok := parenthesized(c.tokens[c.pos:])
+	if !ok {
+		return nil, fmt.Errorf("expected (, found %q", c.peek().text)
+	}
+	c.pos += n
+	return inner, nil
+}
+
+func (c *sqlCursor) skipGroup() {
+	if c.peek().isPunct("(") {
+		c.group()
+	}
+}
+
+// columns reads a parenthesized list of column names
+func (c *sqlCursor) columns() ([]string, error) {
+	tokens, err := c.group()
+	if err != nil {
+		return nil, err
+	}
+	names, ok := columnNames(tokens)
+	if !ok {
+		return nil, fmt.Errorf("expected column names, found (%s)", tokenTexts(tokens))
+	}
+	return names, nil
+}
+
+// typeWords are the words of multi-word types such as double precision or timestamp with time zone,
+// and MySQL's numeric attributes
+var typeWords = map[string]bool{
+	"precision": true, "varying": true, "with": true, "without": true, "time": true, "zone": true,
+	"unsigned": true, "signed": true, "zerofill": true,
+}
+
+// dataType reads a column type, e.g. varchar(100), int unsigned, text[] or timestamp(3) with time zone
+func (c *sqlCursor) dataType() (sqlType, error) {
+	name, err := c.name()
+	if err != nil {
+		return sqlType{}, err
+	}
+	t := sqlType{name: strings.ToLower(name)}
+	for {
+		next := c.peek()
+		switch {
+		case next.kind == sqlWord && typeWords[strings.ToLower(next.text)]:
+			c.next()
+			if word := strings.ToLower(next.text); word != "unsigned" && word != "signed" && word != "zerofill" {
+				t.name += " " + word
+			}
+		case next.isPunct("("):
+			args, err := c.group()
+			if err != nil {
+				return sqlType{}, err
+			}
+			t.args = literals(args)
+		case next.isPunct("["):
+			for !c.done() && !c.next().isPunct("]") {
+			}
+			t.array = true
+		case next.is("ARRAY"):
+			c.next()
+			t.array = true
+		default:
+			return t, nil
+		}
+	}
+}
+
+// skipExpression skips a default value such as 0, -1, 'draft'::character varying, now() or (uuid())
+func (c *sqlCursor) skipExpression() {
+	for {
+		for c.acceptPunct("-") || c.acceptPunct("+") {
+		}
+		if c.peek().isPunct("(") {
+			c.skipGroup()
+		} else {
+			c.next()
+			c.skipGroup()
+		}
+		for c.acceptPunct("::") {
+			c.dataType()
+		}
+		next := c.peek()
+		if next.kind != sqlPunct || next.text == "" || next.isPunct("(") || next.isPunct(")") || next.isPunct(",") {
+			return
+		}
+		c.next() // Binary operator such as || or +
+	}
+}
@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"fmt"
+
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+var typeScriptTypes = &typeSystem{
+	scalars: map[string]enum.DataType{
+		"string": enum.String, "String": enum.String, "number": enum.Float, "Number": enum.Float, "bigint": enum.Int,
+		"boolean": enum.Bool, "Boolean": enum.Bool, "Date": enum.DateTime,
+		"any": enum.String, "unknown": enum.String, "object": enum.String, "Object": enum.String,
+	},
+	collections: map[string]enum.CollectionType{
+		"Array": enum.List, "ReadonlyArray": enum.List, "Set": enum.Set, "ReadonlySet": enum.Set,
+		"Map": enum.Map, "ReadonlyMap": enum.Map, "Record": enum.Map,
+	},
+	arrays: enum.List,
+	nulls:  map[string]bool{"null": true, "undefined": true, "void": true},
+}
+
+// typeScriptModifiers precede a member name; a member may also be named like one, e.g. readonly: boolean
+var typeScriptModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "readonly": true, "declare": true,
+	"override": true, "abstract": true, "accessor": true, "get": true, "set": true,
+}
+
+// TypeScriptParser reads the first interface, object type alias or class of a TypeScript source. Enums, literal
+// unions and aliases declared in the same source are resolved, and class-validator decorators are read as annotations.
+type TypeScriptParser struct{}
+
+func NewTypeScriptParser() service.CodeParser {
+	return &TypeScriptParser{}
+}
+
+func (p *TypeScriptParser) Parse(content string) (service.ParsedMetadata, error) {
+	tokens, err := lexSource(content, sourceSyntax{regexLiterals: true, backtickStrings: true})
+	if err != nil {
+		return service.ParsedMetadata{}, fmt.Errorf("failed to parse typescript code: %v", err)
+	}
+
+	c := newSourceCursor(content, tokens)
+	m := newSourceModel(typeScriptTypes)
+	var name string
+	var body []sourceToken
+	found := false
+	for !c.done() {
+		switch {
+		case c.accept("export") || c.accept("declare") || c.accept("default") || c.accept("abstract") || c.accept("const"):
+		case c.accept("@"):
+			c.annotation() // A class decorator
+		case c.is("enum") && c.peekAt(1).kind == sourceIdent:
+			c.skip(1)
+			enumName := c.next().text
+			if c.is("{") {
+				m.enums[enumName] = enumConstants(c, c.inside())
+			}
+		case c.is("type") && c.peekAt(1).kind == sourceIdent:
+			c.skip(1)
+			alias := c.next().text
+			if c.is("<") {
+				c.inside()
+			}
+			c.accept("=")
+			if c.is("{") && !found {
+				name, body, found = alias, c.inside(), true
+				continue
+			}
+			m.aliases[alias] = parseTypeExpr(c, c.typeScriptMemberEnd())
+		case (c.is("interface") || c.is("class")) && c.peekAt(1).kind == sourceIdent:
+			c.skip(1)
+			declared := c.next().text
+			for !c.done() && !c.is("{") {
+				c.skip(1) // Type parameters, extends and implements
+			}
+			if members := c.inside(); !found {
+				name, body, found = declared, members, true
+			}
+		case c.is("}"):
+			c.skip(1)
+		default:
+			c.skipStatement()
+		}
+	}
+	if !found {
+		return c.result("typescript", service.ParsedMetadata{}, fmt.Errorf("no interface, type or class definition found in code"))
+	}
+
+	metadata := service.ParsedMetadata{Name: name}
+	members := c.sub(body)
+	var annotations []service.ParsedAnnotation
+	for !members.done() {
+		t := members.peek()
+		switch {
+		case members.accept(";") || members.accept(","):
+		case members.accept("@"):
+			annotations = append(annotations, members.annotation())
+		case members.accept("static"):
+			for typeScriptModifiers[members.peek().text] {
+				members.skip(1)
+			}
+			if !members.is("{") {
+				members.skip(1) // The name of a static member; a static block has none
+				members.accept("?")
+			}
+			members.skipTypeScriptMember()
+			annotations = nil
+		case typeScriptModifiers[t.text] && t.kind == sourceIdent && isTypeScriptMemberName(members.peekAt(1)):
+			members.skip(1)
+		case members.is("["):
+			members.inside() // An index signature
+			members.skipTypeScriptMember()
+			annotations = nil
+		case isTypeScriptMemberName(t):
+			members.skip(1)
+			optional := members.accept("?")
+			members.accept("!")
+			if !members.accept(":") {
+				members.skipTypeScriptMember() // A method, or a class property without a type
+				annotations = nil
+				continue
+			}
+			typeTokens := members.typeScriptMemberEnd()
+			field := m.field(t.text, members.text(typeTokens), parseTypeExpr(members, typeTokens), annotations, optional)
+			metadata.Fields = append(metadata.Fields, field)
+			annotations = nil
+			if members.accept("=") {
+				members.typeScriptMemberEnd()
+			}
+		default:
+			members.skip(1)
+		}
+	}
+	return c.result("typescript", metadata, nil)
+}
+
+func isTypeScriptMemberName(t sourceToken) bool {
+	return t.kind == sourceIdent || t.kind == sourceString
+}
+
+// typeScriptMemberEnd returns the tokens up to the end of a member's type or initializer: a semicolon or comma,
+// the end of the body, or a line break the type does not continue over
+func (c *sourceCursor) typeScriptMemberEnd() []sourceToken {
+	start, depth := c.pos, 0
+	for ; !c.done(); c.skip(1) {
+		t := c.peek()
+		if depth == 0 && c.pos > start && t.line > c.tokens[c.pos-1].line && !continuesType(c.tokens[c.pos-1], t) {
+			break
+		}
+		if t.kind != sourcePunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{", "<":
+			depth++
+		case ")", "]", "}", ">":
+			if depth == 0 {
+				return c.tokens[start:c.pos]
+			}
+			depth--
+		case ";", ",", "=":
+			if depth == 0 {
+				return c.tokens[start:c.pos]
+			}
+		}
+	}
+	return c.tokens[start:c.pos]
+}
+
+func continuesType(last, next sourceToken) bool {
+	isPunct := func(t sourceToken, texts ...string) bool {
+		for _, text := range texts {
+			if t.kind == sourcePunct && t.text == text {
+				return true
+			}
+		}
+		return false
+	}
+	return isPunct(last, "|", "&", "=>", ":", "=") || isPunct(next, "|", "&", "=>")
+}
+
+// skipTypeScriptMember moves past a method, accessor or untyped property
+func (c *sourceCursor) skipTypeScriptMember() {
+	if c.is("<") {
+		c.inside()
+	}
+	if c.is("(") {
+		c.inside()
+	}
+	if c.accept(":") {
+		c.typeScriptMemberEnd()
+	}
+	if c.accept("=") {
+		c.typeScriptMemberEnd()
+	}
+	if c.is("{") {
+		c.inside()
+	}
+}
+
+// enumConstants reads the constants of an enum body, preferring their string values, e.g. ACTIVE = 'active'.
+// A Java enum body ends its constants with a semicolon before its fields and methods.
+func enumConstants(parent *sourceCursor, body []sourceToken) []string {
+	for i, t := range body {
+		if t.kind == sourcePunct && t.text == ";" {
+			body = body[:i]
+			break
+		}
+	}
+	var values []string
+	for _, part := range splitSourceTokens(body, ",") {
+		c := parent.sub(part)
+		for c.accept("@") || c.is("[") {
+			if c.is("[") {
+				c.inside() // A C# attribute
+			} else {
+				c.annotation()
+			}
+		}
+		if c.peek().kind != sourceIdent {
+			continue
+		}
+		value := c.next().text
+		if c.accept("=") && c.peek().kind == sourceString && c.pos+1 == len(part) {
+			value = c.peek().text
+		}
+		values = append(values, value)
+	}
+	return values
+}
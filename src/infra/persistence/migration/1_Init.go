@@ -59,8 +59,10 @@ func createTables(database *gorm.DB) {
 
 	//Blueprint
 	tables = addNewTable(database, models.Blueprint{}, tables)
+	tables = addNewTable(database, models.Placeholder{}, tables)
 	tables = addNewTable(database, models.Functionality{}, tables)
 	tables = addNewTable(database, models.FunctionalOperation{}, tables)
+	tables = addNewTable(database, models.BlueprintTemplate{}, tables)
 
 	// Library
 	tables = addNewTable(database, models.Library{}, tables)
@@ -72,6 +74,10 @@ func createTables(database *gorm.DB) {
 	tables = addNewTable(database, models.JourneyNode{}, tables)
 	tables = addNewTable(database, models.JourneyEdge{}, tables)
 
+	// Generation
+	tables = addNewTable(database, models.GenerationRun{}, tables)
+	tables = addNewTable(database, models.GenerationManifest{}, tables)
+
 	er := database.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";").Error
 	if er != nil {
 		logger.Error(logging.Postgres, logging.Migration, er.Error(), nil)
@@ -82,6 +88,26 @@ func createTables(database *gorm.DB) {
 		logger.Error(logging.Postgres, logging.Migration, err.Error(), nil)
 	}
 	logger.Info(logging.Postgres, logging.Migration, "tables created", nil)
+
+	addNewColumns(database)
+}
+
+// addNewColumns runs AutoMigrate on the tables below, which addNewTable skips when they exist,
+// so the columns and indexes they are missing are added.
+func addNewColumns(database *gorm.DB) {
+	changed := []interface{}{
+		models.Blueprint{},         // template_version: current template revision
+		models.BlueprintTemplate{}, // scope: rendered per entity or per project; unique (blueprint_id, path, version)
+		models.Placeholder{},       // allowed_values: values accepted by an Enum placeholder
+		models.JourneyStep{},       // http_call: call spec parsed from the step's curl and sample response
+		models.InputValidation{},   // rule_type, min, max, pattern, allowed_values, operator, other_field: typed rule and its arguments
+		models.GenerationRun{},     // instance: server instance whose worker pool executes the run
+	}
+	if err := database.Migrator().AutoMigrate(changed...); err != nil {
+		logger.Error(logging.Postgres, logging.Migration, err.Error(), nil)
+		return
+	}
+	logger.Info(logging.Postgres, logging.Migration, "columns added", nil)
 }
 
 func addNewTable(database *gorm.DB, model interface{}, tables []interface{}) []interface{} {
@@ -2,13 +2,17 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
 	"gen-concept-api/config"
 	"gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/infra/persistence/database"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BlueprintRepository struct {
@@ -40,6 +44,7 @@ func (r *BlueprintRepository) CreateWithRelationships(ctx context.Context, bluep
 		StandardName: blueprint.StandardName,
 		Type:         blueprint.Type,
 		Description:  blueprint.Description,
+		TemplatePath: blueprint.TemplatePath,
 	}
 
 	if err := tx.Create(&blueprintToCreate).Error; err != nil {
@@ -47,6 +52,28 @@ func (r *BlueprintRepository) CreateWithRelationships(ctx context.Context, bluep
 		return model.Blueprint{}, err
 	}
 
+	// Create the first version of the template files
+	if blueprint.Templates != nil {
+		version, err := saveTemplates(tx, blueprintToCreate.ID, 0, blueprint.Templates)
+		if err != nil {
+			tx.Rollback()
+			return model.Blueprint{}, err
+		}
+		if err := tx.Model(&blueprintToCreate).Update("template_version", version).Error; err != nil {
+			tx.Rollback()
+			return model.Blueprint{}, err
+		}
+	}
+
+	// Create placeholders
+	for i := range blueprint.Placeholders {
+		blueprint.Placeholders[i].BlueprintID = blueprintToCreate.ID
+		if err := tx.Create(&blueprint.Placeholders[i]).Error; err != nil {
+			tx.Rollback()
+			return model.Blueprint{}, err
+		}
+	}
+
 	// Create functionalities with operations
 	for i := range blueprint.Functionalities {
 		blueprint.Functionalities[i].BlueprintID = blueprintToCreate.ID
@@ -99,6 +126,12 @@ func (r *BlueprintRepository) CreateWithRelationships(ctx context.Context, bluep
 		return model.Blueprint{}, err
 	}
 
+	templates, err := loadTemplates(r.database.WithContext(ctx), result.ID, result.TemplateVersion)
+	if err != nil {
+		return model.Blueprint{}, err
+	}
+	result.Templates = templates
+
 	return result, nil
 }
 
@@ -111,9 +144,9 @@ func (r *BlueprintRepository) UpdateWithRelationships(ctx context.Context, bluep
 		}
 	}()
 
-	// Get existing blueprint
+	// Get existing blueprint, locked so concurrent updates cannot append the same template version
 	var existing model.Blueprint
-	if err := tx.Where("uuid = ? and deleted_by is null", blueprintUuid).First(&existing).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ? and deleted_by is null", blueprintUuid).First(&existing).Error; err != nil {
 		tx.Rollback()
 		return model.Blueprint{}, err
 	}
@@ -122,12 +155,38 @@ func (r *BlueprintRepository) UpdateWithRelationships(ctx context.Context, bluep
 	existing.StandardName = blueprint.StandardName
 	existing.Type = blueprint.Type
 	existing.Description = blueprint.Description
+	existing.TemplatePath = blueprint.TemplatePath
+
+	// Append a new template version when files were added, changed or removed.
+	// A nil Templates slice leaves the template files untouched.
+	if blueprint.Templates != nil {
+		version, err := saveTemplates(tx, existing.ID, existing.TemplateVersion, blueprint.Templates)
+		if err != nil {
+			tx.Rollback()
+			return model.Blueprint{}, err
+		}
+		existing.TemplateVersion = version
+	}
 
 	if err := tx.Save(&existing).Error; err != nil {
 		tx.Rollback()
 		return model.Blueprint{}, err
 	}
 
+	// Replace placeholders
+	if err := tx.Unscoped().Where("blueprint_id = ?", existing.ID).Delete(&model.Placeholder{}).Error; err != nil {
+		tx.Rollback()
+		return model.Blueprint{}, err
+	}
+	for i := range blueprint.Placeholders {
+		blueprint.Placeholders[i].ID = 0
+		blueprint.Placeholders[i].BlueprintID = existing.ID
+		if err := tx.Create(&blueprint.Placeholders[i]).Error; err != nil {
+			tx.Rollback()
+			return model.Blueprint{}, err
+		}
+	}
+
 	// Handle Functionalities (one-to-many with cascading delete)
 	// First, get all functionality IDs for this blueprint
 	var functionalityIDs []uint
@@ -214,6 +273,12 @@ func (r *BlueprintRepository) UpdateWithRelationships(ctx context.Context, bluep
 		return model.Blueprint{}, err
 	}
 
+	templates, err := loadTemplates(r.database.WithContext(ctx), result.ID, result.TemplateVersion)
+	if err != nil {
+		return model.Blueprint{}, err
+	}
+	result.Templates = templates
+
 	return result, nil
 }
 
@@ -230,5 +295,82 @@ func (r *BlueprintRepository) GetByUuidWithRelationships(ctx context.Context, uu
 		First(&result).Error; err != nil {
 		return model.Blueprint{}, err
 	}
+
+	templates, err := loadTemplates(r.database.WithContext(ctx), result.ID, result.TemplateVersion)
+	if err != nil {
+		return model.Blueprint{}, err
+	}
+	result.Templates = templates
+	return result, nil
+}
+
+// GetByUuidAtTemplateVersion loads the blueprint with the template files as they were at the given version.
+// Version 0 means the current version.
+func (r *BlueprintRepository) GetByUuidAtTemplateVersion(ctx context.Context, uuid uuid.UUID, version int) (model.Blueprint, error) {
+	result, err := r.GetByUuidWithRelationships(ctx, uuid)
+	if err != nil {
+		return model.Blueprint{}, err
+	}
+	if version == 0 || version == result.TemplateVersion {
+		return result, nil
+	}
+	if version < 0 || version > result.TemplateVersion {
+		return model.Blueprint{}, fmt.Errorf("template version %d does not exist, current version is %d", version, result.TemplateVersion)
+	}
+
+	templates, err := loadTemplates(r.database.WithContext(ctx), result.ID, version)
+	if err != nil {
+		return model.Blueprint{}, err
+	}
+	result.TemplateVersion = version
+	result.Templates = templates
 	return result, nil
 }
+
+// GetTemplateHistory returns every stored version of every template file, oldest first
+func (r *BlueprintRepository) GetTemplateHistory(ctx context.Context, uuid uuid.UUID) ([]model.BlueprintTemplate, error) {
+	var blueprint model.Blueprint
+	if err := r.database.WithContext(ctx).Where(softDeleteExp, uuid).First(&blueprint).Error; err != nil {
+		return nil, err
+	}
+
+	var history []model.BlueprintTemplate
+	if err := r.database.WithContext(ctx).
+		Where("blueprint_id = ?", blueprint.ID).
+		Order("version, path").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// saveTemplates appends a row with the next version for every template file that was added, changed or removed.
+// It returns the resulting template version, which stays the same when nothing differs.
+func saveTemplates(tx *gorm.DB, blueprintID uint, currentVersion int, incoming []model.BlueprintTemplate) (int, error) {
+	current, err := loadTemplates(tx, blueprintID, currentVersion)
+	if err != nil {
+		return currentVersion, err
+	}
+	changes, err := service.TemplateChanges(current, incoming, blueprintID, currentVersion+1)
+	if err != nil || len(changes) == 0 {
+		return currentVersion, err
+	}
+	for i := range changes {
+		if err := tx.Create(&changes[i]).Error; err != nil {
+			return currentVersion, err
+		}
+	}
+	return currentVersion + 1, nil
+}
+
+// loadTemplates resolves the template files of a blueprint at the given version, sorted by path
+func loadTemplates(db *gorm.DB, blueprintID uint, version int) ([]model.BlueprintTemplate, error) {
+	var rows []model.BlueprintTemplate
+	if err := db.
+		Where("blueprint_id = ? AND version <= ?", blueprintID, version).
+		Order("version").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return service.TemplatesAtVersion(rows, version), nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"gen-concept-api/config"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/enum"
+	"gen-concept-api/infra/persistence/database"
+	"gen-concept-api/pkg/logging"
+	"gen-concept-api/pkg/service_errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// latestRunsScanned is how many recent succeeded runs GetLatestSucceeded compares the entities of
+const latestRunsScanned = 50
+
+type GenerationRunRepository struct {
+	*BaseRepository[model.GenerationRun]
+}
+
+func NewGenerationRunRepository(cfg *config.Config) repository.GenerationRunRepository {
+	return &GenerationRunRepository{
+		BaseRepository: NewBaseRepository[model.GenerationRun](cfg, []database.PreloadEntity{
+			{Entity: "File"},
+		}),
+	}
+}
+
+func (r *GenerationRunRepository) SaveProgress(ctx context.Context, run *model.GenerationRun) error {
+	columns := []interface{}{"TotalFiles", "CompletedFiles", "RegionIssues", "Error", "StartedAt", "FinishedAt", "FileID", "ManifestUuid", "ModifiedAt", "ModifiedBy"}
+	if run.Status.IsFinal() {
+		columns = append(columns, "Files") // Written once rather than after every file
+	}
+	err := r.database.WithContext(ctx).Model(run).
+		Select("Status", columns...).
+		Updates(run).Error
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+	}
+	return err
+}
+
+func (r *GenerationRunRepository) FailUnfinished(ctx context.Context, instance string, message string) (int64, error) {
+	result := r.database.WithContext(ctx).Model(&model.GenerationRun{}).
+		Where("instance = ? AND status IN ?", instance, []string{enum.RunQueued.String(), enum.RunRunning.String()}).
+		Updates(map[string]interface{}{
+			"status":      enum.RunFailed,
+			"error":       message,
+			"finished_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		r.logger.Error(logging.Postgres, logging.Update, result.Error.Error(), nil)
+	}
+	return result.RowsAffected, result.Error
+}
+
+func (r *GenerationRunRepository) GetLatestSucceeded(ctx context.Context, organizationId uint, blueprintUuid uuid.UUID, entityUuids []uuid.UUID) (model.GenerationRun, error) {
+	var runs []model.GenerationRun
+	err := database.Preload(r.database.WithContext(ctx), r.preloads).
+		Where("organization_id = ? AND blueprint_uuid = ? AND status = ? AND file_id IS NOT NULL",
+			organizationId, blueprintUuid, enum.RunSucceeded.String()).
+		Order("id desc").
+		Limit(latestRunsScanned).
+		Find(&runs).Error
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return model.GenerationRun{}, err
+	}
+
+	// Entity lists are stored as JSON, so they are compared here
+	want := sortedUuids(entityUuids)
+	for _, run := range runs {
+		if slices.Equal(sortedUuids(run.EntityUuids), want) {
+			return run, nil
+		}
+	}
+	return model.GenerationRun{}, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+}
+
+func sortedUuids(ids []uuid.UUID) []string {
+	sorted := make([]string, len(ids))
+	for i, id := range ids {
+		sorted[i] = id.String()
+	}
+	slices.Sort(sorted)
+	return sorted
+}
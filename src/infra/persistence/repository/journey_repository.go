@@ -7,6 +7,7 @@ import (
 	"gen-concept-api/domain/repository"
 	"gen-concept-api/infra/persistence/database"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -41,3 +42,31 @@ func (r *JourneyRepository) UpdateJourney(ctx context.Context, journey *model.Jo
 	tx.Commit()
 	return journey, nil
 }
+
+// subStepDepth is how many levels of nested steps GetByProjectUuid loads
+const subStepDepth = 3
+
+// GetByProjectUuid loads every journey of the project with its entity journeys, operations and steps,
+// including nested sub steps
+func (r *JourneyRepository) GetByProjectUuid(ctx context.Context, projectUuid uuid.UUID) ([]model.Journey, error) {
+	preloads := append([]database.PreloadEntity(nil), r.preloads...)
+	parent := "EntityJourneys.Operations.BackendJourney"
+	for i := 0; i < subStepDepth; i++ {
+		parent += ".SubSteps"
+		preloads = append(preloads,
+			database.PreloadEntity{Entity: parent},
+			database.PreloadEntity{Entity: parent + ".FieldsInvolved"},
+			database.PreloadEntity{Entity: parent + ".RetryConditions"},
+			database.PreloadEntity{Entity: parent + ".ResponseActions"},
+		)
+	}
+
+	var journeys []model.Journey
+	db := database.Preload(r.database, preloads)
+	if err := db.WithContext(ctx).
+		Where("project_uuid = ? and deleted_by is null", projectUuid).
+		Find(&journeys).Error; err != nil {
+		return nil, err
+	}
+	return journeys, nil
+}
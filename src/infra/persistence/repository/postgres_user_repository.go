@@ -136,3 +136,15 @@ func (r *PostgresUserRepository) GetDefaultRole(ctx context.Context) (roleId uin
 	}
 	return roleId, nil
 }
+
+func (r *PostgresUserRepository) GetOrganizationID(ctx context.Context, userId uint) (uint, error) {
+	var organizationId uint
+	if err := r.database.WithContext(ctx).Model(&model.User{}).
+		Select("organization_id").
+		Where("id = ?", userId).
+		First(&organizationId).Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return 0, err
+	}
+	return organizationId, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"gen-concept-api/config"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/infra/persistence/database"
+	"gen-concept-api/pkg/logging"
+
+	"gorm.io/gorm"
+)
+
+type ProjectRepository struct {
+	*BaseRepository[model.Project]
+}
+
+func NewProjectRepository(cfg *config.Config) repository.ProjectRepository {
+	return &ProjectRepository{
+		BaseRepository: NewBaseRepository[model.Project](cfg, []database.PreloadEntity{
+			{Entity: "Entities"},
+			{Entity: "Entities.DependsOnEntities"},
+			{Entity: "Entities.EntityFields"},
+			{Entity: "Entities.EntityFields.InputValidations"},
+		}),
+	}
+}
+
+func (r *ProjectRepository) FindByName(ctx context.Context, name string) (model.Project, bool, error) {
+	var projects []model.Project
+	err := database.Preload(r.database.WithContext(ctx), r.preloads).
+		Where("project_name = ? and deleted_by is null", name).
+		Limit(1).
+		Find(&projects).Error
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return model.Project{}, false, err
+	}
+	if len(projects) == 0 {
+		return model.Project{}, false, nil
+	}
+	return projects[0], true, nil
+}
+
+func (r *ProjectRepository) SaveProject(ctx context.Context, project *model.Project, journey *model.Journey) (*model.Project, error) {
+	tx := r.database.WithContext(ctx).Begin()
+
+	// FullSaveAssociations creates the new entities, fields and relations and updates the existing ones
+	if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(project).Error; err != nil {
+		tx.Rollback()
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		return nil, err
+	}
+	if journey != nil {
+		journey.ProjectUUID = project.Uuid
+		if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(journey).Error; err != nil {
+			tx.Rollback()
+			r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		return nil, err
+	}
+	return project, nil
+}
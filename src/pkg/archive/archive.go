@@ -0,0 +1,226 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	Zip   Format = "zip"
+	TarGz Format = "tar.gz"
+)
+
+// Limits of Read on the decompressed size of an archive, so a small upload cannot expand without bound
+const (
+	MaxEntryBytes = 16 << 20 // One file
+	MaxTotalBytes = 64 << 20 // All files together
+)
+
+// ErrTooLarge is returned by Read when an archive decompresses beyond MaxEntryBytes or MaxTotalBytes
+var ErrTooLarge = errors.New("archive exceeds the decompressed size limit")
+
+// Entry is a single file inside an archive
+type Entry struct {
+	Path    string
+	Content []byte
+}
+
+// ParseFormat maps a user supplied format name to a Format, defaulting to zip
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "zip":
+		return Zip, nil
+	case "tar.gz", "tgz", "targz":
+		return TarGz, nil
+	default:
+		return "", fmt.Errorf("unsupported archive format: %s", name)
+	}
+}
+
+// Extension returns the file extension (without the leading dot) for the format
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ContentType returns the MIME type served for the format
+func (f Format) ContentType() string {
+	if f == TarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// Write streams the entries to w in the requested format
+func Write(w io.Writer, format Format, entries []Entry) error {
+	switch format {
+	case Zip:
+		return writeZip(w, entries)
+	case TarGz:
+		return writeTarGz(w, entries)
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+func writeZip(w io.Writer, entries []Entry) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		header := &zip.FileHeader{
+			Name:     e.Path,
+			Method:   zip.Deflate,
+			Modified: time.Unix(0, 0).UTC(), // Fixed timestamp keeps archives reproducible
+		}
+		header.SetMode(0644)
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(e.Content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTarGz(w io.Writer, entries []Entry) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		header := &tar.Header{
+			Name:     e.Path,
+			Mode:     0644,
+			Size:     int64(len(e.Content)),
+			ModTime:  time.Unix(0, 0).UTC(),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(e.Content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// Read extracts the regular files of a zip or tar.gz archive, detecting the format from its content.
+// Paths are cleaned, so "./a.go" reads as "a.go". Archives decompressing beyond the limits fail with ErrTooLarge.
+func Read(data []byte) ([]Entry, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK")):
+		return readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return readTarGz(data)
+	default:
+		return nil, fmt.Errorf("unsupported archive: expected zip or tar.gz")
+	}
+}
+
+func readZip(data []byte) ([]Entry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if f.UncompressedSize64 > MaxEntryBytes {
+			return nil, fmt.Errorf("%w: %s", ErrTooLarge, f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := readEntry(rc, f.Name, &total)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Path: cleanPath(f.Name), Content: content})
+	}
+	return entries, nil
+}
+
+func readTarGz(data []byte) ([]Entry, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	// Headers, padding and skipped entries are decompressed too; twice the total leaves room for the first two
+	tr := tar.NewReader(&limitedReader{r: gr, n: 2 * MaxTotalBytes})
+	var entries []Entry
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > MaxEntryBytes {
+			return nil, fmt.Errorf("%w: %s", ErrTooLarge, header.Name)
+		}
+		content, err := readEntry(tr, header.Name, &total)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Path: cleanPath(header.Name), Content: content})
+	}
+}
+
+// readEntry reads one file of an archive, adding its size to total
+func readEntry(r io.Reader, name string, total *int64) ([]byte, error) {
+	content, err := io.ReadAll(&limitedReader{r: r, n: MaxEntryBytes})
+	if errors.Is(err, ErrTooLarge) {
+		return nil, fmt.Errorf("%w: %s", ErrTooLarge, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if *total += int64(len(content)); *total > MaxTotalBytes {
+		return nil, ErrTooLarge
+	}
+	return content, nil
+}
+
+// limitedReader reads up to n bytes from r and fails with ErrTooLarge when r holds more
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if l.n -= int64(n); l.n < 0 {
+		return n + int(l.n), ErrTooLarge
+	}
+	return n, err
+}
+
+func cleanPath(name string) string {
+	return path.Clean(strings.ReplaceAll(name, "\\", "/"))
+}
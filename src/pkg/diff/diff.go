@@ -0,0 +1,252 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Edit is one line of the edit script turning a into b.
+// A and B are the 0-based line indexes in a and b; A is -1 for inserts and B is -1 for deletes.
+type Edit struct {
+	Op   Op
+	A, B int
+}
+
+// SplitLines splits text into lines that keep their trailing newline
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// maxEditCost bounds the edit distance searched for between two stretches of lines. Stretches differing
+// more than that are reported as replaced as a whole, which keeps the time of a diff in check.
+const maxEditCost = 1024
+
+// Lines computes an edit script between two line slices with the linear space variant of Myers' algorithm.
+// The script is a shortest one unless a changed stretch exceeds maxEditCost.
+func Lines(a, b []string) []Edit {
+	s := &script{a: a, b: b}
+	s.compare(0, len(a), 0, len(b))
+	return s.edits
+}
+
+type script struct {
+	a, b  []string
+	edits []Edit
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi]
+func (s *script) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && s.a[aLo] == s.b[bLo] {
+		s.edits = append(s.edits, Edit{Op: Equal, A: aLo, B: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi && bLo < bHi && s.a[aHi-1] == s.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+
+	if x, y, ok := s.split(aLo, aHi, bLo, bHi); ok {
+		s.compare(aLo, x, bLo, y)
+		s.compare(x, aHi, y, bHi)
+	} else {
+		// Deletions go first, as in the rest of a change
+		for x := aLo; x < aHi; x++ {
+			s.edits = append(s.edits, Edit{Op: Delete, A: x, B: -1})
+		}
+		for y := bLo; y < bHi; y++ {
+			s.edits = append(s.edits, Edit{Op: Insert, A: -1, B: y})
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		s.edits = append(s.edits, Edit{Op: Equal, A: aHi + i, B: bHi + i})
+	}
+}
+
+// split finds where a shortest edit script of a[aLo:aHi] and b[bLo:bHi] crosses its middle, searching from
+// both ends at once so only the furthest point of each diagonal is kept. The stretches must not start or end
+// with a common line. It fails when either stretch is empty, or the edit distance exceeds maxEditCost.
+func (s *script) split(aLo, aHi, bLo, bHi int) (x, y int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := min((n+m+1)/2, maxEditCost)
+	offset := maxD + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals that ran off the grid are not extended again
+	kStart, kEnd, cStart, cEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + kStart; k <= d-kEnd; k += 2 {
+			var fx int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				fx = forward[offset+k+1]
+			} else {
+				fx = forward[offset+k-1] + 1
+			}
+			fy := fx - k
+			for fx < n && fy < m && s.a[aLo+fx] == s.b[bLo+fy] {
+				fx++
+				fy++
+			}
+			forward[offset+k] = fx
+			switch {
+			case fx > n:
+				kEnd += 2
+			case fy > m:
+				kStart += 2
+			case odd:
+				if c := offset + delta - k; c >= 0 && c < len(backward) && backward[c] != -1 && fx >= n-backward[c] {
+					return aLo + fx, bLo + fy, true
+				}
+			}
+		}
+
+		for c := -d + cStart; c <= d-cEnd; c += 2 {
+			var bx int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				bx = backward[offset+c+1]
+			} else {
+				bx = backward[offset+c-1] + 1
+			}
+			by := bx - c
+			for bx < n && by < m && s.a[aHi-1-bx] == s.b[bHi-1-by] {
+				bx++
+				by++
+			}
+			backward[offset+c] = bx
+			switch {
+			case bx > n:
+				cEnd += 2
+			case by > m:
+				cStart += 2
+			case !odd:
+				if k := offset + delta - c; k >= 0 && k < len(forward) && forward[k] != -1 {
+					fx := forward[k]
+					if fx >= n-bx {
+						return aLo + fx, bLo + fx - (k - offset), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Unified renders the changes from a to b as a unified diff with the given lines of context.
+// It returns "" when the texts are equal, with the number of inserted and deleted lines.
+func Unified(fromName, toName, a, b string, context int) (text string, insertions, deletions int) {
+	aLines, bLines := SplitLines(a), SplitLines(b)
+	edits := Lines(aLines, bLines)
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change and the hunk it opens
+		first := start
+		for first < len(edits) && edits[first].Op == Equal {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		from := max(first-context, start)
+		to := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].Op != Equal {
+				to = i
+			} else if i-to > 2*context {
+				break
+			}
+		}
+		to = min(to+context+1, len(edits))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		aStart, bStart, aCount, bCount := position(edits, from, to)
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, e := range edits[from:to] {
+			switch e.Op {
+			case Equal:
+				writeLine(&out, ' ', aLines[e.A])
+			case Delete:
+				deletions++
+				writeLine(&out, '-', aLines[e.A])
+			case Insert:
+				insertions++
+				writeLine(&out, '+', bLines[e.B])
+			}
+		}
+		start = to
+	}
+	return out.String(), insertions, deletions
+}
+
+// position returns the 1-based first lines and the line counts of a hunk in a and b
+func position(edits []Edit, from, to int) (aStart, bStart, aCount, bCount int) {
+	// Lines before the hunk
+	for _, e := range edits[:from] {
+		if e.Op != Insert {
+			aStart++
+		}
+		if e.Op != Delete {
+			bStart++
+		}
+	}
+	for _, e := range edits[from:to] {
+		if e.Op != Insert {
+			aCount++
+		}
+		if e.Op != Delete {
+			bCount++
+		}
+	}
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+	return
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func writeLine(out *strings.Builder, prefix byte, line string) {
+	out.WriteByte(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
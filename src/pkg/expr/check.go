@@ -0,0 +1,233 @@
+package expr
+
+import "strings"
+
+// Type is the static type of an expression
+type Type int
+
+const (
+	Any Type = iota // Unknown shape, e.g. a nested entity or a free-form response property
+	Int
+	Float
+	String
+	Bool
+	DateTime
+	Null
+	ListOf
+)
+
+func (t Type) String() string {
+	switch t {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case String:
+		return "string"
+	case Bool:
+		return "bool"
+	case DateTime:
+		return "datetime"
+	case Null:
+		return "null"
+	case ListOf:
+		return "list"
+	default:
+		return "any"
+	}
+}
+
+// Scope lists the fields an expression may reference, by dotted name.
+// A field typed Any also accepts any dotted path below it.
+type Scope map[string]Type
+
+// Lookup resolves a dotted name, case-insensitively
+func (s Scope) Lookup(name string) (Type, bool) {
+	if t, ok := s[name]; ok {
+		return t, true
+	}
+	for key, t := range s {
+		if strings.EqualFold(key, name) {
+			return t, true
+		}
+	}
+	for prefix := name; strings.Contains(prefix, "."); {
+		prefix = prefix[:strings.LastIndex(prefix, ".")]
+		if t, ok := s.Lookup(prefix); ok && t == Any {
+			return Any, true
+		}
+	}
+	return Any, false
+}
+
+// CheckCondition type checks an expression that must evaluate to a boolean
+func CheckCondition(node Node, scope Scope) error {
+	t, err := Check(node, scope)
+	if err != nil {
+		return err
+	}
+	if t != Bool && t != Any {
+		return errorAt(node.Pos(), "condition must be a boolean, got %s", t)
+	}
+	return nil
+}
+
+// Check returns the type of the expression, failing on unknown fields and mismatched operands
+func Check(node Node, scope Scope) (Type, error) {
+	switch n := node.(type) {
+	case *Literal:
+		switch n.Value.(type) {
+		case int64:
+			return Int, nil
+		case float64:
+			return Float, nil
+		case string:
+			return String, nil
+		case bool:
+			return Bool, nil
+		}
+		return Null, nil
+	case *Ident:
+		t, ok := scope.Lookup(n.Name())
+		if !ok {
+			return Any, errorAt(n.Offset, "unknown field %s", n.Name())
+		}
+		return t, nil
+	case *List:
+		for _, item := range n.Items {
+			if _, err := Check(item, scope); err != nil {
+				return Any, err
+			}
+		}
+		return ListOf, nil
+	case *Unary:
+		t, err := Check(n.X, scope)
+		if err != nil {
+			return Any, err
+		}
+		if n.Op == "!" {
+			if t != Bool && t != Any {
+				return Any, errorAt(n.Offset, "! needs a boolean, got %s", t)
+			}
+			return Bool, nil
+		}
+		if !numeric(t) {
+			return Any, errorAt(n.Offset, "- needs a number, got %s", t)
+		}
+		return t, nil
+	case *Binary:
+		return checkBinary(n, scope)
+	}
+	return Any, errorAt(node.Pos(), "unsupported expression")
+}
+
+func checkBinary(n *Binary, scope Scope) (Type, error) {
+	x, err := Check(n.X, scope)
+	if err != nil {
+		return Any, err
+	}
+	if n.Op == "in" {
+		list, ok := n.Y.(*List)
+		if !ok {
+			if y, err := Check(n.Y, scope); err != nil {
+				return Any, err
+			} else if y != ListOf && y != Any {
+				return Any, errorAt(n.Offset, "in needs a list, got %s", y)
+			}
+			return Bool, nil
+		}
+		for _, item := range list.Items {
+			t, err := Check(item, scope)
+			if err != nil {
+				return Any, err
+			}
+			if !comparable(x, t) {
+				return Any, errorAt(item.Pos(), "cannot compare %s with %s", x, t)
+			}
+		}
+		return Bool, nil
+	}
+	if _, ok := n.Y.(*List); ok {
+		return Any, errorAt(n.Y.Pos(), "a list is only allowed after in")
+	}
+	y, err := Check(n.Y, scope)
+	if err != nil {
+		return Any, err
+	}
+
+	switch n.Op {
+	case "&&", "||":
+		if (x != Bool && x != Any) || (y != Bool && y != Any) {
+			return Any, errorAt(n.Offset, "%s needs booleans, got %s and %s", n.Op, x, y)
+		}
+		return Bool, nil
+	case "==", "!=":
+		if !comparable(x, y) {
+			return Any, errorAt(n.Offset, "cannot compare %s with %s", x, y)
+		}
+		return Bool, nil
+	case "<", "<=", ">", ">=":
+		ordered := (numeric(x) && numeric(y)) ||
+			(x == String || x == Any) && (y == String || y == Any) ||
+			(x == DateTime || x == Any) && (y == DateTime || y == Any)
+		if !ordered {
+			return Any, errorAt(n.Offset, "cannot order %s and %s", x, y)
+		}
+		return Bool, nil
+	case "+":
+		if (x == String || x == Any) && (y == String || y == Any) && (x == String || y == String) {
+			return String, nil
+		}
+		fallthrough
+	case "-", "*", "/", "%":
+		if !numeric(x) || !numeric(y) {
+			return Any, errorAt(n.Offset, "%s needs numbers, got %s and %s", n.Op, x, y)
+		}
+		if x == Any || y == Any {
+			return Any, nil
+		}
+		if x == Float || y == Float {
+			return Float, nil
+		}
+		return Int, nil
+	}
+	return Any, errorAt(n.Offset, "unsupported operator %s", n.Op)
+}
+
+func numeric(t Type) bool {
+	return t == Int || t == Float || t == Any
+}
+
+func comparable(x, y Type) bool {
+	if x == Any || y == Any || x == Null || y == Null || x == y {
+		return true
+	}
+	return numeric(x) && numeric(y)
+}
+
+// Fields returns the distinct field names referenced by the expression, in order of appearance
+func Fields(node Node) []string {
+	var names []string
+	seen := make(map[string]bool)
+	var walk func(Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case *Ident:
+			if !seen[n.Name()] {
+				seen[n.Name()] = true
+				names = append(names, n.Name())
+			}
+		case *Unary:
+			walk(n.X)
+		case *Binary:
+			walk(n.X)
+			walk(n.Y)
+		case *List:
+			for _, item := range n.Items {
+				walk(item)
+			}
+		}
+	}
+	walk(node)
+	return names
+}
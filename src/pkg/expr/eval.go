@@ -0,0 +1,325 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Eval evaluates the expression against the given values.
+// Dotted names are looked up as a whole first, then through nested maps.
+func Eval(node Node, vars map[string]interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case *Literal:
+		return n.Value, nil
+	case *Ident:
+		value, ok := lookupValue(vars, n.Path)
+		if !ok {
+			return nil, errorAt(n.Offset, "no value for %s", n.Name())
+		}
+		return normalize(value), nil
+	case *List:
+		items := make([]interface{}, len(n.Items))
+		for i, item := range n.Items {
+			v, err := Eval(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case *Unary:
+		x, err := Eval(n.X, vars)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == "!" {
+			b, ok := x.(bool)
+			if !ok {
+				return nil, errorAt(n.Offset, "! needs a boolean, got %v", x)
+			}
+			return !b, nil
+		}
+		switch v := x.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+		return nil, errorAt(n.Offset, "- needs a number, got %v", x)
+	case *Binary:
+		return evalBinary(n, vars)
+	}
+	return nil, errorAt(node.Pos(), "unsupported expression")
+}
+
+// EvalCondition evaluates an expression that must produce a boolean
+func EvalCondition(node Node, vars map[string]interface{}) (bool, error) {
+	value, err := Eval(node, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, errorAt(node.Pos(), "condition evaluated to %v, not a boolean", value)
+	}
+	return b, nil
+}
+
+func evalBinary(n *Binary, vars map[string]interface{}) (interface{}, error) {
+	x, err := Eval(n.X, vars)
+	if err != nil {
+		return nil, err
+	}
+	// && and || short-circuit
+	if n.Op == "&&" || n.Op == "||" {
+		left, ok := x.(bool)
+		if !ok {
+			return nil, errorAt(n.Offset, "%s needs booleans, got %v", n.Op, x)
+		}
+		if (n.Op == "&&" && !left) || (n.Op == "||" && left) {
+			return left, nil
+		}
+		y, err := Eval(n.Y, vars)
+		if err != nil {
+			return nil, err
+		}
+		right, ok := y.(bool)
+		if !ok {
+			return nil, errorAt(n.Offset, "%s needs booleans, got %v", n.Op, y)
+		}
+		return right, nil
+	}
+
+	y, err := Eval(n.Y, vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Op {
+	case "in":
+		items, ok := y.([]interface{})
+		if !ok {
+			return nil, errorAt(n.Offset, "in needs a list, got %v", y)
+		}
+		for _, item := range items {
+			if equal(x, normalize(item)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(x, y)
+		if err != nil {
+			return nil, errorAt(n.Offset, "%s", err.Error())
+		}
+		switch n.Op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+
+	if n.Op == "+" {
+		xs, xok := x.(string)
+		ys, yok := y.(string)
+		if xok && yok {
+			return xs + ys, nil
+		}
+	}
+	xi, xInt := x.(int64)
+	yi, yInt := y.(int64)
+	if xInt && yInt {
+		switch n.Op {
+		case "+":
+			return xi + yi, nil
+		case "-":
+			return xi - yi, nil
+		case "*":
+			return xi * yi, nil
+		case "/", "%":
+			if yi == 0 {
+				return nil, errorAt(n.Offset, "division by zero")
+			}
+			if n.Op == "/" {
+				return xi / yi, nil
+			}
+			return xi % yi, nil
+		}
+	}
+	xf, xok := toFloat(x)
+	yf, yok := toFloat(y)
+	if !xok || !yok {
+		return nil, errorAt(n.Offset, "%s needs numbers, got %v and %v", n.Op, x, y)
+	}
+	switch n.Op {
+	case "+":
+		return xf + yf, nil
+	case "-":
+		return xf - yf, nil
+	case "*":
+		return xf * yf, nil
+	case "/":
+		if yf == 0 {
+			return nil, errorAt(n.Offset, "division by zero")
+		}
+		return xf / yf, nil
+	case "%":
+		if yf == 0 {
+			return nil, errorAt(n.Offset, "division by zero")
+		}
+		return math.Mod(xf, yf), nil
+	}
+	return nil, errorAt(n.Offset, "unsupported operator %s", n.Op)
+}
+
+func lookupValue(vars map[string]interface{}, path []string) (interface{}, bool) {
+	if value, ok := lookupKey(vars, strings.Join(path, ".")); ok {
+		return value, true
+	}
+	var current interface{} = vars
+	for _, part := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = lookupKey(m, part); !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func lookupKey(m map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := m[key]; ok {
+		return value, true
+	}
+	for k, value := range m {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// normalize widens Go numbers to int64 and float64 so operators only handle those
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return value
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// equal compares numbers by value, and lists and objects item by item. Other values are compared deeply,
+// since == panics on values like slices a payload may hold.
+func equal(x, y interface{}) bool {
+	if xf, ok := toFloat(x); ok {
+		yf, ok := toFloat(y)
+		return ok && xf == yf
+	}
+	switch xv := x.(type) {
+	case time.Time:
+		yt, ok := y.(time.Time)
+		return ok && xv.Equal(yt)
+	case []interface{}:
+		yv, ok := y.([]interface{})
+		if !ok || len(xv) != len(yv) {
+			return false
+		}
+		for i := range xv {
+			if !equal(normalize(xv[i]), normalize(yv[i])) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		yv, ok := y.(map[string]interface{})
+		if !ok || len(xv) != len(yv) {
+			return false
+		}
+		for key, value := range xv {
+			other, ok := yv[key]
+			if !ok || !equal(normalize(value), normalize(other)) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func compare(x, y interface{}) (int, error) {
+	if xf, ok := toFloat(x); ok {
+		if yf, ok := toFloat(y); ok {
+			switch {
+			case xf < yf:
+				return -1, nil
+			case xf > yf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if xs, ok := x.(string); ok {
+		if ys, ok := y.(string); ok {
+			return strings.Compare(xs, ys), nil
+		}
+	}
+	if xt, ok := x.(time.Time); ok {
+		if yt, ok := y.(time.Time); ok {
+			return xt.Compare(yt), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot order %v and %v", x, y)
+}
@@ -0,0 +1,327 @@
+// Package expr implements the condition language of journeys:
+// literals, dotted field references, arithmetic, comparison, logical operators and "in" lists,
+// e.g. `quantity * price > 100 && status in ['open', 'pending']`.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Node is a parsed expression
+type Node interface {
+	Pos() int // Offset of the node in the source, used in error messages
+}
+
+// Literal is a number, string, boolean or null constant
+type Literal struct {
+	Offset int
+	Value  interface{} // int64, float64, string, bool or nil
+}
+
+// Ident references a field, e.g. quantity or response.status
+type Ident struct {
+	Offset int
+	Path   []string
+}
+
+// Unary is "!x" or "-x"
+type Unary struct {
+	Offset int
+	Op     string
+	X      Node
+}
+
+// Binary is "x op y"
+type Binary struct {
+	Offset int
+	Op     string // + - * / % == != < <= > >= && || in
+	X, Y   Node
+}
+
+// List is "[a, b, c]", only valid on the right of "in"
+type List struct {
+	Offset int
+	Items  []Node
+}
+
+func (n *Literal) Pos() int { return n.Offset }
+func (n *Ident) Pos() int   { return n.Offset }
+func (n *Unary) Pos() int   { return n.Offset }
+func (n *Binary) Pos() int  { return n.Offset }
+func (n *List) Pos() int    { return n.Offset }
+
+// Name returns the dotted name of the field
+func (n *Ident) Name() string { return strings.Join(n.Path, ".") }
+
+// Error is a parse, type or evaluation error at a position of the expression
+type Error struct {
+	Offset  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Offset+1, e.Message)
+}
+
+func errorAt(offset int, format string, args ...interface{}) *Error {
+	return &Error{Offset: offset, Message: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	value  interface{}
+	offset int
+}
+
+// binaryPrecedence lists the binary operators from loosest to tightest binding
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "in": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// keywordOperators are word spellings of the operators
+var keywordOperators = map[string]string{"and": "&&", "or": "||", "not": "!", "in": "in"}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				i++
+			}
+			text := strings.ReplaceAll(string(runes[start:i]), "_", "")
+			if strings.Contains(text, ".") {
+				f, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, errorAt(start, "invalid number %s", text)
+				}
+				tokens = append(tokens, token{kind: tokNumber, text: text, value: f, offset: start})
+			} else {
+				n, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return nil, errorAt(start, "invalid number %s", text)
+				}
+				tokens = append(tokens, token{kind: tokNumber, text: text, value: n, offset: start})
+			}
+		case c == '\'' || c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == c {
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, errorAt(start, "unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokString, text: string(runes[start:i]), value: b.String(), offset: start})
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if op, ok := keywordOperators[strings.ToLower(text)]; ok {
+				tokens = append(tokens, token{kind: tokOp, text: op, offset: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, text: text, offset: start})
+			}
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				tokens = append(tokens, token{kind: tokOp, text: two, offset: start})
+				i += 2
+				continue
+			}
+			if strings.ContainsRune("+-*/%<>!()[],=", c) {
+				text := string(c)
+				if text == "=" {
+					text = "==" // A single = is a common slip for equality
+				}
+				tokens = append(tokens, token{kind: tokOp, text: text, offset: start})
+				i++
+				continue
+			}
+			return nil, errorAt(start, "unexpected character %q", c)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, offset: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses an expression
+func Parse(source string) (Node, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, errorAt(0, "empty expression")
+	}
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorAt(tok.offset, "unexpected %s", tok.text)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	tok := p.next()
+	if tok.kind != tokOp || tok.text != op {
+		return errorAt(tok.offset, "expected %s", op)
+	}
+	return nil
+}
+
+// parseBinary parses operators binding at least as tightly as minPrecedence
+func (p *parser) parseBinary(minPrecedence int) (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		precedence, ok := binaryPrecedence[tok.text]
+		if tok.kind != tokOp || !ok || precedence < minPrecedence {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(precedence + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Offset: tok.offset, Op: tok.text, X: left, Y: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	tok := p.peek()
+	if tok.kind == tokOp && (tok.text == "!" || tok.text == "-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Offset: tok.offset, Op: tok.text, X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber, tokString:
+		return &Literal{Offset: tok.offset, Value: tok.value}, nil
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return &Literal{Offset: tok.offset, Value: true}, nil
+		case "false":
+			return &Literal{Offset: tok.offset, Value: false}, nil
+		case "null", "nil":
+			return &Literal{Offset: tok.offset, Value: nil}, nil
+		}
+		path := strings.Split(tok.text, ".")
+		for _, part := range path {
+			if part == "" {
+				return nil, errorAt(tok.offset, "invalid field reference %s", tok.text)
+			}
+		}
+		return &Ident{Offset: tok.offset, Path: path}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			node, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case "[":
+			list := &List{Offset: tok.offset}
+			if next := p.peek(); next.kind == tokOp && next.text == "]" {
+				p.next()
+				return list, nil
+			}
+			for {
+				item, err := p.parseBinary(1)
+				if err != nil {
+					return nil, err
+				}
+				list.Items = append(list.Items, item)
+				sep := p.next()
+				if sep.kind == tokOp && sep.text == "]" {
+					return list, nil
+				}
+				if sep.kind != tokOp || sep.text != "," {
+					return nil, errorAt(sep.offset, "expected , or ]")
+				}
+			}
+		}
+	case tokEOF:
+		return nil, errorAt(tok.offset, "unexpected end of expression")
+	}
+	return nil, errorAt(tok.offset, "unexpected %s", tok.text)
+}
@@ -0,0 +1,249 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect is a target language of Transpile
+type Dialect int
+
+const (
+	Go Dialect = iota
+	TypeScript
+	Java
+	CSharp
+	Python
+	SQL // Portable SQL for generated columns and check constraints
+)
+
+// IdentFunc renders a field reference in the target language, e.g. e.Quantity or entity.getQuantity()
+type IdentFunc func(path []string) string
+
+// Transpile renders a checked expression as source code of the dialect.
+// The scope gives operand types where the dialect needs them (Java compares objects with Objects.equals and compareTo).
+func Transpile(node Node, dialect Dialect, scope Scope, ident IdentFunc) (string, error) {
+	t := &transpiler{dialect: dialect, scope: scope, ident: ident}
+	return t.render(node)
+}
+
+type transpiler struct {
+	dialect Dialect
+	scope   Scope
+	ident   IdentFunc
+}
+
+func (t *transpiler) render(node Node) (string, error) {
+	switch n := node.(type) {
+	case *Literal:
+		return t.literal(n.Value), nil
+	case *Ident:
+		if t.ident == nil {
+			return n.Name(), nil
+		}
+		return t.ident(n.Path), nil
+	case *Unary:
+		x, err := t.operand(n.X)
+		if err != nil {
+			return "", err
+		}
+		if n.Op == "!" && t.dialect == Python {
+			return "not " + x, nil
+		}
+		if n.Op == "!" && t.dialect == SQL {
+			return "NOT " + x, nil
+		}
+		return n.Op + x, nil
+	case *Binary:
+		return t.binary(n)
+	case *List:
+		return "", errorAt(n.Offset, "a list is only allowed after in")
+	}
+	return "", errorAt(node.Pos(), "unsupported expression")
+}
+
+// operand renders a sub expression, parenthesized when it is itself an operation
+func (t *transpiler) operand(node Node) (string, error) {
+	s, err := t.render(node)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := node.(*Binary); ok {
+		return "(" + s + ")", nil
+	}
+	return s, nil
+}
+
+func (t *transpiler) binary(n *Binary) (string, error) {
+	if n.Op == "in" {
+		return t.in(n)
+	}
+	x, err := t.operand(n.X)
+	if err != nil {
+		return "", err
+	}
+	y, err := t.operand(n.Y)
+	if err != nil {
+		return "", err
+	}
+	op := n.Op
+	switch t.dialect {
+	case Python:
+		if y == "None" && (op == "==" || op == "!=") {
+			if op == "==" {
+				return x + " is None", nil
+			}
+			return x + " is not None", nil
+		}
+		switch op {
+		case "&&":
+			op = "and"
+		case "||":
+			op = "or"
+		}
+	case TypeScript:
+		switch op {
+		case "==":
+			op = "==="
+		case "!=":
+			op = "!=="
+		}
+	case SQL:
+		if y == "NULL" && (op == "==" || op == "!=") {
+			if op == "==" {
+				return x + " IS NULL", nil
+			}
+			return x + " IS NOT NULL", nil
+		}
+		switch op {
+		case "==":
+			op = "="
+		case "!=":
+			op = "<>"
+		case "&&":
+			op = "AND"
+		case "||":
+			op = "OR"
+		case "+":
+			if t.isString(n.X) || t.isString(n.Y) {
+				return "CONCAT(" + x + ", " + y + ")", nil
+			}
+		}
+	case Java:
+		if (op == "==" || op == "!=") && t.needsEquals(n.X, n.Y) {
+			eq := "Objects.equals(" + x + ", " + y + ")"
+			if op == "!=" {
+				return "!" + eq, nil
+			}
+			return eq, nil
+		}
+		if (op == "<" || op == "<=" || op == ">" || op == ">=") && t.needsEquals(n.X, n.Y) {
+			return x + ".compareTo(" + y + ") " + op + " 0", nil
+		}
+	}
+	return x + " " + op + " " + y, nil
+}
+
+// in expands "x in [a, b]" to the idiom of the dialect
+func (t *transpiler) in(n *Binary) (string, error) {
+	x, err := t.operand(n.X)
+	if err != nil {
+		return "", err
+	}
+	list, ok := n.Y.(*List)
+	if !ok {
+		y, err := t.operand(n.Y)
+		if err != nil {
+			return "", err
+		}
+		switch t.dialect {
+		case Go:
+			return "slices.Contains(" + y + ", " + x + ")", nil
+		case TypeScript:
+			return y + ".includes(" + x + ")", nil
+		case Java:
+			return y + ".contains(" + x + ")", nil
+		case CSharp:
+			return y + ".Contains(" + x + ")", nil
+		case SQL:
+			return "", errorAt(n.Offset, "in needs a list literal in SQL")
+		}
+		return x + " in " + y, nil
+	}
+
+	if t.dialect == Python || t.dialect == SQL {
+		items := make([]string, len(list.Items))
+		for i, item := range list.Items {
+			if items[i], err = t.operand(item); err != nil {
+				return "", err
+			}
+		}
+		if t.dialect == SQL {
+			return x + " IN (" + strings.Join(items, ", ") + ")", nil
+		}
+		return x + " in [" + strings.Join(items, ", ") + "]", nil
+	}
+	if len(list.Items) == 0 {
+		return "false", nil
+	}
+	comparisons := make([]string, len(list.Items))
+	for i, item := range list.Items {
+		if comparisons[i], err = t.binary(&Binary{Offset: n.Offset, Op: "==", X: n.X, Y: item}); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(comparisons, " || "), nil
+}
+
+func (t *transpiler) isString(node Node) bool {
+	typ, _ := Check(node, t.scope)
+	return typ == String
+}
+
+// needsEquals reports whether Java must compare the operands as objects, with equals or compareTo
+func (t *transpiler) needsEquals(x, y Node) bool {
+	xt, _ := Check(x, t.scope)
+	yt, _ := Check(y, t.scope)
+	if xt == Null || yt == Null {
+		return false
+	}
+	return !(xt == Int || xt == Float || xt == Bool) || !(yt == Int || yt == Float || yt == Bool)
+}
+
+func (t *transpiler) literal(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case string:
+		if t.dialect == SQL {
+			return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		}
+		return strconv.Quote(v)
+	case bool:
+		if t.dialect == SQL {
+			return strings.ToUpper(strconv.FormatBool(v))
+		}
+		if t.dialect == Python {
+			if v {
+				return "True"
+			}
+			return "False"
+		}
+		return strconv.FormatBool(v)
+	}
+	switch t.dialect {
+	case Go:
+		return "nil"
+	case Python:
+		return "None"
+	case SQL:
+		return "NULL"
+	}
+	return "null"
+}
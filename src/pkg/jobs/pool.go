@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrPoolClosed = errors.New("job pool is closed")
+
+// Pool runs jobs on a fixed number of workers. Jobs sharing a key (e.g. an organization) run at most
+// limitPerKey at a time; the others wait in the queue without blocking jobs of other keys.
+type Pool struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	limitPerKey int
+	queue       []*job
+	running     map[string]int
+	active      map[string]context.CancelFunc
+	closed      bool
+	onPanic     func(id string, recovered any)
+	wg          sync.WaitGroup
+}
+
+type job struct {
+	id  string
+	key string
+	run func(ctx context.Context)
+}
+
+// NewPool starts workers goroutines. A limitPerKey of 0 or less does not limit keys.
+func NewPool(workers, limitPerKey int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		limitPerKey: limitPerKey,
+		running:     make(map[string]int),
+		active:      make(map[string]context.CancelFunc),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues a job. The context passed to run is cancelled by Cancel and Close.
+func (p *Pool) Submit(id, key string, run func(ctx context.Context)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if _, ok := p.active[id]; ok || p.queuedIndex(id) >= 0 {
+		return fmt.Errorf("job %s is already submitted", id)
+	}
+	p.queue = append(p.queue, &job{id: id, key: key, run: run})
+	p.cond.Broadcast()
+	return nil
+}
+
+// Cancel removes a queued job, or cancels the context of a running one.
+// wasQueued reports that the job never started; found is false for unknown or finished jobs.
+func (p *Pool) Cancel(id string) (wasQueued bool, found bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i := p.queuedIndex(id); i >= 0 {
+		p.queue = append(p.queue[:i], p.queue[i+1:]...)
+		return true, true
+	}
+	if cancel, ok := p.active[id]; ok {
+		cancel()
+		return false, true
+	}
+	return false, false
+}
+
+// Close drops the queued jobs, cancels the running ones and waits for the workers to return
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.queue = nil
+	for _, cancel := range p.active {
+		cancel()
+	}
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// OnPanic sets the function told about jobs that panicked. The worker recovers and goes on with the next job.
+func (p *Pool) OnPanic(fn func(id string, recovered any)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onPanic = fn
+}
+
+func (p *Pool) queuedIndex(id string) int {
+	for i, j := range p.queue {
+		if j.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// next pops the oldest queued job whose key is under its limit, waiting until one is available
+func (p *Pool) next() (*job, context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.closed {
+			return nil, nil
+		}
+		for i, j := range p.queue {
+			if p.limitPerKey > 0 && p.running[j.key] >= p.limitPerKey {
+				continue
+			}
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			p.running[j.key]++
+			ctx, cancel := context.WithCancel(context.Background())
+			p.active[j.id] = cancel
+			return j, ctx
+		}
+		p.cond.Wait()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		j, ctx := p.next()
+		if j == nil {
+			return
+		}
+		p.run(j, ctx)
+	}
+}
+
+// run executes a job and frees its slots afterwards, also when it panics
+func (p *Pool) run(j *job, ctx context.Context) {
+	defer func() {
+		recovered := recover()
+		p.mu.Lock()
+		p.active[j.id]()
+		delete(p.active, j.id)
+		if p.running[j.key]--; p.running[j.key] <= 0 {
+			delete(p.running, j.key)
+		}
+		onPanic := p.onPanic
+		p.cond.Broadcast()
+		p.mu.Unlock()
+		if recovered != nil && onPanic != nil {
+			onPanic(j.id, recovered)
+		}
+	}()
+	j.run(ctx)
+}
@@ -18,6 +18,7 @@ const (
 const (
 	// General
 	Startup         SubCategory = "Startup"
+	Shutdown        SubCategory = "Shutdown"
 	ExternalService SubCategory = "ExternalService"
 
 	// Postgres
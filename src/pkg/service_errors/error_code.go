@@ -21,4 +21,11 @@ const (
 
 	// DB
 	RecordNotFound = "record not found"
+
+	// Generation
+	GenerationRunFinished = "generation run already finished"
+	GenerationRunNotReady = "generation run has no output to download"
+	InvalidPreviousOutput = "previous output must be a zip or tar.gz archive"
+	BaselineTooLarge      = "diff baseline is too large"
+	EntityRequired        = "blueprint renders files per entity, an entity is required"
 )
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	gen_ai "gen-concept-api/infra/ai"
+)
+
+// countingAIProvider wraps the offline mock and records the prompts it answers
+type countingAIProvider struct {
+	prompts []string
+}
+
+func (p *countingAIProvider) GenerateContent(prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	return gen_ai.NewMockAIProvider().GenerateContent(prompt)
+}
+
+func TestAIGapFilling(t *testing.T) {
+	provider := &countingAIProvider{}
+	genService := service.NewGenerationService(nil, provider, nil, nil)
+
+	blueprint := model.Blueprint{
+		Templates: []model.BlueprintTemplate{
+			{Path: "validate.go", Content: `func validate() { {{ai "implement validation for" .Entity}} }`},
+		},
+	}
+	entity := model.Entity{
+		EntityName: "Customer",
+		EntityFields: []model.EntityField{
+			{FieldName: "email", FieldType: enum.String, IsMandatory: true},
+		},
+	}
+
+	first, err := genService.GenerateCode(context.Background(), blueprint, entity, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := genService.GenerateCode(context.Background(), blueprint, entity, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first != second {
+		t.Errorf("expected identical previews, got %q and %q", first, second)
+	}
+	if len(provider.prompts) != 1 {
+		t.Fatalf("expected the second preview to hit the cache, provider called %d times", len(provider.prompts))
+	}
+	if !strings.Contains(first, "implement validation for") {
+		t.Errorf("expected the answer in the output, got %q", first)
+	}
+	if prompt := provider.prompts[0]; !strings.Contains(prompt, "Entity: Customer") || !strings.Contains(prompt, "email string (required)") {
+		t.Errorf("prompt does not describe the entity: %q", prompt)
+	}
+}
+
+func TestAICacheEvictsLeastRecentlyUsed(t *testing.T) {
+	provider := &countingAIProvider{}
+	genService := service.NewGenerationService(nil, provider, nil, nil)
+	genService.SetAICacheLimit(2)
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "fill.go", Content: `{{ai "describe" .Entity}}`}}}
+
+	// Order is used again before Product comes in, so Customer is the one evicted
+	for _, name := range []string{"Customer", "Order", "Order", "Customer", "Order", "Product", "Order", "Customer"} {
+		if _, err := genService.GenerateCode(context.Background(), blueprint, model.Entity{EntityName: name}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := []string{"Customer", "Order", "Product", "Customer"}
+	if len(provider.prompts) != len(expected) {
+		t.Fatalf("expected %d prompts to reach the provider, got %d", len(expected), len(provider.prompts))
+	}
+	for i, name := range expected {
+		if !strings.Contains(provider.prompts[i], "Entity: "+name) {
+			t.Errorf("expected prompt %d to describe %s, got %q", i, name, provider.prompts[i])
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gen-concept-api/pkg/archive"
+)
+
+func archiveOf(t *testing.T, format archive.Format, entries []archive.Entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, format, entries); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveReadRejectsOversizedContent(t *testing.T) {
+	large := make([]byte, archive.MaxEntryBytes+1)
+	part := make([]byte, archive.MaxEntryBytes)
+	var parts []archive.Entry
+	for i := 0; i <= archive.MaxTotalBytes/archive.MaxEntryBytes; i++ {
+		parts = append(parts, archive.Entry{Path: fmt.Sprintf("part%d.bin", i), Content: part})
+	}
+
+	for _, format := range []archive.Format{archive.Zip, archive.TarGz} {
+		tests := map[string][]archive.Entry{
+			"entry": {{Path: "small.txt", Content: []byte("ok")}, {Path: "bomb.bin", Content: large}},
+			"total": parts,
+		}
+		for name, entries := range tests {
+			data := archiveOf(t, format, entries)
+			if len(data) > 1<<20 {
+				t.Fatalf("%s %s: expected the zeros to compress, got %d bytes", format, name, len(data))
+			}
+			if _, err := archive.Read(data); !errors.Is(err, archive.ErrTooLarge) {
+				t.Errorf("%s %s: expected ErrTooLarge, got %v", format, name, err)
+			}
+		}
+
+		entries, err := archive.Read(archiveOf(t, format, parts[:2]))
+		if err != nil || len(entries) != 2 || len(entries[1].Content) != archive.MaxEntryBytes {
+			t.Errorf("%s: expected entries within the limits to be read, got %d entries, %v", format, len(entries), err)
+		}
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	entries := []archive.Entry{
+		{Path: "go.mod", Content: []byte("module shop\n")},
+		{Path: "internal/order/model.go", Content: []byte("package order\n")},
+		{Path: "empty.txt", Content: []byte{}},
+	}
+	for _, name := range []string{"zip", "tar.gz", "tgz"} {
+		format, err := archive.ParseFormat(name)
+		if err != nil {
+			t.Fatalf("ParseFormat(%q): %v", name, err)
+		}
+		data := archiveOf(t, format, entries)
+		if again := archiveOf(t, format, entries); !bytes.Equal(data, again) {
+			t.Errorf("%s: expected archives of the same files to be identical", format)
+		}
+
+		read, err := archive.Read(data)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if len(read) != len(entries) {
+			t.Fatalf("%s: expected %d entries, got %+v", format, len(entries), read)
+		}
+		for i, e := range entries {
+			if read[i].Path != e.Path || !bytes.Equal(read[i].Content, e.Content) {
+				t.Errorf("%s: expected %s with %q, got %s with %q", format, e.Path, e.Content, read[i].Path, read[i].Content)
+			}
+		}
+	}
+
+	if format, err := archive.ParseFormat(""); err != nil || format != archive.Zip || format.ContentType() != "application/zip" {
+		t.Errorf("expected zip by default, got %q, %v", format, err)
+	}
+	if _, err := archive.ParseFormat("rar"); err == nil {
+		t.Errorf("expected rar to be unsupported")
+	}
+	if read, err := archive.Read(archiveOf(t, archive.TarGz, []archive.Entry{{Path: "./a\\b.go", Content: []byte("x")}})); err != nil || read[0].Path != "a/b.go" {
+		t.Errorf("expected the path to be cleaned, got %+v, %v", read, err)
+	}
+	if _, err := archive.Read([]byte("plain text")); err == nil {
+		t.Errorf("expected plain text to be rejected")
+	}
+}
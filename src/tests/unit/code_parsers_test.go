@@ -0,0 +1,464 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/infra/parser"
+)
+
+func parseCode(t *testing.T, p service.CodeParser, source string) (service.ParsedMetadata, map[string]service.ParsedField) {
+	t.Helper()
+	metadata, err := p.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fields := map[string]service.ParsedField{}
+	for _, f := range metadata.Fields {
+		fields[f.Name] = f
+	}
+	return metadata, fields
+}
+
+func ruleOf(f service.ParsedField, ruleType enum.ValidationRuleType) (model.InputValidation, bool) {
+	for _, rule := range f.InputValidations {
+		if rule.RuleType == ruleType {
+			return rule, true
+		}
+	}
+	return model.InputValidation{}, false
+}
+
+func hasBounds(rule model.InputValidation, min, max float64) bool {
+	return rule.Min != nil && *rule.Min == min && rule.Max != nil && *rule.Max == max
+}
+
+func TestTypeScriptParserReadsInterfaces(t *testing.T) {
+	metadata, fields := parseCode(t, parser.NewTypeScriptParser(), `
+import { Customer } from './customer';
+
+export type Status = 'draft' | 'placed' // Orders start as drafts
+export enum Channel { Web = 'web', Store = 'store' }
+
+/** An order of a customer */
+export interface Order {
+  readonly id: string;
+  status: Status
+  channel?: Channel
+  note: string | null
+  customer: Customer
+  items: Array<LineItem>
+  tags: readonly string[]
+  totals: Record<string, number>
+  placedAt: Date
+  total(): number
+}`)
+
+	if metadata.Name != "Order" || len(metadata.Fields) != 9 {
+		t.Fatalf("unexpected metadata: %+v", metadata)
+	}
+	if id := fields["id"]; id.FieldType != enum.String || id.IsOptional {
+		t.Errorf("unexpected id: %+v", id)
+	}
+	if status := fields["status"]; status.FieldType != enum.Enum || !reflect.DeepEqual(status.EnumValues, []string{"draft", "placed"}) {
+		t.Errorf("expected the literal union alias as an enum, got %+v", status)
+	}
+	if channel := fields["channel"]; !channel.IsOptional || !reflect.DeepEqual(channel.EnumValues, []string{"web", "store"}) {
+		t.Errorf("expected an optional enum with its string values, got %+v", channel)
+	}
+	if note := fields["note"]; !note.IsOptional || note.FieldType != enum.String || note.Type != "string | null" {
+		t.Errorf("expected a nullable string, got %+v", note)
+	}
+	if customer := fields["customer"]; customer.FieldType != enum.Entity || customer.Reference != "Customer" {
+		t.Errorf("expected a reference to Customer, got %+v", customer)
+	}
+	if items := fields["items"]; items.CollectionType != enum.List || items.CollectionItemType != enum.OtherEntityType || items.Reference != "LineItem" {
+		t.Errorf("expected a list of LineItem, got %+v", items)
+	}
+	if tags := fields["tags"]; !tags.IsCollection || tags.CollectionItemType != enum.StringType {
+		t.Errorf("expected a list of strings, got %+v", tags)
+	}
+	if totals := fields["totals"]; totals.CollectionType != enum.Map || totals.CollectionItemType != enum.FloatType {
+		t.Errorf("expected a map of numbers, got %+v", totals)
+	}
+	if placed := fields["placedAt"]; placed.FieldType != enum.DateTime {
+		t.Errorf("expected a DateTime, got %+v", placed)
+	}
+}
+
+func TestTypeScriptParserReadsClassValidatorDecorators(t *testing.T) {
+	_, fields := parseCode(t, parser.NewTypeScriptParser(), `
+export class CreateUserDto {
+  @IsEmail()
+  email!: string;
+
+  @Length(2, 50)
+  @Matches(/^[a-z ]+$/i)
+  name: string;
+
+  @IsOptional() @Min(18)
+  age: number = 18;
+
+  static create(): CreateUserDto { return new CreateUserDto(); }
+}`)
+
+	if len(fields) != 3 {
+		t.Fatalf("expected the three properties, got %+v", fields)
+	}
+	if _, ok := ruleOf(fields["email"], enum.EmailRule); !ok {
+		t.Errorf("expected an email rule, got %+v", fields["email"])
+	}
+	name := fields["name"]
+	if length, ok := ruleOf(name, enum.LengthRule); !ok || !hasBounds(length, 2, 50) {
+		t.Errorf("expected a length rule of 2 to 50, got %+v", name.InputValidations)
+	}
+	if pattern, ok := ruleOf(name, enum.PatternRule); !ok || pattern.Pattern != "^[a-z ]+$" {
+		t.Errorf("expected the regular expression as pattern, got %+v", name.InputValidations)
+	}
+	if age := fields["age"]; !age.IsOptional || len(age.Annotations) != 2 {
+		t.Errorf("expected @IsOptional to make age optional, got %+v", age)
+	}
+}
+
+func TestJavaParserReadsClassesAndRecords(t *testing.T) {
+	metadata, fields := parseCode(t, parser.NewJavaParser(), `
+package shop.orders;
+
+import jakarta.validation.constraints.*;
+import java.util.*;
+
+@Entity
+public class Order {
+    public enum Status { PLACED, SHIPPED; }
+
+    private static final long serialVersionUID = 1L;
+
+    @NotNull
+    @Size(min = 3, max = 20)
+    private String code;
+
+    @Pattern(regexp = "^[A-Z]{2}\\d+$")
+    private String reference;
+
+    private int quantity;
+    private Integer priority;
+    private Optional<String> note;
+
+    @Min(0) @Max(100)
+    private double discount;
+
+    private Status status = Status.PLACED;
+    private Map<String, List<LineItem>> itemsByWarehouse;
+    private String[] tags;
+    private java.time.Instant placedAt;
+
+    public Order(String code) { this.code = code; }
+
+    public String getCode() { return code; }
+}`)
+
+	if metadata.Name != "Order" || len(metadata.Fields) != 10 {
+		t.Fatalf("expected the ten instance fields, got %+v", metadata)
+	}
+	code := fields["code"]
+	if code.IsOptional || code.FieldType != enum.String || len(code.Annotations) != 2 {
+		t.Errorf("expected @NotNull to make code mandatory, got %+v", code)
+	}
+	if length, ok := ruleOf(code, enum.LengthRule); !ok || !hasBounds(length, 3, 20) {
+		t.Errorf("expected @Size as a length rule, got %+v", code.InputValidations)
+	}
+	if pattern, ok := ruleOf(fields["reference"], enum.PatternRule); !ok || pattern.Pattern != `^[A-Z]{2}\d+$` {
+		t.Errorf("expected the unescaped pattern, got %+v", fields["reference"].InputValidations)
+	}
+	if quantity := fields["quantity"]; quantity.IsOptional || quantity.FieldType != enum.Int {
+		t.Errorf("expected a primitive to be mandatory, got %+v", quantity)
+	}
+	if priority := fields["priority"]; !priority.IsOptional || priority.FieldType != enum.Int {
+		t.Errorf("expected a boxed Integer to be optional, got %+v", priority)
+	}
+	if note := fields["note"]; !note.IsOptional || note.FieldType != enum.String {
+		t.Errorf("expected Optional<String> to be an optional String, got %+v", note)
+	}
+	if discount, ok := ruleOf(fields["discount"], enum.RangeRule); !ok || !hasBounds(discount, 0, 100) {
+		t.Errorf("expected @Min and @Max as one range, got %+v", fields["discount"].InputValidations)
+	}
+	if status := fields["status"]; status.FieldType != enum.Enum || !reflect.DeepEqual(status.EnumValues, []string{"PLACED", "SHIPPED"}) {
+		t.Errorf("expected the nested enum, got %+v", status)
+	}
+	if items := fields["itemsByWarehouse"]; items.CollectionType != enum.Map || items.CollectionItemType != enum.NestedCollectionType ||
+		items.NestedCollectionItemType != enum.OtherEntityType || items.Reference != "LineItem" {
+		t.Errorf("expected a map of lists of LineItem, got %+v", items)
+	}
+	if tags := fields["tags"]; tags.CollectionType != enum.Array || tags.CollectionItemType != enum.StringType {
+		t.Errorf("expected an array of strings, got %+v", tags)
+	}
+	if placed := fields["placedAt"]; placed.FieldType != enum.DateTime {
+		t.Errorf("expected a qualified Instant to be a DateTime, got %+v", placed)
+	}
+
+	metadata, fields = parseCode(t, parser.NewJavaParser(), `public record Customer(@NotBlank String name, @Email String email, long id) {}`)
+	if metadata.Name != "Customer" || len(metadata.Fields) != 3 {
+		t.Fatalf("expected the record components, got %+v", metadata)
+	}
+	if name := fields["name"]; name.IsOptional {
+		t.Errorf("expected @NotBlank to make name mandatory, got %+v", name)
+	}
+	if _, ok := ruleOf(fields["email"], enum.EmailRule); !ok {
+		t.Errorf("expected an email rule, got %+v", fields["email"])
+	}
+}
+
+func TestCSharpParserReadsProperties(t *testing.T) {
+	metadata, fields := parseCode(t, parser.NewCSharpParser(), `
+using System.ComponentModel.DataAnnotations;
+
+namespace Shop.Orders
+{
+    public enum OrderStatus { Placed = 1, Shipped = 2 }
+
+    public class Order : EntityBase
+    {
+        public const int MaxItems = 50;
+
+        [Key]
+        public Guid Id { get; set; }
+
+        [Required, StringLength(20, MinimumLength = 3)]
+        public string Code { get; set; } = "";
+
+        [EmailAddress]
+        public string? ContactEmail { get; init; }
+
+        [Range(1, 10)]
+        public int Quantity { get; set; }
+
+        public decimal? Discount { get; set; }
+        public required OrderStatus Status { get; set; }
+        public List<LineItem> Items { get; set; } = new();
+        public Dictionary<string, int> Stock;
+        public DateTimeOffset PlacedAt { get; private set; }
+
+        public int ItemCount => Items.Count;
+
+        public void Ship() { Status = OrderStatus.Shipped; }
+    }
+}`)
+
+	if metadata.Name != "Order" || len(metadata.Fields) != 9 {
+		t.Fatalf("expected the nine properties and fields, got %+v", metadata)
+	}
+	code := fields["Code"]
+	if code.IsOptional || code.FieldType != enum.String {
+		t.Errorf("expected [Required] to make Code mandatory, got %+v", code)
+	}
+	if length, ok := ruleOf(code, enum.LengthRule); !ok || !hasBounds(length, 3, 20) {
+		t.Errorf("expected [StringLength] as a length rule, got %+v", code.InputValidations)
+	}
+	if email := fields["ContactEmail"]; !email.IsOptional {
+		t.Errorf("expected string? to be optional, got %+v", email)
+	} else if _, ok := ruleOf(email, enum.EmailRule); !ok {
+		t.Errorf("expected [EmailAddress] as an email rule, got %+v", email.InputValidations)
+	}
+	if quantity, ok := ruleOf(fields["Quantity"], enum.RangeRule); !ok || !hasBounds(quantity, 1, 10) {
+		t.Errorf("expected [Range] as a range rule, got %+v", fields["Quantity"].InputValidations)
+	}
+	if discount := fields["Discount"]; !discount.IsOptional || discount.FieldType != enum.Float {
+		t.Errorf("expected decimal? to be an optional Float, got %+v", discount)
+	}
+	if status := fields["Status"]; status.IsOptional || !reflect.DeepEqual(status.EnumValues, []string{"Placed", "Shipped"}) {
+		t.Errorf("expected a required enum, got %+v", status)
+	}
+	if items := fields["Items"]; items.CollectionType != enum.List || items.Reference != "LineItem" {
+		t.Errorf("expected a list of LineItem, got %+v", items)
+	}
+	if stock := fields["Stock"]; stock.CollectionType != enum.Map || stock.CollectionItemType != enum.IntType {
+		t.Errorf("expected a map of ints, got %+v", stock)
+	}
+	if placed := fields["PlacedAt"]; placed.FieldType != enum.DateTime {
+		t.Errorf("expected a DateTime, got %+v", placed)
+	}
+}
+
+func TestPythonParserReadsModels(t *testing.T) {
+	metadata, fields := parseCode(t, parser.NewPythonParser(), `
+from datetime import datetime
+from enum import Enum
+from typing import Annotated, Literal, Optional
+from pydantic import BaseModel, EmailStr, Field
+
+
+class Status(str, Enum):
+    PLACED = "placed"
+    SHIPPED = "shipped"
+
+
+class Order(BaseModel):
+    """An order of a customer"""
+
+    model_config = {"frozen": True}
+
+    code: str = Field(..., min_length=3, max_length=20, pattern=r"^[A-Z]+\d*$")
+    status: Status = Status.PLACED
+    channel: Literal["web", "store"]
+    contact: EmailStr
+    note: Optional[str] = None
+    quantity: Annotated[int, Field(ge=1, le=10)]
+    items: list["LineItem"] = Field(default_factory=list)
+    totals: dict[str, float]
+    placed_at: datetime | None
+    _secret: str = "hidden"
+
+    def total(self) -> float:
+        quantity: int = 0
+        return quantity
+`)
+
+	if metadata.Name != "Order" || len(metadata.Fields) != 9 {
+		t.Fatalf("expected the nine annotated fields, got %+v", metadata)
+	}
+	code := fields["code"]
+	if code.IsOptional || code.FieldType != enum.String {
+		t.Errorf("expected Field(...) to be required, got %+v", code)
+	}
+	if length, ok := ruleOf(code, enum.LengthRule); !ok || !hasBounds(length, 3, 20) {
+		t.Errorf("expected min_length and max_length as a length rule, got %+v", code.InputValidations)
+	}
+	if pattern, ok := ruleOf(code, enum.PatternRule); !ok || pattern.Pattern != `^[A-Z]+\d*$` {
+		t.Errorf("expected the raw string as pattern, got %+v", code.InputValidations)
+	}
+	if status := fields["status"]; !status.IsOptional || !reflect.DeepEqual(status.EnumValues, []string{"placed", "shipped"}) {
+		t.Errorf("expected an enum with a default, got %+v", status)
+	}
+	if channel := fields["channel"]; channel.FieldType != enum.Enum || !reflect.DeepEqual(channel.EnumValues, []string{"web", "store"}) {
+		t.Errorf("expected the Literal as an enum, got %+v", channel)
+	}
+	if _, ok := ruleOf(fields["contact"], enum.EmailRule); !ok {
+		t.Errorf("expected EmailStr as an email rule, got %+v", fields["contact"])
+	}
+	if note := fields["note"]; !note.IsOptional {
+		t.Errorf("expected Optional[str] = None to be optional, got %+v", note)
+	}
+	if quantity, ok := ruleOf(fields["quantity"], enum.RangeRule); !ok || !hasBounds(quantity, 1, 10) {
+		t.Errorf("expected the Annotated Field as a range rule, got %+v", fields["quantity"])
+	}
+	if items := fields["items"]; !items.IsOptional || items.CollectionItemType != enum.OtherEntityType || items.Reference != "LineItem" {
+		t.Errorf("expected a list of the forward referenced LineItem, got %+v", items)
+	}
+	if totals := fields["totals"]; totals.CollectionType != enum.Map || totals.CollectionItemType != enum.FloatType {
+		t.Errorf("expected a map of floats, got %+v", totals)
+	}
+	if placed := fields["placed_at"]; !placed.IsOptional || placed.FieldType != enum.DateTime {
+		t.Errorf("expected an optional DateTime, got %+v", placed)
+	}
+}
+
+func TestGoParserReadsTypesAndTags(t *testing.T) {
+	_, fields := parseCode(t, parser.NewGoParser(), "type Order struct {\n"+
+		"\tCode string `json:\"code\" validate:\"required,min=3,max=20\"`\n"+
+		"\tQuantity int `validate:\"gte=1,lte=10\"`\n"+
+		"\tNote *string\n"+
+		"\tItems []LineItem `json:\"items,omitempty\"`\n"+
+		"\tPlacedAt time.Time\n"+
+		"}")
+
+	if code, ok := ruleOf(fields["Code"], enum.LengthRule); !ok || !hasBounds(code, 3, 20) {
+		t.Errorf("expected min and max of a string as a length rule, got %+v", fields["Code"])
+	}
+	if quantity, ok := ruleOf(fields["Quantity"], enum.RangeRule); !ok || !hasBounds(quantity, 1, 10) {
+		t.Errorf("expected gte and lte of a number as a range rule, got %+v", fields["Quantity"])
+	}
+	if note := fields["Note"]; !note.IsOptional || note.Type != "*string" {
+		t.Errorf("expected a pointer to be optional, got %+v", note)
+	}
+	if items := fields["Items"]; !items.IsOptional || items.CollectionType != enum.List || items.Reference != "LineItem" {
+		t.Errorf("expected an omitempty list of LineItem, got %+v", items)
+	}
+	if placed := fields["PlacedAt"]; placed.FieldType != enum.DateTime {
+		t.Errorf("expected time.Time to be a DateTime, got %+v", placed)
+	}
+}
+
+func TestImportFromSourceSupportsEachLanguage(t *testing.T) {
+	importer := service.NewImporterService(parser.NewGoParser())
+	importer.RegisterCodeParser("typescript", parser.NewTypeScriptParser())
+	importer.RegisterCodeParser("java", parser.NewJavaParser())
+	importer.RegisterCodeParser("csharp", parser.NewCSharpParser())
+	importer.RegisterCodeParser("python", parser.NewPythonParser())
+
+	sources := map[string]string{
+		"typescript": "interface Order { status: 'placed' | 'shipped' }",
+		"java":       "class Order { enum Status { PLACED, SHIPPED } Status status; }",
+		"csharp":     "enum Status { Placed, Shipped } class Order { public Status Status { get; set; } }",
+		"python":     "class Order(BaseModel):\n    status: Literal['placed', 'shipped']\n",
+	}
+	for lang, source := range sources {
+		blueprint, err := importer.ImportFromSource(source, lang)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", lang, err)
+			continue
+		}
+		if blueprint.StandardName != "Order" || len(blueprint.Placeholders) != 1 || len(blueprint.Placeholders[0].AllowedValues) != 2 {
+			t.Errorf("%s: unexpected blueprint: %+v", lang, blueprint)
+		}
+	}
+	if _, err := importer.ImportFromSource("fun main() {}", "kotlin"); err == nil {
+		t.Errorf("expected an unsupported language to be rejected")
+	}
+}
+
+var codeParserSamples = map[string]struct {
+	parser service.CodeParser
+	source string
+}{
+	"typescript": {parser.NewTypeScriptParser(), "export type Status = 'a' | 'b'\n" +
+		"export class Order {\n  @Length(1, 5) @Matches(/^[a-z]+$/)\n  code!: string;\n  items: Array<Item | null>\n  get total(): number { return 0 }\n}"},
+	"java": {parser.NewJavaParser(), "public record Customer(@NotBlank String name, List<@Valid Item> items) {}\n" +
+		"class Order { @Size(min = 1) private Map<String, int[]> stock; enum Kind { A, B; } }"},
+	"csharp": {parser.NewCSharpParser(), "namespace Shop {\n  public record Order([property: Required] string Code) {\n" +
+		"    [Range(1, 10)] public int? Quantity { get; set; } = 1;\n    public List<Item> Items => new();\n  }\n}"},
+	"python": {parser.NewPythonParser(), "class Status(str, Enum):\n    A = 'a'\n\nclass Order(BaseModel):\n" +
+		"    code: Annotated[str, Field(max_length=5)] = Field(..., pattern=r'^\\d+$')\n    items: list['Item'] | None = None\n"},
+}
+
+func TestCodeParsersRejectMalformedSource(t *testing.T) {
+	malformed := map[string][]string{
+		"typescript": {"interface O", "export class C", "class Order(BaseModel):\n    code: str\n", "interface O { a: Array<", "@"},
+		"java":       {"public record Customer(@", "class Order { @Size(", "public class"},
+		"csharp":     {"public class Order { [Required", "record R(", "namespace N { class"},
+		"python":     {"class Order(BaseModel:\n    code: str\n", "class Order(BaseModel):\n    code: str = Field(\n", "class"},
+	}
+	for lang, sources := range malformed {
+		for _, source := range sources {
+			if _, err := codeParserSamples[lang].parser.Parse(source); err == nil {
+				t.Errorf("%s: expected %q to be rejected", lang, source)
+			}
+		}
+	}
+}
+
+func TestCodeParsersSurviveTruncatedAndGarbageSource(t *testing.T) {
+	garbage := []string{"", "{{{{", "}}}}", ")]>", "<<<", "@@@", "[[", "class class class", "record(", "enum {",
+		"type = |", "a: b: c:", "\x00\xff", "\"\"\"", "'", "/*", "$@\"", "static", "namespace { {"}
+	for lang, sample := range codeParserSamples {
+		sources := append([]string{}, garbage...)
+		for i := range sample.source {
+			sources = append(sources, sample.source[:i])
+		}
+		for _, source := range sources {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("%s: parsing %q panicked: %v", lang, source, r)
+					}
+				}()
+				sample.parser.Parse(source)
+			}()
+		}
+		if _, err := sample.parser.Parse(sample.source); err != nil {
+			t.Errorf("%s: expected the whole sample to parse, got %v", lang, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+)
+
+func TestParseCurl(t *testing.T) {
+	command := `curl -sS -X POST 'https://api.example.com/v1/customers/{{customerId}}/orders?expand=items&ref={{reference}}' \
+  -H 'Content-Type: application/json' \
+  -H "Authorization: Bearer {{token}}" \
+  --data-raw '{"quantity": {{quantity}}, "note": "fine"}'`
+
+	fields := []model.FieldInvolved{
+		{ID: "f1", Name: "customerId"},
+		{ID: "f2", Name: "quantity"},
+		{ID: "f3", Name: "reference"},
+	}
+
+	spec, err := service.ParseCurl(command, fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Method != "POST" {
+		t.Errorf("expected POST, got %s", spec.Method)
+	}
+	if spec.URL != "https://api.example.com/v1/customers/{{customerId}}/orders" {
+		t.Errorf("unexpected URL %s", spec.URL)
+	}
+	if spec.Query["expand"] != "items" || spec.Query["ref"] != "{{reference}}" {
+		t.Errorf("unexpected query %v", spec.Query)
+	}
+	if spec.Headers["Authorization"] != "Bearer {{token}}" || spec.Headers["Content-Type"] != "application/json" {
+		t.Errorf("unexpected headers %v", spec.Headers)
+	}
+	if spec.BodyFormat != "json" {
+		t.Errorf("expected a json body, got %s", spec.BodyFormat)
+	}
+
+	resolved := map[string]bool{}
+	for _, b := range spec.Bindings {
+		resolved[b.Name+"@"+b.Location] = b.Resolved
+	}
+	expected := map[string]bool{
+		"customerId@path":            true,
+		"reference@query:ref":        true,
+		"token@header:Authorization": false,
+		"quantity@body":              true,
+	}
+	for key, want := range expected {
+		got, ok := resolved[key]
+		if !ok || got != want {
+			t.Errorf("binding %s: expected resolved=%v, got %v (present %v)", key, want, got, ok)
+		}
+	}
+
+	get, err := service.ParseCurl(`curl -G https://api.example.com/search -d q=shoes`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if get.Method != "GET" || get.Query["q"] != "shoes" || get.Body != "" {
+		t.Errorf("expected -G to move data into the query, got %+v", get)
+	}
+
+	for _, invalid := range []string{`curl -X POST`, `curl 'https://example.com`, `curl --proxy-magic https://example.com`} {
+		if _, err := service.ParseCurl(invalid, nil); err == nil {
+			t.Errorf("expected %q to fail", invalid)
+		}
+	}
+}
+
+func TestInferResponseSchema(t *testing.T) {
+	schema, err := service.InferResponseSchema(`{"id": 7, "price": 9.5, "createdAt": "2024-01-02T15:04:05Z",
+		"tags": ["a"], "items": [{"sku": "x"}, {"sku": "y", "qty": 2}], "parent": null}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	props := schema.Properties
+	if schema.Type != "object" || props["id"].Type != "integer" || props["price"].Type != "number" {
+		t.Errorf("unexpected scalar types: %+v", props)
+	}
+	if props["createdAt"].Format != "date-time" || props["parent"].Type != "null" {
+		t.Errorf("unexpected createdAt/parent: %+v %+v", props["createdAt"], props["parent"])
+	}
+	if props["tags"].Items.Type != "string" {
+		t.Errorf("expected string items, got %+v", props["tags"].Items)
+	}
+	items := props["items"].Items
+	if items.Type != "object" || items.Properties["qty"] == nil || items.Properties["sku"] == nil {
+		t.Errorf("expected merged item properties, got %+v", items)
+	}
+
+	if _, err := service.InferResponseSchema(`{"id": `); err == nil {
+		t.Errorf("expected invalid JSON to fail")
+	}
+}
+
+func TestPrepareJourneyReportsInvalidCurls(t *testing.T) {
+	journey := model.Journey{EntityJourneys: []model.EntityJourney{{
+		EntityName: "Order",
+		Operations: []model.Operation{{
+			Name: "createOrder",
+			BackendJourney: []model.JourneyStep{
+				{Index: 1, Type: "API_CALL", Curl: `curl https://api.example.com/stock`, SampleResponse: `{"available": true}`},
+				{Index: 2, Type: "API_CALL", Curl: `curl -X POST`},
+			},
+		}},
+	}}}
+
+	err := service.PrepareJourney(&journey, nil)
+	var journeyErr *service.JourneyValidationError
+	if !errors.As(err, &journeyErr) {
+		t.Fatalf("expected a JourneyValidationError, got %v", err)
+	}
+	if len(journeyErr.Issues) != 1 || journeyErr.Issues[0].StepIndex != 2 || journeyErr.Issues[0].Property != "curl" {
+		t.Errorf("unexpected issues %+v", journeyErr.Issues)
+	}
+
+	spec := journey.EntityJourneys[0].Operations[0].BackendJourney[0].HTTPCall
+	if spec == nil || spec.Method != "GET" || spec.Response == nil || spec.Response.Properties["available"].Type != "boolean" {
+		t.Errorf("expected the valid step to carry its parsed spec, got %+v", spec)
+	}
+}
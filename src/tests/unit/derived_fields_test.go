@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+func orderFields() []model.EntityField {
+	return []model.EntityField{
+		{FieldName: "total", FieldType: enum.Float, IsDerived: true, DerivativeType: enum.Arithmetic, DerivativeExpression: "subtotal + tax"},
+		{FieldName: "subtotal", FieldType: enum.Float, IsDerived: true, DerivativeType: enum.Arithmetic, DerivativeExpression: "quantity * unitPrice"},
+		{FieldName: "tax", FieldType: enum.Float, SampleData: "2.5"},
+		{FieldName: "quantity", FieldType: enum.Int, SampleData: "4"},
+		{FieldName: "unitPrice", FieldType: enum.Float, SampleData: "10.25"},
+		{FieldName: "code", FieldType: enum.String, SampleData: "A7"},
+		{FieldName: "label", FieldType: enum.String, IsDerived: true, DerivativeType: enum.Concatenation, DerivativeExpression: "code + '-' + quantity"},
+		{FieldName: "eta", FieldType: enum.DateTime, IsDerived: true, DerivativeType: enum.Runtime, DerivativeExpression: "computed by the shipping service"},
+	}
+}
+
+func TestCompileDerivedFieldsOrdersDependencies(t *testing.T) {
+	derived, err := service.CompileDerivedFields("Order", orderFields())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, d := range derived {
+		names = append(names, d.Field.FieldName)
+	}
+	if got := strings.Join(names, ","); got != "subtotal,total,label,eta" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
+
+func TestCompileDerivedFieldsReportsIssues(t *testing.T) {
+	fields := []model.EntityField{
+		{FieldName: "a", FieldType: enum.Int, IsDerived: true, DerivativeType: enum.Arithmetic, DerivativeExpression: "b + 1"},
+		{FieldName: "b", FieldType: enum.Int, IsDerived: true, DerivativeType: enum.Arithmetic, DerivativeExpression: "a * 2"},
+		{FieldName: "c", FieldType: enum.Int, IsDerived: true, DerivativeType: enum.Arithmetic, DerivativeExpression: "missing + 1"},
+		{FieldName: "d", FieldType: enum.Int, IsDerived: true, DerivativeType: enum.Formula, DerivativeExpression: "a > 1"},
+	}
+	_, err := service.CompileDerivedFields("Sample", fields)
+	var derivedErr *service.DerivedFieldError
+	if !errors.As(err, &derivedErr) {
+		t.Fatalf("expected a DerivedFieldError, got %v", err)
+	}
+	messages := make(map[string]string)
+	for _, issue := range derivedErr.Issues {
+		messages[issue.Field] = issue.Message
+	}
+	if !strings.Contains(messages["a"], "circular derivation a -> b -> a") {
+		t.Errorf("expected a cycle on a, got %q", messages["a"])
+	}
+	if !strings.Contains(messages["c"], "unknown field missing") {
+		t.Errorf("expected an unknown field on c, got %q", messages["c"])
+	}
+	if !strings.Contains(messages["d"], "produces bool") {
+		t.Errorf("expected a type mismatch on d, got %q", messages["d"])
+	}
+}
+
+func TestComputeDerivedValuesFromSamples(t *testing.T) {
+	fields := orderFields()
+	values, err := service.ComputeDerivedValues("Order", fields, service.SampleValues(fields))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["total"] != 43.5 {
+		t.Errorf("expected total 43.5, got %v", values["total"])
+	}
+	if values["label"] != "A7-4" {
+		t.Errorf("expected label A7-4, got %v", values["label"])
+	}
+	if _, ok := values["eta"]; ok {
+		t.Errorf("runtime fields have no computed value")
+	}
+}
+
+func TestBuildContextCompilesDerivedFields(t *testing.T) {
+	entity := model.Entity{EntityName: "Order", PreferredDB: enum.Postgres, EntityFields: orderFields()}
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+
+	genCtx, err := genService.BuildContext(context.Background(), nil, entity, enum.Golang)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fields := make(map[string]service.GenField)
+	for _, f := range genCtx.Entity.Fields {
+		fields[f.Name] = f
+	}
+
+	subtotal := fields["subtotal"].Derived
+	if subtotal == nil || subtotal.Code != "order.Quantity * order.UnitPrice" || subtotal.ColumnExpression != "quantity * unit_price" {
+		t.Errorf("unexpected subtotal %+v", subtotal)
+	}
+	if label := fields["label"].Derived; label == nil || label.Code != `order.Code + "-" + fmt.Sprint(order.Quantity)` ||
+		label.ColumnExpression != "CONCAT(code, '-', quantity)" {
+		t.Errorf("unexpected label %+v", label)
+	}
+	if fields["total"].SampleData != "43.5" {
+		t.Errorf("expected the computed sample 43.5, got %q", fields["total"].SampleData)
+	}
+	if eta := fields["eta"].Derived; eta == nil || eta.Type != "Runtime" || eta.Code != "" {
+		t.Errorf("unexpected eta %+v", eta)
+	}
+	if fields["quantity"].Derived != nil {
+		t.Errorf("plain fields are not derived")
+	}
+
+	found := false
+	for _, imp := range genCtx.Imports {
+		found = found || imp == "fmt"
+	}
+	if !found {
+		t.Errorf("expected the fmt import for the concatenation, got %v", genCtx.Imports)
+	}
+}
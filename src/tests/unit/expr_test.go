@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/pkg/expr"
+)
+
+func TestExprEvalCondition(t *testing.T) {
+	vars := map[string]interface{}{
+		"quantity": 3,
+		"price":    40.5,
+		"status":   "open",
+		"response": map[string]interface{}{"code": 5000},
+		"tags":     []string{"a", "b"},
+		"lines":    []interface{}{map[string]interface{}{"sku": "x", "qty": 2}},
+		"raw":      []int{1, 2},
+	}
+	tests := []struct {
+		source   string
+		expected bool
+	}{
+		{"quantity * price > 100", true},
+		{"quantity * price > 200", false},
+		{"status in ['open', 'pending'] && !(quantity < 2)", true},
+		{"status == 'closed' or quantity % 2 == 1", true},
+		{"response.code == 5000", true},
+		{"not (price >= 40.5)", false},
+		{"[1, 2] == [1, 2.0]", true},
+		{"[1, 2] != [2, 1]", true},
+		{"tags == ['a', 'b']", true},
+		{"tags in [['b'], ['a', 'b']]", true},
+		{"[1] in [[2], [3]]", false},
+		{"lines == lines && response != lines", true},
+		{"raw == raw && raw != tags", true},
+	}
+	for _, tt := range tests {
+		node, err := expr.Parse(tt.source)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.source, err)
+		}
+		got, err := expr.EvalCondition(node, vars)
+		if err != nil {
+			t.Fatalf("EvalCondition(%q): %v", tt.source, err)
+		}
+		if got != tt.expected {
+			t.Errorf("EvalCondition(%q) = %v, expected %v", tt.source, got, tt.expected)
+		}
+	}
+}
+
+func TestExprCheck(t *testing.T) {
+	scope := expr.Scope{"quantity": expr.Int, "price": expr.Float, "status": expr.String, "payload": expr.Any}
+	valid := []string{"quantity * price > 100", "status in ['a', 'b']", "[1] in [[1], [2]]", "payload.items.count > 0", "status != null"}
+	for _, source := range valid {
+		node, err := expr.Parse(source)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", source, err)
+		}
+		if err := expr.CheckCondition(node, scope); err != nil {
+			t.Errorf("CheckCondition(%q): %v", source, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"discount > 3":     "unknown field discount",
+		"quantity + 1":     "must be a boolean",
+		"status > 3":       "cannot order",
+		"quantity && true": "needs booleans",
+	}
+	for source, message := range invalid {
+		node, err := expr.Parse(source)
+		if err == nil {
+			err = expr.CheckCondition(node, scope)
+		}
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("CheckCondition(%q) = %v, expected %q", source, err, message)
+		}
+	}
+
+	if _, err := expr.Parse("quantity > (1"); err == nil {
+		t.Errorf("expected an unbalanced parenthesis to fail")
+	}
+}
+
+func TestExprTranspile(t *testing.T) {
+	scope := expr.Scope{"quantity": expr.Int, "status": expr.String}
+	node, err := expr.Parse("quantity > 2 && status in ['open', 'held']")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	expected := map[expr.Dialect]string{
+		expr.Go:         `(quantity > 2) && (status == "open" || status == "held")`,
+		expr.TypeScript: `(quantity > 2) && (status === "open" || status === "held")`,
+		expr.Java:       `(quantity > 2) && (Objects.equals(status, "open") || Objects.equals(status, "held"))`,
+		expr.Python:     `(quantity > 2) and (status in ["open", "held"])`,
+	}
+	for dialect, want := range expected {
+		got, err := expr.Transpile(node, dialect, scope, nil)
+		if err != nil {
+			t.Fatalf("Transpile(%d): %v", dialect, err)
+		}
+		if got != want {
+			t.Errorf("Transpile(%d) = %s, expected %s", dialect, got, want)
+		}
+	}
+}
+
+func TestPrepareJourneyChecksConditions(t *testing.T) {
+	entities := []model.Entity{{
+		EntityName: "Order",
+		EntityFields: []model.EntityField{
+			{FieldName: "quantity", FieldType: enum.Int},
+			{FieldName: "price", FieldType: enum.Float},
+		},
+	}}
+	journey := model.Journey{EntityJourneys: []model.EntityJourney{{
+		EntityName: "Order",
+		Operations: []model.Operation{{
+			Name: "createOrder",
+			BackendJourney: []model.JourneyStep{
+				{Index: 1, Type: "BUSINESS_VALIDATION", Condition: "quantity * price > 100"},
+				{Index: 2, Type: "API_CALL", Curl: `curl https://api.example.com/stock`, SampleResponse: `{"available": true}`,
+					Retry: true, RetryConditions: []model.RetryCondition{{Condition: "statusCode == 5000"}},
+					ResponseActions: []model.ResponseAction{{Condition: "response.available == true"}, {Condition: "discount > 0"}}},
+				{Index: 3, Type: "BUSINESS_VALIDATION", Condition: "quantity >"},
+			},
+		}},
+	}}}
+
+	err := service.PrepareJourney(&journey, entities)
+	var journeyErr *service.JourneyValidationError
+	if !errors.As(err, &journeyErr) {
+		t.Fatalf("expected a JourneyValidationError, got %v", err)
+	}
+	if len(journeyErr.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", journeyErr.Issues)
+	}
+	if issue := journeyErr.Issues[0]; issue.StepIndex != 2 || issue.Property != "responseActions[1].condition" || !strings.Contains(issue.Message, "discount") {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+	if issue := journeyErr.Issues[1]; issue.StepIndex != 3 || issue.Property != "condition" {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+}
+
+func TestGenerationTranspilesConditions(t *testing.T) {
+	journeys := &fakeJourneyRepository{journeys: []model.Journey{{
+		EntityJourneys: []model.EntityJourney{{
+			EntityName: "Order",
+			Operations: []model.Operation{{
+				Name: "create order",
+				BackendJourney: []model.JourneyStep{
+					{Index: 1, Type: "BUSINESS_VALIDATION", Condition: "quantity * unitPrice > 100"},
+				},
+			}},
+		}},
+	}}}
+	entity := model.Entity{EntityName: "Order", EntityFields: []model.EntityField{
+		{FieldName: "quantity", FieldType: enum.Int},
+		{FieldName: "unitPrice", FieldType: enum.Float},
+	}}
+	genService := service.NewGenerationService(nil, nil, nil, journeys)
+
+	expected := map[enum.ProgrammingLanguage]string{
+		enum.Golang: "(order.Quantity * order.UnitPrice) > 100",
+		enum.Java:   "(order.getQuantity() * order.getUnitPrice()) > 100",
+		enum.Python: "(order.quantity * order.unit_price) > 100",
+	}
+	for language, want := range expected {
+		genCtx, err := genService.BuildContext(context.Background(), nil, entity, language)
+		if err != nil {
+			t.Fatalf("BuildContext: %v", err)
+		}
+		if got := genCtx.Operations[0].Steps[0].Validation.ConditionCode; got != want {
+			t.Errorf("%s: expected %s, got %s", language, want, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+func TestFormatGoFixesContextImports(t *testing.T) {
+	code := `package order
+import (
+"fmt"
+"strings"
+)
+func Created() time.Time {
+return   time.Now()
+}
+func Name() string { return strings.ToUpper("x") }
+`
+	formatted, diagnostics := service.FormatGo("order.go", code, []string{"time", "fmt"})
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %+v", diagnostics)
+	}
+	expected := `package order
+
+import (
+	"strings"
+	"time"
+)
+
+func Created() time.Time {
+	return time.Now()
+}
+func Name() string { return strings.ToUpper("x") }
+`
+	if formatted != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+}
+
+func TestFormatGoReportsSyntaxErrors(t *testing.T) {
+	code := "package order\n\nfunc Broken() {\n\treturn 1 +\n}\n"
+	formatted, diagnostics := service.FormatGo("order.go", code, nil)
+	if formatted != code {
+		t.Errorf("code that does not parse must be returned unchanged")
+	}
+	if len(diagnostics) == 0 || diagnostics[0].Line != 5 || diagnostics[0].Column != 1 {
+		t.Errorf("unexpected diagnostics %+v", diagnostics)
+	}
+
+	if _, diagnostics := service.FormatGo("README.md", "not go", nil); len(diagnostics) != 0 {
+		t.Errorf("files other than .go are not checked, got %+v", diagnostics)
+	}
+}
+
+func TestCheckBrackets(t *testing.T) {
+	code := "class Order {\n  String note = \"}\"; // )\n  void total() {\n    sum(items];\n  }\n"
+	_, diagnostics := service.CheckBrackets("Order.java", code, nil)
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", diagnostics)
+	}
+	if d := diagnostics[0]; d.Line != 4 || d.Column != 14 || !strings.Contains(d.Message, "] does not close (") {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if d := diagnostics[1]; d.Line != 1 || !strings.Contains(d.Message, "{ is never closed") {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+}
+
+func TestPreviewCodePointsDiagnosticsAtTemplate(t *testing.T) {
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{{
+		Path:     "model.go",
+		Language: enum.Golang,
+		Content:  "package model\n\ntype {{.Entity.Name}} struct {\n\tID int64\n\tName string,\n}\n",
+	}}}
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+
+	file, err := genService.PreviewCode(context.Background(), blueprint, model.Entity{EntityName: "Order"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(file.Diagnostics) == 0 {
+		t.Fatalf("expected diagnostics for the stray comma")
+	}
+	d := file.Diagnostics[0]
+	if d.File != "model.go" || d.Line != 5 || d.Template != "model.go" || d.TemplateLine != 5 {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+
+	genService.RegisterFormatter(enum.Golang, nil)
+	if file, _ := genService.PreviewCode(context.Background(), blueprint, model.Entity{EntityName: "Order"}, nil); len(file.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics without a formatter, got %+v", file.Diagnostics)
+	}
+}
@@ -0,0 +1,266 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"gen-concept-api/api/helper"
+	"gen-concept-api/config"
+	"gen-concept-api/constant"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/pkg/diff"
+	"gen-concept-api/pkg/jobs"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/google/uuid"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\ntwo\nthree\nfour\nFIVE\nsix\nseven\neight\nnine\nten\neleven"
+	text, insertions, deletions := diff.Unified("a/n.txt", "b/n.txt", a, b, 2)
+	expected := `--- a/n.txt
++++ b/n.txt
+@@ -3,5 +3,5 @@
+ three
+ four
+-five
++FIVE
+ six
+ seven
+@@ -9,2 +9,3 @@
+ nine
+ ten
++eleven
+\ No newline at end of file
+`
+	if text != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, text)
+	}
+	if insertions != 2 || deletions != 1 {
+		t.Errorf("expected 2 insertions and 1 deletion, got %d and %d", insertions, deletions)
+	}
+	if text, _, _ := diff.Unified("a", "b", a, a, 3); text != "" {
+		t.Errorf("equal texts have no diff, got %q", text)
+	}
+	if text, insertions, _ := diff.Unified("/dev/null", "b/x", "", "x\n", 3); text != "--- /dev/null\n+++ b/x\n@@ -0,0 +1 @@\n+x\n" || insertions != 1 {
+		t.Errorf("unexpected diff of a new file %q", text)
+	}
+}
+
+func TestDiffLinesIsShortestEditScript(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		out := make([]string, random.Intn(30))
+		for i := range out {
+			out[i] = string(rune('a' + random.Intn(4)))
+		}
+		return out
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		edits := diff.Lines(a, b)
+
+		var got []string
+		cost, x, y := 0, 0, 0
+		for _, e := range edits {
+			switch e.Op {
+			case diff.Equal:
+				if e.A != x || e.B != y || a[e.A] != b[e.B] {
+					t.Fatalf("%v to %v: invalid equal edit %+v", a, b, e)
+				}
+				got = append(got, a[e.A])
+				x, y = x+1, y+1
+			case diff.Delete:
+				if e.A != x {
+					t.Fatalf("%v to %v: out of order delete %+v", a, b, e)
+				}
+				x, cost = x+1, cost+1
+			case diff.Insert:
+				if e.B != y {
+					t.Fatalf("%v to %v: out of order insert %+v", a, b, e)
+				}
+				got = append(got, b[e.B])
+				y, cost = y+1, cost+1
+			}
+		}
+		if x != len(a) || strings.Join(got, "") != strings.Join(b, "") {
+			t.Fatalf("%v to %v: edits %+v do not produce b", a, b, edits)
+		}
+
+		// The shortest script keeps a longest common subsequence
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		if shortest := len(a) + len(b) - 2*lcs[0][0]; cost != shortest {
+			t.Fatalf("%v to %v: %d edits, expected %d", a, b, cost, shortest)
+		}
+	}
+}
+
+func TestDiffLinesBoundsLargeRewrites(t *testing.T) {
+	a, b := make([]string, 200000), make([]string, 200000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+	b[100000] = a[100000]
+
+	started := time.Now()
+	edits := diff.Lines(a, b)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("diffing two rewritten files took %s", elapsed)
+	}
+	if len(edits) < len(a)+len(b)-1 {
+		t.Errorf("expected every line to change, got %d edits", len(edits))
+	}
+}
+
+func (r *fakeRunRepository) GetLatestSucceeded(ctx context.Context, organizationId uint, blueprintUuid uuid.UUID, entityUuids []uuid.UUID) (model.GenerationRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		if run.Status == enum.RunSucceeded && run.OrganizationID == organizationId {
+			return run, nil
+		}
+	}
+	return model.GenerationRun{}, nil
+}
+
+func TestGenerationDiffAgainstLastRun(t *testing.T) {
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "model.txt", Content: "entity {{.Entity.Name}}\n// gen:keep begin notes\n// gen:keep end\n"},
+		{Path: "old.txt", Content: "removed later\n"},
+	}}
+	blueprints := &fakeBlueprintRepository{blueprint: blueprint}
+	entities := fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}
+	runs := &fakeRunRepository{runs: make(map[uuid.UUID]model.GenerationRun)}
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+
+	// Record an output to diff against, with a hand-written note in the protected region
+	pool := jobs.NewPool(1, 0)
+	defer pool.Close()
+	cfg := &config.Config{Generation: config.GenerationConfig{OutputDirectory: t.TempDir()}}
+	runUsecase := usecase.NewGenerationRunUsecase(cfg, runs, blueprints, entities, nil, fakeFileRepository{},
+		&fakeManifestRepository{manifests: make(map[uuid.UUID]model.GenerationManifest)}, fakeUserRepository{}, genService, pool)
+	run, err := runUsecase.Submit(ctx, dto.GenerationRunRequest{
+		EntityIDs: []uuid.UUID{order.Uuid},
+		Previous:  dto.PreviousOutput{Files: map[string]string{"model.txt": "// gen:keep begin notes\nkeep me\n// gen:keep end\n"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); !run.Status.IsFinal() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		run, _ = runUsecase.GetById(ctx, run.Uuid)
+	}
+	if run.Status != enum.RunSucceeded {
+		t.Fatalf("unexpected run %+v", run)
+	}
+
+	blueprints.blueprint = model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "model.txt", Content: "entity {{.Entity.Name}} v2\n// gen:keep begin notes\n// gen:keep end\n"},
+		{Path: "new.txt", Content: "added\n"},
+	}}
+	diffUsecase := usecase.NewGenerationDiffUsecase(cfg, blueprints, entities, nil, runs, fakeUserRepository{}, genService)
+	result, err := diffUsecase.Diff(ctx, dto.GenerationDiffRequest{EntityID: &order.Uuid})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Baseline != "run" || result.BaselineRunID == nil || *result.BaselineRunID != run.Uuid {
+		t.Errorf("expected the last run as baseline, got %+v", result)
+	}
+	summary := result.Summary
+	if summary.Added != 1 || summary.Removed != 1 || summary.Modified != 1 || summary.Insertions != 2 || summary.Deletions != 2 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	expected := `--- a/model.txt
++++ b/model.txt
+@@ -1,4 +1,4 @@
+-entity Order
++entity Order v2
+ // gen:keep begin notes
+ keep me
+ // gen:keep end
+`
+	if len(result.Files) != 3 || result.Files[0].Path != "model.txt" || result.Files[0].Diff != expected {
+		t.Fatalf("unexpected files %+v", result.Files)
+	}
+	if result.Files[1].Status != dto.FileAdded || result.Files[2].Status != dto.FileRemoved {
+		t.Errorf("unexpected statuses %+v", result.Files)
+	}
+}
+
+func TestGenerationDiffRejectsLargeBaselines(t *testing.T) {
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	blueprints := &fakeBlueprintRepository{blueprint: model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "model.txt", Content: "x\n"}}}}
+	entities := fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}
+	diffUsecase := usecase.NewGenerationDiffUsecase(&config.Config{}, blueprints, entities, nil, nil, fakeUserRepository{},
+		service.NewGenerationService(nil, nil, nil, nil))
+
+	large := strings.Repeat("line\n", usecase.MaxBaselineBytes/10+1)
+	_, err := diffUsecase.Diff(context.Background(), dto.GenerationDiffRequest{
+		EntityID: &order.Uuid,
+		Baseline: dto.PreviousOutput{Files: map[string]string{"a.txt": large, "b.txt": large}},
+	})
+	if err == nil || helper.TranslateErrorToStatusCode(err) != 413 {
+		t.Errorf("expected the baseline to be rejected as too large, got %v", err)
+	}
+}
+
+func TestGenerationWithoutEntity(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	entities := fakeEntityRepository{entities: map[uuid.UUID]model.Entity{}}
+	manifests := &fakeManifestRepository{manifests: make(map[uuid.UUID]model.GenerationManifest)}
+
+	perEntity := &fakeBlueprintRepository{blueprint: model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "model.txt", Content: "{{.Entity.Name}}\n"},
+		{Path: "routes.txt", Content: "{{.Project.Name}}\n", Scope: enum.ScopeProject},
+	}}}
+	generation := usecase.NewGenerationUsecase(nil, perEntity, entities, nil, manifests, fakeUserRepository{}, genService)
+	if _, err := generation.GenerateFiles(ctx, dto.GenerationRequest{}); err == nil || helper.TranslateErrorToStatusCode(err) != 400 {
+		t.Errorf("expected per-entity files to need an entity, got %v", err)
+	}
+	if _, _, _, err := generation.Generate(ctx, dto.GenerationRequest{}); err == nil || helper.TranslateErrorToStatusCode(err) != 400 {
+		t.Errorf("expected the preview to need an entity, got %v", err)
+	}
+	diffUsecase := usecase.NewGenerationDiffUsecase(&config.Config{}, perEntity, entities, nil, nil, fakeUserRepository{}, genService)
+	_, err := diffUsecase.Diff(ctx, dto.GenerationDiffRequest{Baseline: dto.PreviousOutput{Files: map[string]string{"model.txt": "Order\n"}}})
+	if err == nil || helper.TranslateErrorToStatusCode(err) != 400 {
+		t.Errorf("expected the diff to need an entity or project, got %v", err)
+	}
+
+	// Blueprints with per-project files only render for an empty project
+	perProject := &fakeBlueprintRepository{blueprint: model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "routes.txt", Content: "routes of {{len .Project.Entities}} entities\n", Scope: enum.ScopeProject},
+	}}}
+	output, err := usecase.NewGenerationUsecase(nil, perProject, entities, nil, manifests, fakeUserRepository{}, genService).
+		GenerateFiles(ctx, dto.GenerationRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Files) != 1 || output.Files[0].Content != "routes of 0 entities\n" {
+		t.Errorf("unexpected files %+v", output.Files)
+	}
+}
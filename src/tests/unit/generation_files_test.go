@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+)
+
+func TestGenerateFilesRendersPathTemplates(t *testing.T) {
+	blueprint := model.Blueprint{
+		TemplatePath: "internal/{{snake .Entity.Name}}/model.txt",
+		Templates: []model.BlueprintTemplate{
+			{Path: "internal/{{snake .Entity.Name}}/model.txt", Content: `{{template "_header.txt" .}}model {{.Entity.Name}}`},
+			{Path: "./docs/../{{.Entity.VarName}}\\README.txt", Content: "readme"},
+			{Path: "_header.txt", Content: "// {{.Inputs.Owner}}\n"},
+		},
+		Placeholders:    []model.Placeholder{{Name: "Owner", Type: "String"}},
+		Functionalities: []model.Functionality{{FilePathsCSV: " api/{{kebab .Entity.Name}}.txt , internal/{{snake .Entity.Name}}/model.txt"}},
+	}
+	files, err := service.NewGenerationService(nil, nil, nil, nil).GenerateFiles(context.Background(), blueprint,
+		model.Entity{EntityName: "OrderItem"}, map[string]string{"Owner": "shop"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []service.GeneratedFile{
+		{Path: "internal/order_item/model.txt", Content: "// shop\nmodel OrderItem"},
+		{Path: "orderItem/README.txt", Content: "readme"},
+		{Path: "api/order-item.txt", Content: "// shop\nmodel OrderItem"}, // Declared only, rendered with the main template
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files without the partial, got %+v", len(expected), files)
+	}
+	for i, f := range expected {
+		if files[i].Path != f.Path || files[i].Content != f.Content {
+			t.Errorf("expected %+v, got %+v", f, files[i])
+		}
+	}
+}
+
+func TestGenerateFilesRejectsPathsOutsideTheOutput(t *testing.T) {
+	paths := map[string]string{
+		"../{{.Entity.Name}}.txt":      "renders to invalid path",
+		"a/../../{{.Entity.Name}}.txt": "renders to invalid path",
+		"/etc/{{.Entity.Name}}":        "renders to invalid path",
+		`{{"/"}}{{.Entity.Name}}.txt`:  "renders to invalid path",
+		`{{".."}}`:                     "renders to invalid path",
+		`..\{{.Entity.Name}}.txt`:      "renders to invalid path",
+		`{{if false}}x{{end}}`:         "renders to invalid path",
+		"{{.Entity.Name}}/./..":        "renders to invalid path",
+		"{{.Entity.Name":               "failed to parse file path",
+		"{{.Entity.Name}}.txt,{{.Entity.Name}}/../{{.Entity.Name}}.txt": "both render to Order.txt",
+	}
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	for rawPaths, message := range paths {
+		blueprint := model.Blueprint{
+			Templates:       []model.BlueprintTemplate{{Path: "_main.txt", Content: "content"}},
+			Functionalities: []model.Functionality{{FilePathsCSV: rawPaths}},
+		}
+		_, err := genService.GenerateFiles(context.Background(), blueprint, model.Entity{EntityName: "Order"}, nil)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", rawPaths, message, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"gen-concept-api/constant"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/google/uuid"
+)
+
+type fakeManifestRepository struct {
+	repository.GenerationManifestRepository
+	mu        sync.Mutex
+	manifests map[uuid.UUID]model.GenerationManifest
+}
+
+func (r *fakeManifestRepository) Create(ctx context.Context, manifest model.GenerationManifest) (model.GenerationManifest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	manifest.ID = uint(len(r.manifests) + 1)
+	manifest.Uuid = uuid.New()
+	r.manifests[manifest.Uuid] = manifest
+	return manifest, nil
+}
+
+func (r *fakeManifestRepository) GetById(ctx context.Context, id uuid.UUID) (model.GenerationManifest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.manifests[id], nil
+}
+
+// fixedAIProvider answers every prompt the same way
+type fixedAIProvider struct {
+	answer string
+}
+
+func (p fixedAIProvider) GenerateContent(prompt string) (string, error) {
+	return p.answer, nil
+}
+
+func TestSnapshotHashIgnoresBookkeeping(t *testing.T) {
+	entity := model.Entity{BaseModel: model.BaseModel{ID: 1, Uuid: uuid.New()}, EntityName: "Order"}
+	first, err := service.SnapshotHash(entity)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entity.ID = 2
+	entity.ModifiedBy = &entity.ID
+	if second, _ := service.SnapshotHash(entity); second != first {
+		t.Errorf("ids and audit columns must not change the hash")
+	}
+	entity.EntityName = "Purchase"
+	if third, _ := service.SnapshotHash(entity); third == first {
+		t.Errorf("a renamed entity must change the hash")
+	}
+}
+
+func TestManifestReproducesOutput(t *testing.T) {
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	blueprint := model.Blueprint{
+		BaseModel:    model.BaseModel{Uuid: uuid.New()},
+		StandardName: "notes",
+		Templates: []model.BlueprintTemplate{
+			{Path: "model.txt", Content: "entity {{.Entity.Name}}\n// {{ai \"summarize\"}}\n// gen:keep begin notes\n// gen:keep end\n"},
+		},
+	}
+	blueprints := &fakeBlueprintRepository{blueprint: blueprint}
+	entities := fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}
+	manifests := &fakeManifestRepository{manifests: make(map[uuid.UUID]model.GenerationManifest)}
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+
+	genService := service.NewGenerationService(nil, fixedAIProvider{answer: "first answer"}, nil, nil)
+	generation := usecase.NewGenerationUsecase(nil, blueprints, entities, nil, manifests, fakeUserRepository{}, genService)
+	output, err := generation.GenerateFiles(ctx, dto.GenerationRequest{
+		Inputs:   map[string]string{"entity_id": order.Uuid.String()},
+		Previous: dto.PreviousOutput{Files: map[string]string{"model.txt": "// gen:keep begin notes\nkeep me\n// gen:keep end\n"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := output.Entries()
+	last := entries[len(entries)-1]
+	if last.Path != service.ManifestPath {
+		t.Fatalf("expected the manifest in the output, got %+v", entries)
+	}
+	var document model.Manifest
+	if err := json.Unmarshal(last.Content, &document); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if document.EngineVersion != service.EngineVersion || len(document.Entities) != 1 || len(document.AICalls) != 1 ||
+		len(document.PreservedRegions) != 1 || document.PreservedRegions[0].Content != "keep me\n" || len(document.Files) != 1 {
+		t.Fatalf("unexpected manifest %+v", document)
+	}
+	if stored := manifests.manifests[output.Manifest.Uuid]; stored.OrganizationID != 7 || stored.Digest != service.ContentHash(last.Content) {
+		t.Errorf("unexpected stored manifest %+v", stored)
+	}
+
+	// Another provider answers differently, so only the recorded answer reproduces the output
+	genService = service.NewGenerationService(nil, fixedAIProvider{answer: "second answer"}, nil, nil)
+	manifestUsecase := usecase.NewGenerationManifestUsecase(nil, manifests, blueprints, entities, nil, fakeUserRepository{}, genService)
+	result, err := manifestUsecase.Reproduce(ctx, output.Manifest.Uuid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Identical || len(result.Files) != 1 || result.Files[0].Status != dto.FileIdentical || len(result.Drift) != 0 {
+		t.Errorf("expected an identical reproduction, got %+v", result)
+	}
+
+	order.EntityName = "Purchase"
+	entities.entities[order.Uuid] = order
+	if result, err = manifestUsecase.Reproduce(ctx, output.Manifest.Uuid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Identical || result.Files[0].Status != dto.FileChanged {
+		t.Errorf("expected a changed file, got %+v", result)
+	}
+	if len(result.Drift) != 1 || !strings.Contains(result.Drift[0], "entity Order changed") {
+		t.Errorf("expected the entity drift, got %v", result.Drift)
+	}
+}
+
+func TestManifestReportsJourneyDrift(t *testing.T) {
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	blueprint := model.Blueprint{
+		BaseModel: model.BaseModel{Uuid: uuid.New()},
+		Templates: []model.BlueprintTemplate{{Path: "handler.go", Content: "{{range .Operations}}func {{.MethodName}}() {}\n{{end}}"}},
+	}
+	journeys := &fakeJourneyRepository{journeys: []model.Journey{{
+		BaseModel: model.BaseModel{Uuid: uuid.New()},
+		EntityJourneys: []model.EntityJourney{{EntityName: "Order", Operations: []model.Operation{
+			{Type: enum.Create, Name: "create order"},
+		}}},
+	}}}
+	blueprints := &fakeBlueprintRepository{blueprint: blueprint}
+	entities := fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}
+	manifests := &fakeManifestRepository{manifests: make(map[uuid.UUID]model.GenerationManifest)}
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	genService := service.NewGenerationService(nil, nil, nil, journeys)
+
+	output, err := usecase.NewGenerationUsecase(nil, blueprints, entities, nil, manifests, fakeUserRepository{}, genService).
+		GenerateFiles(ctx, dto.GenerationRequest{Inputs: map[string]string{"entity_id": order.Uuid.String()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recorded := output.Manifest.Content.Journeys
+	if len(recorded) != 1 || recorded[0].Uuid != journeys.journeys[0].Uuid || recorded[0].Hash == "" {
+		t.Fatalf("expected the journey in the manifest, got %+v", recorded)
+	}
+
+	journeys.journeys[0].EntityJourneys[0].Operations[0].Name = "place order"
+	result, err := usecase.NewGenerationManifestUsecase(nil, manifests, blueprints, entities, nil, fakeUserRepository{}, genService).
+		Reproduce(ctx, output.Manifest.Uuid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Identical || len(result.Drift) != 1 || !strings.Contains(result.Drift[0], "journey of Order changed") {
+		t.Errorf("expected the edited journey to explain the changed file, got %+v", result)
+	}
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+
+	"github.com/google/uuid"
+)
+
+// fakeJourneyRepository serves journeys from memory; other methods are not used by generation
+type fakeJourneyRepository struct {
+	repository.JourneyRepository
+	journeys []model.Journey
+	queries  int
+}
+
+func (r *fakeJourneyRepository) GetByProjectUuid(ctx context.Context, projectUuid uuid.UUID) ([]model.Journey, error) {
+	r.queries++
+	return r.journeys, nil
+}
+
+func TestGenerationOperations(t *testing.T) {
+	parentID := uint(10)
+	journeys := &fakeJourneyRepository{journeys: []model.Journey{{
+		EntityJourneys: []model.EntityJourney{{
+			EntityName: "Customer",
+			Operations: []model.Operation{{
+				Type: enum.Create,
+				Name: "create customer",
+				BackendJourney: []model.JourneyStep{
+					{BaseModel: model.BaseModel{ID: 12}, Index: 3, Type: "RETURN"},
+					{BaseModel: model.BaseModel{ID: 10}, Index: 2, Type: "DATABASE_OPERATION", DBAction: enum.Insert, Retry: true, RetryCount: 3},
+					{BaseModel: model.BaseModel{ID: 11}, Index: 1, Type: "CACHE_OPERATION", CacheAction: enum.CacheInsert, ParentStepID: &parentID},
+					{BaseModel: model.BaseModel{ID: 9}, Index: 1, Type: "INPUT_VALIDATION", Condition: "email != ''",
+						FieldsInvolved: []model.FieldInvolved{{Name: "email"}}},
+				},
+			}},
+		}},
+	}}}
+
+	genService := service.NewGenerationService(nil, nil, nil, journeys)
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "handler.go", Content: `{{range .Operations}}func {{.MethodName}}() {
+{{range .Steps}}{{.Index}}:{{.Type}}{{with .Validation}} if {{.Condition}}{{end}}{{with .DbAction}} {{.Action}}{{end}}{{with .Retry}} x{{.Count}}{{end}}{{range .SubSteps}} [{{.Type}}{{with .CacheAction}} {{.Action}}{{end}}]{{end}}
+{{end}}}{{end}}`}}}
+
+	code, err := genService.GenerateCode(context.Background(), blueprint, model.Entity{EntityName: "Customer"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `func CreateCustomer() {
+1:INPUT_VALIDATION if email != ''
+2:DATABASE_OPERATION INSERT x3 [CACHE_OPERATION INSERT]
+3:RETURN
+}`
+	if code != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, code)
+	}
+}
+
+func TestGenerationLoadsJourneysOncePerScope(t *testing.T) {
+	journeys := &fakeJourneyRepository{journeys: []model.Journey{{
+		EntityJourneys: []model.EntityJourney{{EntityName: "Customer", Operations: []model.Operation{{Type: enum.Create, Name: "create customer"}}}},
+	}}}
+	genService := service.NewGenerationService(nil, nil, nil, journeys)
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "{{.Entity.VarName}}.go", Content: "{{range .Operations}}{{.MethodName}}{{end}}", Language: enum.Golang},
+		{Path: "{{.Entity.VarName}}.py", Content: "{{range .Operations}}{{.MethodName}}{{end}}", Language: enum.Python},
+	}}
+
+	scope := service.NewGenerationScope()
+	for _, entity := range []model.Entity{{EntityName: "Customer"}, {EntityName: "Order"}} {
+		if _, err := genService.GenerateFilesWithProgress(context.Background(), scope, blueprint, entity, nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if journeys.queries != 1 {
+		t.Errorf("expected the journeys to be loaded once, got %d queries", journeys.queries)
+	}
+}
@@ -0,0 +1,370 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gen-concept-api/config"
+	"gen-concept-api/constant"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/pkg/jobs"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/google/uuid"
+)
+
+func TestPoolLimitsJobsPerKey(t *testing.T) {
+	pool := jobs.NewPool(4, 1)
+	defer pool.Close()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+	for _, id := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		err := pool.Submit(id, "org-1", func(ctx context.Context) {
+			defer wg.Done()
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	wg.Wait()
+	if maxRunning != 1 {
+		t.Errorf("expected one job of the key at a time, got %d", maxRunning)
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	pool := jobs.NewPool(1, 0)
+	defer pool.Close()
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	_ = pool.Submit("running", "org", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+	})
+	<-started
+	_ = pool.Submit("queued", "org", func(ctx context.Context) {
+		t.Errorf("a cancelled queued job must not run")
+	})
+
+	if wasQueued, found := pool.Cancel("queued"); !wasQueued || !found {
+		t.Errorf("expected the queued job to be dropped, got %v %v", wasQueued, found)
+	}
+	if wasQueued, found := pool.Cancel("running"); wasQueued || !found {
+		t.Errorf("expected the running job to be cancelled, got %v %v", wasQueued, found)
+	}
+	<-stopped
+	if _, found := pool.Cancel("unknown"); found {
+		t.Errorf("unknown jobs are not found")
+	}
+}
+
+func TestPoolRecoversPanickingJobs(t *testing.T) {
+	pool := jobs.NewPool(1, 1)
+	defer pool.Close()
+
+	panicked := make(chan string, 1)
+	pool.OnPanic(func(id string, recovered any) {
+		panicked <- fmt.Sprintf("%s: %v", id, recovered)
+	})
+	_ = pool.Submit("panics", "org", func(ctx context.Context) {
+		panic("boom")
+	})
+	ran := make(chan struct{})
+	_ = pool.Submit("next", "org", func(ctx context.Context) {
+		close(ran)
+	})
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the worker and the key slot to be released after the panic")
+	}
+	if message := <-panicked; message != "panics: boom" {
+		t.Errorf("unexpected panic report %q", message)
+	}
+	if _, found := pool.Cancel("panics"); found {
+		t.Errorf("the panicked job must not stay active")
+	}
+}
+
+type fakeRunRepository struct {
+	repository.GenerationRunRepository
+	mu   sync.Mutex
+	runs map[uuid.UUID]model.GenerationRun
+}
+
+func (r *fakeRunRepository) Create(ctx context.Context, run model.GenerationRun) (model.GenerationRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = uint(len(r.runs) + 1)
+	run.Uuid = uuid.New()
+	r.runs[run.Uuid] = run
+	return run, nil
+}
+
+func (r *fakeRunRepository) SaveProgress(ctx context.Context, run *model.GenerationRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := *run
+	saved.Files = append([]model.GenerationRunFile(nil), run.Files...)
+	r.runs[run.Uuid] = saved
+	return nil
+}
+
+func (r *fakeRunRepository) FailUnfinished(ctx context.Context, instance string, message string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for id, run := range r.runs {
+		if run.Instance == instance && !run.Status.IsFinal() {
+			run.Status, run.Error = enum.RunFailed, message
+			r.runs[id] = run
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func (r *fakeRunRepository) GetById(ctx context.Context, id uuid.UUID) (model.GenerationRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs[id], nil
+}
+
+type fakeBlueprintRepository struct {
+	repository.BlueprintRepository
+	blueprint model.Blueprint
+}
+
+func (r fakeBlueprintRepository) GetByUuidAtTemplateVersion(ctx context.Context, id uuid.UUID, version int) (model.Blueprint, error) {
+	return r.blueprint, nil
+}
+
+type fakeEntityRepository struct {
+	repository.EntityRepository
+	entities map[uuid.UUID]model.Entity
+}
+
+func (r fakeEntityRepository) GetById(ctx context.Context, id uuid.UUID) (model.Entity, error) {
+	return r.entities[id], nil
+}
+
+type fakeFileRepository struct {
+	repository.FileRepository
+}
+
+func (fakeFileRepository) Create(ctx context.Context, file model.File) (model.File, error) {
+	file.ID = 1
+	return file, nil
+}
+
+type failingFileRepository struct {
+	repository.FileRepository
+}
+
+func (failingFileRepository) Create(ctx context.Context, file model.File) (model.File, error) {
+	return model.File{}, errors.New("database is down")
+}
+
+type fakeUserRepository struct {
+	repository.UserRepository
+}
+
+func (fakeUserRepository) GetOrganizationID(ctx context.Context, userId uint) (uint, error) {
+	return 7, nil
+}
+
+func TestGenerationRunStoresArchive(t *testing.T) {
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	customer := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Customer"}
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "{{.Entity.VarName}}.txt", Content: "{{.Entity.Name}}"},
+	}}
+
+	runs := &fakeRunRepository{runs: make(map[uuid.UUID]model.GenerationRun)}
+	cfg := &config.Config{Generation: config.GenerationConfig{OutputDirectory: t.TempDir()}}
+	pool := jobs.NewPool(1, 1)
+	defer pool.Close()
+	u := usecase.NewGenerationRunUsecase(cfg, runs, fakeBlueprintRepository{blueprint: blueprint},
+		fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order, customer.Uuid: customer}},
+		nil, fakeFileRepository{}, &fakeManifestRepository{manifests: make(map[uuid.UUID]model.GenerationManifest)},
+		fakeUserRepository{}, service.NewGenerationService(nil, nil, nil, nil), pool)
+
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	run, err := u.Submit(ctx, dto.GenerationRunRequest{EntityIDs: []uuid.UUID{order.Uuid, customer.Uuid}, Format: "tar.gz"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !run.Status.IsFinal() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		if run, err = u.GetById(ctx, run.Uuid); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if run.Status != enum.RunSucceeded || run.CompletedFiles != 2 || run.TotalFiles != 2 || !run.Downloadable {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	if run.Files[0].Path != "order.txt" || run.Files[1].Entity != "Customer" || run.ManifestID == nil {
+		t.Errorf("unexpected files %+v", run.Files)
+	}
+
+	file, err := u.Download(ctx, run.Uuid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(file.Directory, file.Name)); err != nil || file.MimeType != "application/gzip" {
+		t.Errorf("expected the stored archive, got %+v (%v)", file, err)
+	}
+	if _, err := u.Cancel(ctx, run.Uuid); err == nil {
+		t.Errorf("finished runs cannot be cancelled")
+	}
+}
+
+func waitForRun(t *testing.T, u *usecase.GenerationRunUsecase, ctx context.Context, id uuid.UUID) dto.GenerationRun {
+	t.Helper()
+	run, err := u.GetById(ctx, id)
+	for deadline := time.Now().Add(5 * time.Second); err == nil && !run.Status.IsFinal() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		run, err = u.GetById(ctx, id)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return run
+}
+
+func TestGenerationRunsBelongToTheirInstance(t *testing.T) {
+	runs := &fakeRunRepository{runs: map[uuid.UUID]model.GenerationRun{}}
+	other := model.GenerationRun{BaseModel: model.BaseModel{Uuid: uuid.New()}, Instance: "api-2", Status: enum.RunRunning}
+	leftover := model.GenerationRun{BaseModel: model.BaseModel{Uuid: uuid.New()}, Instance: "api-1", Status: enum.RunQueued}
+	runs.runs[other.Uuid], runs.runs[leftover.Uuid] = other, leftover
+
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	cfg := &config.Config{Generation: config.GenerationConfig{OutputDirectory: t.TempDir(), InstanceID: "api-1"}}
+	pool := jobs.NewPool(1, 0)
+	u := usecase.NewGenerationRunUsecase(cfg, runs, fakeBlueprintRepository{blueprint: model.Blueprint{
+		Templates: []model.BlueprintTemplate{{Path: "spin.txt", Content: "{{range 4000000000}}{{end}}"}},
+	}}, fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}, nil, fakeFileRepository{}, &fakeManifestRepository{manifests: map[uuid.UUID]model.GenerationManifest{}},
+		fakeUserRepository{}, service.NewGenerationService(nil, nil, nil, nil), pool)
+
+	if err := u.FailInterrupted(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runs.runs[leftover.Uuid].Status != enum.RunFailed || runs.runs[other.Uuid].Status != enum.RunRunning {
+		t.Errorf("expected only the leftover of this instance to fail, got %+v", runs.runs)
+	}
+
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	running, err := u.Submit(ctx, dto.GenerationRunRequest{EntityIDs: []uuid.UUID{order.Uuid}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queued, err := u.Submit(ctx, dto.GenerationRunRequest{EntityIDs: []uuid.UUID{order.Uuid}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for run, _ := u.GetById(ctx, running.Uuid); run.Status != enum.RunRunning; run, _ = u.GetById(ctx, running.Uuid) {
+		if run.Status.IsFinal() {
+			t.Fatalf("expected the run to keep running, got %+v", run)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if runs.runs[running.Uuid].Instance != "api-1" {
+		t.Errorf("expected the run to belong to api-1, got %+v", runs.runs[running.Uuid])
+	}
+
+	if err := u.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []uuid.UUID{running.Uuid, queued.Uuid} {
+		if run := runs.runs[id]; run.Status != enum.RunFailed || !strings.Contains(run.Error, "shutdown") {
+			t.Errorf("expected the run to fail on shutdown, got %+v", run)
+		}
+	}
+	if runs.runs[other.Uuid].Status != enum.RunRunning {
+		t.Errorf("expected the run of api-2 to be left alone, got %+v", runs.runs[other.Uuid])
+	}
+	if _, err := u.Submit(ctx, dto.GenerationRunRequest{EntityIDs: []uuid.UUID{order.Uuid}}); !errors.Is(err, jobs.ErrPoolClosed) {
+		t.Errorf("expected no runs after Close, got %v", err)
+	}
+}
+
+// panickingEntityRepository panics while the entities are loaded
+type panickingEntityRepository struct {
+	repository.EntityRepository
+}
+
+func (panickingEntityRepository) GetById(ctx context.Context, id uuid.UUID) (model.Entity, error) {
+	panic("entity store exploded")
+}
+
+func TestGenerationRunFailsWhenGenerationPanics(t *testing.T) {
+	runs := &fakeRunRepository{runs: map[uuid.UUID]model.GenerationRun{}}
+	pool := jobs.NewPool(1, 0)
+	defer pool.Close()
+	u := usecase.NewGenerationRunUsecase(&config.Config{Generation: config.GenerationConfig{OutputDirectory: t.TempDir()}}, runs,
+		fakeBlueprintRepository{blueprint: model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "a.txt", Content: "a"}}}},
+		panickingEntityRepository{}, nil, fakeFileRepository{}, &fakeManifestRepository{manifests: map[uuid.UUID]model.GenerationManifest{}},
+		fakeUserRepository{}, service.NewGenerationService(nil, nil, nil, nil), pool)
+
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	run, err := u.Submit(ctx, dto.GenerationRunRequest{EntityIDs: []uuid.UUID{uuid.New()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run = waitForRun(t, u, ctx, run.Uuid); run.Status != enum.RunFailed || !strings.Contains(run.Error, "entity store exploded") {
+		t.Fatalf("expected the panic to fail the run, got %+v", run)
+	}
+}
+
+func TestGenerationRunRemovesUnrecordedArchives(t *testing.T) {
+	runs := &fakeRunRepository{runs: map[uuid.UUID]model.GenerationRun{}}
+	order := model.Entity{BaseModel: model.BaseModel{Uuid: uuid.New()}, EntityName: "Order"}
+	directory := t.TempDir()
+	pool := jobs.NewPool(1, 0)
+	defer pool.Close()
+	u := usecase.NewGenerationRunUsecase(&config.Config{Generation: config.GenerationConfig{OutputDirectory: directory}}, runs,
+		fakeBlueprintRepository{blueprint: model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "a.txt", Content: "a"}}}},
+		fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}, nil, failingFileRepository{}, &fakeManifestRepository{manifests: map[uuid.UUID]model.GenerationManifest{}},
+		fakeUserRepository{}, service.NewGenerationService(nil, nil, nil, nil), pool)
+
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	run, err := u.Submit(ctx, dto.GenerationRunRequest{EntityIDs: []uuid.UUID{order.Uuid}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run = waitForRun(t, u, ctx, run.Uuid); run.Status != enum.RunFailed || !strings.Contains(run.Error, "database is down") {
+		t.Fatalf("expected the run to fail, got %+v", run)
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 0 {
+		t.Errorf("expected the archive to be removed, found %v", entries)
+	}
+}
@@ -0,0 +1,354 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/infra/parser"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/google/uuid"
+)
+
+const petStoreSpec = `
+openapi: 3.0.3
+info: {title: Pet Store, version: "1.0"}
+security:
+  - bearerAuth: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+        - {name: status, in: query, schema: {$ref: '#/components/schemas/PetStatus'}}
+        - {name: weight_gte, in: query, schema: {type: number}}
+        - {name: bornAfter, in: query, schema: {type: string, format: date}}
+        - {name: q, in: query, schema: {type: string}}
+        - {name: sort, in: query, schema: {type: string, enum: [name, -born]}}
+        - {name: color, in: query, schema: {type: string}}
+        - {name: X-Tenant, in: header, schema: {type: string}}
+      responses:
+        '200':
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+    post:
+      summary: Add a pet
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+      responses:
+        '201': {description: Created}
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: string}}
+    get:
+      operationId: getPet
+      security: []
+      responses:
+        '200':
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+    patch:
+      operationId: updatePet
+      responses: {'204': {description: Updated}}
+    delete:
+      operationId: deletePet
+      responses: {'204': {description: Deleted}}
+  /pets/{petId}/adopt:
+    post:
+      operationId: adoptPet
+      responses: {'204': {description: Adopted}}
+  /search:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data: {type: array, items: {$ref: '#/components/schemas/Owner'}}
+  /health:
+    head:
+      responses: {'200': {description: OK}}
+components:
+  schemas:
+    PetStatus:
+      type: string
+      enum: [available, adopted]
+    Named:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, minLength: 1, maxLength: 50, example: Rex}
+    Pet:
+      description: An animal of the store
+      allOf:
+        - $ref: '#/components/schemas/Named'
+        - type: object
+          required: [status, born]
+          properties:
+            id: {type: string, format: uuid, readOnly: true}
+            status: {$ref: '#/components/schemas/PetStatus'}
+            born: {type: string, format: date}
+            weight: {type: number, minimum: 0}
+            tags: {type: array, uniqueItems: true, items: {type: string}}
+            owner: {$ref: '#/components/schemas/Owner'}
+            vaccinations:
+              type: array
+              items:
+                type: object
+                properties:
+                  vaccine: {type: string}
+                  givenAt: {type: string, format: date-time}
+            attributes: {type: object, additionalProperties: {type: integer}}
+            extra: {type: object}
+            photo: {$ref: 'photos.yaml#/Photo'}
+    Owner:
+      type: object
+      properties:
+        email: {type: string, format: email, nullable: true}
+        pets: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+`
+
+func parseOpenAPI(t *testing.T, content string) (map[string]model.Entity, map[string]model.Operation, []string) {
+	t.Helper()
+	schema, err := parser.NewOpenAPIParser().ParseSchema(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entities := map[string]model.Entity{}
+	for _, e := range schema.Entities {
+		entities[e.EntityName] = e
+	}
+	operations := map[string]model.Operation{}
+	for _, j := range schema.Journeys {
+		for _, op := range j.Operations {
+			operations[j.EntityName+"."+op.Name] = op
+		}
+	}
+	return entities, operations, schema.Unmapped
+}
+
+func TestOpenAPIImporterMapsSchemas(t *testing.T) {
+	entities, _, unmapped := parseOpenAPI(t, petStoreSpec)
+
+	pet := entities["Pet"]
+	if pet.EntityDescription != "An animal of the store" || !pet.IsAuthenticationRequired {
+		t.Errorf("unexpected pet entity: %+v", pet)
+	}
+	if name := fieldOf(t, pet, "Name"); !name.IsMandatory || name.SampleData != "Rex" || len(name.InputValidations) != 1 ||
+		name.InputValidations[0].RuleType != enum.LengthRule {
+		t.Errorf("expected the allOf parent's required name with its length, got %+v", name)
+	}
+	if status := fieldOf(t, pet, "Status"); status.FieldType != enum.Enum || !status.IsMandatory ||
+		!reflect.DeepEqual(status.EnumValues, []string{"available", "adopted"}) {
+		t.Errorf("expected the referenced enum schema, got %+v", status)
+	}
+	if id := fieldOf(t, pet, "Id"); !id.IsReadOnly || id.IsEditable {
+		t.Errorf("expected a read-only id, got %+v", id)
+	}
+	if born := fieldOf(t, pet, "Born"); born.FieldType != enum.DateTime {
+		t.Errorf("expected a date to be a DateTime, got %+v", born)
+	}
+	if tags := fieldOf(t, pet, "Tags"); tags.CollectionType != enum.Set || tags.CollectionItemType != enum.StringType {
+		t.Errorf("expected a set of strings, got %+v", tags)
+	}
+	if attributes := fieldOf(t, pet, "Attributes"); attributes.CollectionType != enum.Map || attributes.CollectionItemType != enum.IntType {
+		t.Errorf("expected a map of integers, got %+v", attributes)
+	}
+
+	relations := relationsOf(pet)
+	if relations["Named"].RelationType != enum.Inheritance {
+		t.Errorf("expected the allOf parent as an inheritance, got %+v", relations)
+	}
+	if relations["Owner"].RelationType != enum.ManyToOne || relations["Vaccinations"].EntityName != "PetVaccination" ||
+		relations["Vaccinations"].RelationType != enum.OneToMany {
+		t.Errorf("unexpected pet relations: %+v", relations)
+	}
+	if relationsOf(entities["Owner"])["Pets"].RelationType != enum.OneToMany {
+		t.Errorf("expected the owner's pets as a one-to-many relation")
+	}
+	if given := fieldOf(t, entities["PetVaccination"], "GivenAt"); given.FieldType != enum.DateTime {
+		t.Errorf("expected the inline item schema as an entity, got %+v", given)
+	}
+	if email := fieldOf(t, entities["Owner"], "Email"); email.IsMandatory || email.InputValidations[0].RuleType != enum.EmailRule {
+		t.Errorf("unexpected email field: %+v", email)
+	}
+
+	report := strings.Join(unmapped, "\n")
+	for _, want := range []string{"Pet.extra: free-form object", "Pet.photo: reference photos.yaml#/Photo", "HEAD /health"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q to be reported as unmapped, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestOpenAPIImporterMapsOperations(t *testing.T) {
+	_, operations, unmapped := parseOpenAPI(t, petStoreSpec)
+
+	types := map[string]enum.OperationType{
+		"Pet.listPets": enum.Read, "Pet.postPets": enum.Create, "Pet.getPet": enum.ReadById, "Pet.updatePet": enum.Update,
+		"Pet.deletePet": enum.Delete, "Pet.adoptPet": enum.CustomAPI, "Owner.getSearch": enum.CustomAPI,
+	}
+	for name, want := range types {
+		if op, ok := operations[name]; !ok || op.Type != want {
+			t.Errorf("expected operation %s of type %v, got %+v", name, want, op)
+		}
+	}
+	if operations["Pet.postPets"].Description != "Add a pet" {
+		t.Errorf("expected the summary as the description")
+	}
+
+	list := operations["Pet.listPets"]
+	filters := map[string]model.Filter{}
+	for _, f := range list.Filters {
+		filters[f.Name] = f
+	}
+	if len(filters) != 4 {
+		t.Fatalf("expected status, weight_gte, bornAfter and q filters, got %+v", list.Filters)
+	}
+	if f := filters["status"]; f.Type != enum.EnumFilter || f.FieldID != "Status" || f.Operator != enum.Equals {
+		t.Errorf("unexpected status filter: %+v", f)
+	}
+	if f := filters["weight_gte"]; f.Type != enum.NumericRange || f.FieldID != "Weight" || f.Operator != enum.GreaterThanOrEqual {
+		t.Errorf("unexpected weight filter: %+v", f)
+	}
+	if f := filters["bornAfter"]; f.Type != enum.DateRange || f.FieldID != "Born" || f.Operator != enum.GreaterThan {
+		t.Errorf("unexpected born filter: %+v", f)
+	}
+	if f := filters["q"]; f.Type != enum.TextSearch {
+		t.Errorf("unexpected search filter: %+v", f)
+	}
+	if len(list.Sort) != 2 || list.Sort[0].FieldID != "Name" || list.Sort[1].FieldID != "Born" {
+		t.Errorf("expected the sort values as sort fields, got %+v", list.Sort)
+	}
+
+	report := strings.Join(unmapped, "\n")
+	for _, want := range []string{"query parameter color", "header parameter X-Tenant"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q to be reported as unmapped, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestOpenAPIImporterRejectsSwagger2(t *testing.T) {
+	_, err := parser.NewOpenAPIParser().ParseSchema(`{"swagger": "2.0", "paths": {}}`)
+	if err == nil || !strings.Contains(err.Error(), "swagger 2.0") {
+		t.Errorf("expected swagger 2 to be rejected, got %v", err)
+	}
+}
+
+func TestMergeJourneysKeepsExistingOperations(t *testing.T) {
+	existing := []model.EntityJourney{{EntityName: "Pet", Operations: []model.Operation{
+		{Name: "getPet", Type: enum.ReadById, Description: "Loads a pet with its owner"},
+	}}}
+	imported := []model.EntityJourney{
+		{EntityName: "pet", Operations: []model.Operation{{Name: "GetPet", Type: enum.ReadById}, {Name: "deletePet", Type: enum.Delete}}},
+		{EntityName: "Owner", Operations: []model.Operation{{Name: "listOwners", Type: enum.Read}}},
+	}
+
+	merged, added := service.MergeJourneys(existing, imported)
+	if len(merged) != 2 || len(merged[0].Operations) != 2 || merged[0].Operations[0].Description != "Loads a pet with its owner" {
+		t.Errorf("unexpected merge: %+v", merged)
+	}
+	if !reflect.DeepEqual(added, []string{"Pet.deletePet", "Owner.listOwners"}) {
+		t.Errorf("unexpected added operations: %v", added)
+	}
+	if len(existing[0].Operations) != 1 {
+		t.Errorf("expected the existing journeys to be left untouched")
+	}
+}
+
+// fakeProjectRepository records what the import saves; there are no projects to find
+type fakeProjectRepository struct {
+	repository.ProjectRepository
+	saved   []model.Project
+	journey *model.Journey
+}
+
+func (r *fakeProjectRepository) FindByName(ctx context.Context, name string) (model.Project, bool, error) {
+	return model.Project{}, false, nil
+}
+
+func (r *fakeProjectRepository) SaveProject(ctx context.Context, project *model.Project, journey *model.Journey) (*model.Project, error) {
+	r.saved = append(r.saved, *project)
+	r.journey = journey
+	return project, nil
+}
+
+func TestImportProjectSavesJourneysWithTheProject(t *testing.T) {
+	importer := service.NewImporterService(nil)
+	importer.RegisterSchemaParser("openapi", parser.NewOpenAPIParser())
+	projects := &fakeProjectRepository{}
+	u := usecase.NewSchemaImportUsecase(nil, projects, &fakeJourneyRepository{}, importer)
+
+	result, err := u.ImportProject(context.Background(), dto.SchemaImport{Content: petStoreSpec, Format: "openapi", ProjectName: "pets"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(projects.saved) != 1 || projects.journey == nil || len(result.Operations) == 0 {
+		t.Fatalf("expected one save of the project with its journey, got %d saves and %+v", len(projects.saved), projects.journey)
+	}
+
+	project := projects.saved[0]
+	if project.Uuid == uuid.Nil || projects.journey.ProjectUUID != project.Uuid {
+		t.Errorf("expected the journey of the project %s, got %s", project.Uuid, projects.journey.ProjectUUID)
+	}
+	entityIds := map[string]string{}
+	for _, e := range project.Entities {
+		entityIds[e.EntityName] = e.Uuid.String()
+	}
+	for _, ej := range projects.journey.EntityJourneys {
+		if ej.EntityID == "" || ej.EntityID != entityIds[ej.EntityName] {
+			t.Errorf("expected %s to refer to its entity %q, got %q", ej.EntityName, entityIds[ej.EntityName], ej.EntityID)
+		}
+	}
+}
+
+func TestImportProjectPreparesTheMergedJourney(t *testing.T) {
+	importer := service.NewImporterService(nil)
+	importer.RegisterSchemaParser("openapi", parser.NewOpenAPIParser())
+	existing := model.Journey{EntityJourneys: []model.EntityJourney{{
+		EntityName: "Pet",
+		Operations: []model.Operation{{Name: "adoptPet", BackendJourney: []model.JourneyStep{
+			{Index: 1, Type: "API_CALL", Curl: `curl https://api.example.com/shelter`, SampleResponse: `{"open": true}`},
+		}}},
+	}}}
+	projects := &fakeProjectRepository{}
+	journeys := &fakeJourneyRepository{journeys: []model.Journey{existing}}
+	u := usecase.NewSchemaImportUsecase(nil, projects, journeys, importer)
+
+	if _, err := u.ImportProject(context.Background(), dto.SchemaImport{Content: petStoreSpec, Format: "openapi", ProjectName: "pets"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec := projects.journey.EntityJourneys[0].Operations[0].BackendJourney[0].HTTPCall; spec == nil || spec.Response == nil {
+		t.Errorf("expected the merged journey to carry its parsed steps, got %+v", spec)
+	}
+
+	// A step that does not pass its checks rejects the import before anything is saved
+	existing.EntityJourneys[0].Operations[0].BackendJourney = []model.JourneyStep{{Index: 1, Type: "BUSINESS_VALIDATION", Condition: "age >"}}
+	journeys.journeys = []model.Journey{existing}
+	projects.saved = nil
+	_, err := u.ImportProject(context.Background(), dto.SchemaImport{Content: petStoreSpec, Format: "openapi", ProjectName: "pets"})
+	var journeyErr *service.JourneyValidationError
+	if !errors.As(err, &journeyErr) || journeyErr.Issues[0].Operation != "adoptPet" {
+		t.Errorf("expected the invalid step to be reported, got %v", err)
+	}
+	if len(projects.saved) != 0 {
+		t.Errorf("expected nothing to be saved, got %+v", projects.saved)
+	}
+}
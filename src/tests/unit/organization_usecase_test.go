@@ -53,6 +53,9 @@ func (m *MockUserRepository) CreateUser(ctx context.Context, u model.User) (mode
 	u.ID = 1
 	return u, nil
 }
+func (m *MockUserRepository) GetOrganizationID(ctx context.Context, userId uint) (uint, error) {
+	return 1, nil
+}
 
 func TestOnboardOrganization(t *testing.T) {
 	cfg := &config.Config{
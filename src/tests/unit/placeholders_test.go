@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+)
+
+func TestResolvePlaceholders(t *testing.T) {
+	placeholders := []model.Placeholder{
+		{Name: "ServiceName", Type: "String"},
+		{Name: "UseCache", Type: "Boolean", DefaultVal: "false"},
+		{Name: "Port", Type: "Int", DefaultVal: "8080"},
+		{Name: "Style", Type: "Enum", AllowedValues: []string{"rest", "grpc"}, DefaultVal: "rest"},
+	}
+
+	resolved, err := service.ResolvePlaceholders(placeholders, map[string]string{
+		"ServiceName": "orders",
+		"UseCache":    "true",
+		"entity_id":   "ignored",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved["ServiceName"] != "orders" || resolved["UseCache"] != true || resolved["Port"] != int64(8080) || resolved["Style"] != "rest" {
+		t.Errorf("unexpected values: %v", resolved)
+	}
+	if _, ok := resolved["entity_id"]; ok {
+		t.Errorf("undeclared inputs must not be exposed")
+	}
+
+	_, err = service.ResolvePlaceholders(placeholders, map[string]string{
+		"UseCache": "maybe",
+		"Port":     "80a",
+		"Style":    "soap",
+	})
+	var placeholderErr *service.PlaceholderError
+	if !errors.As(err, &placeholderErr) {
+		t.Fatalf("expected a PlaceholderError, got %v", err)
+	}
+
+	expected := map[string]string{
+		"ServiceName": service.PlaceholderMissing,
+		"UseCache":    service.PlaceholderInvalid,
+		"Port":        service.PlaceholderInvalid,
+		"Style":       service.PlaceholderInvalid,
+	}
+	if len(placeholderErr.Issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %v", len(expected), placeholderErr.Issues)
+	}
+	for _, issue := range placeholderErr.Issues {
+		if expected[issue.Name] != issue.Problem {
+			t.Errorf("%s: expected %s, got %s", issue.Name, expected[issue.Name], issue.Problem)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+func TestBuildProjectContext(t *testing.T) {
+	project := model.Project{ProjectName: "shop", IsMultiTenant: true, Entities: relationProject()}
+
+	genCtx, err := service.NewGenerationService(nil, nil, nil, nil).BuildProjectContext(context.Background(), project, enum.Golang)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if genCtx.ProjectName != "shop" || genCtx.Project.Name != "shop" || !genCtx.Project.IsMultiTenant || genCtx.Project.IsMultiLingual {
+		t.Errorf("unexpected project %+v", genCtx.Project)
+	}
+	if len(genCtx.Project.Entities) != 5 || genCtx.Entity.Name != "" {
+		t.Fatalf("expected the five entities and no current entity, got %+v", genCtx)
+	}
+	order := genCtx.Project.Entities[0]
+	if order.Name != "Order" || len(order.Fields) != 2 || len(order.Relations) != 3 || order.Relations[0].BackReference != "Orders" {
+		t.Errorf("unexpected entity %+v", order)
+	}
+}
+
+func TestGenerateProjectFilesOncePerProject(t *testing.T) {
+	project := model.Project{ProjectName: "shop", Entities: relationProject()}
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "{{.Entity.VarName}}.txt", Content: "{{.Entity.Name}} of {{.Project.Name}}"},
+		{Path: "routes.txt", Content: "{{range .Project.Entities}}{{.Name}};{{end}}", Scope: enum.ScopeProject},
+	}}
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+
+	if paths := service.EntityFilePaths(blueprint); len(paths) != 1 || paths[0] != "{{.Entity.VarName}}.txt" {
+		t.Errorf("unexpected entity paths %v", paths)
+	}
+	files, err := genService.GenerateProjectFiles(context.Background(), nil, blueprint, project, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "routes.txt" || files[0].Content != "Order;Customer;Product;OrderItem;Category;" {
+		t.Errorf("unexpected project files %+v", files)
+	}
+
+	entity := project.Entities[1]
+	entity.Project = project
+	files, err = genService.GenerateFiles(context.Background(), blueprint, entity, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "customer.txt" || files[0].Content != "Customer of shop" {
+		t.Errorf("unexpected entity files %+v", files)
+	}
+}
+
+func TestBuildContextReportsOnlyTheEntitysOwnErrors(t *testing.T) {
+	invoice := model.Entity{
+		EntityName: "Invoice",
+		EntityFields: []model.EntityField{
+			{FieldName: "total", FieldType: enum.Float, IsDerived: true, DerivativeType: enum.Arithmetic, DerivativeExpression: "payment + 1"},
+		},
+	}
+	project := model.Project{ProjectName: "shop", Entities: append(relationProject(), invoice)}
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	scope := service.NewGenerationScope()
+
+	for _, language := range []enum.ProgrammingLanguage{enum.Golang, enum.Golang, enum.Python} {
+		order := project.Entities[0]
+		order.Project = project
+		genCtx, err := genService.BuildContext(context.Background(), scope, order, language)
+		if err != nil {
+			t.Fatalf("expected Order to build despite Invoice, got %v", err)
+		}
+		if len(genCtx.Project.Entities) != 5 || genCtx.Project.Entities[0].Name != "Order" {
+			t.Errorf("expected the project without Invoice, got %+v", genCtx.Project.Entities)
+		}
+	}
+
+	invoice.Project = project
+	if _, err := genService.BuildContext(context.Background(), scope, invoice, enum.Golang); err == nil || !strings.Contains(err.Error(), "payment") {
+		t.Errorf("expected Invoice's own derived field error, got %v", err)
+	}
+	if _, err := genService.BuildProjectContext(context.Background(), project, enum.Golang); err == nil || !strings.Contains(err.Error(), "entity Invoice") {
+		t.Errorf("expected per-project files to name Invoice, got %v", err)
+	}
+}
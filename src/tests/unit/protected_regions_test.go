@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"bytes"
+	"testing"
+
+	"gen-concept-api/domain/service"
+	"gen-concept-api/pkg/archive"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+)
+
+func TestPreserveRegionsCarriesHandWrittenCode(t *testing.T) {
+	generated := []service.GeneratedFile{{Path: "order.go", Content: `package order
+
+type Order struct {
+	ID    int64
+	Total float64
+}
+
+func (o Order) Validate() error {
+	// gen:keep begin validate
+	return nil
+	// gen:keep end
+}
+`}}
+	previous := map[string]string{
+		"order.go": `package order
+
+type Order struct {
+	ID int64
+}
+
+func (o Order) Validate() error {
+	// gen:keep begin validate
+	if o.ID == 0 {
+		return errMissingID
+	}
+	return nil
+	// gen:keep end
+}
+
+// gen:keep begin helpers
+var errMissingID = errors.New("missing id")
+// gen:keep end
+`,
+		"legacy.py": "# gen:keep begin imports\nimport os\n# gen:keep end\n",
+	}
+
+	files, issues := service.PreserveRegions(generated, previous)
+	expected := `package order
+
+type Order struct {
+	ID    int64
+	Total float64
+}
+
+func (o Order) Validate() error {
+	// gen:keep begin validate
+	if o.ID == 0 {
+		return errMissingID
+	}
+	return nil
+	// gen:keep end
+}
+`
+	if files[0].Content != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, files[0].Content)
+	}
+
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", issues)
+	}
+	if i := issues[0]; i.File != "order.go" || i.Region != "helpers" || i.Problem != service.RegionOrphaned ||
+		i.Line != 16 || i.Content != "var errMissingID = errors.New(\"missing id\")\n" {
+		t.Errorf("unexpected issue %+v", i)
+	}
+	if i := issues[1]; i.File != "legacy.py" || i.Region != "imports" || i.Problem != service.RegionOrphaned {
+		t.Errorf("unexpected issue %+v", i)
+	}
+}
+
+func TestPreserveRegionsReportsMalformedMarkers(t *testing.T) {
+	generated := []service.GeneratedFile{{Path: "app.ts", Content: "// gen:keep begin a\nx\n// gen:keep end\n"}}
+	previous := map[string]string{"app.ts": "// gen:keep end\n// gen:keep begin a\nkept\n"}
+
+	files, issues := service.PreserveRegions(generated, previous)
+	if files[0].Content != generated[0].Content {
+		t.Errorf("an unterminated region must not be merged, got %q", files[0].Content)
+	}
+	problems := map[string]bool{}
+	for _, i := range issues {
+		problems[i.Problem] = true
+	}
+	if len(issues) != 2 || !problems[service.RegionUnexpected] || !problems[service.RegionUnterminated] {
+		t.Errorf("unexpected issues %+v", issues)
+	}
+}
+
+func TestPreviousFilesReadsArchives(t *testing.T) {
+	var buf bytes.Buffer
+	err := archive.Write(&buf, archive.TarGz, []archive.Entry{
+		{Path: "./a.go", Content: []byte("from archive")},
+		{Path: "b.go", Content: []byte("from archive")},
+		{Path: usecase.RegionReportPath, Content: []byte("[]")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := usecase.PreviousFiles(dto.PreviousOutput{Archive: buf.Bytes(), Files: map[string]string{"b.go": "uploaded"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 || files["a.go"] != "from archive" || files["b.go"] != "uploaded" {
+		t.Errorf("unexpected files %v", files)
+	}
+	if _, err := usecase.PreviousFiles(dto.PreviousOutput{Archive: []byte("plain text")}); err == nil {
+		t.Errorf("expected an error for an unknown archive")
+	}
+}
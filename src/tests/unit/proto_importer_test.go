@@ -0,0 +1,241 @@
+package unit
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/infra/parser"
+)
+
+const customerProto = `
+syntax = "proto3";
+package shop.common.v1;
+
+// A person who places orders
+message Customer {
+  string email = 1 [(google.api.field_behavior) = REQUIRED];
+  repeated string tags = 2;
+}
+`
+
+const orderProto = `
+syntax = "proto3";
+package shop.orders.v1;
+
+import "google/api/field_behavior.proto";
+import "google/protobuf/timestamp.proto";
+import "shop/common/v1/customer.proto";
+import "shop/payments/v1/payment.proto";
+
+enum OrderStatus {
+  ORDER_STATUS_UNSPECIFIED = 0;
+  ORDER_STATUS_PLACED = 1;
+  ORDER_STATUS_SHIPPED = 2;
+}
+
+message Order {
+  string id = 1 [(google.api.field_behavior) = OUTPUT_ONLY];
+  OrderStatus status = 2;
+  google.protobuf.Timestamp placed_at = 3; // When the customer checked out
+  shop.common.v1.Customer customer = 4;
+  repeated LineItem items = 5;
+  map<string, double> totals = 6;
+  oneof discount {
+    string coupon = 7;
+    double percent = 8;
+  }
+  shop.payments.v1.Payment payment = 9;
+  Order replaces = 10;
+
+  message LineItem {
+    string sku = 1;
+    int32 quantity = 2;
+  }
+}
+
+service OrderService {
+  // Loads an order
+  rpc GetOrder(GetOrderRequest) returns (Order);
+  rpc ListOrders(ListOrdersRequest) returns (ListOrdersResponse) {
+    option (google.api.http) = { get: "/v1/orders" };
+  }
+  rpc CreateOrder(CreateOrderRequest) returns (Order);
+  rpc DeleteOrder(DeleteOrderRequest) returns (Empty);
+  rpc ShipOrder(ShipOrderRequest) returns (Order);
+  rpc WatchOrders(ListOrdersRequest) returns (stream Order);
+}
+
+message Empty {}
+message GetOrderRequest { string id = 1; }
+message ListOrdersRequest {
+  int32 page_size = 1;
+  string page_token = 2;
+  repeated OrderStatus status = 3;
+  string placed_at_after = 4;
+  string color = 5;
+}
+message ListOrdersResponse { repeated Order orders = 1; string next_page_token = 2; }
+message CreateOrderRequest { Order order = 1; }
+message DeleteOrderRequest { string id = 1; }
+message ShipOrderRequest { string id = 1; }
+`
+
+func parseProto(t *testing.T, files ...service.SchemaFile) (service.ParsedSchema, map[string]model.Entity) {
+	t.Helper()
+	schema, err := parser.NewProtoParser().ParseFiles(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entities := map[string]model.Entity{}
+	for _, e := range schema.Entities {
+		entities[e.EntityName] = e
+	}
+	return schema, entities
+}
+
+func TestProtoImporterMapsMessagesAcrossFiles(t *testing.T) {
+	schema, entities := parseProto(t,
+		service.SchemaFile{Name: "shop/common/v1/customer.proto", Content: customerProto},
+		service.SchemaFile{Name: "shop/orders/v1/order.proto", Content: orderProto},
+	)
+
+	if len(entities) != 3 {
+		t.Fatalf("expected Customer, Order and LineItem without the requests and responses, got %v",
+			reflect.ValueOf(entities).MapKeys())
+	}
+	customer := entities["Customer"]
+	if customer.EntityDescription != "A person who places orders" {
+		t.Errorf("expected the comment as description, got %q", customer.EntityDescription)
+	}
+	if email := fieldOf(t, customer, "Email"); !email.IsMandatory || email.FieldType != enum.String {
+		t.Errorf("expected field_behavior REQUIRED to make email mandatory, got %+v", email)
+	}
+	if tags := fieldOf(t, customer, "Tags"); !tags.IsCollection || tags.CollectionType != enum.List || tags.CollectionItemType != enum.StringType {
+		t.Errorf("expected repeated tags as a list, got %+v", tags)
+	}
+
+	order := entities["Order"]
+	if id := fieldOf(t, order, "Id"); !id.IsReadOnly || id.IsEditable {
+		t.Errorf("expected OUTPUT_ONLY to make id read-only, got %+v", id)
+	}
+	if status := fieldOf(t, order, "Status"); !status.IsEnum || !reflect.DeepEqual(status.EnumValues, []string{"PLACED", "SHIPPED"}) {
+		t.Errorf("expected the enum values without prefix and zero value, got %+v", status)
+	}
+	if placed := fieldOf(t, order, "PlacedAt"); placed.FieldType != enum.DateTime || placed.FieldDescription != "When the customer checked out" {
+		t.Errorf("expected a Timestamp to be a DateTime with its trailing comment, got %+v", placed)
+	}
+	if totals := fieldOf(t, order, "Totals"); totals.CollectionType != enum.Map || totals.CollectionItemType != enum.FloatType {
+		t.Errorf("expected a map of doubles, got %+v", totals)
+	}
+	if coupon := fieldOf(t, order, "Coupon"); coupon.IsMandatory {
+		t.Errorf("expected oneof fields to be optional")
+	}
+
+	relations := relationsOf(order)
+	want := map[string]enum.RelationType{
+		"Customer": enum.ManyToOne, "Items": enum.OneToMany, "Replaces": enum.SelfReferencing,
+	}
+	for name, relation := range want {
+		if relations[name].RelationType != relation {
+			t.Errorf("expected %s to be %v, got %+v", name, relation, relations[name])
+		}
+	}
+	if relations["Items"].EntityName != "LineItem" {
+		t.Errorf("expected the nested message as an entity, got %+v", relations["Items"])
+	}
+
+	report := strings.Join(schema.Unmapped, "\n")
+	for _, expected := range []string{
+		"imported file shop/payments/v1/payment.proto was not given",
+		"Order.payment: unknown type shop.payments.v1.Payment",
+		"Order.discount: oneof",
+		"OrderService.WatchOrders: streaming",
+		"request field color matches no field of Order",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected %q to be reported, got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestProtoImporterMapsServicesToOperations(t *testing.T) {
+	schema, _ := parseProto(t,
+		service.SchemaFile{Name: "customer.proto", Content: customerProto},
+		service.SchemaFile{Name: "order.proto", Content: orderProto},
+	)
+
+	operations := map[string]model.Operation{}
+	for _, j := range schema.Journeys {
+		for _, op := range j.Operations {
+			operations[j.EntityName+"."+op.Name] = op
+		}
+	}
+	types := map[string]enum.OperationType{
+		"Order.getOrder": enum.ReadById, "Order.listOrders": enum.Read, "Order.createOrder": enum.Create,
+		"Order.deleteOrder": enum.Delete, "Order.shipOrder": enum.CustomAPI,
+	}
+	for name, want := range types {
+		if op, ok := operations[name]; !ok || op.Type != want {
+			t.Errorf("expected operation %s of type %v, got %+v", name, want, op)
+		}
+	}
+	if operations["Order.getOrder"].Description != "Loads an order" {
+		t.Errorf("expected the rpc comment as description")
+	}
+
+	filters := operations["Order.listOrders"].Filters
+	if len(filters) != 2 {
+		t.Fatalf("expected the status and placed_at_after filters, got %+v", filters)
+	}
+	if filters[0].FieldID != "Status" || filters[0].Type != enum.EnumFilter || filters[0].Operator != enum.In {
+		t.Errorf("unexpected status filter: %+v", filters[0])
+	}
+	if filters[1].FieldID != "PlacedAt" || filters[1].Type != enum.DateRange || filters[1].Operator != enum.GreaterThan {
+		t.Errorf("unexpected placed_at filter: %+v", filters[1])
+	}
+}
+
+func TestProtoParserReadsFirstMessageAsBlueprint(t *testing.T) {
+	metadata, err := parser.NewProtoParser().Parse(orderProto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metadata.Name != "Order" || len(metadata.Fields) != 10 {
+		t.Fatalf("unexpected metadata: %+v", metadata)
+	}
+	if metadata.Fields[4].Type != "repeated LineItem" || metadata.Fields[5].Type != "map<string, double>" {
+		t.Errorf("unexpected field types: %+v", metadata.Fields)
+	}
+}
+
+func TestProtoImporterReportsSyntaxErrors(t *testing.T) {
+	_, err := parser.NewProtoParser().ParseSchema("message Order { string id = ; }")
+	if err == nil || !strings.Contains(err.Error(), "schema.proto:1") {
+		t.Errorf("expected an error with its position, got %v", err)
+	}
+}
+
+func TestImportSchemaFilesNeedsAMultiFileParser(t *testing.T) {
+	importer := service.NewImporterService(parser.NewGoParser())
+	importer.RegisterSchemaParser("postgres", parser.NewSQLParser(enum.Postgres))
+	importer.RegisterSchemaParser("proto", parser.NewProtoParser())
+
+	files := []service.SchemaFile{{Name: "a.sql", Content: "CREATE TABLE a (name text);"}, {Name: "b.sql", Content: "CREATE TABLE b (name text);"}}
+	var schemaErr *service.SchemaError
+	if _, err := importer.ImportSchemaFiles(files, "postgres"); !errors.As(err, &schemaErr) {
+		t.Errorf("expected several SQL files to be rejected, got %v", err)
+	}
+	if schema, err := importer.ImportSchemaFiles(files[:1], "postgres"); err != nil || len(schema.Entities) != 1 {
+		t.Errorf("expected a single SQL file to be read, got %v", err)
+	}
+
+	protoFiles := []service.SchemaFile{{Name: "customer.proto", Content: customerProto}, {Name: "order.proto", Content: orderProto}}
+	if schema, err := importer.ImportSchemaFiles(protoFiles, "proto"); err != nil || len(schema.Entities) != 3 {
+		t.Errorf("expected both proto files to be read, got %v", err)
+	}
+}
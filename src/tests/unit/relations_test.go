@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gen-concept-api/constant"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/usecase"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/google/uuid"
+)
+
+func relationProject() []model.Entity {
+	return []model.Entity{
+		{
+			EntityName:   "Order",
+			EntityFields: []model.EntityField{{FieldName: "CustomerId", IsMandatory: true}, {FieldName: "Items", IsCollection: true}},
+			DependsOnEntities: []model.DependsOnEntity{
+				{EntityName: "Customer", FieldName: "Customer", RelationType: enum.ManyToOne},
+				{EntityName: "Product", FieldName: "Products", RelationType: enum.ManyToMany},
+				{EntityName: "OrderItem", FieldName: "Items", RelationType: enum.Composition},
+			},
+		},
+		{
+			EntityName:        "Customer",
+			DependsOnEntities: []model.DependsOnEntity{{EntityName: "Order", FieldName: "Orders", RelationType: enum.OneToMany}},
+		},
+		{EntityName: "Product"},
+		{
+			EntityName:        "OrderItem",
+			DependsOnEntities: []model.DependsOnEntity{{EntityName: "Order", FieldName: "Order", RelationType: enum.ManyToOne}},
+		},
+		{
+			EntityName: "Category",
+			DependsOnEntities: []model.DependsOnEntity{
+				{EntityName: "Category", FieldName: "Parent", RelationType: enum.SelfReferencing},
+				{EntityName: "Category", FieldName: "Children", RelationType: enum.OneToMany},
+			},
+		},
+	}
+}
+
+func relationsByName(t *testing.T, entity model.Entity, project []model.Entity) map[string]service.GenRelation {
+	t.Helper()
+	relations, skipped := service.BuildRelations(entity, project)
+	if len(skipped) > 0 {
+		t.Fatalf("unexpected skipped relations: %v", skipped)
+	}
+	byName := make(map[string]service.GenRelation, len(relations))
+	for _, r := range relations {
+		byName[r.Name] = r
+	}
+	return byName
+}
+
+func TestBuildRelations(t *testing.T) {
+	project := relationProject()
+
+	order := relationsByName(t, project[0], project)
+	customer := order["Customer"]
+	if customer.Cardinality != service.CardinalityManyToOne || customer.ForeignKey != "CustomerId" || customer.ForeignKeyColumn != "customer_id" ||
+		customer.ForeignKeyOnTarget || customer.BackReference != "Orders" || !customer.BackReferenceMany || customer.OnDelete != "RESTRICT" {
+		t.Errorf("unexpected many-to-one %+v", customer)
+	}
+	products := order["Products"]
+	if products.Cardinality != service.CardinalityManyToMany || !products.Many || products.JoinTable != "orders_products" ||
+		products.JoinForeignKey != "order_id" || products.JoinReferences != "product_id" || products.ForeignKey != "" {
+		t.Errorf("unexpected many-to-many %+v", products)
+	}
+	items := order["Items"]
+	if items.Cardinality != service.CardinalityOneToMany || items.Target != "OrderItem" || !items.ForeignKeyOnTarget ||
+		items.ForeignKey != "OrderID" || items.BackReference != "Order" || items.OnDelete != "CASCADE" {
+		t.Errorf("unexpected composition %+v", items)
+	}
+
+	orders := relationsByName(t, project[1], project)["Orders"]
+	if orders.Cardinality != service.CardinalityOneToMany || orders.ForeignKey != "CustomerId" || orders.BackReference != "Customer" ||
+		orders.OnDelete != customer.OnDelete {
+		t.Errorf("unexpected one-to-many %+v", orders)
+	}
+
+	category := relationsByName(t, project[4], project)
+	if parent := category["Parent"]; !parent.SelfReference || parent.ForeignKey != "ParentID" || parent.BackReference != "Children" || parent.OnDelete != "SET NULL" {
+		t.Errorf("unexpected self reference %+v", parent)
+	}
+	if children := category["Children"]; !children.Many || children.ForeignKey != "ParentID" || children.BackReference != "Parent" {
+		t.Errorf("unexpected children %+v", children)
+	}
+}
+
+func TestBuildRelationsSkipsUnknownTargets(t *testing.T) {
+	project := relationProject()
+	entity := model.Entity{
+		EntityName: "Invoice",
+		DependsOnEntities: []model.DependsOnEntity{
+			{EntityName: "Payment", FieldName: "Payment", RelationType: enum.OneToOne},
+			{EntityName: "Customer", FieldName: "Customer", RelationType: enum.ManyToOne},
+		},
+	}
+	relations, skipped := service.BuildRelations(entity, project)
+	if len(relations) != 1 || relations[0].Target != "Customer" {
+		t.Errorf("expected only the resolvable relation, got %+v", relations)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "Payment") {
+		t.Errorf("expected the unknown target to be reported, got %v", skipped)
+	}
+	// Without the project's entities the target is taken as declared
+	if relations, skipped := service.BuildRelations(entity, nil); len(skipped) != 0 || len(relations) != 2 || relations[0].ForeignKey != "PaymentID" {
+		t.Errorf("unexpected relations %+v, %v", relations, skipped)
+	}
+}
+
+func TestGenerationReportsRelationsToDeletedEntities(t *testing.T) {
+	// Payment was deleted after Order declared its relation to it
+	order := model.Entity{
+		BaseModel:  model.BaseModel{Uuid: uuid.New()},
+		EntityName: "Order",
+		DependsOnEntities: []model.DependsOnEntity{
+			{EntityName: "Customer", FieldName: "Customer", RelationType: enum.ManyToOne},
+			{EntityName: "Payment", FieldName: "Payment", RelationType: enum.OneToOne},
+		},
+	}
+	order.Project = model.Project{ProjectName: "shop", Entities: []model.Entity{order, {EntityName: "Customer"}}}
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "model.txt", Content: "{{range .Entity.Relations}}{{.Name}} {{end}}"},
+	}}
+	generation := usecase.NewGenerationUsecase(nil, &fakeBlueprintRepository{blueprint: blueprint},
+		fakeEntityRepository{entities: map[uuid.UUID]model.Entity{order.Uuid: order}}, nil,
+		&fakeManifestRepository{manifests: make(map[uuid.UUID]model.GenerationManifest)}, fakeUserRepository{},
+		service.NewGenerationService(nil, nil, nil, nil))
+
+	ctx := context.WithValue(context.Background(), constant.UserIdKey, float64(3))
+	output, err := generation.GenerateFiles(ctx, dto.GenerationRequest{Inputs: map[string]string{"entity_id": order.Uuid.String()}})
+	if err != nil {
+		t.Fatalf("expected the generation to go on without the relation, got %v", err)
+	}
+	if len(output.Files) != 1 || output.Files[0].Content != "Customer " {
+		t.Errorf("unexpected files %+v", output.Files)
+	}
+	if len(output.Warnings) != 1 || !strings.Contains(output.Warnings[0], "Payment") {
+		t.Fatalf("expected the skipped relation to be reported, got %v", output.Warnings)
+	}
+	var reported bool
+	for _, e := range output.Entries() {
+		reported = reported || (e.Path == usecase.WarningReportPath && strings.Contains(string(e.Content), "Payment"))
+	}
+	if !reported {
+		t.Errorf("expected the warnings in the archive")
+	}
+}
+
+func TestRelationsInGenerationContext(t *testing.T) {
+	project := relationProject()
+	order := project[0]
+	order.Project = model.Project{ProjectName: "shop", Entities: project}
+
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{{
+		Path:    "model.txt",
+		Content: "{{range .Entity.Relations}}{{.Name}}:{{.Cardinality}}:{{.Target}}{{with .JoinTable}}:{{.}}{{end}};{{end}}",
+	}}}
+	code, err := service.NewGenerationService(nil, nil, nil, nil).GenerateCode(context.Background(), blueprint, order, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != "Customer:ManyToOne:Customer;Products:ManyToMany:Product:orders_products;Items:OneToMany:OrderItem;" {
+		t.Errorf("unexpected output %q", code)
+	}
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/enum"
+	"gen-concept-api/infra/parser"
+)
+
+var orderSamples = []string{
+	`{
+		"id": 1,
+		"status": "placed",
+		"total": 10,
+		"placedAt": "2024-03-01T10:00:00Z",
+		"note": "Leave at the door",
+		"contact": "ann@example.com",
+		"customer": {"id": 7, "name": "Ann"},
+		"shipping": {"street": "1 Main St", "city": "Springfield"},
+		"items": [{"sku": "A-1", "quantity": 2}, {"sku": "B-2", "quantity": 1, "gift": true}],
+		"tags": ["new", "promo"],
+		"coupon": null
+	}`,
+	`[{
+		"id": 2,
+		"status": "shipped",
+		"total": 12.5,
+		"placedAt": "2024-03-02T11:30:00Z",
+		"contact": "bob@example.com",
+		"customer": {"id": 8, "name": "Bob"},
+		"shipping": {"street": "2 Oak Ave", "city": "Shelbyville"},
+		"items": [],
+		"tags": [],
+		"coupon": null
+	}, {
+		"id": 3,
+		"status": "placed",
+		"total": 7,
+		"placedAt": "2024-03-03",
+		"contact": "cy@example.com",
+		"customer": {"id": 7, "name": "Ann"},
+		"shipping": {"street": "1 Main St", "city": "Springfield"},
+		"items": [{"sku": "A-1", "quantity": 1}],
+		"tags": ["promo"],
+		"coupon": null
+	}]`,
+}
+
+func parseSamples(t *testing.T, resource string, samples []string) (map[string]model.Entity, []string) {
+	t.Helper()
+	schema, err := parser.NewJSONSampleParser().ParseSamples(resource, samples)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entities := map[string]model.Entity{}
+	for _, e := range schema.Entities {
+		entities[e.EntityName] = e
+	}
+	return entities, schema.Unmapped
+}
+
+func TestSampleImporterInfersFields(t *testing.T) {
+	entities, unmapped := parseSamples(t, "orders", orderSamples)
+
+	if len(entities) != 4 {
+		t.Fatalf("expected Order, Customer, Shipping and Item, got %v", reflect.ValueOf(entities).MapKeys())
+	}
+	order := entities["Order"]
+	if id := fieldOf(t, order, "Id"); id.FieldType != enum.Int || !id.IsMandatory || id.SampleData != "1" {
+		t.Errorf("unexpected id field: %+v", id)
+	}
+	if total := fieldOf(t, order, "Total"); total.FieldType != enum.Float {
+		t.Errorf("expected integers mixed with decimals to be Float, got %+v", total)
+	}
+	if placed := fieldOf(t, order, "PlacedAt"); placed.FieldType != enum.DateTime {
+		t.Errorf("expected timestamps and dates to be DateTime, got %+v", placed)
+	}
+	if status := fieldOf(t, order, "Status"); status.FieldType != enum.Enum || !reflect.DeepEqual(status.EnumValues, []string{"placed", "shipped"}) {
+		t.Errorf("expected status to be a likely enum, got %+v", status)
+	}
+	if note := fieldOf(t, order, "Note"); note.IsMandatory || note.FieldType != enum.String || note.SampleData != "Leave at the door" {
+		t.Errorf("expected a key missing from some samples to be optional, got %+v", note)
+	}
+	if contact := fieldOf(t, order, "Contact"); contact.FieldType != enum.String || len(contact.InputValidations) != 1 ||
+		contact.InputValidations[0].RuleType != enum.EmailRule {
+		t.Errorf("expected an email validation, got %+v", contact)
+	}
+	if tags := fieldOf(t, order, "Tags"); tags.CollectionType != enum.List || tags.CollectionItemType != enum.StringType ||
+		tags.SampleData != `["new","promo"]` {
+		t.Errorf("expected a list of strings, got %+v", tags)
+	}
+	if gift := fieldOf(t, entities["Item"], "Gift"); gift.IsMandatory || gift.FieldType != enum.Bool {
+		t.Errorf("expected gift to be an optional Bool, got %+v", gift)
+	}
+
+	relations := relationsOf(order)
+	want := map[string]enum.RelationType{"Customer": enum.ManyToOne, "Shipping": enum.OneToOne, "Items": enum.OneToMany}
+	for name, relation := range want {
+		if relations[name].RelationType != relation {
+			t.Errorf("expected %s to be %v, got %+v", name, relation, relations[name])
+		}
+	}
+	if relations["Items"].EntityName != "Item" {
+		t.Errorf("expected the list items as entity Item, got %+v", relations["Items"])
+	}
+
+	if !strings.Contains(strings.Join(unmapped, "\n"), "Order.coupon: always null") {
+		t.Errorf("expected the always null key to be reported, got %v", unmapped)
+	}
+}
+
+func TestSampleImporterRejectsInvalidSamples(t *testing.T) {
+	for _, sample := range []string{`{"id": 1`, `[1, 2]`, `{"id": 1} {"id": 2}`} {
+		if _, err := parser.NewJSONSampleParser().ParseSamples("order", []string{sample}); err == nil {
+			t.Errorf("expected %s to be rejected", sample)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+)
+
+func sandboxLimit(t *testing.T, sandbox service.Sandbox, content string) *service.SandboxLimitError {
+	t.Helper()
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	genService.SetSandbox(sandbox)
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "model.go", Content: content}}}
+	_, err := genService.GenerateCode(context.Background(), blueprint, model.Entity{EntityName: "Order"}, nil)
+	var limitErr *service.SandboxLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected a sandbox limit error, got %v", err)
+	}
+	return limitErr
+}
+
+func TestSandboxLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		sandbox service.Sandbox
+		content string
+		limit   string
+		value   string
+	}{
+		{"output", service.Sandbox{MaxOutputBytes: 100}, "{{range 1000}}0123456789{{end}}", service.LimitMaxOutputBytes, "100"},
+		{"depth", service.Sandbox{MaxDepth: 10}, `{{define "loop"}}{{template "loop" .}}{{end}}{{template "loop" .}}`, service.LimitMaxDepth, "10"},
+		{"functions", service.Sandbox{AllowedFunctions: []string{"pascal"}}, "{{pascal .Entity.Name}} {{snake .Entity.Name}}", service.LimitAllowedFunctions, "snake"},
+		{"timeout", service.Sandbox{Timeout: 50 * time.Millisecond}, "{{range 1000000000}}x{{end}}", service.LimitTimeout, "50ms"},
+		{"repeat", service.Sandbox{MaxOutputBytes: 100}, `{{repeat 1000000000 "x"}}`, service.LimitMaxOutputBytes, "100"},
+		{"indent", service.Sandbox{MaxOutputBytes: 100}, `ab{{"a\nb" | indent 50}}`, service.LimitMaxOutputBytes, "100"},
+		{"nindent", service.Sandbox{MaxOutputBytes: 1 << 20}, `{{"x" | nindent 2000000000}}`, service.LimitMaxOutputBytes, "1048576"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limitErr := sandboxLimit(t, tt.sandbox, tt.content)
+			if limitErr.Limit != tt.limit || limitErr.Value != tt.value || limitErr.Template != "model.go" {
+				t.Errorf("unexpected limit error %+v: %v", limitErr, limitErr)
+			}
+		})
+	}
+}
+
+func TestSandboxTimeoutStopsSilentLoops(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for _, content := range []string{
+		"{{range 4000000000}}{{end}}",
+		"{{range 4000000000}}{{if true}}{{else}}x{{end}}{{end}}",
+		`{{define "spin"}}{{range 4000000000}}{{with 1}}{{end}}{{end}}{{end}}{{template "spin"}}`,
+	} {
+		limitErr := sandboxLimit(t, service.Sandbox{Timeout: 20 * time.Millisecond}, content)
+		if limitErr.Limit != service.LimitTimeout {
+			t.Errorf("expected a timeout for %q, got %v", content, limitErr)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("expected the timed out renders to stop, %d goroutines are still running", after-before)
+	}
+}
+
+func TestSandboxAllowsTemplatesWithinLimits(t *testing.T) {
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	genService.SetSandbox(service.Sandbox{MaxOutputBytes: 100, MaxDepth: 3, AllowedFunctions: []string{"snake"}})
+	blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{
+		{Path: "model.go", Content: `{{define "name"}}{{snake .}}{{end}}{{template "name" .Entity.Name}}{{if eq 1 1}}!{{end}}`},
+	}}
+	code, err := genService.GenerateCode(context.Background(), blueprint, model.Entity{EntityName: "OrderItem"}, nil)
+	if err != nil || code != "order_item!" {
+		t.Errorf("unexpected result %q, %v", code, err)
+	}
+}
+
+func TestSandboxRejectsNegativeCounts(t *testing.T) {
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	for _, content := range []string{`{{repeat -1 "x"}}`, `{{"x" | indent -2}}`, `{{"x" | nindent -2}}`} {
+		blueprint := model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "model.go", Content: content}}}
+		if _, err := genService.GenerateCode(context.Background(), blueprint, model.Entity{EntityName: "Order"}, nil); err == nil || !strings.Contains(err.Error(), "negative") {
+			t.Errorf("expected %q to be rejected, got %v", content, err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package unit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/infra/parser"
+)
+
+const postgresSchema = `
+CREATE TYPE order_status AS ENUM ('draft', 'placed', 'shipped');
+
+CREATE TABLE public.customers (
+    id bigserial PRIMARY KEY,
+    email character varying(255) NOT NULL UNIQUE,
+    name text NOT NULL,
+    tier text CHECK (tier IN ('basic', 'gold')),
+    tags text[]
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    customer_id bigint NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+    status order_status DEFAULT 'draft'::order_status NOT NULL,
+    total numeric(10,2),
+    placed_at timestamp with time zone,
+    CONSTRAINT orders_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE products (id serial PRIMARY KEY, sku varchar(40) NOT NULL, picture bytea);
+CREATE UNIQUE INDEX products_sku_key ON products USING btree (sku);
+
+CREATE TABLE orders_products (
+    order_id uuid NOT NULL REFERENCES orders,
+    product_id integer NOT NULL,
+    created_at timestamp DEFAULT now(),
+    PRIMARY KEY (order_id, product_id)
+);
+ALTER TABLE ONLY orders_products
+    ADD CONSTRAINT orders_products_product_fk FOREIGN KEY (product_id) REFERENCES products(id);
+
+CREATE TABLE categories (id serial PRIMARY KEY, parent_id integer REFERENCES categories (id), title text);
+COMMENT ON COLUMN categories.title IS 'Shown in menus';
+CREATE VIEW big_orders AS SELECT * FROM orders WHERE total > 100;
+`
+
+func parseSchema(t *testing.T, dialect enum.PreferredDB, content string) (map[string]model.Entity, []string) {
+	t.Helper()
+	schema, err := parser.NewSQLParser(dialect).ParseSchema(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byName := map[string]model.Entity{}
+	for _, e := range schema.Entities {
+		byName[e.EntityName] = e
+	}
+	return byName, schema.Unmapped
+}
+
+func fieldOf(t *testing.T, entity model.Entity, name string) model.EntityField {
+	t.Helper()
+	for _, f := range entity.EntityFields {
+		if f.FieldName == name {
+			return f
+		}
+	}
+	t.Fatalf("entity %s has no field %s", entity.EntityName, name)
+	return model.EntityField{}
+}
+
+func relationsOf(entity model.Entity) map[string]model.DependsOnEntity {
+	byField := map[string]model.DependsOnEntity{}
+	for _, d := range entity.DependsOnEntities {
+		byField[d.FieldName] = d
+	}
+	return byField
+}
+
+func TestSQLImporterMapsPostgresTables(t *testing.T) {
+	entities, unmapped := parseSchema(t, enum.Postgres, postgresSchema)
+
+	if len(entities) != 4 {
+		t.Fatalf("expected Customer, Order, Product and Category, got %v", reflect.ValueOf(entities).MapKeys())
+	}
+	customer := entities["Customer"]
+	if len(customer.EntityFields) != 4 {
+		t.Errorf("expected the surrogate id to be left out, got %d fields", len(customer.EntityFields))
+	}
+	if email := fieldOf(t, customer, "Email"); !email.IsMandatory || !email.IsUnique || email.FieldType != enum.String {
+		t.Errorf("unexpected email field: %+v", email)
+	}
+	if tier := fieldOf(t, customer, "Tier"); !tier.IsEnum || tier.FieldType != enum.Enum || tier.IsMandatory ||
+		!reflect.DeepEqual(tier.EnumValues, []string{"basic", "gold"}) {
+		t.Errorf("expected the CHECK constraint to make an optional enum, got %+v", tier)
+	}
+	if tags := fieldOf(t, customer, "Tags"); !tags.IsCollection || tags.CollectionItemType != enum.StringType {
+		t.Errorf("expected an array collection, got %+v", tags)
+	}
+
+	order := entities["Order"]
+	if status := fieldOf(t, order, "Status"); status.FieldType != enum.Enum ||
+		!reflect.DeepEqual(status.EnumValues, []string{"draft", "placed", "shipped"}) {
+		t.Errorf("expected the enum type's values, got %+v", status)
+	}
+	if total := fieldOf(t, order, "Total"); total.FieldType != enum.Float || total.IsMandatory {
+		t.Errorf("unexpected total field: %+v", total)
+	}
+	if placed := fieldOf(t, order, "PlacedAt"); placed.FieldType != enum.DateTime {
+		t.Errorf("unexpected placedAt field: %+v", placed)
+	}
+	if key := fieldOf(t, order, "CustomerId"); !key.IsMandatory || key.FieldType != enum.Int {
+		t.Errorf("expected the foreign key column to stay a mandatory field, got %+v", key)
+	}
+	if sku := fieldOf(t, entities["Product"], "Sku"); !sku.IsUnique {
+		t.Errorf("expected the unique index to make sku unique")
+	}
+
+	orderRelations := relationsOf(order)
+	if r := orderRelations["Customer"]; r.EntityName != "Customer" || r.RelationType != enum.ManyToOne {
+		t.Errorf("unexpected customer relation: %+v", r)
+	}
+	if r := relationsOf(customer)["Orders"]; r.EntityName != "Order" || r.RelationType != enum.OneToMany {
+		t.Errorf("expected the inverse one-to-many, got %+v", r)
+	}
+	if r := orderRelations["Products"]; r.EntityName != "Product" || r.RelationType != enum.ManyToMany {
+		t.Errorf("expected the join table to relate orders and products, got %+v", r)
+	}
+	if r := relationsOf(entities["Product"])["Orders"]; r.RelationType != enum.ManyToMany {
+		t.Errorf("expected the many-to-many from both sides, got %+v", r)
+	}
+
+	category := entities["Category"]
+	if r := relationsOf(category)["Parent"]; r.RelationType != enum.SelfReferencing {
+		t.Errorf("unexpected parent relation: %+v", r)
+	}
+	if r := relationsOf(category)["Children"]; r.RelationType != enum.OneToMany {
+		t.Errorf("unexpected children relation: %+v", r)
+	}
+	if title := fieldOf(t, category, "Title"); title.FieldDescription != "Shown in menus" {
+		t.Errorf("expected the column comment, got %q", title.FieldDescription)
+	}
+
+	// The imported entities resolve as a project
+	var project []model.Entity
+	for _, e := range entities {
+		project = append(project, e)
+	}
+	for _, e := range project {
+		if _, skipped := service.BuildRelations(e, project); len(skipped) > 0 {
+			t.Errorf("relations of %s do not resolve: %v", e.EntityName, skipped)
+		}
+	}
+
+	report := strings.Join(unmapped, "\n")
+	for _, want := range []string{"bytea", "CREATE VIEW"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q to be reported as unmapped, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestSQLImporterMapsMySQLTables(t *testing.T) {
+	entities, unmapped := parseSchema(t, enum.Mysql, "# accounts\n"+
+		"CREATE TABLE `users` (\n"+
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n"+
+		"  `active` tinyint(1) NOT NULL DEFAULT '1',\n"+
+		"  `role` enum('admin','member') NOT NULL COMMENT 'Access level',\n"+
+		"  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  KEY `idx_role` (`role`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Site users';\n"+
+		"CREATE TABLE `profiles` (\n"+
+		"  `id` int NOT NULL AUTO_INCREMENT PRIMARY KEY,\n"+
+		"  `user_id` int unsigned NOT NULL,\n"+
+		"  `bio` varchar(500),\n"+
+		"  `first_name` varchar(50), `last_name` varchar(50),\n"+
+		"  UNIQUE KEY `uq_user` (`user_id`),\n"+
+		"  UNIQUE KEY `uq_name` (`first_name`, `last_name`),\n"+
+		"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE\n"+
+		");")
+
+	user := entities["User"]
+	if user.EntityDescription != "Site users" || user.PreferredDB != enum.Mysql {
+		t.Errorf("unexpected user entity: %+v", user)
+	}
+	if active := fieldOf(t, user, "Active"); active.FieldType != enum.Bool {
+		t.Errorf("expected tinyint(1) to be a Bool, got %v", active.FieldType)
+	}
+	if role := fieldOf(t, user, "Role"); !role.IsEnum || role.FieldDescription != "Access level" ||
+		!reflect.DeepEqual(role.EnumValues, []string{"admin", "member"}) {
+		t.Errorf("unexpected role field: %+v", role)
+	}
+	if r := relationsOf(entities["Profile"])["User"]; r.RelationType != enum.OneToOne {
+		t.Errorf("expected a unique foreign key to be one-to-one, got %+v", r)
+	}
+	if r := relationsOf(user)["Profile"]; r.RelationType != enum.OneToOne {
+		t.Errorf("expected the inverse one-to-one, got %+v", r)
+	}
+	if !strings.Contains(strings.Join(unmapped, "\n"), "composite unique key profiles (first_name, last_name)") {
+		t.Errorf("expected the composite unique key to be reported, got %v", unmapped)
+	}
+}
+
+func TestSQLImporterRejectsScriptsWithoutTables(t *testing.T) {
+	if _, err := parser.NewSQLParser(enum.Postgres).ParseSchema("INSERT INTO t VALUES (1);"); err == nil {
+		t.Error("expected an error for a script without CREATE TABLE")
+	}
+	if _, err := parser.NewSQLParser(enum.Postgres).ParseSchema("CREATE TABLE t (name text"); err == nil {
+		t.Error("expected an error for an unbalanced column list")
+	}
+}
+
+func TestMergeEntitiesKeepsProjectDetails(t *testing.T) {
+	existing := []model.Entity{{
+		EntityName: "Customer",
+		EntityFields: []model.EntityField{{
+			FieldName: "Email", FieldDescription: "Login", SampleData: "a@b.c", FieldType: enum.String,
+			InputValidations: []model.InputValidation{{RuleType: enum.EmailRule}},
+		}},
+		DependsOnEntities: []model.DependsOnEntity{{EntityName: "Order", FieldName: "Orders", RelationType: enum.Composition}},
+	}}
+	imported := []model.Entity{
+		{
+			EntityName:        "customer",
+			EntityFields:      []model.EntityField{{FieldName: "email", FieldDescription: "Email address", IsMandatory: true, IsUnique: true}, {FieldName: "Name"}},
+			DependsOnEntities: []model.DependsOnEntity{{EntityName: "Order", FieldName: "Orders", RelationType: enum.OneToMany}},
+		},
+		{
+			EntityName:        "Order",
+			DependsOnEntities: []model.DependsOnEntity{{EntityName: "Customer", FieldName: "Customer", RelationType: enum.ManyToOne}, {EntityName: "Coupon", FieldName: "Coupon", RelationType: enum.ManyToOne}},
+		},
+	}
+
+	merge := service.MergeEntities(existing, imported)
+	if !reflect.DeepEqual(merge.Created, []string{"Order"}) || !reflect.DeepEqual(merge.Updated, []string{"Customer"}) {
+		t.Errorf("unexpected created %v and updated %v", merge.Created, merge.Updated)
+	}
+	customer := merge.Entities[0]
+	email := customer.EntityFields[0]
+	if !email.IsMandatory || !email.IsUnique || email.FieldDescription != "Login" || email.SampleData != "a@b.c" ||
+		len(email.InputValidations) != 1 {
+		t.Errorf("expected the schema's constraints and the project's details, got %+v", email)
+	}
+	if len(customer.EntityFields) != 2 {
+		t.Errorf("expected the new field to be added, got %d fields", len(customer.EntityFields))
+	}
+	if len(customer.DependsOnEntities) != 1 || customer.DependsOnEntities[0].RelationType != enum.Composition {
+		t.Errorf("expected the declared relation to keep its type, got %+v", customer.DependsOnEntities)
+	}
+	if len(merge.Entities[1].DependsOnEntities) != 1 || len(merge.Dropped) != 1 || !strings.Contains(merge.Dropped[0], "Coupon") {
+		t.Errorf("expected the relation to the missing Coupon to be dropped, got %+v and %v", merge.Entities[1].DependsOnEntities, merge.Dropped)
+	}
+	if len(existing[0].EntityFields) != 1 {
+		t.Error("expected the existing entities to be left untouched")
+	}
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"gen-concept-api/domain/service"
+)
+
+func TestCaseConversion(t *testing.T) {
+	cases := []struct {
+		input  string
+		camel  string
+		pascal string
+		snake  string
+		kebab  string
+	}{
+		{"Customer", "customer", "Customer", "customer", "customer"},
+		{"order_item", "orderItem", "OrderItem", "order_item", "order-item"},
+		{"HTTPServer", "httpServer", "HTTPServer", "http_server", "http-server"},
+		{"userID", "userID", "UserID", "user_id", "user-id"},
+		{"EMAIL_ADDRESS", "emailAddress", "EmailAddress", "email_address", "email-address"},
+		{"Ärger bericht", "ärgerBericht", "ÄrgerBericht", "ärger_bericht", "ärger-bericht"},
+		{"address2Line", "address2Line", "Address2Line", "address2_line", "address2-line"},
+	}
+
+	for _, c := range cases {
+		if got := service.ToCamel(c.input); got != c.camel {
+			t.Errorf("ToCamel(%q): expected %q, got %q", c.input, c.camel, got)
+		}
+		if got := service.ToPascal(c.input); got != c.pascal {
+			t.Errorf("ToPascal(%q): expected %q, got %q", c.input, c.pascal, got)
+		}
+		if got := service.ToSnake(c.input); got != c.snake {
+			t.Errorf("ToSnake(%q): expected %q, got %q", c.input, c.snake, got)
+		}
+		if got := service.ToKebab(c.input); got != c.kebab {
+			t.Errorf("ToKebab(%q): expected %q, got %q", c.input, c.kebab, got)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	cases := map[string]string{
+		"customer":  "customers",
+		"Category":  "Categories",
+		"status":    "statuses",
+		"box":       "boxes",
+		"person":    "people",
+		"OrderItem": "OrderItems",
+		"data":      "data",
+		"analysis":  "analyses",
+		"key":       "keys",
+		"userID":    "userIDs",
+	}
+	for input, expected := range cases {
+		if got := service.Pluralize(input); got != expected {
+			t.Errorf("Pluralize(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+
+	singulars := map[string]string{
+		"customers":  "customer",
+		"Categories": "Category",
+		"statuses":   "status",
+		"addresses":  "address",
+		"people":     "person",
+		"houses":     "house",
+		"analyses":   "analysis",
+		"status":     "status",
+	}
+	for input, expected := range singulars {
+		if got := service.Singularize(input); got != expected {
+			t.Errorf("Singularize(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestTemplateFuncsInTemplates(t *testing.T) {
+	genCtx := service.GenContext{
+		ProjectName: "shop",
+		Entity: service.GenEntity{
+			Name:    "OrderItem",
+			VarName: "orderItem",
+			Fields: []service.GenField{
+				{Name: "Email", Type: "string"},
+				{Name: "Quantity", Type: "int"},
+			},
+		},
+		Imports: []string{"time"},
+	}
+
+	cases := map[string]string{
+		`{{snake .Entity.Name}}`:                                 "order_item",
+		`{{plural .Entity.VarName}}`:                             "orderItems",
+		`{{.Entity.Name | plural | kebab}}`:                      "order-items",
+		`{{pascal "order_item"}}`:                                "OrderItem",
+		`{{join ", " (list "a" "b" "c")}}`:                       "a, b, c",
+		`{{"a\nb" | indent 2}}`:                                  "  a\n  b",
+		`{{quote .ProjectName}}`:                                 `"shop"`,
+		`{{if hasField .Entity "Email"}}yes{{else}}no{{end}}`:    "yes",
+		`{{if hasField .Entity "Password"}}yes{{else}}no{{end}}`: "no",
+		`{{(field .Entity "quantity").Type}}`:                    "int",
+		`{{if hasImport . "time"}}time{{end}}`:                   "time",
+		`{{default "none" ""}}`:                                  "none",
+		`{{ternary "on" "off" true}}`:                            "on",
+		`{{first (list 1 2 3)}}-{{last (list 1 2 3)}}`:           "1-3",
+		`{{join "," (uniq (list "b" "a" "b"))}}`:                 "b,a",
+		`{{join "," (sortAlpha (list "b" "c" "a"))}}`:            "a,b,c",
+		`{{if has "b" (list "a" "b")}}found{{end}}`:              "found",
+		`{{screamingSnake "maxRetries"}}`:                        "MAX_RETRIES",
+	}
+
+	for source, expected := range cases {
+		tmpl, err := template.New("test").Funcs(service.TemplateFuncs()).Parse(source)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", source, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, genCtx); err != nil {
+			t.Errorf("Execute(%q): %v", source, err)
+			continue
+		}
+		if buf.String() != expected {
+			t.Errorf("%s: expected %q, got %q", source, expected, buf.String())
+		}
+	}
+}
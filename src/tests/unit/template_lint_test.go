@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+)
+
+func lintIssues(t *testing.T, templates map[string]string, placeholders ...string) []service.TemplateLintIssue {
+	t.Helper()
+	blueprint := model.Blueprint{}
+	for path, content := range templates {
+		blueprint.Templates = append(blueprint.Templates, model.BlueprintTemplate{Path: path, Content: content})
+	}
+	for _, name := range placeholders {
+		blueprint.Placeholders = append(blueprint.Placeholders, model.Placeholder{Name: name, Type: "String"})
+	}
+	return service.LintTemplates(blueprint)
+}
+
+func findIssue(issues []service.TemplateLintIssue, message string) *service.TemplateLintIssue {
+	for i := range issues {
+		if strings.Contains(issues[i].Message, message) {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+func TestLintTemplatesAcceptsValidTemplates(t *testing.T) {
+	issues := lintIssues(t, map[string]string{
+		"model.go": "package {{.Inputs.Package}}\n" +
+			"type {{.Entity.Name}} struct {\n" +
+			"{{range $i, $f := .Entity.Fields}}\t{{$f.Name | pascal}} {{$f.Type}} {{$f.JSONTag}}\n{{end}}}\n" +
+			"{{range .Entity.Fields}}{{with .Derived}}{{.Code}}{{end}}{{if .Derived}}{{.Derived.Expression}}{{end}}{{end}}\n" +
+			"{{range .Operations}}{{range .Steps}}{{with .Validation}}{{.}}{{end}}{{end}}{{end}}\n" +
+			"{{if hasField .Entity \"Email\"}}{{(field .Entity \"Email\").Type}}{{end}}{{template \"fields\" .Entity}}\n" +
+			"// {{.Language.String}} {{.Database.String | upper}}",
+		"_shared.tmpl": "{{define \"fields\"}}{{range .Fields}}{{.Name}}{{end}}{{end}}",
+	}, "Package")
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %+v", issues)
+	}
+}
+
+func TestLintTemplatesReportsErrors(t *testing.T) {
+	issues := lintIssues(t, map[string]string{
+		"model.go": "package main\n" +
+			"{{range .Entity.Feilds}}{{.Name}}{{end}}\n" +
+			"{{pluralize .Entity.Name}}\n" +
+			"{{.Inputs.ServiceNme}}\n" +
+			"{{range .Entity.Name}}{{end}}\n" +
+			"{{template \"missing\" .}}",
+	}, "ServiceName")
+
+	expected := []struct {
+		message string
+		line    int
+	}{
+		{"GenEntity has no field Feilds (did you mean Fields?)", 2},
+		{"function \"pluralize\" is not defined (did you mean plural?)", 3},
+		{"unknown placeholder ServiceNme (did you mean ServiceName?)", 4},
+		{"range can't iterate over string", 5},
+		{"template \"missing\" is not defined", 6},
+	}
+	for _, e := range expected {
+		issue := findIssue(issues, e.message)
+		if issue == nil {
+			t.Errorf("expected %q in %+v", e.message, issues)
+			continue
+		}
+		if issue.Severity != service.LintError || issue.Template != "model.go" || issue.Line != e.line {
+			t.Errorf("unexpected issue %+v, expected an error on line %d", issue, e.line)
+		}
+	}
+
+	_, err := service.LintBlueprint(model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "a.go", Content: "{{if}}"}}})
+	var lintErr *service.TemplateLintError
+	if !errors.As(err, &lintErr) || len(lintErr.Issues) != 1 || lintErr.Issues[0].Line != 1 {
+		t.Errorf("expected the parse error, got %v", err)
+	}
+}
+
+func TestLintTemplatesWarnings(t *testing.T) {
+	issues := lintIssues(t, map[string]string{
+		"model.go":     "{{range .Entity.Fields}}\n{{.Derived.Code}}{{end}}",
+		"_shared.tmpl": "{{define \"unused\"}}{{end}}",
+	}, "Unused")
+
+	for _, message := range []string{".Derived may be nil", "template \"unused\" is never used", "placeholder Unused is never used"} {
+		issue := findIssue(issues, message)
+		if issue == nil || issue.Severity != service.LintWarning {
+			t.Errorf("expected the warning %q in %+v", message, issues)
+		}
+	}
+	if issue := findIssue(issues, ".Derived may be nil"); issue != nil && issue.Line != 2 {
+		t.Errorf("expected the warning on line 2, got %+v", issue)
+	}
+
+	warnings, err := service.LintBlueprint(model.Blueprint{Templates: []model.BlueprintTemplate{{Path: "model.go", Content: "{{.Entity.Name}}"}}})
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("expected a clean blueprint, got %v %v", warnings, err)
+	}
+}
+
+func TestLintTemplatesChecksDefinitionsWithTheirCallers(t *testing.T) {
+	issues := lintIssues(t, map[string]string{
+		"model.go":     "{{template \"field\" (index .Entity.Fields 0)}}",
+		"_shared.tmpl": "{{define \"field\"}}\n{{.Nme}}{{end}}",
+	})
+	issue := findIssue(issues, "GenField has no field Nme")
+	if issue == nil || issue.Template != "_shared.tmpl" || issue.Line != 2 {
+		t.Errorf("expected the field error in the partial, got %+v", issues)
+	}
+}
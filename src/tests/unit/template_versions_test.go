@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+func templatePaths(templates []model.BlueprintTemplate) map[string]string {
+	paths := make(map[string]string, len(templates))
+	for _, t := range templates {
+		paths[t.Path] = t.Content
+	}
+	return paths
+}
+
+func TestTemplateVersionsAppendChangesAndTombstones(t *testing.T) {
+	var rows []model.BlueprintTemplate
+	save := func(version int, incoming ...model.BlueprintTemplate) int {
+		t.Helper()
+		changes, err := service.TemplateChanges(service.TemplatesAtVersion(rows, version), incoming, 7, version+1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(changes) == 0 {
+			return version
+		}
+		rows = append(rows, changes...)
+		return version + 1
+	}
+
+	v1 := save(0,
+		model.BlueprintTemplate{Path: "model.go", Content: "v1", Language: enum.Golang},
+		model.BlueprintTemplate{Path: "repo.go", Content: "repo", Language: enum.Golang})
+	v2 := save(v1,
+		model.BlueprintTemplate{Path: "model.go", Content: "v2", Language: enum.Golang},
+		model.BlueprintTemplate{Path: "repo.go", Content: "repo", Language: enum.Golang})
+	v3 := save(v2, model.BlueprintTemplate{Path: "model.go", Content: "v2", Language: enum.Golang})
+	v4 := save(v3, model.BlueprintTemplate{Path: "model.go", Content: "v2", Language: enum.Golang})
+
+	if v1 != 1 || v2 != 2 || v3 != 3 || v4 != 3 {
+		t.Fatalf("expected versions 1, 2, 3 and an unchanged save, got %d, %d, %d, %d", v1, v2, v3, v4)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected two files, one change and one tombstone, got %+v", rows)
+	}
+	for _, row := range rows {
+		if row.BlueprintID != 7 || (!row.Removed && row.Checksum != service.TemplateChecksum(row.Content)) {
+			t.Errorf("unexpected row %+v", row)
+		}
+	}
+	if tombstone := rows[3]; !tombstone.Removed || tombstone.Path != "repo.go" || tombstone.Version != 3 || tombstone.Content != "" {
+		t.Errorf("expected a tombstone for repo.go, got %+v", tombstone)
+	}
+
+	expected := []map[string]string{
+		{},
+		{"model.go": "v1", "repo.go": "repo"},
+		{"model.go": "v2", "repo.go": "repo"},
+		{"model.go": "v2"},
+	}
+	for version, files := range expected {
+		got := templatePaths(service.TemplatesAtVersion(rows, version))
+		if len(got) != len(files) {
+			t.Errorf("version %d: expected %v, got %v", version, files, got)
+			continue
+		}
+		for p, content := range files {
+			if got[p] != content {
+				t.Errorf("version %d: expected %s to hold %q, got %q", version, p, content, got[p])
+			}
+		}
+	}
+
+	// A file added again after its tombstone comes back at the new version only
+	v5 := save(v3, model.BlueprintTemplate{Path: "model.go", Content: "v2", Language: enum.Golang},
+		model.BlueprintTemplate{Path: "repo.go", Content: "repo again", Language: enum.Golang})
+	if got := templatePaths(service.TemplatesAtVersion(rows, v5)); v5 != 4 || got["repo.go"] != "repo again" {
+		t.Errorf("expected repo.go to be restored in version 4, got %d: %v", v5, got)
+	}
+	if got := templatePaths(service.TemplatesAtVersion(rows, 3)); len(got) != 1 {
+		t.Errorf("expected version 3 to keep its tombstone, got %v", got)
+	}
+}
+
+func TestTemplateVersionsRecordScopeAndLanguageChanges(t *testing.T) {
+	current := []model.BlueprintTemplate{{Path: "app.go", Content: "x", Language: enum.Golang, Checksum: service.TemplateChecksum("x"), Version: 1}}
+	changes, err := service.TemplateChanges(current, []model.BlueprintTemplate{{Path: " app.go ", Content: "x", Language: enum.Golang, Scope: enum.ScopeProject}}, 1, 2)
+	if err != nil || len(changes) != 1 || changes[0].Scope != enum.ScopeProject || changes[0].Path != "app.go" {
+		t.Errorf("expected a scope change to add a version, got %+v, %v", changes, err)
+	}
+
+	invalid := [][]model.BlueprintTemplate{
+		{{Path: " "}},
+		{{Path: "a.go"}, {Path: "a.go "}},
+	}
+	for _, incoming := range invalid {
+		if _, err := service.TemplateChanges(nil, incoming, 1, 1); err == nil {
+			t.Errorf("expected %+v to be rejected", incoming)
+		}
+	}
+}
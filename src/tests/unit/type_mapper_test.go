@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"testing"
+
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+)
+
+func TestTypeMapperLanguages(t *testing.T) {
+	entity := model.Entity{
+		EntityName: "Order",
+		DependsOnEntities: []model.DependsOnEntity{
+			{EntityName: "Customer", FieldName: "buyer"},
+		},
+	}
+	createdAt := model.EntityField{FieldName: "createdAt", FieldType: enum.DateTime, IsMandatory: true}
+	quantity := model.EntityField{FieldName: "quantity", FieldType: enum.Int}
+	tags := model.EntityField{FieldName: "tags", FieldType: enum.Collection, IsCollection: true, CollectionType: enum.Set, CollectionItemType: enum.StringType}
+	items := model.EntityField{FieldName: "items", FieldType: enum.Collection, IsCollection: true, CollectionType: enum.List, CollectionItemType: enum.OtherEntityType, CollectionEntity: "OrderItem"}
+	status := model.EntityField{FieldName: "status", FieldType: enum.String, IsEnum: true, EnumValues: []string{"open", "closed"}, IsMandatory: true}
+	buyer := model.EntityField{FieldName: "buyer", FieldType: enum.Entity}
+
+	cases := []struct {
+		lang     enum.ProgrammingLanguage
+		field    model.EntityField
+		typ      string
+		nullable string
+		imports  []string
+	}{
+		{enum.Golang, createdAt, "time.Time", "*time.Time", []string{"time"}},
+		{enum.Golang, quantity, "int64", "*int64", nil},
+		{enum.Golang, tags, "map[string]struct{}", "map[string]struct{}", nil},
+		{enum.Golang, buyer, "Customer", "*Customer", nil},
+		{enum.TypeScript, items, "Array<OrderItem>", "Array<OrderItem> | null", nil},
+		{enum.TypeScript, createdAt, "Date", "Date | null", nil},
+		{enum.Java, quantity, "long", "Long", nil},
+		{enum.Java, tags, "Set<String>", "Set<String>", []string{"java.util.Set"}},
+		{enum.Java, status, "OrderStatus", "OrderStatus", nil},
+		{enum.Csharp, createdAt, "DateTimeOffset", "DateTimeOffset?", []string{"System"}},
+		{enum.Csharp, items, "List<OrderItem>", "List<OrderItem>?", []string{"System.Collections.Generic"}},
+		{enum.Python, quantity, "int", "int | None", nil},
+		{enum.Python, tags, "set[str]", "set[str] | None", nil},
+	}
+
+	mapper := service.NewTypeMapper()
+	for _, c := range cases {
+		got := mapper.MapField(c.lang, enum.Postgres, entity, c.field)
+		if got.Type != c.typ || got.NullableType != c.nullable {
+			t.Errorf("%s %s: expected %q/%q, got %q/%q", c.lang, c.field.FieldName, c.typ, c.nullable, got.Type, got.NullableType)
+		}
+		if len(got.Imports) != len(c.imports) || (len(c.imports) > 0 && got.Imports[0] != c.imports[0]) {
+			t.Errorf("%s %s: expected imports %v, got %v", c.lang, c.field.FieldName, c.imports, got.Imports)
+		}
+	}
+}
+
+func TestTypeMapperColumns(t *testing.T) {
+	entity := model.Entity{EntityName: "Order"}
+	status := model.EntityField{FieldName: "status", IsEnum: true, EnumValues: []string{"open", "it's closed"}}
+	items := model.EntityField{FieldName: "items", IsCollection: true, CollectionType: enum.List, CollectionItemType: enum.OtherEntityType}
+	price := model.EntityField{FieldName: "price", FieldType: enum.Float}
+
+	cases := []struct {
+		db     enum.PreferredDB
+		field  model.EntityField
+		column string
+	}{
+		{enum.Postgres, price, "double precision"},
+		{enum.Postgres, items, ""},
+		{enum.Mysql, status, "ENUM('open','it''s closed')"},
+		{enum.MongoDB, items, "array"},
+		{enum.MongoDB, price, "double"},
+		{enum.Oracle, price, ""},
+	}
+
+	mapper := service.NewTypeMapper()
+	for _, c := range cases {
+		if got := mapper.MapField(enum.Golang, c.db, entity, c.field).ColumnType; got != c.column {
+			t.Errorf("%s %s: expected %q, got %q", c.db, c.field.FieldName, c.column, got)
+		}
+	}
+
+	// Unregistered languages keep the raw data type
+	if got := mapper.MapField(enum.Rust, enum.Postgres, entity, price).Type; got != "Float" {
+		t.Errorf("expected raw data type for unregistered language, got %q", got)
+	}
+}
@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	apidto "gen-concept-api/api/dto"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func bound(v float64) *float64 { return &v }
+
+func validationEntity() model.Entity {
+	return model.Entity{EntityName: "Booking", EntityFields: []model.EntityField{
+		{FieldName: "code", FieldType: enum.String, IsMandatory: true, InputValidations: []model.InputValidation{
+			{RuleType: enum.LengthRule, Min: bound(3), Max: bound(10)},
+			{RuleType: enum.PatternRule, Pattern: "^[A-Z]+$", CustomErrorMessage: "code must be upper case"},
+			{Description: "checked by the team", RuleType: enum.CustomRule},
+		}},
+		{FieldName: "email", FieldType: enum.String, InputValidations: []model.InputValidation{{RuleType: enum.EmailRule}, {RuleType: enum.UniqueRule}}},
+		{FieldName: "seats", FieldType: enum.Int, IsMandatory: true, InputValidations: []model.InputValidation{
+			{RuleType: enum.RangeRule, Min: bound(1), Max: bound(9), CustomErrorMessage: "1 to 9 seats"},
+		}},
+		{FieldName: "startDate", FieldType: enum.DateTime, IsMandatory: true},
+		{FieldName: "endDate", FieldType: enum.DateTime, InputValidations: []model.InputValidation{
+			{RuleType: enum.CrossFieldRule, Operator: enum.GreaterThan, OtherField: "startDate"},
+		}},
+	}}
+}
+
+func validationCodes(entity model.Entity, language enum.ProgrammingLanguage) map[string]service.GenValidation {
+	byRule := make(map[string]service.GenValidation)
+	for _, f := range entity.EntityFields {
+		for _, v := range service.BuildValidations(entity, f, language) {
+			byRule[f.FieldName+"."+v.Type] = v
+		}
+	}
+	return byRule
+}
+
+func TestBuildValidationsPerLanguage(t *testing.T) {
+	entity := validationEntity()
+	expected := map[enum.ProgrammingLanguage]map[string]string{
+		enum.Golang: {
+			"code.LENGTH": "min=3,max=10", "email.EMAIL": "email", "email.UNIQUE": "", "seats.RANGE": "gte=1,lte=9",
+			"endDate.CROSS_FIELD": "gtfield=StartDate",
+		},
+		enum.TypeScript: {
+			"code.LENGTH": "@Length(3, 10)", "code.PATTERN": `@Matches(/^[A-Z]+$/, { message: "code must be upper case" })`,
+			"email.EMAIL": "@IsEmail()", "seats.RANGE": `@Min(1, { message: "1 to 9 seats" }) @Max(9, { message: "1 to 9 seats" })`,
+		},
+		enum.Java: {
+			"code.LENGTH": "@Size(min = 3, max = 10)", "code.PATTERN": `@Pattern(regexp = "^[A-Z]+$", message = "code must be upper case")`,
+			"email.EMAIL": "@Email", "seats.RANGE": `@Min(value = 1, message = "1 to 9 seats") @Max(value = 9, message = "1 to 9 seats")`,
+		},
+		enum.Csharp: {
+			"code.LENGTH": "[StringLength(10, MinimumLength = 3)]", "email.EMAIL": "[EmailAddress]",
+			"seats.RANGE": `[Range(1, 9, ErrorMessage = "1 to 9 seats")]`,
+		},
+		enum.Python: {
+			"code.LENGTH": "min_length=3, max_length=10", "email.EMAIL": "", "seats.RANGE": "",
+		},
+	}
+	for language, codes := range expected {
+		byRule := validationCodes(entity, language)
+		if _, ok := byRule["code.CUSTOM"]; ok {
+			t.Errorf("%s: CUSTOM rules have no generated check", language)
+		}
+		for rule, code := range codes {
+			if got := byRule[rule].Code; got != code {
+				t.Errorf("%s %s: got %q, expected %q", language, rule, got, code)
+			}
+		}
+	}
+
+	goPattern := validationCodes(entity, enum.Golang)["code.PATTERN"]
+	if goPattern.Code != "" || !strings.Contains(goPattern.Check, "regexp.MustCompile(`^[A-Z]+$`).MatchString(booking.Code)") ||
+		!strings.Contains(goPattern.Check, `errors.New("code must be upper case")`) {
+		t.Errorf("unexpected Go pattern check %+v", goPattern)
+	}
+	javaCross := validationCodes(entity, enum.Java)["endDate.CROSS_FIELD"]
+	if !strings.Contains(javaCross.Check, "public boolean isEndDateGreaterThanStartDate()") ||
+		!strings.Contains(javaCross.Check, "(this.getEndDate() == null) || (this.getEndDate().compareTo(this.getStartDate()) > 0)") {
+		t.Errorf("unexpected Java cross-field check %q", javaCross.Check)
+	}
+	python := validationCodes(entity, enum.Python)
+	if python["email.EMAIL"].FieldType != "EmailStr" {
+		t.Errorf("expected EmailStr, got %+v", python["email.EMAIL"])
+	}
+	if check := python["seats.RANGE"].Check; !strings.Contains(check, "(value < 1 or value > 9)") || !strings.Contains(check, `raise ValueError("1 to 9 seats")`) {
+		t.Errorf("expected a validator keeping the message, got %q", check)
+	}
+	if check := python["endDate.CROSS_FIELD"].Check; !strings.Contains(check, "(self.end_date is None) or (self.end_date > self.start_date)") {
+		t.Errorf("unexpected model validator %q", check)
+	}
+}
+
+func TestValidationRulesInGenerationContext(t *testing.T) {
+	genService := service.NewGenerationService(nil, nil, nil, nil)
+	genCtx, err := genService.BuildContext(context.Background(), nil, validationEntity(), enum.Golang)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags := make(map[string]string)
+	for _, f := range genCtx.Entity.Fields {
+		tags[f.Name] = f.ValidateTag
+	}
+	if tags["code"] != `binding:"required,min=3,max=10"` || tags["email"] != `binding:"omitempty,email"` || tags["startDate"] != `binding:"required"` ||
+		tags["endDate"] != `binding:"omitempty,gtfield=StartDate"` {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if len(genCtx.Entity.Checks) != 1 || !hasImportPath(genCtx.Imports, "regexp") {
+		t.Errorf("expected the pattern check and its imports, got %v %v", genCtx.Entity.Checks, genCtx.Imports)
+	}
+
+	genCtx, err = genService.BuildContext(context.Background(), nil, validationEntity(), enum.Python)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	email := genCtx.Entity.Fields[1]
+	if email.Type != "EmailStr" || email.NullableType != "EmailStr | None" || len(genCtx.Entity.Checks) != 3 {
+		t.Errorf("unexpected Python context %+v %v", email, genCtx.Entity.Checks)
+	}
+}
+
+func hasImportPath(imports []string, path string) bool {
+	for _, i := range imports {
+		if i == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInputValidationRulesAreChecked(t *testing.T) {
+	entity := apidto.Entity{EntityName: "Booking", EntityFields: []apidto.EntityField{
+		{FieldName: "seats", FieldType: enum.Int, InputValidations: []apidto.InputValidation{
+			{RuleType: enum.RangeRule, Min: bound(9), Max: bound(1)},
+			{RuleType: enum.PatternRule, Pattern: "("},
+			{RuleType: enum.CrossFieldRule, Operator: enum.Like, OtherField: "missing"},
+		}},
+		{FieldName: "code", FieldType: enum.String, InputValidations: []apidto.InputValidation{{RuleType: enum.LengthRule, Min: bound(3)}}},
+	}}
+	err := entity.Validate()
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	got := make(map[string]bool)
+	for _, fe := range fieldErrs {
+		got[fe.Field()+":"+fe.Tag()] = true
+	}
+	for _, want := range []string{"Max:gtefield", "Pattern:invalid", "Operator:oneof", "OtherField:invalid"} {
+		if !got[want] {
+			t.Errorf("expected %s in %v", want, got)
+		}
+	}
+	if got["RuleType:invalid"] {
+		t.Errorf("a LENGTH rule applies to a string field: %v", got)
+	}
+
+	entity.EntityFields = entity.EntityFields[1:]
+	entity.EntityFields[0].FieldType = enum.Bool
+	if err := entity.Validate(); err == nil || !strings.Contains(err.Error(), "RuleType invalid") {
+		t.Errorf("expected a LENGTH rule on a bool field to be rejected, got %v", err)
+	}
+}
+
+func TestOneOfTagsEscapeAllowedValues(t *testing.T) {
+	allowed := []string{"in progress", "a,b", "x|y", `say "hi"`, `back\slash`, ""}
+	entity := model.Entity{EntityName: "Ticket", EntityFields: []model.EntityField{
+		{FieldName: "status", FieldType: enum.String, IsMandatory: true, InputValidations: []model.InputValidation{
+			{RuleType: enum.OneOfRule, AllowedValues: allowed},
+		}},
+		{FieldName: "note", FieldType: enum.String, InputValidations: []model.InputValidation{
+			{RuleType: enum.OneOfRule, AllowedValues: []string{"it's", "done"}},
+		}},
+	}}
+	genCtx, err := service.NewGenerationService(nil, nil, nil, nil).BuildContext(context.Background(), nil, entity, enum.Golang)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The tag is read back from a struct field the way gin binds it
+	tag := reflect.StructTag(genCtx.Entity.Fields[0].JSONTag + " " + genCtx.Entity.Fields[0].ValidateTag)
+	binding, ok := tag.Lookup("binding")
+	if !ok || tag.Get("json") != "status" {
+		t.Fatalf("the tag does not parse: %s", tag)
+	}
+	validate := validator.New()
+	for _, value := range []string{"in progress", "a,b", "x|y", `say "hi"`, `back\slash`, "in", "progress", "a", "x", "other"} {
+		err := validate.Var(value, binding)
+		if valid := slices.Contains(allowed, value); valid != (err == nil) {
+			t.Errorf("%q: expected valid %v with %s, got %v", value, valid, binding, err)
+		}
+	}
+
+	note := genCtx.Entity.Fields[1]
+	if note.ValidateTag != "" || len(genCtx.Entity.Checks) != 1 ||
+		!strings.Contains(genCtx.Entity.Checks[0], `slices.Contains([]string{"it's", "done"}, ticket.Note)`) || !hasImportPath(genCtx.Imports, "slices") {
+		t.Errorf("expected values the tag cannot carry to be checked in code, got %s %v %v", note.ValidateTag, genCtx.Entity.Checks, genCtx.Imports)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"gen-concept-api/domain/filter"
 	model "gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase/dto"
 
 	"github.com/google/uuid"
@@ -60,15 +61,22 @@ func (u *BlueprintUsecase) Create(ctx context.Context, req dto.Blueprint) (dto.B
 				BaseModel: model.BaseModel{
 					Uuid: p.Uuid,
 				},
-				Name:        p.Name,
-				Description: p.Description,
-				Type:        p.Type,
-				DefaultVal:  p.DefaultVal,
+				Name:          p.Name,
+				Description:   p.Description,
+				Type:          p.Type,
+				DefaultVal:    p.DefaultVal,
+				AllowedValues: p.AllowedValues,
 			}
 		}
 		blueprintModel.Placeholders = placeholders
 	}
 
+	// Templates that could never render are rejected before they are stored
+	warnings, err := service.LintBlueprint(blueprintModel)
+	if err != nil {
+		return response, err
+	}
+
 	// Use the custom repository method to create with relationships
 	created, err := u.repository.CreateWithRelationships(ctx, blueprintModel)
 	if err != nil {
@@ -77,6 +85,7 @@ func (u *BlueprintUsecase) Create(ctx context.Context, req dto.Blueprint) (dto.B
 
 	// Convert result to DTO
 	response, _ = common.TypeConverter[dto.Blueprint](created)
+	response.LintWarnings = warnings
 	return response, nil
 }
 
@@ -113,15 +122,30 @@ func (s *BlueprintUsecase) Update(ctx context.Context, uuid uuid.UUID, req dto.B
 				BaseModel: model.BaseModel{
 					Uuid: p.Uuid,
 				},
-				Name:        p.Name,
-				Description: p.Description,
-				Type:        p.Type,
-				DefaultVal:  p.DefaultVal,
+				Name:          p.Name,
+				Description:   p.Description,
+				Type:          p.Type,
+				DefaultVal:    p.DefaultVal,
+				AllowedValues: p.AllowedValues,
 			}
 		}
 		blueprintModel.Placeholders = placeholders
 	}
 
+	// Without templates the stored files are kept, so those are what the placeholders are checked against
+	linted := blueprintModel
+	if linted.Templates == nil {
+		existing, err := s.repository.GetByUuidWithRelationships(ctx, uuid)
+		if err != nil {
+			return response, err
+		}
+		linted.Templates = existing.Templates
+	}
+	warnings, err := service.LintBlueprint(linted)
+	if err != nil {
+		return response, err
+	}
+
 	// Use the custom repository method to update with relationships
 	updated, err := s.repository.UpdateWithRelationships(ctx, uuid, blueprintModel)
 	if err != nil {
@@ -130,6 +154,7 @@ func (s *BlueprintUsecase) Update(ctx context.Context, uuid uuid.UUID, req dto.B
 
 	// Convert result to DTO
 	response, _ = common.TypeConverter[dto.Blueprint](updated)
+	response.LintWarnings = warnings
 	return response, nil
 }
 
@@ -138,9 +163,22 @@ func (s *BlueprintUsecase) Delete(ctx context.Context, uuid uuid.UUID) error {
 	return s.base.Delete(ctx, uuid)
 }
 
-// Get By Id
+// Get By Id - includes the template files of the current version
 func (s *BlueprintUsecase) GetById(ctx context.Context, uuid uuid.UUID) (dto.Blueprint, error) {
-	return s.base.GetById(ctx, uuid)
+	blueprint, err := s.repository.GetByUuidWithRelationships(ctx, uuid)
+	if err != nil {
+		return dto.Blueprint{}, err
+	}
+	return dto.FromBlueprintModel(blueprint), nil
+}
+
+// GetTemplateHistory returns every stored version of the blueprint's template files
+func (s *BlueprintUsecase) GetTemplateHistory(ctx context.Context, uuid uuid.UUID) ([]dto.Template, error) {
+	history, err := s.repository.GetTemplateHistory(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromTemplateModels(history), nil
 }
 
 // Get By Filter
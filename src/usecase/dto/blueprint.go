@@ -2,6 +2,8 @@ package dto
 
 import (
 	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
 
 	"github.com/google/uuid"
 )
@@ -12,17 +14,34 @@ type Blueprint struct {
 	Type            string          `json:"type"`
 	Description     string          `json:"description"`
 	TemplatePath    string          `json:"templatePath"`
+	TemplateVersion int             `json:"templateVersion"`
+	Templates       []Template      `json:"templates"`
 	Placeholders    []Placeholder   `json:"placeholders"`
 	Functionalities []Functionality `json:"functionalities"`
 	Libraries       []Library       `json:"libraries"`
+
+	// LintWarnings are found when the blueprint is saved
+	LintWarnings []service.TemplateLintIssue `json:"lintWarnings,omitempty"`
+}
+
+type Template struct {
+	Uuid     uuid.UUID                `json:"uuid"`
+	Path     string                   `json:"path"`
+	Content  string                   `json:"content"`
+	Language enum.ProgrammingLanguage `json:"language"`
+	Scope    enum.TemplateScope       `json:"scope"`
+	Checksum string                   `json:"checksum"`
+	Version  int                      `json:"version"`
+	Removed  bool                     `json:"removed"`
 }
 
 type Placeholder struct {
-	Uuid        uuid.UUID `json:"uuid"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Type        string    `json:"type"`
-	DefaultVal  string    `json:"defaultVal"`
+	Uuid          uuid.UUID `json:"uuid"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Type          string    `json:"type"`
+	DefaultVal    string    `json:"defaultVal"`
+	AllowedValues []string  `json:"allowedValues"`
 }
 
 type Functionality struct {
@@ -49,21 +68,41 @@ func FromBlueprintModel(m model.Blueprint) Blueprint {
 		Type:            m.Type,
 		Description:     m.Description,
 		TemplatePath:    m.TemplatePath,
+		TemplateVersion: m.TemplateVersion,
+		Templates:       FromTemplateModels(m.Templates),
 		Placeholders:    FromPlaceholderModels(m.Placeholders),
 		Functionalities: FromFunctionalityModels(m.Functionalities),
 		Libraries:       FromLibraryModels(m.Libraries),
 	}
 }
 
+func FromTemplateModels(models []model.BlueprintTemplate) []Template {
+	dtos := make([]Template, len(models))
+	for i, m := range models {
+		dtos[i] = Template{
+			Uuid:     m.Uuid,
+			Path:     m.Path,
+			Content:  m.Content,
+			Language: m.Language,
+			Scope:    m.Scope,
+			Checksum: m.Checksum,
+			Version:  m.Version,
+			Removed:  m.Removed,
+		}
+	}
+	return dtos
+}
+
 func FromPlaceholderModels(models []model.Placeholder) []Placeholder {
 	dtos := make([]Placeholder, len(models))
 	for i, m := range models {
 		dtos[i] = Placeholder{
-			Uuid:        m.Uuid,
-			Name:        m.Name,
-			Description: m.Description,
-			Type:        m.Type,
-			DefaultVal:  m.DefaultVal,
+			Uuid:          m.Uuid,
+			Name:          m.Name,
+			Description:   m.Description,
+			Type:          m.Type,
+			DefaultVal:    m.DefaultVal,
+			AllowedValues: m.AllowedValues,
 		}
 	}
 	return dtos
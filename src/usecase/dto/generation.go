@@ -0,0 +1,150 @@
+package dto
+
+import (
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GenerationRequest struct {
+	BlueprintID     uuid.UUID
+	TemplateVersion int // 0 renders the current template version
+	Inputs          map[string]string
+	Previous        PreviousOutput
+}
+
+// PreviousOutput is an earlier generation whose protected regions are carried into the new one,
+// given as files by path, as a zip or tar.gz archive, or both (files win over archive entries)
+type PreviousOutput struct {
+	Files   map[string]string
+	Archive []byte
+}
+
+// GenerationRunRequest submits a background generation. Each entity is rendered with its uuid as the
+// entity_id input; without entities the blueprint is rendered once with the given inputs.
+type GenerationRunRequest struct {
+	BlueprintID     uuid.UUID
+	TemplateVersion int
+	EntityIDs       []uuid.UUID
+	Inputs          map[string]string
+	Format          string // zip (default) or tar.gz
+	Previous        PreviousOutput
+}
+
+type GenerationRun struct {
+	Uuid            uuid.UUID                 `json:"uuid"`
+	BlueprintID     uuid.UUID                 `json:"blueprintId"`
+	TemplateVersion int                       `json:"templateVersion"`
+	EntityIDs       []uuid.UUID               `json:"entityIds"`
+	Format          string                    `json:"format"`
+	Status          enum.GenerationRunStatus  `json:"status"`
+	TotalFiles      int                       `json:"totalFiles"`
+	CompletedFiles  int                       `json:"completedFiles"`
+	Files           []model.GenerationRunFile `json:"files"`
+	RegionIssues    int                       `json:"regionIssues"`
+	Error           string                    `json:"error,omitempty"`
+	CreatedAt       time.Time                 `json:"createdAt"`
+	StartedAt       *time.Time                `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time                `json:"finishedAt,omitempty"`
+	Downloadable    bool                      `json:"downloadable"`
+	ManifestID      *uuid.UUID                `json:"manifestId,omitempty"`
+}
+
+func FromGenerationRunModel(run model.GenerationRun) GenerationRun {
+	files := run.Files
+	if files == nil {
+		files = []model.GenerationRunFile{}
+	}
+	entityIDs := run.EntityUuids
+	if entityIDs == nil {
+		entityIDs = []uuid.UUID{}
+	}
+	return GenerationRun{
+		Uuid:            run.Uuid,
+		BlueprintID:     run.BlueprintUuid,
+		TemplateVersion: run.TemplateVersion,
+		EntityIDs:       entityIDs,
+		Format:          run.Format,
+		Status:          run.Status,
+		TotalFiles:      run.TotalFiles,
+		CompletedFiles:  run.CompletedFiles,
+		Files:           files,
+		RegionIssues:    run.RegionIssues,
+		Error:           run.Error,
+		CreatedAt:       run.CreatedAt,
+		StartedAt:       run.StartedAt,
+		FinishedAt:      run.FinishedAt,
+		Downloadable:    run.Status == enum.RunSucceeded && run.FileID != nil,
+		ManifestID:      run.ManifestUuid,
+	}
+}
+
+// GenerationDiffRequest renders a blueprint for an entity, for every entity of a project, or without an
+// entity, and compares it with the Baseline, or with the last output recorded for the same entities
+type GenerationDiffRequest struct {
+	BlueprintID     uuid.UUID
+	TemplateVersion int
+	EntityID        *uuid.UUID
+	ProjectID       *uuid.UUID
+	Inputs          map[string]string
+	Baseline        PreviousOutput
+}
+
+// File statuses of a generation diff
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+type GenerationDiff struct {
+	Baseline        string                `json:"baseline"` // upload or run
+	BaselineRunID   *uuid.UUID            `json:"baselineRunId,omitempty"`
+	TemplateVersion int                   `json:"templateVersion"`
+	Summary         GenerationDiffSummary `json:"summary"`
+	Files           []GenerationFileDiff  `json:"files"` // Changed files by path
+	Regions         []service.RegionIssue `json:"regions"`
+	Warnings        []string              `json:"warnings,omitempty"` // What the generation left out, e.g. relations to deleted entities
+}
+
+type GenerationDiffSummary struct {
+	Added      int `json:"added"`
+	Removed    int `json:"removed"`
+	Modified   int `json:"modified"`
+	Unchanged  int `json:"unchanged"`
+	Insertions int `json:"insertions"` // Lines
+	Deletions  int `json:"deletions"`  // Lines
+}
+
+type GenerationFileDiff struct {
+	Path       string `json:"path"`
+	Status     string `json:"status"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Diff       string `json:"diff"` // Unified diff
+}
+
+// File statuses of a manifest reproduction
+const (
+	FileIdentical = "identical"
+	FileChanged   = "changed"
+	FileMissing   = "missing" // Recorded but no longer generated
+	FileExtra     = "extra"   // Generated but not recorded
+)
+
+type ManifestReproduction struct {
+	ManifestID uuid.UUID        `json:"manifestId"`
+	Identical  bool             `json:"identical"` // Every file is byte-identical and no file is missing or extra
+	Files      []ReproducedFile `json:"files"`
+	Drift      []string         `json:"drift"` // Inputs that changed since the manifest was recorded
+}
+
+type ReproducedFile struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"` // Recorded SHA-256
+	Actual   string `json:"actual,omitempty"`   // Reproduced SHA-256
+}
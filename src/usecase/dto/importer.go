@@ -0,0 +1,45 @@
+package dto
+
+import "github.com/google/uuid"
+
+// SchemaImport asks to create a project from a schema, such as a database schema or an API description, or to merge the schema into one
+type SchemaImport struct {
+	Content     string
+	Files       []SchemaFile // Files of a schema that imports other files; used instead of Content when given
+	Format      string       // Registered schema format, e.g. postgres, mysql, openapi or proto
+	ProjectId   uuid.UUID    // Project to merge into; when nil the project is found or created by ProjectName
+	ProjectName string
+}
+
+type SchemaFile struct {
+	Name    string
+	Content string
+}
+
+// SchemaImportResult is the saved project with what the import changed and what it could not map
+type SchemaImportResult struct {
+	Project    Project
+	Created    []string // Entities added to the project
+	Updated    []string // Entities of the project the schema was merged into
+	Operations []string // Journey operations added to the project, as Entity.operation
+	Unmapped   []string // Parts of the schema the project cannot express
+}
+
+// SampleImport asks for the entities of a resource inferred from example documents of it
+type SampleImport struct {
+	Resource string   // Name of the resource the samples show, e.g. Order
+	Samples  []string // JSON documents
+}
+
+// EntityDraft is a set of entities proposed for a project, not saved until confirmed with an EntityImport
+type EntityDraft struct {
+	Entities []Entity
+	Unmapped []string // Parts of the samples the entities cannot express, or guesses worth checking
+}
+
+// EntityImport merges confirmed entities, such as a reviewed EntityDraft, into a project
+type EntityImport struct {
+	Entities    []Entity
+	ProjectId   uuid.UUID // Project to merge into; when nil the project is found or created by ProjectName
+	ProjectName string
+}
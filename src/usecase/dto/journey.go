@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"gen-concept-api/domain/model"
 	"gen-concept-api/enum" // Update this import path to the correct one
 
 	"github.com/google/uuid"
@@ -42,6 +43,7 @@ type JourneyStep struct {
 	Error           string                     `json:"error,omitempty"`
 	Curl            string                     `json:"curl,omitempty"`
 	SampleResponse  string                     `json:"sampleResponse,omitempty"`
+	HTTPCall        *HTTPCallSpec              `json:"httpCall,omitempty"` // Derived from Curl and SampleResponse on save
 	Retry           bool                       `json:"retry,omitempty"`
 	RetryCount      int                        `json:"retryCount,omitempty"`
 	RetryInterval   int                        `json:"retryInterval,omitempty"`
@@ -59,6 +61,9 @@ type JourneyStep struct {
 	Level    string        `json:"level,omitempty"`
 }
 
+// HTTPCallSpec is the parsed form of a step's curl command and sample response
+type HTTPCallSpec = model.HTTPCallSpec
+
 type FieldInvolved struct {
 	UUID   uuid.UUID `json:"uuid"`
 	ID     string    `json:"id"`
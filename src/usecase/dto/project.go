@@ -73,6 +73,13 @@ type EntityField struct {
 type InputValidation struct {
 	Description        string `json:"description"`
 	Uuid               uuid.UUID
-	AbortOnFailure     bool   `json:"abortOnFailure"`
-	CustomErrorMessage string `json:"customErrorMessage"`
+	AbortOnFailure     bool                    `json:"abortOnFailure"`
+	CustomErrorMessage string                  `json:"customErrorMessage"`
+	RuleType           enum.ValidationRuleType `json:"ruleType"`
+	Min                *float64                `json:"min"`
+	Max                *float64                `json:"max"`
+	Pattern            string                  `json:"pattern"`
+	AllowedValues      []string                `json:"allowedValues"`
+	Operator           enum.OperatorType       `json:"operator"`
+	OtherField         string                  `json:"otherField"`
 }
@@ -8,6 +8,7 @@ import (
 	"gen-concept-api/domain/filter"
 	model "gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase/dto"
 
 	"github.com/google/uuid"
@@ -40,6 +41,14 @@ func (u *EntityFieldUsecase) Create(ctx context.Context, req dto.EntityField) (d
 	// 3. Set EntityID
 	entityFieldModel.EntityID = entity.ID
 
+	// Derived fields are checked against the fields already on the entity
+	if entityFieldModel.IsDerived {
+		siblings := append(append([]model.EntityField(nil), entity.EntityFields...), entityFieldModel)
+		if _, err := service.CompileDerivedFields(entity.EntityName, siblings); err != nil {
+			return response, err
+		}
+	}
+
 	// 4. Save
 	createdField, err := u.base.repository.Create(ctx, entityFieldModel)
 	if err != nil {
@@ -3,10 +3,12 @@ package usecase
 import (
 	"context"
 
+	"gen-concept-api/common"
 	"gen-concept-api/config"
 	"gen-concept-api/domain/filter"
 	model "gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase/dto"
 
 	"github.com/google/uuid"
@@ -22,16 +24,32 @@ func NewEntityUsecase(cfg *config.Config, repository repository.EntityRepository
 	}
 }
 
-// Create
+// Create checks the derived fields before saving
 func (u *EntityUsecase) Create(ctx context.Context, req dto.Entity) (dto.Entity, error) {
+	if err := checkDerivedFields(req); err != nil {
+		return dto.Entity{}, err
+	}
 	return u.base.Create(ctx, req)
 }
 
-// Update
+// Update checks the derived fields before saving
 func (s *EntityUsecase) Update(ctx context.Context, uuid uuid.UUID, req dto.Entity) (dto.Entity, error) {
+	if err := checkDerivedFields(req); err != nil {
+		return dto.Entity{}, err
+	}
 	return s.base.Update(ctx, uuid, req)
 }
 
+// checkDerivedFields validates the derivative expressions of the entity against its fields
+func checkDerivedFields(req dto.Entity) error {
+	entity, err := common.TypeConverter[model.Entity](req)
+	if err != nil {
+		return err
+	}
+	_, err = service.CompileDerivedFields(entity.EntityName, entity.EntityFields)
+	return err
+}
+
 // Delete
 func (s *EntityUsecase) Delete(ctx context.Context, uuid uuid.UUID) error {
 	return s.base.Delete(ctx, uuid)
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"gen-concept-api/config"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/pkg/diff"
+	"gen-concept-api/pkg/service_errors"
+	"gen-concept-api/usecase/dto"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// diffContext is the number of unchanged lines around each change of a file diff
+const diffContext = 3
+
+// MaxBaselineBytes bounds the total content of an uploaded baseline
+const MaxBaselineBytes = 32 << 20
+
+// GenerationDiffUsecase shows what regenerating would change before anything is written
+type GenerationDiffUsecase struct {
+	blueprintRepo     repository.BlueprintRepository
+	entityRepo        repository.EntityRepository
+	projectRepo       repository.ProjectRepository
+	runRepo           repository.GenerationRunRepository
+	userRepo          repository.UserRepository
+	generationService *service.GenerationService
+}
+
+func NewGenerationDiffUsecase(cfg *config.Config, blueprintRepo repository.BlueprintRepository, entityRepo repository.EntityRepository,
+	projectRepo repository.ProjectRepository, runRepo repository.GenerationRunRepository, userRepo repository.UserRepository,
+	genService *service.GenerationService) *GenerationDiffUsecase {
+	return &GenerationDiffUsecase{
+		blueprintRepo:     blueprintRepo,
+		entityRepo:        entityRepo,
+		projectRepo:       projectRepo,
+		runRepo:           runRepo,
+		userRepo:          userRepo,
+		generationService: genService,
+	}
+}
+
+// Diff renders the blueprint and compares every file with the baseline. Protected regions of the baseline
+// are carried into the render first, so the diff shows what a regeneration would really change.
+func (u *GenerationDiffUsecase) Diff(ctx context.Context, req dto.GenerationDiffRequest) (dto.GenerationDiff, error) {
+	blueprint, err := u.blueprintRepo.GetByUuidAtTemplateVersion(ctx, req.BlueprintID, req.TemplateVersion)
+	if err != nil {
+		return dto.GenerationDiff{}, err
+	}
+	entities, err := u.entities(ctx, req)
+	if err != nil {
+		return dto.GenerationDiff{}, err
+	}
+
+	result := dto.GenerationDiff{Baseline: "upload", TemplateVersion: blueprint.TemplateVersion}
+	var baseline map[string]string
+	if len(req.Baseline.Files) > 0 || len(req.Baseline.Archive) > 0 {
+		if baseline, err = PreviousFiles(req.Baseline); err != nil {
+			return dto.GenerationDiff{}, err
+		}
+		size := 0
+		for _, content := range baseline {
+			size += len(content)
+		}
+		if size > MaxBaselineBytes {
+			return dto.GenerationDiff{}, &service_errors.ServiceError{EndUserMessage: service_errors.BaselineTooLarge,
+				TechnicalMessage: fmt.Sprintf("baseline holds %d bytes, at most %d are diffed", size, MaxBaselineBytes)}
+		}
+	} else {
+		var run model.GenerationRun
+		if run, baseline, err = u.lastOutput(ctx, req.BlueprintID, entities); err != nil {
+			return dto.GenerationDiff{}, err
+		}
+		result.Baseline = "run"
+		result.BaselineRunID = &run.Uuid
+	}
+
+	projects, err := projectsOf(ctx, u.projectRepo, entities)
+	if err != nil {
+		return dto.GenerationDiff{}, err
+	}
+	scope := service.NewGenerationScope()
+	files, err := renderEntities(ctx, u.generationService, scope, blueprint, entities, projects, req.Inputs, nil)
+	if err != nil {
+		return dto.GenerationDiff{}, err
+	}
+	result.Warnings = scope.Warnings()
+	files, result.Regions = service.PreserveRegions(files, baseline)
+	if result.Regions == nil {
+		result.Regions = []service.RegionIssue{}
+	}
+
+	result.Files = []dto.GenerationFileDiff{}
+	rendered := make(map[string]bool, len(files))
+	for _, f := range files {
+		rendered[f.Path] = true
+		old, existed := baseline[f.Path]
+		if existed && old == f.Content {
+			result.Summary.Unchanged++
+			continue
+		}
+		fileDiff := dto.GenerationFileDiff{Path: f.Path, Status: dto.FileModified}
+		from := "a/" + f.Path
+		if !existed {
+			fileDiff.Status = dto.FileAdded
+			from = "/dev/null"
+		}
+		fileDiff.Diff, fileDiff.Insertions, fileDiff.Deletions = diff.Unified(from, "b/"+f.Path, old, f.Content, diffContext)
+		result.Files = append(result.Files, fileDiff)
+	}
+	for p, old := range baseline {
+		if rendered[p] || p == RegionReportPath || p == WarningReportPath || p == service.ManifestPath {
+			continue
+		}
+		fileDiff := dto.GenerationFileDiff{Path: p, Status: dto.FileRemoved}
+		fileDiff.Diff, fileDiff.Insertions, fileDiff.Deletions = diff.Unified("a/"+p, "/dev/null", old, "", diffContext)
+		result.Files = append(result.Files, fileDiff)
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Path < result.Files[j].Path })
+
+	for _, f := range result.Files {
+		switch f.Status {
+		case dto.FileAdded:
+			result.Summary.Added++
+		case dto.FileRemoved:
+			result.Summary.Removed++
+		default:
+			result.Summary.Modified++
+		}
+		result.Summary.Insertions += f.Insertions
+		result.Summary.Deletions += f.Deletions
+	}
+	return result, nil
+}
+
+// entities loads the requested entity, or every entity of the requested project
+func (u *GenerationDiffUsecase) entities(ctx context.Context, req dto.GenerationDiffRequest) ([]model.Entity, error) {
+	if req.ProjectID != nil {
+		project, err := u.projectRepo.GetById(ctx, *req.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		return project.Entities, nil
+	}
+	if req.EntityID != nil {
+		entity, err := u.entityRepo.GetById(ctx, *req.EntityID)
+		if err != nil {
+			return nil, err
+		}
+		return []model.Entity{entity}, nil
+	}
+	return nil, nil
+}
+
+// lastOutput reads the archive of the newest succeeded run of the caller's organization for the same entities
+func (u *GenerationDiffUsecase) lastOutput(ctx context.Context, blueprintUuid uuid.UUID, entities []model.Entity) (model.GenerationRun, map[string]string, error) {
+	_, organizationId, err := caller(ctx, u.userRepo)
+	if err != nil {
+		return model.GenerationRun{}, nil, err
+	}
+	entityUuids := make([]uuid.UUID, len(entities))
+	for i, e := range entities {
+		entityUuids[i] = e.Uuid
+	}
+	run, err := u.runRepo.GetLatestSucceeded(ctx, organizationId, blueprintUuid, entityUuids)
+	if err != nil {
+		return model.GenerationRun{}, nil, err
+	}
+	if run.File == nil {
+		return model.GenerationRun{}, nil, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	data, err := os.ReadFile(filepath.Join(run.File.Directory, run.File.Name))
+	if err != nil {
+		return model.GenerationRun{}, nil, err
+	}
+	files, err := PreviousFiles(dto.PreviousOutput{Archive: data})
+	return run, files, err
+}
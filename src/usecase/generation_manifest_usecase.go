@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"gen-concept-api/config"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/pkg/archive"
+	"gen-concept-api/pkg/service_errors"
+	"gen-concept-api/usecase/dto"
+
+	"github.com/google/uuid"
+)
+
+// GenerationOutput is everything a multi-file generation produced
+type GenerationOutput struct {
+	Blueprint model.Blueprint
+	Files     []service.GeneratedFile
+	Regions   []service.RegionIssue
+	Warnings  []string // What the generation left out, e.g. relations to deleted entities
+	Manifest  model.GenerationManifest
+	document  []byte // gen-manifest.json
+}
+
+// Entries lists the generated files, the region and warning reports and the manifest as archive entries
+func (o GenerationOutput) Entries() []archive.Entry {
+	entries := make([]archive.Entry, 0, len(o.Files)+3)
+	for _, f := range o.Files {
+		entries = append(entries, archive.Entry{Path: f.Path, Content: []byte(f.Content)})
+	}
+	if report, ok := RegionReport(o.Regions); ok {
+		entries = append(entries, report)
+	}
+	if report, ok := WarningReport(o.Warnings); ok {
+		entries = append(entries, report)
+	}
+	if o.document != nil {
+		entries = append(entries, archive.Entry{Path: service.ManifestPath, Content: o.document})
+	}
+	return entries
+}
+
+// generationInput is what generateOutput renders
+type generationInput struct {
+	blueprint model.Blueprint
+	entities  []model.Entity
+	projects  []model.Project
+	inputs    map[string]string
+	previous  map[string]string      // Previous output to carry protected regions from
+	replay    []model.ManifestAICall // AI answers to reuse instead of asking the provider
+	progress  func(model.Entity, service.GeneratedFile)
+}
+
+// generateOutput renders the blueprint for the entities, carries over the protected regions and builds
+// the manifest of the generation. The manifest is not persisted.
+func generateOutput(ctx context.Context, genService *service.GenerationService, in generationInput) (GenerationOutput, error) {
+	ctx, recorder := service.WithAIRecorder(ctx, in.replay)
+	scope := service.NewGenerationScope()
+	files, err := renderEntities(ctx, genService, scope, in.blueprint, in.entities, in.projects, in.inputs, in.progress)
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	files, regions := service.PreserveRegions(files, in.previous)
+
+	manifest, err := service.BuildManifest(service.ManifestInput{
+		Blueprint: in.blueprint,
+		Entities:  in.entities,
+		Projects:  in.projects,
+		Journeys:  scope.Journeys(),
+		Inputs:    in.inputs,
+		AICalls:   recorder.Calls(),
+		Previous:  in.previous,
+		Files:     files,
+	})
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	document, digest, err := service.ManifestDocument(manifest)
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	return GenerationOutput{
+		Blueprint: in.blueprint,
+		Files:     files,
+		Regions:   regions,
+		Warnings:  scope.Warnings(),
+		Manifest: model.GenerationManifest{
+			BlueprintUuid:   manifest.Blueprint.Uuid,
+			TemplateVersion: manifest.Blueprint.TemplateVersion,
+			Digest:          digest,
+			Content:         manifest,
+		},
+		document: document,
+	}, nil
+}
+
+// projectsOf loads the distinct projects of the entities
+func projectsOf(ctx context.Context, projectRepo repository.ProjectRepository, entities []model.Entity) ([]model.Project, error) {
+	var projects []model.Project
+	seen := make(map[uuid.UUID]bool)
+	for _, e := range entities {
+		if e.ProjectUuid == uuid.Nil || seen[e.ProjectUuid] {
+			continue
+		}
+		seen[e.ProjectUuid] = true
+		project, err := projectRepo.GetById(ctx, e.ProjectUuid)
+		if err != nil {
+			return nil, fmt.Errorf("project of entity %s: %w", e.EntityName, err)
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// saveManifest persists the manifest of an output for the organization, and of the run if there is one
+func saveManifest(ctx context.Context, manifestRepo repository.GenerationManifestRepository, organizationId uint, runId *uint,
+	manifest *model.GenerationManifest) error {
+	manifest.OrganizationID = organizationId
+	manifest.GenerationRunID = runId
+	saved, err := manifestRepo.Create(ctx, *manifest)
+	if err != nil {
+		return err
+	}
+	*manifest = saved
+	return nil
+}
+
+// GenerationManifestUsecase serves persisted manifests and verifies that they reproduce their output
+type GenerationManifestUsecase struct {
+	manifestRepo      repository.GenerationManifestRepository
+	blueprintRepo     repository.BlueprintRepository
+	entityRepo        repository.EntityRepository
+	projectRepo       repository.ProjectRepository
+	userRepo          repository.UserRepository
+	generationService *service.GenerationService
+}
+
+func NewGenerationManifestUsecase(cfg *config.Config, manifestRepo repository.GenerationManifestRepository, blueprintRepo repository.BlueprintRepository,
+	entityRepo repository.EntityRepository, projectRepo repository.ProjectRepository, userRepo repository.UserRepository,
+	genService *service.GenerationService) *GenerationManifestUsecase {
+	return &GenerationManifestUsecase{
+		manifestRepo:      manifestRepo,
+		blueprintRepo:     blueprintRepo,
+		entityRepo:        entityRepo,
+		projectRepo:       projectRepo,
+		userRepo:          userRepo,
+		generationService: genService,
+	}
+}
+
+// GetById returns a manifest of the caller's organization
+func (u *GenerationManifestUsecase) GetById(ctx context.Context, id uuid.UUID) (model.GenerationManifest, error) {
+	_, organizationId, err := caller(ctx, u.userRepo)
+	if err != nil {
+		return model.GenerationManifest{}, err
+	}
+	manifest, err := u.manifestRepo.GetById(ctx, id)
+	if err != nil {
+		return model.GenerationManifest{}, err
+	}
+	if manifest.OrganizationID != organizationId {
+		return model.GenerationManifest{}, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	return manifest, nil
+}
+
+// Reproduce renders the manifest again, with its template version, inputs, AI answers and preserved regions,
+// from the current entities, and compares every file byte for byte. Drift lists what changed since the
+// manifest was recorded and explains differences.
+func (u *GenerationManifestUsecase) Reproduce(ctx context.Context, id uuid.UUID) (dto.ManifestReproduction, error) {
+	stored, err := u.GetById(ctx, id)
+	if err != nil {
+		return dto.ManifestReproduction{}, err
+	}
+	recorded := stored.Content
+
+	blueprint, err := u.blueprintRepo.GetByUuidAtTemplateVersion(ctx, stored.BlueprintUuid, stored.TemplateVersion)
+	if err != nil {
+		return dto.ManifestReproduction{}, err
+	}
+	var entities []model.Entity
+	for _, e := range recorded.Entities {
+		entity, err := u.entityRepo.GetById(ctx, e.Uuid)
+		if err != nil {
+			return dto.ManifestReproduction{}, fmt.Errorf("entity %s: %w", e.Name, err)
+		}
+		entities = append(entities, entity)
+	}
+	projects, err := projectsOf(ctx, u.projectRepo, entities)
+	if err != nil {
+		return dto.ManifestReproduction{}, err
+	}
+
+	output, err := generateOutput(ctx, u.generationService, generationInput{
+		blueprint: blueprint,
+		entities:  entities,
+		projects:  projects,
+		inputs:    recorded.Inputs,
+		previous:  service.RegionsPrevious(recorded.PreservedRegions),
+		replay:    recorded.AICalls,
+	})
+	if err != nil {
+		return dto.ManifestReproduction{}, err
+	}
+	return compareManifests(stored.Uuid, recorded, output.Manifest.Content), nil
+}
+
+// compareManifests compares the files of a reproduction with the recorded ones and lists the drift of its inputs
+func compareManifests(id uuid.UUID, recorded, reproduced model.Manifest) dto.ManifestReproduction {
+	result := dto.ManifestReproduction{ManifestID: id, Identical: true, Files: []dto.ReproducedFile{}, Drift: []string{}}
+
+	actual := make(map[string]model.ManifestFile, len(reproduced.Files))
+	for _, f := range reproduced.Files {
+		actual[f.Path] = f
+	}
+	for _, f := range recorded.Files {
+		file := dto.ReproducedFile{Path: f.Path, Status: dto.FileIdentical, Expected: f.SHA256}
+		if a, ok := actual[f.Path]; !ok {
+			file.Status = dto.FileMissing
+		} else if file.Actual = a.SHA256; a.SHA256 != f.SHA256 {
+			file.Status = dto.FileChanged
+		}
+		delete(actual, f.Path)
+		result.Files = append(result.Files, file)
+	}
+	for _, f := range reproduced.Files {
+		if _, extra := actual[f.Path]; extra {
+			result.Files = append(result.Files, dto.ReproducedFile{Path: f.Path, Status: dto.FileExtra, Actual: f.SHA256})
+		}
+	}
+	for _, f := range result.Files {
+		result.Identical = result.Identical && f.Status == dto.FileIdentical
+	}
+
+	if recorded.EngineVersion != reproduced.EngineVersion {
+		result.Drift = append(result.Drift, fmt.Sprintf("engine version %s is now %s", recorded.EngineVersion, reproduced.EngineVersion))
+	}
+	result.Drift = append(result.Drift, snapshotDrift("entity", recorded.Entities, reproduced.Entities)...)
+	result.Drift = append(result.Drift, snapshotDrift("project", recorded.Projects, reproduced.Projects)...)
+	result.Drift = append(result.Drift, snapshotDrift("journey", recorded.Journeys, reproduced.Journeys)...)
+	known := make(map[uuid.UUID]bool, len(recorded.Journeys))
+	for _, j := range recorded.Journeys {
+		known[j.Uuid] = true
+	}
+	for _, j := range reproduced.Journeys {
+		if !known[j.Uuid] {
+			result.Drift = append(result.Drift, fmt.Sprintf("journey %s was added since the manifest was recorded", j.Name))
+		}
+	}
+	versions := make(map[uuid.UUID]model.ManifestLibrary)
+	for _, l := range reproduced.Libraries {
+		versions[l.Uuid] = l
+	}
+	for _, l := range recorded.Libraries {
+		now, ok := versions[l.Uuid]
+		switch {
+		case !ok:
+			result.Drift = append(result.Drift, fmt.Sprintf("library %s is no longer used", l.Name))
+		case now.Version != l.Version || now.Tag != l.Tag || now.CommitHash != l.CommitHash:
+			result.Drift = append(result.Drift, fmt.Sprintf("library %s %s is now %s", l.Name, l.Version, now.Version))
+		}
+	}
+	return result
+}
+
+func snapshotDrift(kind string, recorded, current []model.ManifestSnapshot) []string {
+	hashes := make(map[uuid.UUID]string, len(current))
+	for _, s := range current {
+		hashes[s.Uuid] = s.Hash
+	}
+	var drift []string
+	for _, s := range recorded {
+		if hash, ok := hashes[s.Uuid]; !ok {
+			drift = append(drift, fmt.Sprintf("%s %s is missing", kind, s.Name))
+		} else if hash != s.Hash {
+			drift = append(drift, fmt.Sprintf("%s %s changed since the manifest was recorded", kind, s.Name))
+		}
+	}
+	return drift
+}
@@ -0,0 +1,447 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"gen-concept-api/config"
+	"gen-concept-api/constant"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/enum"
+	"gen-concept-api/pkg/archive"
+	"gen-concept-api/pkg/jobs"
+	"gen-concept-api/pkg/service_errors"
+	"gen-concept-api/usecase/dto"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// interruptedRunMessage is the error of runs that were still queued or running when the server stopped
+const interruptedRunMessage = "generation run was interrupted by a server restart"
+
+// shutdownRunMessage is the error of runs that were still queued or running when the server shut down
+const shutdownRunMessage = "generation run was interrupted by a server shutdown"
+
+// maxRunErrorLength is the size of GenerationRun.Error
+const maxRunErrorLength = 2000
+
+// GenerationRunUsecase executes generations in the background on a worker pool, limiting how many runs
+// of one organization execute at once. The archive of a successful run is stored as a File.
+// Runs are owned by the instance whose pool executes them, so instances sharing a database leave each other's runs alone.
+type GenerationRunUsecase struct {
+	outputDirectory   string
+	instance          string
+	runRepo           repository.GenerationRunRepository
+	blueprintRepo     repository.BlueprintRepository
+	entityRepo        repository.EntityRepository
+	projectRepo       repository.ProjectRepository
+	fileRepo          repository.FileRepository
+	manifestRepo      repository.GenerationManifestRepository
+	userRepo          repository.UserRepository
+	generationService *service.GenerationService
+	pool              *jobs.Pool
+	closing           atomic.Bool
+}
+
+func NewGenerationRunUsecase(cfg *config.Config, runRepo repository.GenerationRunRepository, blueprintRepo repository.BlueprintRepository,
+	entityRepo repository.EntityRepository, projectRepo repository.ProjectRepository, fileRepo repository.FileRepository,
+	manifestRepo repository.GenerationManifestRepository, userRepo repository.UserRepository,
+	genService *service.GenerationService, pool *jobs.Pool) *GenerationRunUsecase {
+	outputDirectory := cfg.Generation.OutputDirectory
+	if outputDirectory == "" {
+		outputDirectory = "generated"
+	}
+	instance := cfg.Generation.InstanceID
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	u := &GenerationRunUsecase{
+		outputDirectory:   outputDirectory,
+		instance:          instance,
+		runRepo:           runRepo,
+		blueprintRepo:     blueprintRepo,
+		entityRepo:        entityRepo,
+		projectRepo:       projectRepo,
+		fileRepo:          fileRepo,
+		manifestRepo:      manifestRepo,
+		userRepo:          userRepo,
+		generationService: genService,
+		pool:              pool,
+	}
+	pool.OnPanic(u.failPanicked)
+	return u
+}
+
+// failPanicked marks the run of a job that panicked as failed
+func (u *GenerationRunUsecase) failPanicked(id string, recovered any) {
+	runId, err := uuid.Parse(id)
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	run, err := u.runRepo.GetById(ctx, runId)
+	if err != nil {
+		return
+	}
+	u.finish(ctx, &run, enum.RunFailed, fmt.Sprintf("generation run panicked: %v", recovered))
+}
+
+// FailInterrupted marks the runs a previous process of this instance left queued or running as failed; their
+// jobs are gone. Runs of other instances are left alone.
+func (u *GenerationRunUsecase) FailInterrupted(ctx context.Context) error {
+	_, err := u.runRepo.FailUnfinished(ctx, u.instance, interruptedRunMessage)
+	return err
+}
+
+// Close stops the worker pool when the server shuts down. Queued runs are dropped and running ones stopped;
+// both are marked failed.
+func (u *GenerationRunUsecase) Close(ctx context.Context) error {
+	u.closing.Store(true)
+	u.pool.Close()
+	_, err := u.runRepo.FailUnfinished(ctx, u.instance, shutdownRunMessage)
+	return err
+}
+
+// Submit records a queued run and hands it to the worker pool
+func (u *GenerationRunUsecase) Submit(ctx context.Context, req dto.GenerationRunRequest) (dto.GenerationRun, error) {
+	format, err := archive.ParseFormat(req.Format)
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+	previous, err := PreviousFiles(req.Previous)
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+	userId, organizationId, err := caller(ctx, u.userRepo)
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+	blueprint, err := u.blueprintRepo.GetByUuidAtTemplateVersion(ctx, req.BlueprintID, req.TemplateVersion)
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+	if err := requireEntities(blueprint, len(req.EntityIDs)); err != nil {
+		return dto.GenerationRun{}, err
+	}
+
+	run, err := u.runRepo.Create(ctx, model.GenerationRun{
+		OrganizationID:  organizationId,
+		BlueprintUuid:   req.BlueprintID,
+		TemplateVersion: req.TemplateVersion,
+		EntityUuids:     req.EntityIDs,
+		Inputs:          req.Inputs,
+		Format:          string(format),
+		Status:          enum.RunQueued,
+		Instance:        u.instance,
+	})
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+
+	// The run outlives the request, so its context only carries the caller for the audit columns.
+	// The previous output is only kept in memory for the job.
+	err = u.pool.Submit(run.Uuid.String(), fmt.Sprint(organizationId), func(jobCtx context.Context) {
+		u.execute(context.WithValue(jobCtx, constant.UserIdKey, float64(userId)), run, previous)
+	})
+	if err != nil {
+		u.finish(ctx, &run, enum.RunFailed, err.Error())
+		return dto.GenerationRun{}, err
+	}
+	return dto.FromGenerationRunModel(run), nil
+}
+
+// GetById returns a run of the caller's organization
+func (u *GenerationRunUsecase) GetById(ctx context.Context, id uuid.UUID) (dto.GenerationRun, error) {
+	run, err := u.load(ctx, id)
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+	return dto.FromGenerationRunModel(run), nil
+}
+
+// Cancel drops a queued run or stops a running one. A running run turns cancelled once its worker notices.
+func (u *GenerationRunUsecase) Cancel(ctx context.Context, id uuid.UUID) (dto.GenerationRun, error) {
+	run, err := u.load(ctx, id)
+	if err != nil {
+		return dto.GenerationRun{}, err
+	}
+	if run.Status.IsFinal() {
+		return dto.GenerationRun{}, &service_errors.ServiceError{EndUserMessage: service_errors.GenerationRunFinished}
+	}
+	wasQueued, found := u.pool.Cancel(run.Uuid.String())
+	if !found {
+		// The worker may have just finished; otherwise the job was lost
+		if run, err = u.runRepo.GetById(ctx, id); err != nil {
+			return dto.GenerationRun{}, err
+		}
+		if run.Status.IsFinal() {
+			return dto.FromGenerationRunModel(run), nil
+		}
+	}
+	if wasQueued || !found {
+		u.finish(ctx, &run, enum.RunCancelled, "")
+	}
+	return dto.FromGenerationRunModel(run), nil
+}
+
+// Download returns the stored archive of a succeeded run
+func (u *GenerationRunUsecase) Download(ctx context.Context, id uuid.UUID) (model.File, error) {
+	run, err := u.load(ctx, id)
+	if err != nil {
+		return model.File{}, err
+	}
+	if run.Status != enum.RunSucceeded || run.File == nil {
+		return model.File{}, &service_errors.ServiceError{EndUserMessage: service_errors.GenerationRunNotReady}
+	}
+	return *run.File, nil
+}
+
+// load fetches a run, hiding the runs of other organizations
+func (u *GenerationRunUsecase) load(ctx context.Context, id uuid.UUID) (model.GenerationRun, error) {
+	_, organizationId, err := caller(ctx, u.userRepo)
+	if err != nil {
+		return model.GenerationRun{}, err
+	}
+	run, err := u.runRepo.GetById(ctx, id)
+	if err != nil {
+		return model.GenerationRun{}, err
+	}
+	if run.OrganizationID != organizationId {
+		return model.GenerationRun{}, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	return run, nil
+}
+
+// caller resolves the authenticated user and their organization
+func caller(ctx context.Context, userRepo repository.UserRepository) (uint, uint, error) {
+	value, ok := ctx.Value(constant.UserIdKey).(float64)
+	if !ok {
+		return 0, 0, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	userId := uint(value)
+	organizationId, err := userRepo.GetOrganizationID(ctx, userId)
+	if err != nil {
+		return 0, 0, err
+	}
+	return userId, organizationId, nil
+}
+
+// execute renders every requested entity, stores the archive and records the outcome of the run
+func (u *GenerationRunUsecase) execute(ctx context.Context, run model.GenerationRun, previous map[string]string) {
+	now := time.Now().UTC()
+	run.Status = enum.RunRunning
+	run.StartedAt = &now
+	_ = u.runRepo.SaveProgress(ctx, &run)
+
+	file, err := u.generate(ctx, &run, previous)
+	switch {
+	case err == nil:
+		run.FileID = &file.ID
+		run.File = &file
+		u.finish(ctx, &run, enum.RunSucceeded, "")
+	case ctx.Err() != nil && u.closing.Load():
+		u.finish(context.WithoutCancel(ctx), &run, enum.RunFailed, shutdownRunMessage)
+	case ctx.Err() != nil:
+		u.finish(context.WithoutCancel(ctx), &run, enum.RunCancelled, "")
+	default:
+		u.finish(ctx, &run, enum.RunFailed, err.Error())
+	}
+}
+
+func (u *GenerationRunUsecase) generate(ctx context.Context, run *model.GenerationRun, previous map[string]string) (model.File, error) {
+	blueprint, err := u.blueprintRepo.GetByUuidAtTemplateVersion(ctx, run.BlueprintUuid, run.TemplateVersion)
+	if err != nil {
+		return model.File{}, err
+	}
+
+	var entities []model.Entity
+	for _, id := range run.EntityUuids {
+		entity, err := u.entityRepo.GetById(ctx, id)
+		if err != nil {
+			return model.File{}, fmt.Errorf("entity %s: %w", id, err)
+		}
+		entities = append(entities, entity)
+	}
+	projects, err := projectsOf(ctx, u.projectRepo, entities)
+	if err != nil {
+		return model.File{}, err
+	}
+	run.TotalFiles = max(len(entities), 1) * len(service.EntityFilePaths(blueprint))
+	if projectFiles := len(service.ProjectFilePaths(blueprint)); projectFiles > 0 {
+		run.TotalFiles += max(len(projects), 1) * projectFiles
+	}
+	_ = u.runRepo.SaveProgress(ctx, run)
+	output, err := generateOutput(ctx, u.generationService, generationInput{
+		blueprint: blueprint,
+		entities:  entities,
+		projects:  projects,
+		inputs:    run.Inputs,
+		previous:  previous,
+		progress: func(entity model.Entity, f service.GeneratedFile) {
+			run.CompletedFiles++
+			run.Files = append(run.Files, model.GenerationRunFile{
+				Path: f.Path, Entity: entity.EntityName, Size: len(f.Content), Diagnostics: len(f.Diagnostics),
+			})
+			_ = u.runRepo.SaveProgress(ctx, run)
+		},
+	})
+	if err != nil {
+		return model.File{}, err
+	}
+	run.RegionIssues = len(output.Regions)
+
+	file, err := u.store(ctx, run, blueprint, output.Entries())
+	if err != nil {
+		return model.File{}, err
+	}
+	if err := saveManifest(ctx, u.manifestRepo, run.OrganizationID, &run.ID, &output.Manifest); err != nil {
+		return model.File{}, err
+	}
+	run.ManifestUuid = &output.Manifest.Uuid
+	return file, nil
+}
+
+// store writes the archive into the output directory and records it as a File.
+// The archive is removed again when it cannot be written or recorded.
+func (u *GenerationRunUsecase) store(ctx context.Context, run *model.GenerationRun, blueprint model.Blueprint, entries []archive.Entry) (model.File, error) {
+	format := archive.Format(run.Format)
+	if err := os.MkdirAll(u.outputDirectory, 0755); err != nil {
+		return model.File{}, err
+	}
+	name := fmt.Sprintf("%s.%s", run.Uuid, format.Extension())
+	archivePath := filepath.Join(u.outputDirectory, name)
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return model.File{}, err
+	}
+	err = archive.Write(out, format, entries)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		return model.File{}, err
+	}
+
+	file, err := u.fileRepo.Create(ctx, model.File{
+		Name:        name,
+		Directory:   u.outputDirectory,
+		Description: fmt.Sprintf("Generated %s v%d", blueprint.StandardName, blueprint.TemplateVersion),
+		MimeType:    format.ContentType(),
+	})
+	if err != nil {
+		os.Remove(archivePath)
+		return model.File{}, err
+	}
+	return file, nil
+}
+
+func (u *GenerationRunUsecase) finish(ctx context.Context, run *model.GenerationRun, status enum.GenerationRunStatus, message string) {
+	now := time.Now().UTC()
+	if len(message) > maxRunErrorLength {
+		message = message[:maxRunErrorLength]
+	}
+	run.Status = status
+	run.Error = message
+	run.FinishedAt = &now
+	_ = u.runRepo.SaveProgress(ctx, run)
+}
+
+// renderEntities renders the blueprint's per-entity files once per entity, with the entity's uuid as the
+// entity_id input, then its per-project files once per project of the entities, or once for an empty project.
+// Without entities only the per-project files are rendered, see requireEntities. Two entities or projects
+// rendering the same path is an error.
+// The passes share scope, which keeps the journeys they loaded.
+func renderEntities(ctx context.Context, genService *service.GenerationService, scope *service.GenerationScope, blueprint model.Blueprint, entities []model.Entity,
+	projects []model.Project, inputs map[string]string, progress func(entity model.Entity, file service.GeneratedFile)) ([]service.GeneratedFile, error) {
+	if err := requireEntities(blueprint, len(entities)); err != nil {
+		return nil, err
+	}
+	passes := withProjects(entities, projects)
+
+	var files []service.GeneratedFile
+	owners := make(map[string]string)
+	collect := func(owner string, rendered []service.GeneratedFile) error {
+		for _, f := range rendered {
+			if previous, ok := owners[f.Path]; ok {
+				return fmt.Errorf("%s and %s both generate %s", previous, owner, f.Path)
+			}
+			owners[f.Path] = owner
+		}
+		files = append(files, rendered...)
+		return nil
+	}
+
+	for _, entity := range passes {
+		entityInputs := make(map[string]string, len(inputs)+1)
+		for k, v := range inputs {
+			entityInputs[k] = v
+		}
+		entityInputs["entity_id"] = entity.Uuid.String()
+
+		rendered, err := genService.GenerateFilesWithProgress(ctx, scope, blueprint, entity, entityInputs, func(f service.GeneratedFile) {
+			if progress != nil {
+				progress(entity, f)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("entity %s: %w", entity.EntityName, err)
+		}
+		if err := collect("entity "+entity.EntityName, rendered); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(service.ProjectFilePaths(blueprint)) == 0 {
+		return files, nil
+	}
+	projectPasses := projects
+	if len(projectPasses) == 0 {
+		projectPasses = []model.Project{{}}
+	}
+	for _, project := range projectPasses {
+		rendered, err := genService.GenerateProjectFiles(ctx, scope, blueprint, project, inputs, func(f service.GeneratedFile) {
+			if progress != nil {
+				progress(model.Entity{}, f)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", project.ProjectName, err)
+		}
+		if err := collect("project "+project.ProjectName, rendered); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// withProjects returns copies of the entities carrying their loaded project, so relations resolve against its entities
+// requireEntities rejects rendering the per-entity files of a blueprint for no entity. Blueprints
+// with per-project files only can be rendered without one.
+func requireEntities(blueprint model.Blueprint, entityCount int) error {
+	if entityCount > 0 || len(service.EntityFilePaths(blueprint)) == 0 {
+		return nil
+	}
+	return &service_errors.ServiceError{EndUserMessage: service_errors.EntityRequired,
+		TechnicalMessage: fmt.Sprintf("blueprint %s renders files per entity and no entity was given", blueprint.StandardName)}
+}
+
+func withProjects(entities []model.Entity, projects []model.Project) []model.Entity {
+	byUuid := make(map[uuid.UUID]model.Project, len(projects))
+	for _, p := range projects {
+		byUuid[p.Uuid] = p
+	}
+	attached := make([]model.Entity, len(entities))
+	for i, e := range entities {
+		if project, ok := byUuid[e.ProjectUuid]; ok {
+			e.Project = project
+		}
+		attached[i] = e
+	}
+	return attached
+}
@@ -2,10 +2,14 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"gen-concept-api/config"
 	"gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
 	"gen-concept-api/domain/service"
+	"gen-concept-api/pkg/archive"
+	"gen-concept-api/pkg/service_errors"
+	"gen-concept-api/usecase/dto"
 
 	"github.com/google/uuid"
 )
@@ -13,27 +17,154 @@ import (
 type GenerationUsecase struct {
 	blueprintRepo     repository.BlueprintRepository
 	entityRepo        repository.EntityRepository
+	projectRepo       repository.ProjectRepository
+	manifestRepo      repository.GenerationManifestRepository
+	userRepo          repository.UserRepository
 	generationService *service.GenerationService
 }
 
-func NewGenerationUsecase(cfg *config.Config, blueprintRepo repository.BlueprintRepository, entityRepo repository.EntityRepository, genService *service.GenerationService) *GenerationUsecase {
+func NewGenerationUsecase(cfg *config.Config, blueprintRepo repository.BlueprintRepository, entityRepo repository.EntityRepository,
+	projectRepo repository.ProjectRepository, manifestRepo repository.GenerationManifestRepository, userRepo repository.UserRepository,
+	genService *service.GenerationService) *GenerationUsecase {
 	return &GenerationUsecase{
 		blueprintRepo:     blueprintRepo,
 		entityRepo:        entityRepo,
+		projectRepo:       projectRepo,
+		manifestRepo:      manifestRepo,
+		userRepo:          userRepo,
 		generationService: genService,
 	}
 }
 
-func (u *GenerationUsecase) Generate(ctx context.Context, blueprintID uuid.UUID, inputs map[string]string) (string, error) {
-	// 1. Fetch Blueprint
-	blueprint, err := u.blueprintRepo.GetByUuidWithRelationships(ctx, blueprintID)
+// Generate renders the main template of the requested blueprint version, formatted with its diagnostics.
+// The returned blueprint carries the template version that was rendered.
+// Protected regions are carried over from the previous version of the same file only.
+func (u *GenerationUsecase) Generate(ctx context.Context, req dto.GenerationRequest) (model.Blueprint, service.GeneratedFile, []service.RegionIssue, error) {
+	previous, err := PreviousFiles(req.Previous)
 	if err != nil {
-		return "", err
+		return model.Blueprint{}, service.GeneratedFile{}, nil, err
+	}
+	blueprint, entity, err := u.load(ctx, req)
+	if err != nil {
+		return model.Blueprint{}, service.GeneratedFile{}, nil, err
+	}
+	if entity.Uuid == uuid.Nil { // The main template is always rendered for an entity
+		return model.Blueprint{}, service.GeneratedFile{}, nil, &service_errors.ServiceError{EndUserMessage: service_errors.EntityRequired,
+			TechnicalMessage: "preview needs the entity_id input"}
+	}
+
+	projects, err := projectsOf(ctx, u.projectRepo, []model.Entity{entity})
+	if err != nil {
+		return model.Blueprint{}, service.GeneratedFile{}, nil, err
+	}
+
+	// 3. Generate
+	file, err := u.generationService.PreviewCode(ctx, blueprint, withProjects([]model.Entity{entity}, projects)[0], req.Inputs)
+	if err != nil {
+		return model.Blueprint{}, service.GeneratedFile{}, nil, err
+	}
+	same := make(map[string]string)
+	if content, ok := previous[file.Path]; ok {
+		same[file.Path] = content
+	}
+	files, issues := service.PreserveRegions([]service.GeneratedFile{file}, same)
+	return blueprint, files[0], issues, nil
+}
+
+// GenerateFiles renders every file the blueprint declares for the requested entity,
+// with the protected regions of the previous output. The manifest of the output is persisted.
+func (u *GenerationUsecase) GenerateFiles(ctx context.Context, req dto.GenerationRequest) (GenerationOutput, error) {
+	previous, err := PreviousFiles(req.Previous)
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	_, organizationId, err := caller(ctx, u.userRepo)
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	blueprint, entity, err := u.load(ctx, req)
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+
+	var entities []model.Entity
+	if entity.Uuid != uuid.Nil {
+		entities = []model.Entity{entity}
+	}
+	projects, err := projectsOf(ctx, u.projectRepo, entities)
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	output, err := generateOutput(ctx, u.generationService, generationInput{
+		blueprint: blueprint,
+		entities:  entities,
+		projects:  projects,
+		inputs:    req.Inputs,
+		previous:  previous,
+	})
+	if err != nil {
+		return GenerationOutput{}, err
+	}
+	if err := saveManifest(ctx, u.manifestRepo, organizationId, nil, &output.Manifest); err != nil {
+		return GenerationOutput{}, err
+	}
+	return output, nil
+}
+
+// RegionReportPath is the archive entry listing the protected regions that were not carried over
+const RegionReportPath = ".gen/regions.json"
+
+// WarningReportPath is the archive entry listing what the generation left out
+const WarningReportPath = ".gen/warnings.json"
+
+// PreviousFiles maps the paths of a previous output to their content
+func PreviousFiles(previous dto.PreviousOutput) (map[string]string, error) {
+	files := make(map[string]string)
+	if len(previous.Archive) > 0 {
+		entries, err := archive.Read(previous.Archive)
+		if err != nil {
+			return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidPreviousOutput, TechnicalMessage: err.Error(), Err: err}
+		}
+		for _, e := range entries {
+			if e.Path != RegionReportPath && e.Path != WarningReportPath && e.Path != service.ManifestPath {
+				files[e.Path] = string(e.Content)
+			}
+		}
+	}
+	for p, content := range previous.Files {
+		files[p] = content
+	}
+	return files, nil
+}
+
+// RegionReport is the archive entry reporting region issues, if there are any
+func RegionReport(issues []service.RegionIssue) (archive.Entry, bool) {
+	if len(issues) == 0 {
+		return archive.Entry{}, false
+	}
+	content, _ := json.MarshalIndent(issues, "", "  ")
+	return archive.Entry{Path: RegionReportPath, Content: append(content, '\n')}, true
+}
+
+// WarningReport is the archive entry reporting the generation's warnings, if there are any
+func WarningReport(warnings []string) (archive.Entry, bool) {
+	if len(warnings) == 0 {
+		return archive.Entry{}, false
+	}
+	content, _ := json.MarshalIndent(warnings, "", "  ")
+	return archive.Entry{Path: WarningReportPath, Content: append(content, '\n')}, true
+}
+
+func (u *GenerationUsecase) load(ctx context.Context, req dto.GenerationRequest) (model.Blueprint, model.Entity, error) {
+	// 1. Fetch Blueprint with the template files of the requested version
+	blueprint, err := u.blueprintRepo.GetByUuidAtTemplateVersion(ctx, req.BlueprintID, req.TemplateVersion)
+	if err != nil {
+		return model.Blueprint{}, model.Entity{}, err
 	}
 
 	// 2. Fetch Entity (if provided)
 	var entity model.Entity
-	if entityIDStr, ok := inputs["entity_id"]; ok { // Standardize key
+	if entityIDStr, ok := req.Inputs["entity_id"]; ok { // Standardize key
 		entityID, err := uuid.Parse(entityIDStr)
 		if err == nil {
 			// Fetched Entity needs fields loaded? Base GetById might not load relations?
@@ -50,6 +181,5 @@ func (u *GenerationUsecase) Generate(ctx context.Context, blueprintID uuid.UUID,
 		}
 	}
 
-	// 3. Generate
-	return u.generationService.GenerateCode(ctx, blueprint, entity, inputs)
+	return blueprint, entity, nil
 }
@@ -8,26 +8,52 @@ import (
 	"gen-concept-api/domain/filter"
 	model "gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase/dto"
 
 	"github.com/google/uuid"
 )
 
 type JourneyUsecase struct {
-	base *BaseUsecase[model.Journey, dto.Journey, dto.Journey, dto.Journey]
-	repo repository.JourneyRepository
+	base        *BaseUsecase[model.Journey, dto.Journey, dto.Journey, dto.Journey]
+	repo        repository.JourneyRepository
+	projectRepo repository.ProjectRepository
 }
 
-func NewJourneyUsecase(cfg *config.Config, repository repository.JourneyRepository) *JourneyUsecase {
+func NewJourneyUsecase(cfg *config.Config, repository repository.JourneyRepository, projectRepo repository.ProjectRepository) *JourneyUsecase {
 	return &JourneyUsecase{
-		base: NewBaseUsecase[model.Journey, dto.Journey, dto.Journey, dto.Journey](cfg, repository),
-		repo: repository,
+		base:        NewBaseUsecase[model.Journey, dto.Journey, dto.Journey, dto.Journey](cfg, repository),
+		repo:        repository,
+		projectRepo: projectRepo,
 	}
 }
 
-// Create
+// prepare checks the journey steps against the entities of its project and stores their parsed forms
+func (u *JourneyUsecase) prepare(ctx context.Context, journey *model.Journey) error {
+	project, err := u.projectRepo.GetById(ctx, journey.ProjectUUID)
+	if err != nil {
+		return err
+	}
+	return service.PrepareJourney(journey, project.Entities)
+}
+
+// Create checks the journey steps and stores their parsed forms before saving
 func (u *JourneyUsecase) Create(ctx context.Context, req dto.Journey) (dto.Journey, error) {
-	return u.base.Create(ctx, req)
+	journey, err := common.TypeConverter[model.Journey](req)
+	if err != nil {
+		return dto.Journey{}, err
+	}
+	if err := u.prepare(ctx, &journey); err != nil {
+		return dto.Journey{}, err
+	}
+
+	created, err := u.repo.Create(ctx, journey)
+	if err != nil {
+		return dto.Journey{}, err
+	}
+
+	response, _ := common.TypeConverter[dto.Journey](created)
+	return response, nil
 }
 
 // Update
@@ -46,6 +72,11 @@ func (s *JourneyUsecase) Update(ctx context.Context, uuid uuid.UUID, req dto.Jou
 	}
 	journey = j
 
+	// Check the steps and store their parsed forms
+	if err := s.prepare(ctx, &journey); err != nil {
+		return dto.Journey{}, err
+	}
+
 	// Fetch existing journey to get the ID (primary key)
 	existingJourney, err := s.repo.GetById(ctx, uuid)
 	if err != nil {
@@ -3,10 +3,12 @@ package usecase
 import (
 	"context"
 
+	"gen-concept-api/common"
 	"gen-concept-api/config"
 	"gen-concept-api/domain/filter"
 	model "gen-concept-api/domain/model"
 	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
 	"gen-concept-api/usecase/dto"
 
 	"github.com/google/uuid"
@@ -22,16 +24,44 @@ func NewProjectUsecase(cfg *config.Config, repository repository.ProjectReposito
 	}
 }
 
-// Create
+// Create checks the derived fields of every entity before saving
 func (u *ProjectUsecase) Create(ctx context.Context, req dto.Project) (dto.Project, error) {
+	if err := checkProjectDerivedFields(req); err != nil {
+		return dto.Project{}, err
+	}
 	return u.base.Create(ctx, req)
 }
 
-// Update
+// Update checks the derived fields of every entity before saving
 func (s *ProjectUsecase) Update(ctx context.Context, uuid uuid.UUID, req dto.Project) (dto.Project, error) {
+	if err := checkProjectDerivedFields(req); err != nil {
+		return dto.Project{}, err
+	}
 	return s.base.Update(ctx, uuid, req)
 }
 
+// checkProjectDerivedFields validates the derivative expressions of all entities, reporting every issue at once
+func checkProjectDerivedFields(req dto.Project) error {
+	project, err := common.TypeConverter[model.Project](req)
+	if err != nil {
+		return err
+	}
+	var issues []service.DerivedFieldIssue
+	for _, entity := range project.Entities {
+		if _, err := service.CompileDerivedFields(entity.EntityName, entity.EntityFields); err != nil {
+			derivedErr, ok := err.(*service.DerivedFieldError)
+			if !ok {
+				return err
+			}
+			issues = append(issues, derivedErr.Issues...)
+		}
+	}
+	if len(issues) > 0 {
+		return &service.DerivedFieldError{Issues: issues}
+	}
+	return nil
+}
+
 // Delete
 func (s *ProjectUsecase) Delete(ctx context.Context, uuid uuid.UUID) error {
 	return s.base.Delete(ctx, uuid)
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"gen-concept-api/common"
+	"gen-concept-api/config"
+	"gen-concept-api/domain/model"
+	"gen-concept-api/domain/repository"
+	"gen-concept-api/domain/service"
+	"gen-concept-api/usecase/dto"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SchemaImportUsecase creates projects from database schemas and API descriptions, and merges them into existing projects
+type SchemaImportUsecase struct {
+	projectRepo repository.ProjectRepository
+	journeyRepo repository.JourneyRepository
+	importer    *service.ImporterService
+}
+
+func NewSchemaImportUsecase(cfg *config.Config, projectRepo repository.ProjectRepository, journeyRepo repository.JourneyRepository, importer *service.ImporterService) *SchemaImportUsecase {
+	return &SchemaImportUsecase{
+		projectRepo: projectRepo,
+		journeyRepo: journeyRepo,
+		importer:    importer,
+	}
+}
+
+// ImportProject parses the schema and saves its entities into the project given by id, or else the project
+// of that name, creating it when there is none. Existing entities are merged, never replaced. Operations of the
+// schema, such as the paths of an OpenAPI document, are merged into the project's journey the same way.
+func (u *SchemaImportUsecase) ImportProject(ctx context.Context, req dto.SchemaImport) (dto.SchemaImportResult, error) {
+	schema, err := u.parse(req)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+
+	project, err := u.project(ctx, req.ProjectId, req.ProjectName)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+	result, err := u.merge(ctx, project, schema.Entities, schema.Journeys)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+	result.Unmapped = append(schema.Unmapped, result.Unmapped...)
+	return result, nil
+}
+
+// DraftFromSamples infers the entities of a resource from example documents. Nothing is saved: the caller
+// reviews the draft and confirms it with ImportEntities.
+func (u *SchemaImportUsecase) DraftFromSamples(req dto.SampleImport) (dto.EntityDraft, error) {
+	schema, err := u.importer.InferSchema(req.Resource, req.Samples)
+	if err != nil {
+		return dto.EntityDraft{}, err
+	}
+	entities, err := common.TypeConverter[[]dto.Entity](schema.Entities)
+	if err != nil {
+		return dto.EntityDraft{}, err
+	}
+	return dto.EntityDraft{Entities: entities, Unmapped: schema.Unmapped}, nil
+}
+
+// ImportEntities merges confirmed entities into the project given by id, or else the project of that name,
+// creating it when there is none
+func (u *SchemaImportUsecase) ImportEntities(ctx context.Context, req dto.EntityImport) (dto.SchemaImportResult, error) {
+	entities, err := common.TypeConverter[[]model.Entity](req.Entities)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+	project, err := u.project(ctx, req.ProjectId, req.ProjectName)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+	return u.merge(ctx, project, entities, nil)
+}
+
+// merge merges entities into the project, and the imported entity journeys into its journey, and saves both together
+func (u *SchemaImportUsecase) merge(ctx context.Context, project model.Project, entities []model.Entity, imported []model.EntityJourney) (dto.SchemaImportResult, error) {
+	merge := service.MergeEntities(project.Entities, entities)
+	project.Entities = merge.Entities
+
+	var journey *model.Journey
+	var added []string
+	if len(imported) > 0 {
+		// The journey refers to the entities by uuid, so new ones get theirs before anything is saved
+		if project.Uuid == uuid.Nil {
+			project.Uuid = uuid.New()
+		}
+		for i := range project.Entities {
+			if project.Entities[i].Uuid == uuid.Nil {
+				project.Entities[i].Uuid = uuid.New()
+			}
+		}
+		var err error
+		if journey, added, err = u.mergeJourneys(ctx, project, imported); err != nil {
+			return dto.SchemaImportResult{}, err
+		}
+		// Checked and prepared like a journey saved on its own, a journey failing that rejects the import
+		if err := service.PrepareJourney(journey, project.Entities); err != nil {
+			return dto.SchemaImportResult{}, err
+		}
+	}
+
+	saved, err := u.projectRepo.SaveProject(ctx, &project, journey)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+	response, err := common.TypeConverter[dto.Project](*saved)
+	if err != nil {
+		return dto.SchemaImportResult{}, err
+	}
+	return dto.SchemaImportResult{
+		Project:    response,
+		Created:    merge.Created,
+		Updated:    merge.Updated,
+		Operations: added,
+		Unmapped:   merge.Dropped,
+	}, nil
+}
+
+// mergeJourneys merges the imported entity journeys into the first journey of the project, or a new one when the
+// project has none, and returns it with the operations added
+func (u *SchemaImportUsecase) mergeJourneys(ctx context.Context, project model.Project, imported []model.EntityJourney) (*model.Journey, []string, error) {
+	for i := range imported {
+		for _, entity := range project.Entities {
+			if strings.EqualFold(entity.EntityName, imported[i].EntityName) {
+				imported[i].EntityID = entity.Uuid.String()
+			}
+		}
+	}
+
+	journeys, err := u.journeyRepo.GetByProjectUuid(ctx, project.Uuid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(journeys) == 0 {
+		_, added := service.MergeJourneys(nil, imported)
+		return &model.Journey{ProjectUUID: project.Uuid, EntityJourneys: imported}, added, nil
+	}
+
+	journey := journeys[0]
+	merged, added := service.MergeJourneys(journey.EntityJourneys, imported)
+	journey.EntityJourneys = merged
+	return &journey, added, nil
+}
+
+func (u *SchemaImportUsecase) parse(req dto.SchemaImport) (service.ParsedSchema, error) {
+	if len(req.Files) == 0 {
+		return u.importer.ImportSchema(req.Content, req.Format)
+	}
+	files := make([]service.SchemaFile, len(req.Files))
+	for i, f := range req.Files {
+		files[i] = service.SchemaFile{Name: f.Name, Content: f.Content}
+	}
+	return u.importer.ImportSchemaFiles(files, req.Format)
+}
+
+func (u *SchemaImportUsecase) project(ctx context.Context, id uuid.UUID, name string) (model.Project, error) {
+	if id != uuid.Nil {
+		return u.projectRepo.GetById(ctx, id)
+	}
+	project, found, err := u.projectRepo.FindByName(ctx, name)
+	if err != nil {
+		return model.Project{}, err
+	}
+	if !found {
+		project = model.Project{ProjectName: name}
+	}
+	return project, nil
+}